@@ -0,0 +1,67 @@
+package httpbin
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	archiveEntriesMax   = 1 << 20
+	archiveEntrySizeMax = 1 << 30
+)
+
+// ArchiveHandler streams a tar archive, gzipped if the 'format' route
+// variable is tar.gz, of 'entries' files (default 10) of 'entry_size'
+// bytes (default 1024). The archive is generated as it is written, so it
+// can be made far larger than would fit in memory, and its content is
+// deterministic.
+func ArchiveHandler(w http.ResponseWriter, r *http.Request) {
+	entries, size := 10, 1024
+	if !intParam(w, r, "entries", 0, archiveEntriesMax, &entries) ||
+		!intParam(w, r, "entry_size", 0, archiveEntrySizeMax, &size) {
+		return
+	}
+
+	var out io.Writer = w
+	if routeVars(r)["format"] == "tar.gz" {
+		w.Header().Set("Content-Type", "application/gzip")
+		gw := gzip.NewWriter(w)
+		defer gw.Close()
+		out = gw
+	} else {
+		w.Header().Set("Content-Type", "application/x-tar")
+	}
+	tw := tar.NewWriter(out)
+	defer tw.Close()
+	chunkSize := instance(r).binaryChunkSize
+	for i := 0; i < entries; i++ {
+		if r.Context().Err() != nil {
+			return
+		}
+		if err := tw.WriteHeader(&tar.Header{
+			Name:     fmt.Sprintf("httpbin/file-%d.txt", i+1),
+			Mode:     0644,
+			Size:     int64(size),
+			ModTime:  zipModified,
+			Typeflag: tar.TypeReg,
+		}); err != nil {
+			return
+		}
+		line := []byte(fmt.Sprintf("httpbin file %d\n", i+1))
+		chunk := bytes.Repeat(line, chunkSize/len(line)+1)[:chunkSize]
+		for left := size; left > 0; {
+			n := len(chunk)
+			if left < n {
+				n = left
+			}
+			if _, err := tw.Write(chunk[:n]); err != nil {
+				return
+			}
+			left -= n
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package httpbin_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchive(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for _, format := range []string{"tar", "tar.gz"} {
+		resp, err := http.Get(srv.URL + "/archive/" + format + "?entries=3&entry_size=100000")
+		require.Nil(t, err)
+		var body io.Reader = resp.Body
+		if format == "tar.gz" {
+			require.Equal(t, "application/gzip", resp.Header.Get("Content-Type"))
+			body, err = gzip.NewReader(resp.Body)
+			require.Nil(t, err)
+		}
+		tr := tar.NewReader(body)
+		var names []string
+		for {
+			h, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			require.Nil(t, err)
+			names = append(names, h.Name)
+			b, err := ioutil.ReadAll(tr)
+			require.Nil(t, err)
+			require.Len(t, b, 100000)
+			require.True(t, strings.HasPrefix(string(b), "httpbin file "))
+		}
+		resp.Body.Close()
+		require.Equal(t, []string{"httpbin/file-1.txt", "httpbin/file-2.txt", "httpbin/file-3.txt"}, names, format)
+	}
+
+	resp, err := http.Get(srv.URL + "/archive/tar?entries=-1")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,168 @@
+package httpbin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultStaticCacheControl is the Cache-Control header of the home page and
+// the other HTML pages served from embedded assets.
+const defaultStaticCacheControl = "public, max-age=300"
+
+// WithStaticCacheControl sets the Cache-Control header of the home page and
+// the other HTML pages, none if empty. It defaults to
+// "public, max-age=300".
+func WithStaticCacheControl(cc string) Option {
+	return func(h *HTTPBin) { h.staticCacheControl = cc }
+}
+
+// linksMax is the largest number of links /links/:n/:offset renders.
+const linksMax = 200
+
+//go:embed assets
+var assetFS embed.FS
+
+// assetTemplates are the embedded pages rendered from data.
+var assetTemplates = template.Must(template.ParseFS(assetFS, "assets/index.html", "assets/links.html"))
+
+// staticPage is a rendered HTML page served with an ETag derived from its
+// content.
+type staticPage struct {
+	body []byte
+	etag string
+}
+
+func newStaticPage(b []byte) staticPage {
+	sum := sha256.Sum256(b)
+	return staticPage{body: b, etag: `"` + hex.EncodeToString(sum[:8]) + `"`}
+}
+
+// assetPage returns the embedded file assets/name as a staticPage, panicking
+// if it is missing as the file set is fixed at build time.
+func assetPage(name string) staticPage {
+	b, err := assetFS.ReadFile("assets/" + name)
+	if err != nil {
+		panic(err)
+	}
+	return newStaticPage(b)
+}
+
+// ServeHTTP serves the page with the static Cache-Control of the HTTPBin
+// serving r, evaluating conditional and Range requests against its ETag.
+func (p staticPage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("ETag", p.etag)
+	if cc := instance(r).staticCacheControl; cc != "" {
+		w.Header().Set("Cache-Control", cc)
+	}
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(p.body))
+}
+
+var (
+	formsPostPage = assetPage("forms-post.html")
+	utf8Page      = assetPage("utf8.html")
+)
+
+// homeRoute is an entry of the ENDPOINTS list of the home page.
+type homeRoute struct {
+	Path        string
+	Description string
+	Example     string
+}
+
+// renderHome renders the home page listing the route table of h.
+func renderHome(h *HTTPBin) (staticPage, error) {
+	var routes []homeRoute
+	for _, rt := range h.routeTable(nil) {
+		example := rt.example
+		if strings.HasPrefix(example, "/") {
+			example = h.prefix + example
+		}
+		routes = append(routes, homeRoute{rt.displayPath(), rt.description, example})
+	}
+	var buf bytes.Buffer
+	if err := assetTemplates.ExecuteTemplate(&buf, "index.html", routes); err != nil {
+		return staticPage{}, fmt.Errorf("failed to render home page: %w", err)
+	}
+	return newStaticPage(buf.Bytes()), nil
+}
+
+// HomeHandler serves the index page, listing the endpoints.
+func HomeHandler(w http.ResponseWriter, r *http.Request) {
+	p, err := renderHome(instance(r))
+	if err != nil {
+		writeErrorJSON(w, err)
+		return
+	}
+	p.ServeHTTP(w, r)
+}
+
+// homeHandler serves the index page of router, rendered on the first request
+// as the route table does not change once the router is built.
+func homeHandler(router *routeMux) http.HandlerFunc {
+	var (
+		once sync.Once
+		page staticPage
+		err  error
+	)
+	return func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() { page, err = renderHome(instance(r)) })
+		if err != nil {
+			writeErrorJSON(w, err)
+			return
+		}
+		page.ServeHTTP(w, r)
+	}
+}
+
+// FormsPostHandler serves an HTML form that submits to /post.
+func FormsPostHandler(w http.ResponseWriter, r *http.Request) {
+	formsPostPage.ServeHTTP(w, r)
+}
+
+// UTF8Handler serves an HTML page of UTF-8 encoded text in many scripts.
+func UTF8Handler(w http.ResponseWriter, r *http.Request) {
+	utf8Page.ServeHTTP(w, r)
+}
+
+// link is a page number of a /links/:n/:offset page.
+type link struct {
+	Prefix   string
+	N, Total int
+	Current  bool
+}
+
+// LinksHandler serves a page of n links to the other pages of the same
+// set, numbered from 0, with the link to the offset page left out. Without
+// an offset it redirects to the first page, like httpbin.org.
+func LinksHandler(w http.ResponseWriter, r *http.Request) {
+	n, _ := strconv.Atoi(routeVars(r)["n"]) // shouldn't fail due to route pattern
+	if _, ok := routeVars(r)["offset"]; !ok {
+		http.Redirect(w, r, prefixed(r, fmt.Sprintf("/links/%d/0", n)), http.StatusFound)
+		return
+	}
+	offset, _ := strconv.Atoi(routeVars(r)["offset"])
+	if n > linksMax {
+		n = linksMax
+	}
+
+	links := make([]link, n)
+	for i := range links {
+		links[i] = link{Prefix: instance(r).prefix, N: i, Total: n, Current: i == offset}
+	}
+	var buf bytes.Buffer
+	if err := assetTemplates.ExecuteTemplate(&buf, "links.html", links); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to render links: %w", err))
+		return
+	}
+	newStaticPage(buf.Bytes()).ServeHTTP(w, r)
+}
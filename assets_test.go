@@ -0,0 +1,65 @@
+package httpbin_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHome_conditional(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, "text/html; charset=utf-8", resp.Header.Get("Content-Type"))
+	require.EqualValues(t, "public, max-age=300", resp.Header.Get("Cache-Control"))
+	etag := resp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	req.Header.Set("If-None-Match", etag)
+	resp, err = http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusNotModified, resp.StatusCode)
+	require.EqualValues(t, etag, resp.Header.Get("ETag"))
+}
+
+func TestHome_prefix(t *testing.T) {
+	srv := httptest.NewServer(httpbin.New(httpbin.WithPrefix("/hb")).Handler())
+	defer srv.Close()
+
+	b := string(get(t, srv.URL+"/hb/"))
+	require.Contains(t, b, `<a href="/hb/"><code>/</code></a>`)
+	require.Contains(t, b, `<a href="redirect/6">`)
+}
+
+func TestStaticPages(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	require.Contains(t, string(get(t, srv.URL+"/forms/post")), `<form method="post" action="../post">`)
+	require.Contains(t, string(get(t, srv.URL+"/encoding/utf8")), "∮ E⋅da = Q")
+}
+
+func TestLinks(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	b := string(get(t, srv.URL+"/links/3/1"))
+	require.Contains(t, b, `<a href="/links/3/0">0</a> 1 <a href="/links/3/2">2</a>`)
+
+	b = string(get(t, srv.URL+"/links/1000/0"))
+	require.Contains(t, b, `<a href="/links/200/199">199</a>`)
+	require.NotContains(t, b, `/links/200/200"`)
+
+	// the first page, through the redirect
+	b = string(get(t, srv.URL+"/links/2"))
+	require.Contains(t, b, `0 <a href="/links/2/1">1</a>`)
+}
@@ -0,0 +1,49 @@
+package httpbin
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// Base64Handler decodes the base64 path value, in the standard or URL-safe
+// alphabet and with or without padding, and returns the plaintext: as
+// text/plain if it is valid UTF-8 and application/octet-stream otherwise.
+func Base64Handler(w http.ResponseWriter, r *http.Request) {
+	s := strings.TrimRight(routeVars(r)["value"], "=")
+	enc := base64.RawStdEncoding
+	if strings.ContainsAny(s, "-_") {
+		enc = base64.RawURLEncoding
+	}
+	b, err := enc.DecodeString(s)
+	if err != nil {
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("invalid base64: %w", err))
+		return
+	}
+
+	contentType := "application/octet-stream"
+	if utf8.Valid(b) {
+		contentType = "text/plain; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(b)
+}
+
+// Base64EncodeHandler base64-encodes the path value, with padding, in the
+// standard alphabet or, if the 'alphabet' query parameter is url, the
+// URL-safe one.
+func Base64EncodeHandler(w http.ResponseWriter, r *http.Request) {
+	enc := base64.StdEncoding
+	switch alphabet := r.URL.Query().Get("alphabet"); alphabet {
+	case "", "std":
+	case "url":
+		enc = base64.URLEncoding
+	default:
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("unsupported alphabet %q, want std or url", alphabet))
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(enc.EncodeToString([]byte(routeVars(r)["value"]))))
+}
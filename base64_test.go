@@ -0,0 +1,51 @@
+package httpbin_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBase64(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		path, wantType, want string
+	}{
+		{"/base64/aGVsbG8gd29ybGQ=", "text/plain; charset=utf-8", "hello world"},
+		{"/base64/aGVsbG8gd29ybGQ", "text/plain; charset=utf-8", "hello world"},
+		{"/base64/Pz8_Pw==", "text/plain; charset=utf-8", "????"},
+		{"/base64/Pz8/Pw==", "text/plain; charset=utf-8", "????"},
+		{"/base64/__8=", "application/octet-stream", "\xff\xff"},
+		{"/base64/encode/hello%20world", "text/plain; charset=utf-8", "aGVsbG8gd29ybGQ="},
+		{"/base64/encode/%3F%3F%3F%3F", "text/plain; charset=utf-8", "Pz8/Pw=="},
+		{"/base64/encode/%3F%3F%3F%3F?alphabet=url", "text/plain; charset=utf-8", "Pz8_Pw=="},
+	} {
+		resp, err := http.Get(srv.URL + tc.path)
+		require.Nil(t, err)
+		b, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.Nil(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode, tc.path)
+		require.Equal(t, tc.wantType, resp.Header.Get("Content-Type"), tc.path)
+		require.Equal(t, tc.want, string(b), tc.path)
+	}
+}
+
+func TestBase64_badRequest(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for _, path := range []string{"/base64/not%20base64", "/base64/Pz8/Pw_=", "/base64/a", "/base64/encode/x?alphabet=hex"} {
+		resp, err := http.Get(srv.URL + path)
+		require.Nil(t, err)
+		b, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.Nil(t, err)
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, path)
+		require.Contains(t, string(b), `"error"`, path)
+	}
+}
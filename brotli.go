@@ -0,0 +1,37 @@
+//go:build !httpbin_nobrotli
+// +build !httpbin_nobrotli
+
+package httpbin
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/andybalholm/brotli"
+)
+
+func init() {
+	featureRoutes = append(featureRoutes,
+		route{name: "brotli", path: `/brotli`, methods: getHead, description: "Returns brotli-encoded data.", example: "brotli", handler: http.HandlerFunc(BrotliHandler)},
+	)
+}
+
+// BrotliHandler returns a Brotli-encoded response.
+func BrotliHandler(w http.ResponseWriter, r *http.Request) {
+	h, _, _ := net.SplitHostPort(r.RemoteAddr)
+
+	v := brotliResponse{
+		headersResponse: headersResponse{getHeaders(r)},
+		ipResponse:      ipResponse{h},
+		Brotli:          true,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Encoding", "br")
+	ww := brotli.NewWriter(w)
+	defer ww.Close() // flush
+	if err := writeJSON(ww, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
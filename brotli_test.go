@@ -0,0 +1,30 @@
+//go:build !httpbin_nobrotli
+// +build !httpbin_nobrotli
+
+package httpbin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrotli(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/brotli")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+
+	require.EqualValues(t, "br", resp.Header.Get("Content-Encoding"))
+	require.EqualValues(t, "application/json", resp.Header.Get("Content-Type"))
+	var v struct {
+		Brotli bool `json:"brotli"`
+	}
+	require.Nil(t, json.NewDecoder(brotli.NewReader(resp.Body)).Decode(&v))
+	require.True(t, v.Brotli)
+}
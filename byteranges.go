@@ -0,0 +1,228 @@
+package httpbin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// byteRangesMax is the most ranges /byteranges serves; requests for more
+// get the whole body.
+const byteRangesMax = 100
+
+// rangeMax is the largest body /range/:n serves.
+const rangeMax = 100 * 1024
+
+// byteRange is a satisfiable range of a body, from start to end inclusive.
+type byteRange struct {
+	start, end int
+}
+
+// ByteRangesHandler serves a body of 'size' bytes (default 1024), like
+// /conditional, answering requests for several ranges with a
+// multipart/byteranges response whose boundary is 'boundary' (default
+// "httpbin-byteranges"). The parts are in the order of the request, or
+// reversed or shuffled if 'order' is reverse or shuffle. Ranges are neither
+// merged nor sorted, so overlapping ones are served as they were asked for.
+func ByteRangesHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	size := 1024
+	if !intParam(w, r, "size", 0, conditionalMaxSize, &size) {
+		return
+	}
+	boundary := q.Get("boundary")
+	if boundary == "" {
+		boundary = "httpbin-byteranges"
+	}
+	mw := multipart.NewWriter(nil)
+	if err := mw.SetBoundary(boundary); err != nil {
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("bad 'boundary': %w", err))
+		return
+	}
+	order := q.Get("order")
+	switch order {
+	case "", "request", "reverse", "shuffle":
+	default:
+		writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("'order' must be request, reverse or shuffle"))
+		return
+	}
+
+	body := bytes.Repeat([]byte("0123456789abcdef"), size/16+1)[:size]
+	w.Header().Set("Accept-Ranges", "bytes")
+	ranges, ok := parseByteRanges(r.Header.Get("Range"), size)
+	if !ok || len(ranges) > byteRangesMax {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write(body)
+		return
+	}
+	if len(ranges) == 0 {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		writeErrorJSONStatus(w, http.StatusRequestedRangeNotSatisfiable, errors.New("no satisfiable range"))
+		return
+	}
+	if len(ranges) == 1 {
+		br := ranges[0]
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", br.start, br.end, size))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(body[br.start : br.end+1])
+		return
+	}
+
+	switch order {
+	case "reverse":
+		for i, j := 0, len(ranges)-1; i < j; i, j = i+1, j-1 {
+			ranges[i], ranges[j] = ranges[j], ranges[i]
+		}
+	case "shuffle":
+		rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+		rnd.Shuffle(len(ranges), func(i, j int) { ranges[i], ranges[j] = ranges[j], ranges[i] })
+	}
+	var buf bytes.Buffer
+	mw = multipart.NewWriter(&buf)
+	mw.SetBoundary(boundary)
+	for _, br := range ranges {
+		pw, _ := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":  {"text/plain; charset=utf-8"},
+			"Content-Range": {fmt.Sprintf("bytes %d-%d/%d", br.start, br.end, size)},
+		})
+		pw.Write(body[br.start : br.end+1])
+	}
+	mw.Close()
+	w.Header().Set("Content-Type", "multipart/byteranges; boundary="+boundary)
+	w.WriteHeader(http.StatusPartialContent)
+	w.Write(buf.Bytes())
+}
+
+// RangeHandler serves n bytes of the repeated alphabet with an ETag,
+// answering a single-range request, or one whose If-Range matches the ETag,
+// with 206 Partial Content and unsatisfiable ones with 416. Requests for
+// several ranges get the whole body; /byteranges serves those. The body is
+// written in 'chunk_size' byte chunks (default 10240), spread evenly over
+// 'duration' seconds if set, to test resumable downloads.
+func RangeHandler(w http.ResponseWriter, r *http.Request) {
+	n, _ := strconv.Atoi(routeVars(r)["n"]) // shouldn't fail due to route pattern
+	if n > rangeMax {
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("n must be at most %d", rangeMax))
+		return
+	}
+	var duration time.Duration
+	if !secondsParam(w, r, "duration", &duration) {
+		return
+	}
+	chunkSize := 10 * 1024
+	if !intParam(w, r, "chunk_size", 1, rangeMax, &chunkSize) {
+		return
+	}
+
+	body := make([]byte, n)
+	for i := range body {
+		body[i] = 'a' + byte(i%26)
+	}
+	etag := fmt.Sprintf(`"range%d"`, n)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Accept-Ranges", "bytes")
+	w.Header().Set("Content-Type", "application/octet-stream")
+
+	status := http.StatusOK
+	ranges, ok := parseByteRanges(r.Header.Get("Range"), n)
+	if ir := r.Header.Get("If-Range"); ir != "" && ir != etag {
+		ok = false // changed since the client got its part, send all of it
+	}
+	switch {
+	case !ok, len(ranges) > 1:
+	case len(ranges) == 0:
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", n))
+		writeErrorJSONStatus(w, http.StatusRequestedRangeNotSatisfiable, errors.New("no satisfiable range"))
+		return
+	default:
+		br := ranges[0]
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", br.start, br.end, n))
+		body = body[br.start : br.end+1]
+		status = http.StatusPartialContent
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	chunks := (len(body) + chunkSize - 1) / chunkSize
+	for i := 0; i < chunks; i++ {
+		if i > 0 && duration > 0 && !sleepRequest(r, duration/time.Duration(chunks)) {
+			return
+		}
+		end := (i + 1) * chunkSize
+		if end > len(body) {
+			end = len(body)
+		}
+		w.Write(body[i*chunkSize : end])
+		if f, ok := w.(http.Flusher); ok && duration > 0 {
+			f.Flush()
+		}
+	}
+}
+
+// parseByteRanges parses a Range header for a body of size bytes into its
+// satisfiable ranges. It reports false if there is no header or it is
+// malformed, in which case it must be ignored.
+func parseByteRanges(s string, size int) ([]byteRange, bool) {
+	const prefix = "bytes="
+	if !strings.HasPrefix(s, prefix) {
+		return nil, false
+	}
+	var ranges []byteRange
+	for _, spec := range strings.Split(s[len(prefix):], ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		i := strings.IndexByte(spec, '-')
+		if i < 0 {
+			return nil, false
+		}
+		first, last := spec[:i], spec[i+1:]
+		var br byteRange
+		if first == "" {
+			// a suffix of the last n bytes
+			n, err := strconv.Atoi(last)
+			if err != nil || n < 0 {
+				return nil, false
+			}
+			if n == 0 || size == 0 {
+				continue
+			}
+			if n > size {
+				n = size
+			}
+			br = byteRange{size - n, size - 1}
+		} else {
+			start, err := strconv.Atoi(first)
+			if err != nil || start < 0 {
+				return nil, false
+			}
+			end := size - 1
+			if last != "" {
+				if end, err = strconv.Atoi(last); err != nil || end < start {
+					return nil, false
+				}
+			}
+			if start >= size {
+				continue
+			}
+			if end >= size {
+				end = size - 1
+			}
+			br = byteRange{start, end}
+		}
+		ranges = append(ranges, br)
+	}
+	return ranges, true
+}
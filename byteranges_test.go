@@ -0,0 +1,132 @@
+package httpbin_test
+
+import (
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func getRange(t *testing.T, url, rng string) *http.Response {
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Set("Range", rng)
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	return resp
+}
+
+func TestByteRanges(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp := getRange(t, srv.URL+"/byteranges?size=32&boundary=sep&order=reverse", "bytes=0-3, 30-, -4, 2-5")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	mt, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	require.Nil(t, err)
+	require.Equal(t, "multipart/byteranges", mt)
+	require.Equal(t, "sep", params["boundary"])
+
+	mr := multipart.NewReader(resp.Body, "sep")
+	var got [][2]string
+	for {
+		p, err := mr.NextPart()
+		if err != nil {
+			break
+		}
+		b, _ := ioutil.ReadAll(p)
+		got = append(got, [2]string{p.Header.Get("Content-Range"), string(b)})
+	}
+	require.Equal(t, [][2]string{
+		{"bytes 2-5/32", "2345"},
+		{"bytes 28-31/32", "cdef"},
+		{"bytes 30-31/32", "ef"},
+		{"bytes 0-3/32", "0123"},
+	}, got)
+}
+
+func TestByteRanges_single(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp := getRange(t, srv.URL+"/byteranges?size=32", "bytes=40-, 4-7")
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	require.Equal(t, "bytes 4-7/32", resp.Header.Get("Content-Range"))
+	require.Equal(t, "4567", string(b))
+
+	resp = getRange(t, srv.URL+"/byteranges?size=32", "bytes=40-")
+	resp.Body.Close()
+	require.Equal(t, http.StatusRequestedRangeNotSatisfiable, resp.StatusCode)
+	require.Equal(t, "bytes */32", resp.Header.Get("Content-Range"))
+
+	resp = getRange(t, srv.URL+"/byteranges?size=32", "bytes=a-b")
+	b, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, b, 32)
+
+	resp = getRange(t, srv.URL+"/byteranges?boundary=bad%00", "bytes=0-1,2-3")
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestRange(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp := getRange(t, srv.URL+"/range/30", "bytes=24-")
+	defer resp.Body.Close()
+	require.EqualValues(t, http.StatusPartialContent, resp.StatusCode)
+	require.EqualValues(t, "bytes 24-29/30", resp.Header.Get("Content-Range"))
+	require.EqualValues(t, "bytes", resp.Header.Get("Accept-Ranges"))
+	etag := resp.Header.Get("ETag")
+	b, _ := ioutil.ReadAll(resp.Body)
+	require.EqualValues(t, "yzabcd", string(b))
+
+	// resuming with a matching If-Range
+	req, _ := http.NewRequest("GET", srv.URL+"/range/30", nil)
+	req.Header.Set("Range", "bytes=26-")
+	req.Header.Set("If-Range", etag)
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	b, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusPartialContent, resp.StatusCode)
+	require.EqualValues(t, "abcd", string(b))
+
+	req.Header.Set("If-Range", `"other"`)
+	resp, err = http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	b, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, b, 30)
+
+	resp = getRange(t, srv.URL+"/range/30", "bytes=30-")
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusRequestedRangeNotSatisfiable, resp.StatusCode)
+	require.EqualValues(t, "bytes */30", resp.Header.Get("Content-Range"))
+
+	resp = getRange(t, srv.URL+"/range/1000000", "")
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestRange_duration(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	start := time.Now()
+	resp := getRange(t, srv.URL+"/range/100?duration=0.2&chunk_size=10", "bytes=0-49")
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusPartialContent, resp.StatusCode)
+	require.Len(t, b, 50)
+	require.True(t, time.Since(start) >= 150*time.Millisecond, "took %s", time.Since(start))
+}
@@ -0,0 +1,247 @@
+package httpbin
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultResponseCacheSize is the maximum total size in bytes of the
+// memoized responses of endpoints generating deterministic content, like
+// images and /bytes with a seed.
+const defaultResponseCacheSize = 32 << 20
+
+// WithResponseCacheSize sets the maximum total size in bytes of the memoized
+// responses, zero to disable the cache. It defaults to 32 MiB.
+func WithResponseCacheSize(n int) Option {
+	return func(h *HTTPBin) { h.responseCacheSize = n }
+}
+
+// cacheHeader is the request header that skips the response cache when set
+// to "bypass", and the response header reporting HIT, MISS or BYPASS.
+const cacheHeader = "X-Httpbin-Cache"
+
+// responseCache memoizes generated responses in least recently used order.
+type responseCache struct {
+	mu    sync.Mutex
+	items map[string]*list.Element
+	lru   *list.List // of *cachedResponse, most recently used first
+	size  int
+
+	hits, misses, bypasses, evictions int
+}
+
+type cachedResponse struct {
+	key    string
+	header http.Header
+	body   []byte
+}
+
+func (c *responseCache) get(key string) (*cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.lru.MoveToFront(e)
+	return e.Value.(*cachedResponse), true
+}
+
+// add adds cr to the cache, evicting the least recently used responses to
+// keep its size within max bytes.
+func (c *responseCache) add(cr *cachedResponse, max int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(cr.body) > max {
+		return
+	}
+	if e, ok := c.items[cr.key]; ok {
+		c.size -= len(e.Value.(*cachedResponse).body)
+		c.lru.Remove(e)
+	}
+	c.items[cr.key] = c.lru.PushFront(cr)
+	c.size += len(cr.body)
+	for c.size > max {
+		e := c.lru.Back()
+		old := c.lru.Remove(e).(*cachedResponse)
+		delete(c.items, old.key)
+		c.size -= len(old.body)
+		c.evictions++
+	}
+}
+
+func (c *responseCache) bypass() {
+	c.mu.Lock()
+	c.bypasses++
+	c.mu.Unlock()
+}
+
+// cachedHandler serves the responses of h from the response cache, keyed by
+// the route name and what key returns for the request. Requests for which
+// key returns false are not cacheable and go straight to h.
+func cachedHandler(name string, key func(*http.Request) (string, bool), h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hb := instance(r)
+		responses, max := hb.responses, hb.responseCacheSize
+		k, ok := key(r)
+		if !ok || max <= 0 {
+			traceEventf(r, "cache: not cacheable")
+			h.ServeHTTP(w, r)
+			return
+		}
+		if strings.EqualFold(r.Header.Get(cacheHeader), "bypass") {
+			responses.bypass()
+			traceEventf(r, "cache: bypassed")
+			w.Header().Set(cacheHeader, "BYPASS")
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		k = name + "?" + k
+		if cr, ok := responses.get(k); ok {
+			traceEventf(r, "cache: hit %s", k)
+			for hk, vs := range cr.header {
+				w.Header()[hk] = append([]string(nil), vs...)
+			}
+			w.Header().Set(cacheHeader, "HIT")
+			if cr.header.Get("ETag") != "" || cr.header.Get("Last-Modified") != "" {
+				// let conditional and Range requests be answered as the
+				// handler would have
+				modtime, _ := http.ParseTime(cr.header.Get("Last-Modified"))
+				http.ServeContent(w, r, "", modtime, bytes.NewReader(cr.body))
+				return
+			}
+			w.Write(cr.body)
+			return
+		}
+
+		traceEventf(r, "cache: miss %s", k)
+		rec := &responseRecorder{w: w, max: max, header: make(http.Header), status: http.StatusOK}
+		h.ServeHTTP(rec, r)
+		if rec.streaming {
+			traceEventf(r, "cache: response larger than the cache, not cached")
+			return
+		}
+		if rec.status == http.StatusOK && !rec.failed {
+			responses.add(&cachedResponse{key: k, header: rec.header, body: rec.body.Bytes()}, max)
+		}
+		rec.writeHeader()
+		w.Write(rec.body.Bytes())
+	})
+}
+
+// responseRecorder buffers a response so it can be cached, up to max bytes.
+// Responses that grow larger are streamed to w instead, without caching
+// them, so that buffering one never takes more memory than the cache. As
+// bufferedResponse does, it lets a handler failing partway through replace
+// what it buffered with an error response, which is not cached either.
+type responseRecorder struct {
+	w           http.ResponseWriter
+	max         int
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	streaming   bool
+	failed      bool // discarded for an error response
+}
+
+func (rec *responseRecorder) Header() http.Header { return rec.header }
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if !rec.wroteHeader {
+		rec.status, rec.wroteHeader = status, true
+	}
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.WriteHeader(http.StatusOK)
+	if rec.streaming {
+		return rec.w.Write(b)
+	}
+	if rec.body.Len()+len(b) <= rec.max {
+		return rec.body.Write(b)
+	}
+	rec.streaming = true
+	rec.writeHeader()
+	if _, err := rec.w.Write(rec.body.Bytes()); err != nil {
+		return 0, err
+	}
+	rec.body = bytes.Buffer{}
+	return rec.w.Write(b)
+}
+
+// discard drops the buffered status and body, and the headers describing
+// the body, reporting false if it is too late as the response is streaming
+// and w committed it. The response is not cached.
+func (rec *responseRecorder) discard() bool {
+	rec.failed = true
+	if rec.streaming {
+		if d, ok := rec.w.(discarder); !ok || !d.discard() {
+			return false
+		}
+		rec.streaming = false // buffer the error response
+	}
+	for _, k := range []string{"Content-Type", "Content-Length", "Content-Encoding", "Content-Disposition", "ETag", "Last-Modified"} {
+		rec.header.Del(k)
+	}
+	rec.status, rec.wroteHeader = http.StatusOK, false
+	rec.body.Reset()
+	return true
+}
+
+// writeHeader writes the recorded header and status to the underlying
+// ResponseWriter, as a cache miss.
+func (rec *responseRecorder) writeHeader() {
+	for hk, vs := range rec.header {
+		rec.w.Header()[hk] = append([]string(nil), vs...)
+	}
+	rec.w.Header().Set(cacheHeader, "MISS")
+	rec.w.WriteHeader(rec.status)
+}
+
+// constantCacheKey is the cache key of endpoints whose response never
+// changes.
+func constantCacheKey(*http.Request) (string, bool) { return "", true }
+
+// bytesCacheKey caches /bytes/:n responses that are deterministic, i.e. have
+// a seed, and fit in the cache.
+func bytesCacheKey(r *http.Request) (string, bool) {
+	seed, err := strconv.ParseInt(r.URL.Query().Get("seed"), 10, 64)
+	if err != nil {
+		return "", false
+	}
+	n, err := strconv.Atoi(routeVars(r)["n"])
+	if err != nil || n > instance(r).responseCacheSize {
+		return "", false
+	}
+	return "n=" + strconv.Itoa(n) + "&seed=" + strconv.FormatInt(seed, 10), true
+}
+
+// ResponseCacheHandler reports the response cache's size and hit statistics.
+func ResponseCacheHandler(w http.ResponseWriter, r *http.Request) {
+	h := instance(r)
+	responses := h.responses
+	responses.mu.Lock()
+	v := responseCacheResponse{
+		Entries:   len(responses.items),
+		Bytes:     responses.size,
+		MaxBytes:  h.responseCacheSize,
+		Hits:      responses.hits,
+		Misses:    responses.misses,
+		Bypasses:  responses.bypasses,
+		Evictions: responses.evictions,
+	}
+	responses.mu.Unlock()
+
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
@@ -0,0 +1,90 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+type cacheStats struct {
+	Entries   int `json:"entries"`
+	Bytes     int `json:"bytes"`
+	Hits      int `json:"hits"`
+	Misses    int `json:"misses"`
+	Bypasses  int `json:"bypasses"`
+	Evictions int `json:"evictions"`
+}
+
+func getCacheStats(t *testing.T, url string) cacheStats {
+	var v cacheStats
+	require.Nil(t, json.Unmarshal(get(t, url+"/response-cache"), &v))
+	return v
+}
+
+func getCached(t *testing.T, url string, bypass bool) (string, []byte) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.Nil(t, err)
+	if bypass {
+		req.Header.Set("X-Httpbin-Cache", "bypass")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	b, err := ioutil.ReadAll(resp.Body)
+	require.Nil(t, err)
+	return resp.Header.Get("X-Httpbin-Cache"), b
+}
+
+func TestResponseCache(t *testing.T) {
+	srv := httptest.NewServer(httpbin.New().Handler())
+	defer srv.Close()
+	before := getCacheStats(t, srv.URL)
+
+	state, b1 := getCached(t, srv.URL+"/bytes/4321?seed=42", false)
+	require.Equal(t, "MISS", state)
+	state, b2 := getCached(t, srv.URL+"/bytes/4321?seed=042", false) // same normalized seed
+	require.Equal(t, "HIT", state)
+	require.Equal(t, b1, b2)
+	state, b3 := getCached(t, srv.URL+"/bytes/4321?seed=42", true)
+	require.Equal(t, "BYPASS", state)
+	require.Equal(t, b1, b3)
+
+	state, _ = getCached(t, srv.URL+"/bytes/4321", false)
+	require.Empty(t, state, "responses without a seed are random")
+
+	after := getCacheStats(t, srv.URL)
+	require.Equal(t, 1, after.Hits-before.Hits)
+	require.Equal(t, 1, after.Misses-before.Misses)
+	require.Equal(t, 1, after.Bypasses-before.Bypasses)
+}
+
+func TestResponseCache_evicts(t *testing.T) {
+	srv := httptest.NewServer(httpbin.New(httpbin.WithResponseCacheSize(1500)).Handler())
+	defer srv.Close()
+	before := getCacheStats(t, srv.URL)
+
+	getCached(t, srv.URL+"/bytes/1000?seed=1", false)
+	getCached(t, srv.URL+"/bytes/1000?seed=2", false) // evicts seed=1
+	state, _ := getCached(t, srv.URL+"/bytes/1000?seed=1", false)
+	require.Equal(t, "MISS", state)
+	state, _ = getCached(t, srv.URL+"/bytes/2000?seed=1", false) // too large to cache
+	require.Empty(t, state)
+
+	// responses outgrowing the cache while generated are streamed
+	_, want := getCached(t, srv.URL+"/zip?entries=1&size=4000&method=store", true)
+	for i := 0; i < 2; i++ {
+		state, b := getCached(t, srv.URL+"/zip?entries=1&size=4000&method=store", false)
+		require.Equal(t, "MISS", state)
+		require.Equal(t, want, b)
+	}
+
+	after := getCacheStats(t, srv.URL)
+	require.True(t, after.Evictions-before.Evictions >= 2)
+	require.True(t, after.Bytes <= 1500)
+}
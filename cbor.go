@@ -0,0 +1,322 @@
+package httpbin
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+const cborContentType = "application/cbor"
+
+// appendCBOR appends the CBOR (RFC 8949) encoding of v, which may be nil, a
+// bool, an integer, a float64, a string, a []byte or a []interface{} or
+// map[string]interface{} of those, as decoded from JSON. Map keys are sorted
+// so the encoding is deterministic.
+func appendCBOR(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xf6)
+	case bool:
+		if v {
+			return append(b, 0xf5)
+		}
+		return append(b, 0xf4)
+	case int:
+		return appendCBORInt(b, int64(v))
+	case int64:
+		return appendCBORInt(b, v)
+	case uint64:
+		return appendCBORHead(b, 0, v)
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return appendCBORInt(b, int64(v))
+		}
+		return appendUint64(append(b, 0xfb), math.Float64bits(v))
+	case string:
+		b = appendCBORHead(b, 3, uint64(len(v)))
+		return append(b, v...)
+	case []byte:
+		b = appendCBORHead(b, 2, uint64(len(v)))
+		return append(b, v...)
+	case []interface{}:
+		b = appendCBORHead(b, 4, uint64(len(v)))
+		for _, e := range v {
+			b = appendCBOR(b, e)
+		}
+		return b
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b = appendCBORHead(b, 5, uint64(len(v)))
+		for _, k := range keys {
+			b = appendCBOR(b, k)
+			b = appendCBOR(b, v[k])
+		}
+		return b
+	}
+	panic("cbor: unsupported type")
+}
+
+func appendCBORInt(b []byte, i int64) []byte {
+	if i < 0 {
+		return appendCBORHead(b, 1, uint64(-1-i))
+	}
+	return appendCBORHead(b, 0, uint64(i))
+}
+
+// appendCBORHead appends the initial byte and argument of a data item of the
+// major type.
+func appendCBORHead(b []byte, major byte, n uint64) []byte {
+	major <<= 5
+	switch {
+	case n < 24:
+		return append(b, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(b, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return append(b, major|25, byte(n>>8), byte(n))
+	case n <= math.MaxUint32:
+		return append(b, major|26, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	return appendUint64(append(b, major|27), n)
+}
+
+func appendUint64(b []byte, n uint64) []byte {
+	return append(b, byte(n>>56), byte(n>>48), byte(n>>40), byte(n>>32), byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+// cborMaxDepth bounds the nesting of decoded CBOR data items.
+const cborMaxDepth = 64
+
+// decodeCBOR decodes a single CBOR data item into the types encoding/json
+// decodes into, so it can be echoed as JSON: integers and floats become
+// float64 (or int64/uint64 beyond float64's integer precision), byte strings
+// []byte, maps map[string]interface{} with non-string keys formatted with
+// fmt.Sprint, and tags are dropped in favor of their content.
+func decodeCBOR(b []byte) (interface{}, error) {
+	d := &cborDecoder{b: b}
+	v, err := d.decode(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.off != len(b) {
+		return nil, errors.New("cbor: trailing data after data item")
+	}
+	return v, nil
+}
+
+type cborDecoder struct {
+	b   []byte
+	off int
+}
+
+var errCBORTruncated = errors.New("cbor: unexpected end of data")
+
+// errCBORBreak is returned by decode for the "break" stop code ending
+// indefinite-length items.
+var errCBORBreak = errors.New("cbor: unexpected break")
+
+func (d *cborDecoder) byte() (byte, error) {
+	if d.off >= len(d.b) {
+		return 0, errCBORTruncated
+	}
+	c := d.b[d.off]
+	d.off++
+	return c, nil
+}
+
+func (d *cborDecoder) bytes(n uint64) ([]byte, error) {
+	if n > uint64(len(d.b)-d.off) {
+		return nil, errCBORTruncated
+	}
+	b := d.b[d.off : d.off+int(n)]
+	d.off += int(n)
+	return b, nil
+}
+
+// arg reads the argument of a data item with the additional information ai,
+// returning indefinite true for ai 31.
+func (d *cborDecoder) arg(ai byte) (n uint64, indefinite bool, err error) {
+	switch {
+	case ai < 24:
+		return uint64(ai), false, nil
+	case ai == 31:
+		return 0, true, nil
+	case ai > 27:
+		return 0, false, fmt.Errorf("cbor: invalid additional information %d", ai)
+	}
+	b, err := d.bytes(1 << (ai - 24))
+	if err != nil {
+		return 0, false, err
+	}
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n, false, nil
+}
+
+func (d *cborDecoder) decode(depth int) (interface{}, error) {
+	if depth > cborMaxDepth {
+		return nil, errors.New("cbor: data nested too deeply")
+	}
+	c, err := d.byte()
+	if err != nil {
+		return nil, err
+	}
+	major, ai := c>>5, c&0x1f
+	if major == 7 {
+		return d.simple(ai)
+	}
+	n, indefinite, err := d.arg(ai)
+	if err != nil {
+		return nil, err
+	}
+	if indefinite && (major < 2 || major == 6) {
+		return nil, fmt.Errorf("cbor: indefinite length for major type %d", major)
+	}
+
+	switch major {
+	case 0:
+		if n > 1<<53 {
+			return n, nil
+		}
+		return float64(n), nil
+	case 1:
+		if n >= 1<<53 {
+			if n > math.MaxInt64 {
+				return nil, errors.New("cbor: negative integer overflows int64")
+			}
+			return -1 - int64(n), nil
+		}
+		return -1 - float64(n), nil
+	case 2, 3:
+		var s []byte
+		if indefinite {
+			for {
+				chunk, err := d.decode(depth + 1)
+				if err == errCBORBreak {
+					break
+				}
+				if err != nil {
+					return nil, err
+				}
+				switch chunk := chunk.(type) {
+				case []byte:
+					s = append(s, chunk...)
+				case string:
+					s = append(s, chunk...)
+				}
+			}
+		} else if s, err = d.bytes(n); err != nil {
+			return nil, err
+		}
+		if major == 2 {
+			return append([]byte(nil), s...), nil
+		}
+		return string(s), nil
+	case 4:
+		a := []interface{}{}
+		for i := uint64(0); indefinite || i < n; i++ {
+			v, err := d.decode(depth + 1)
+			if err == errCBORBreak && indefinite {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			a = append(a, v)
+		}
+		return a, nil
+	case 5:
+		m := make(map[string]interface{})
+		for i := uint64(0); indefinite || i < n; i++ {
+			k, err := d.decode(depth + 1)
+			if err == errCBORBreak && indefinite {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			v, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			ks, ok := k.(string)
+			if !ok {
+				ks = fmt.Sprint(k)
+			}
+			m[ks] = v
+		}
+		return m, nil
+	}
+	return d.decode(depth + 1) // tag
+}
+
+// simple decodes the major type 7 item with additional information ai.
+func (d *cborDecoder) simple(ai byte) (interface{}, error) {
+	switch ai {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23: // null, undefined
+		return nil, nil
+	case 25:
+		b, err := d.bytes(2)
+		if err != nil {
+			return nil, err
+		}
+		return halfToFloat64(uint16(b[0])<<8 | uint16(b[1])), nil
+	case 26:
+		b, err := d.bytes(4)
+		if err != nil {
+			return nil, err
+		}
+		return float64(math.Float32frombits(uint32(b[0])<<24 | uint32(b[1])<<16 | uint32(b[2])<<8 | uint32(b[3]))), nil
+	case 27:
+		b, err := d.bytes(8)
+		if err != nil {
+			return nil, err
+		}
+		var n uint64
+		for _, c := range b {
+			n = n<<8 | uint64(c)
+		}
+		return math.Float64frombits(n), nil
+	case 31:
+		return nil, errCBORBreak
+	}
+	if ai == 24 {
+		if _, err := d.byte(); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil // unassigned simple values
+}
+
+// halfToFloat64 converts an IEEE 754 half-precision float.
+func halfToFloat64(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
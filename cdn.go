@@ -0,0 +1,95 @@
+package httpbin
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// warningTexts are the warn-text of the RFC 7234 warn-codes.
+var warningTexts = map[int]string{
+	110: "Response is Stale",
+	111: "Revalidation Failed",
+	112: "Disconnected Operation",
+	113: "Heuristic Expiration",
+	199: "Miscellaneous Warning",
+	214: "Transformation Applied",
+	299: "Miscellaneous Persistent Warning",
+}
+
+// CDNHandler responds like /get with the headers a CDN would add to a cached
+// response, to test client logic keyed on them:
+//
+//   - Age, from the 'age' query parameter, in seconds;
+//   - Via, from 'via' (default "1.1 httpbin-cdn"), or none if 'via' is "none";
+//   - X-Cache, from 'cache' (default HIT), with X-Cache-Hits from 'hits';
+//   - Warning, one per comma-separated RFC 7234 code in 'warning';
+//   - Cache-Control: max-age from 'max_age', in seconds.
+func CDNHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	nonNegative := func(name string) (string, bool) {
+		s := q.Get(name)
+		if s == "" {
+			return "", true
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("'%s' must be a non-negative integer", name))
+			return "", false
+		}
+		return strconv.Itoa(n), true
+	}
+	age, ok := nonNegative("age")
+	if !ok {
+		return
+	}
+	hits, ok := nonNegative("hits")
+	if !ok {
+		return
+	}
+	maxAge, ok := nonNegative("max_age")
+	if !ok {
+		return
+	}
+	var warnings []string
+	if s := q.Get("warning"); s != "" {
+		for _, c := range strings.Split(s, ",") {
+			code, _ := strconv.Atoi(strings.TrimSpace(c))
+			text, ok := warningTexts[code]
+			if !ok {
+				writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("unknown warn-code %q", c))
+				return
+			}
+			warnings = append(warnings, strconv.Itoa(code)+" httpbin-cdn \""+text+"\"")
+		}
+	}
+
+	via := q.Get("via")
+	if via == "" {
+		via = "1.1 httpbin-cdn"
+	}
+	cache := q.Get("cache")
+	if cache == "" {
+		cache = "HIT"
+	}
+
+	h := w.Header()
+	if age != "" {
+		h.Set("Age", age)
+	}
+	if via != "none" {
+		h.Set("Via", via)
+	}
+	h.Set("X-Cache", cache)
+	if hits != "" {
+		h.Set("X-Cache-Hits", hits)
+	}
+	for _, warning := range warnings {
+		h.Add("Warning", warning)
+	}
+	if maxAge != "" {
+		h.Set("Cache-Control", "public, max-age="+maxAge)
+	}
+	GetHandler(w, r)
+}
@@ -0,0 +1,49 @@
+package httpbin_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCDN(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/cdn?age=120&hits=3&warning=110,214&max_age=60")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "120", resp.Header.Get("Age"))
+	require.Equal(t, "1.1 httpbin-cdn", resp.Header.Get("Via"))
+	require.Equal(t, "HIT", resp.Header.Get("X-Cache"))
+	require.Equal(t, "3", resp.Header.Get("X-Cache-Hits"))
+	require.Equal(t, []string{`110 httpbin-cdn "Response is Stale"`, `214 httpbin-cdn "Transformation Applied"`}, resp.Header["Warning"])
+	require.Equal(t, "public, max-age=60", resp.Header.Get("Cache-Control"))
+}
+
+func TestCDN_defaults(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/cdn?via=none&cache=MISS")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Empty(t, resp.Header.Get("Age"))
+	require.Empty(t, resp.Header.Get("Via"))
+	require.Equal(t, "MISS", resp.Header.Get("X-Cache"))
+	require.Empty(t, resp.Header.Get("Warning"))
+}
+
+func TestCDN_badParams(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for _, q := range []string{"age=-1", "hits=x", "warning=404", "max_age=1.5"} {
+		resp, err := http.Get(srv.URL + "/cdn?" + q)
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, q)
+	}
+}
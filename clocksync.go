@@ -0,0 +1,104 @@
+package httpbin
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// clockSyncSamplesMax is the number of most recent samples /clock-sync
+// keeps.
+const clockSyncSamplesMax = 16
+
+// clockSyncState is what /clock-sync hands the client to send back with its
+// next request: the timestamps of the round in progress and the samples of
+// the previous ones.
+type clockSyncState struct {
+	T0      float64           `json:"t0"`
+	T1      float64           `json:"t1"`
+	T2      float64           `json:"t2"`
+	Samples []clockSyncSample `json:"samples"`
+}
+
+// ClockSyncHandler estimates the offset of the client's clock from the
+// server's and the round trip time, like NTP, over a chain of requests.
+// Each request gives its send time, in Unix seconds, in 't0', and the
+// server answers with its receive and transmit times, t1 and t2, and an
+// opaque 'state'. The next request passes that state back with 't3', the
+// time the client received the previous response, completing a sample:
+// offset ((t1 - t0) + (t2 - t3)) / 2 and rtt (t3 - t0) - (t2 - t1). The
+// reported estimate is the sample with the lowest rtt.
+func ClockSyncHandler(w http.ResponseWriter, r *http.Request) {
+	t1 := unixSeconds(time.Now())
+	q := r.URL.Query()
+	param := func(name string) (float64, bool) {
+		s := q.Get(name)
+		if s == "" {
+			return 0, true
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || f <= 0 || math.IsInf(f, 0) {
+			writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("'%s' must be a time in Unix seconds", name))
+			return 0, false
+		}
+		return f, true
+	}
+	t0, ok := param("t0")
+	if !ok {
+		return
+	}
+	t3, ok := param("t3")
+	if !ok {
+		return
+	}
+
+	var prev clockSyncState
+	if s := q.Get("state"); s != "" {
+		b, err := base64.RawURLEncoding.DecodeString(s)
+		if err == nil {
+			err = json.Unmarshal(b, &prev)
+		}
+		if err != nil {
+			writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("malformed 'state'"))
+			return
+		}
+	}
+	samples := prev.Samples
+	if prev.T0 > 0 && t3 > 0 {
+		samples = append(samples, clockSyncSample{
+			Offset: ((prev.T1 - prev.T0) + (prev.T2 - t3)) / 2,
+			RTT:    (t3 - prev.T0) - (prev.T2 - prev.T1),
+		})
+		if len(samples) > clockSyncSamplesMax {
+			samples = samples[len(samples)-clockSyncSamplesMax:]
+		}
+	}
+
+	v := clockSyncResponse{T0: t0, T1: t1, Samples: samples}
+	if v.Samples == nil {
+		v.Samples = []clockSyncSample{}
+	}
+	for i, s := range samples {
+		if i == 0 || s.RTT < v.RTT {
+			v.Offset, v.RTT = s.Offset, s.RTT
+		}
+	}
+	v.T2 = unixSeconds(time.Now())
+	b, _ := json.Marshal(clockSyncState{T0: t0, T1: t1, T2: v.T2, Samples: samples})
+	v.State = base64.RawURLEncoding.EncodeToString(b)
+
+	w.Header().Set("Cache-Control", "no-store")
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
+
+// unixSeconds returns t in Unix seconds, with microsecond precision.
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()/1e3) / 1e6
+}
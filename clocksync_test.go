@@ -0,0 +1,58 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClockSync(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	type result struct {
+		T1      float64
+		T2      float64
+		Offset  float64
+		RTT     float64
+		Samples []struct{ Offset, RTT float64 }
+		State   string
+	}
+	now := func() float64 { return float64(time.Now().UnixNano()) / 1e9 }
+	// the client's clock runs 10s behind the server's
+	const skew = 10.0
+
+	var v result
+	var t3 float64
+	for i := 0; i < 4; i++ {
+		q := url.Values{"t0": {fmt.Sprint(now() - skew)}}
+		if v.State != "" {
+			q.Set("state", v.State)
+			q.Set("t3", fmt.Sprint(t3))
+		}
+		resp, err := http.Get(srv.URL + "/clock-sync?" + q.Encode())
+		require.Nil(t, err)
+		t3 = now() - skew
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		v = result{}
+		require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+		resp.Body.Close()
+		require.Len(t, v.Samples, i)
+		require.True(t, v.T2 >= v.T1)
+	}
+	require.True(t, math.Abs(v.Offset-skew) < 0.5, "offset %v", v.Offset)
+	require.True(t, v.RTT > -0.01 && v.RTT < 1, "rtt %v", v.RTT)
+
+	for _, q := range []string{"t0=soon", "state=!!"} {
+		resp, err := http.Get(srv.URL + "/clock-sync?" + q)
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, q)
+	}
+}
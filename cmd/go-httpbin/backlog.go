@@ -0,0 +1,11 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import "syscall"
+
+// setBacklog changes the backlog of the listening socket fd.
+func setBacklog(fd uintptr, n int) error {
+	return syscall.Listen(int(fd), n)
+}
@@ -0,0 +1,9 @@
+package main
+
+import "errors"
+
+// setBacklog changes the backlog of the listening socket fd, which Windows
+// doesn't support.
+func setBacklog(fd uintptr, n int) error {
+	return errors.New("changing the backlog is not supported on Windows")
+}
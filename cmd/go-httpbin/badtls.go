@@ -0,0 +1,253 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"strings"
+	"time"
+)
+
+// badTLSModes are the certificate problems a -bad-tls listener can serve.
+var badTLSModes = []string{"expired", "self-signed", "wrong-host", "incomplete-chain", "ocsp-good", "ocsp-revoked"}
+
+// badTLSFlag collects -bad-tls flags.
+type badTLSFlag [][2]string
+
+func (f *badTLSFlag) String() string { return "" }
+
+func (f *badTLSFlag) Set(s string) error {
+	i := strings.IndexByte(s, '=')
+	if i < 0 {
+		return errors.New("want <mode>=<host:port>")
+	}
+	for _, m := range badTLSModes {
+		if m == s[:i] {
+			*f = append(*f, [2]string{s[:i], s[i+1:]})
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown mode %q, want one of %s", s[:i], strings.Join(badTLSModes, ", "))
+}
+
+// testPKI is a root CA and an intermediate CA issuing the certificates of
+// the -bad-tls listeners.
+type testPKI struct {
+	root, intermediate       *x509.Certificate
+	rootKey, intermediateKey *ecdsa.PrivateKey
+}
+
+func newTestPKI() (*testPKI, error) {
+	p := &testPKI{}
+	var err error
+	ca := &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "go-httpbin test root CA", Organization: []string{"go-httpbin"}},
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	if p.root, p.rootKey, err = issue(ca, nil, nil, 10); err != nil {
+		return nil, err
+	}
+	ica := *ca
+	ica.Subject.CommonName = "go-httpbin test intermediate CA"
+	if p.intermediate, p.intermediateKey, err = issue(&ica, p.root, p.rootKey, 5); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// issue creates a certificate from tmpl with a new key, signed by parent, or
+// self-signed if parent is nil, valid from an hour ago for the given number
+// of years (negative for one that expired yesterday).
+func issue(tmpl, parent *x509.Certificate, parentKey *ecdsa.PrivateKey, years int) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	if err != nil {
+		return nil, nil, err
+	}
+	c := *tmpl
+	c.SerialNumber = serial
+	now := time.Now()
+	if years < 0 {
+		c.NotBefore, c.NotAfter = now.AddDate(0, 0, -30), now.AddDate(0, 0, -1)
+	} else {
+		c.NotBefore, c.NotAfter = now.Add(-time.Hour), now.AddDate(years, 0, 0)
+	}
+	if parent == nil {
+		parent, parentKey = &c, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &c, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	return cert, key, err
+}
+
+// certificate returns the certificate a -bad-tls listener serves in the
+// given mode.
+func (p *testPKI) certificate(mode string) (tls.Certificate, error) {
+	leaf := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "localhost", Organization: []string{"go-httpbin " + mode}},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:    []string{"localhost"},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	parent, parentKey, years := p.intermediate, p.intermediateKey, 1
+	switch mode {
+	case "expired":
+		years = -1
+	case "self-signed":
+		parent, parentKey = nil, nil
+	case "wrong-host":
+		leaf.Subject.CommonName = "wrong.host.invalid"
+		leaf.DNSNames, leaf.IPAddresses = []string{"wrong.host.invalid"}, nil
+	}
+	cert, key, err := issue(leaf, parent, parentKey, years)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	c := tls.Certificate{Certificate: [][]byte{cert.Raw}, PrivateKey: key, Leaf: cert}
+	if parent != nil && mode != "incomplete-chain" {
+		c.Certificate = append(c.Certificate, p.intermediate.Raw)
+	}
+	if strings.HasPrefix(mode, "ocsp-") {
+		if c.OCSPStaple, err = p.ocspResponse(cert, mode == "ocsp-revoked"); err != nil {
+			return tls.Certificate{}, err
+		}
+	}
+	return c, nil
+}
+
+// writeRoot writes the root CA certificate to file in PEM.
+func (p *testPKI) writeRoot(file string) error {
+	return ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: p.root.Raw}), 0644)
+}
+
+// The ASN.1 structures of an RFC 6960 OCSP response, signed by the issuer
+// of the certificate it is about.
+
+type ocspResponse struct {
+	Status   asn1.Enumerated
+	Response ocspResponseBytes `asn1:"explicit,tag:0"`
+}
+
+type ocspResponseBytes struct {
+	Type     asn1.ObjectIdentifier
+	Response []byte
+}
+
+type ocspBasicResponse struct {
+	TBSResponseData    asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+}
+
+type ocspResponseData struct {
+	ResponderID asn1.RawValue // byKey
+	ProducedAt  time.Time     `asn1:"generalized"`
+	Responses   []ocspSingleResponse
+}
+
+type ocspSingleResponse struct {
+	CertID     ocspCertID
+	CertStatus asn1.RawValue
+	ThisUpdate time.Time `asn1:"generalized"`
+	NextUpdate time.Time `asn1:"generalized,explicit,tag:0"`
+}
+
+type ocspCertID struct {
+	HashAlgorithm  pkix.AlgorithmIdentifier
+	IssuerNameHash []byte
+	IssuerKeyHash  []byte
+	SerialNumber   *big.Int
+}
+
+var (
+	oidOCSPBasic       = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+	oidSHA1            = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+// ocspResponse returns a DER OCSP response from the intermediate CA
+// reporting cert as good or revoked, valid for a day.
+func (p *testPKI) ocspResponse(cert *x509.Certificate, revoked bool) ([]byte, error) {
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(p.intermediate.RawSubjectPublicKeyInfo, &spki); err != nil {
+		return nil, err
+	}
+	nameHash := sha1.Sum(p.intermediate.RawSubject)
+	keyHash := sha1.Sum(spki.PublicKey.RightAlign())
+
+	now := time.Now().UTC().Truncate(time.Second)
+	status := asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0} // good, an implicit NULL
+	if revoked {
+		revokedAt, err := asn1.MarshalWithParams(now.Add(-time.Hour), "generalized")
+		if err != nil {
+			return nil, err
+		}
+		status = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 1, IsCompound: true, Bytes: revokedAt}
+	}
+	keyID, err := asn1.Marshal(keyHash[:])
+	if err != nil {
+		return nil, err
+	}
+	tbs, err := asn1.Marshal(ocspResponseData{
+		ResponderID: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2, IsCompound: true, Bytes: keyID},
+		ProducedAt:  now,
+		Responses: []ocspSingleResponse{{
+			CertID: ocspCertID{
+				HashAlgorithm:  pkix.AlgorithmIdentifier{Algorithm: oidSHA1, Parameters: asn1.NullRawValue},
+				IssuerNameHash: nameHash[:],
+				IssuerKeyHash:  keyHash[:],
+				SerialNumber:   cert.SerialNumber,
+			},
+			CertStatus: status,
+			ThisUpdate: now,
+			NextUpdate: now.Add(24 * time.Hour),
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(tbs)
+	sig, err := p.intermediateKey.Sign(rand.Reader, sum[:], crypto.SHA256)
+	if err != nil {
+		return nil, err
+	}
+	basic, err := asn1.Marshal(ocspBasicResponse{
+		TBSResponseData:    asn1.RawValue{FullBytes: tbs},
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256},
+		Signature:          asn1.BitString{Bytes: sig, BitLength: 8 * len(sig)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(ocspResponse{
+		Status:   0, // successful
+		Response: ocspResponseBytes{Type: oidOCSPBasic, Response: basic},
+	})
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// restartFlags configure listeners, their TLS and lifetime, so changing them in the
+// config file takes a restart rather than a SIGHUP.
+var restartFlags = map[string]bool{
+	"host": true, "https": true, "profile": true, "bad-tls": true, "bad-tls-ca": true, "config": true,
+	"exit-after": true, "exit-idle": true, "dual-stack": true, "ipv4-faults": true, "ipv6-faults": true,
+	"tls-cert": true, "tls-key": true, "tls-min": true, "tls-max": true, "tls-ciphers": true, "tls-curves": true,
+	"tls-auto": true, "tls-hosts": true, "tls-auto-pem": true,
+}
+
+// configSetting is a flag set by the config file, with its values in order;
+// repeatable flags may have several.
+type configSetting struct {
+	name   string
+	values []string
+}
+
+// applyConfigFile sets the flags named in the file at path, a TOML file or,
+// with a .yaml or .yml extension, a YAML file, except those set on the
+// command line. The keys of a table or nested mapping are prefixed with its
+// name and a dash, so min in a tls table sets -tls-min, except under a
+// repeatable <key>=<value> flag, which each key sets once, so "/docs" = "./site"
+// in a static table sets -static /docs=./site. When reloading, the other
+// flags go back to their defaults first and listener flags are left alone.
+func applyConfigFile(path string, cmdline map[string]bool, reload bool) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var m map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &m)
+	default:
+		err = toml.Unmarshal(b, &m)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	settings, err := configSettings("", m)
+	if err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+	for _, s := range settings {
+		if flag.Lookup(s.name) == nil {
+			return fmt.Errorf("%s: unknown flag %q", path, s.name)
+		}
+	}
+
+	if reload {
+		var err error
+		flag.VisitAll(func(f *flag.Flag) {
+			if cmdline[f.Name] || restartFlags[f.Name] || err != nil {
+				return
+			}
+			switch f.Name {
+			case "quota":
+				quotas = nil
+				return
+			case "static":
+				mounts = nil
+				return
+			}
+			err = f.Value.Set(f.DefValue)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	for _, s := range settings {
+		if cmdline[s.name] || reload && restartFlags[s.name] {
+			continue
+		}
+		for _, v := range s.values {
+			if err := flag.Set(s.name, v); err != nil {
+				return fmt.Errorf("%s: -%s: %v", path, s.name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// keyedFlags are the repeatable <key>=<value> flags, which a table or nested
+// mapping of their name sets once per key rather than prefixing its keys.
+var keyedFlags = map[string]bool{"quota": true, "profile": true, "bad-tls": true, "static": true}
+
+// configSettings flattens a decoded config file into the flags it sets, in
+// key order.
+func configSettings(prefix string, m map[string]interface{}) ([]configSetting, error) {
+	var settings []configSetting
+	for _, k := range sortedKeys(m) {
+		name := configKey(prefix, k)
+		switch v := m[k].(type) {
+		case map[string]interface{}:
+			if keyedFlags[name] {
+				s := configSetting{name: name}
+				for _, key := range sortedKeys(v) {
+					value, err := configValue(name, v[key])
+					if err != nil {
+						return nil, err
+					}
+					s.values = append(s.values, key+"="+value)
+				}
+				settings = append(settings, s)
+				continue
+			}
+			sub, err := configSettings(name, v)
+			if err != nil {
+				return nil, err
+			}
+			settings = append(settings, sub...)
+		case []interface{}:
+			s := configSetting{name: name}
+			for _, item := range v {
+				value, err := configValue(name, item)
+				if err != nil {
+					return nil, err
+				}
+				s.values = append(s.values, value)
+			}
+			settings = append(settings, s)
+		default:
+			value, err := configValue(name, v)
+			if err != nil {
+				return nil, err
+			}
+			settings = append(settings, configSetting{name, []string{value}})
+		}
+	}
+	return settings, nil
+}
+
+// configValue returns the flag value a scalar of a config file stands for.
+func configValue(name string, v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case nil:
+		return "", nil
+	}
+	return "", fmt.Errorf("%s: want a string, number, boolean or array of those", name)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func configKey(table, key string) string {
+	if table == "" {
+		return key
+	}
+	return table + "-" + key
+}
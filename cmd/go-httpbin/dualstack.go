@@ -0,0 +1,212 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ahmetb/go-httpbin"
+)
+
+// familyFaults are the misbehaviours of a -dual-stack listener.
+type familyFaults struct {
+	refuse    bool          // don't listen, so connections are refused
+	blackhole bool          // let connection attempts time out
+	reset     float64       // fraction of accepted connections to reset
+	delay     time.Duration // before reading from accepted connections
+}
+
+// parseFamilyFaults parses the comma-separated faults of -ipv4-faults or
+// -ipv6-faults: refuse, blackhole, reset[=<fraction>] and delay=<duration>.
+func parseFamilyFaults(s string) (familyFaults, error) {
+	var f familyFaults
+	for _, fault := range strings.Split(s, ",") {
+		name, value := strings.TrimSpace(fault), ""
+		if i := strings.IndexByte(name, '='); i >= 0 {
+			name, value = name[:i], name[i+1:]
+		}
+		switch {
+		case name == "":
+		case name == "refuse" && value == "":
+			f.refuse = true
+		case name == "blackhole" && value == "":
+			f.blackhole = true
+		case name == "reset":
+			f.reset = 1
+			if value != "" {
+				p, err := strconv.ParseFloat(value, 64)
+				if err != nil || p < 0 || p > 1 {
+					return f, fmt.Errorf("%s: the fraction of connections to reset must be between 0 and 1", fault)
+				}
+				f.reset = p
+			}
+		case name == "delay":
+			d, err := time.ParseDuration(value)
+			if err != nil || d < 0 {
+				return f, fmt.Errorf("%s: want delay=<duration>", fault)
+			}
+			f.delay = d
+		default:
+			return f, fmt.Errorf("unknown fault %q, want refuse, blackhole, reset[=<fraction>] or delay=<duration>", fault)
+		}
+	}
+	if f.refuse && f.blackhole {
+		return f, errors.New("refuse and blackhole are exclusive")
+	}
+	return f, nil
+}
+
+// blackholes are the listeners of blackholed families and the connections
+// filling their accept queues, kept open for the life of the process.
+var blackholes []interface{}
+
+// serveDualStack serves h on separate IPv4 and IPv6 listeners at the port of
+// addr, on all addresses if its host is empty or the loopback ones if it is
+// localhost, misbehaving as ipv4Faults and ipv6Faults ask. It returns the
+// servers started.
+func serveDualStack(addr, ipv4Faults, ipv6Faults string, h http.Handler) ([]*http.Server, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("-dual-stack: %v", err)
+	}
+	if port == "0" {
+		return nil, errors.New("-dual-stack: the port, shared by both families, can't be 0")
+	}
+	var hosts [2]string
+	switch host {
+	case "":
+		hosts = [2]string{"0.0.0.0", "::"}
+	case "localhost":
+		hosts = [2]string{"127.0.0.1", "::1"}
+	default:
+		return nil, fmt.Errorf("-dual-stack: the host must be empty or localhost, not %q", host)
+	}
+
+	var srvs []*http.Server
+	for i, family := range []struct{ network, name, faults string }{
+		{"tcp4", "IPv4", ipv4Faults},
+		{"tcp6", "IPv6", ipv6Faults},
+	} {
+		f, err := parseFamilyFaults(family.faults)
+		if err != nil {
+			return nil, fmt.Errorf("-%s-faults: %v", strings.ToLower(family.name), err)
+		}
+		addr := net.JoinHostPort(hosts[i], port)
+		desc := family.faults
+		if desc == "" {
+			desc = "no faults"
+		}
+
+		switch {
+		case f.refuse:
+			log.Printf("go-httpbin (%s, refusing connections) not listening on %s", family.name, addr)
+			continue
+		case f.blackhole:
+			if err := listenBlackhole(family.network, addr); err != nil {
+				return nil, err
+			}
+			log.Printf("go-httpbin (%s, %s) blackholing %s", family.name, desc, addr)
+			continue
+		}
+		l, err := net.Listen(family.network, addr)
+		if err != nil {
+			return nil, err
+		}
+		srv := &http.Server{
+			Addr:        addr,
+			Handler:     h,
+			ConnState:   httpbin.ConnState,
+			ConnContext: httpbin.ConnContext,
+		}
+		log.Printf("go-httpbin (%s, %s) listening on %s", family.name, desc, addr)
+		srvs = append(srvs, srv)
+		go func() {
+			if err := srv.Serve(httpbin.Listener(&faultListener{l, f})); err != http.ErrServerClosed {
+				log.Fatal(err)
+			}
+		}()
+	}
+	return srvs, nil
+}
+
+// listenBlackhole listens on addr with a backlog of zero and fills the
+// accept queue with a connection of its own, never accepting it, so that
+// the kernel drops the SYNs of further connection attempts and they time
+// out.
+func listenBlackhole(network, addr string) error {
+	l, err := net.Listen(network, addr)
+	if err != nil {
+		return err
+	}
+	rc, err := l.(*net.TCPListener).SyscallConn()
+	if err == nil {
+		rc.Control(func(fd uintptr) { err = setBacklog(fd, 0) })
+	}
+	if err != nil {
+		l.Close()
+		return fmt.Errorf("blackhole %s: %v", addr, err)
+	}
+
+	target := l.Addr().(*net.TCPAddr)
+	if target.IP.IsUnspecified() {
+		target.IP = net.IPv4(127, 0, 0, 1)
+		if network == "tcp6" {
+			target.IP = net.IPv6loopback
+		}
+	}
+	c, err := net.DialTimeout(network, target.String(), time.Second)
+	if err != nil {
+		l.Close()
+		return fmt.Errorf("blackhole %s: %v", addr, err)
+	}
+	blackholes = append(blackholes, l, c)
+	return nil
+}
+
+// faultListener injects the faults of a -dual-stack family into the
+// connections it accepts.
+type faultListener struct {
+	net.Listener
+	faults familyFaults
+}
+
+func (l *faultListener) Accept() (net.Conn, error) {
+	for {
+		c, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		if l.faults.reset > 0 && rand.Float64() < l.faults.reset {
+			if tc, ok := c.(*net.TCPConn); ok {
+				tc.SetLinger(0) // close with a RST
+			}
+			c.Close()
+			continue
+		}
+		if l.faults.delay > 0 {
+			c = &delayedConn{Conn: c, delay: l.faults.delay}
+		}
+		return c, nil
+	}
+}
+
+// delayedConn waits for delay before its first read.
+type delayedConn struct {
+	net.Conn
+	delay   time.Duration
+	delayed bool
+}
+
+func (c *delayedConn) Read(p []byte) (int, error) {
+	if !c.delayed {
+		c.delayed = true
+		time.Sleep(c.delay)
+	}
+	return c.Conn.Read(p)
+}
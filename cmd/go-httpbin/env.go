@@ -0,0 +1,36 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// envPrefix prefixes the names of the environment variables setting flags.
+const envPrefix = "HTTPBIN_"
+
+// envName returns the name of the environment variable setting the flag
+// name, e.g. HTTPBIN_DELAY_MAX for -delay-max.
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.Replace(name, "-", "_", -1))
+}
+
+// applyEnv sets the flags not set on the command line from their
+// environment variables, marking them in cmdline so the config file does
+// not override them either.
+func applyEnv(cmdline map[string]bool) error {
+	var err error
+	flag.VisitAll(func(f *flag.Flag) {
+		v, ok := os.LookupEnv(envName(f.Name))
+		if cmdline[f.Name] || !ok || err != nil {
+			return
+		}
+		if err = f.Value.Set(v); err != nil {
+			err = fmt.Errorf("%s: %v", envName(f.Name), err)
+			return
+		}
+		cmdline[f.Name] = true
+	})
+	return err
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode/utf8"
+)
+
+// inspectedRequest is a request captured by the inspect subcommand.
+type inspectedRequest struct {
+	Time       string              `json:"time"`
+	RemoteAddr string              `json:"remote_addr"`
+	Method     string              `json:"method"`
+	URL        string              `json:"url"`
+	Proto      string              `json:"proto"`
+	Host       string              `json:"host"`
+	Headers    map[string][]string `json:"headers"`
+	Trailers   map[string][]string `json:"trailers,omitempty"`
+	Body       string              `json:"body"`
+	BodyBase64 bool                `json:"body_base64,omitempty"` // for bodies that are not UTF-8
+}
+
+// inspect runs `go-httpbin inspect`: it listens on -listen, prints the requests
+// it receives to stderr as they arrive, and once -count of them did, writes
+// them to stdout as a JSON array. It returns the exit code.
+func inspect(args []string) int {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	listen := fs.String("listen", ":0", "<host:port> to listen on; port 0 picks a free one")
+	count := fs.Int("count", 1, "number of requests to capture before exiting; 0 captures until interrupted")
+	timeout := fs.Duration("timeout", 0, "exit with status 1 if the requests haven't arrived in time (default: wait forever)")
+	status := fs.Int("status", http.StatusOK, "status to respond to the captured requests with")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), "usage: go-httpbin inspect [flags]\n\nWaits for requests, prints them to stderr, and writes them to stdout as JSON.\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *count < 0 || *status < 100 || *status > 999 || fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+
+	l, err := net.Listen("tcp", *listen)
+	if err != nil {
+		log.Print(err)
+		return 1
+	}
+	log.Printf("inspect listening on %s", l.Addr())
+
+	var (
+		mu       sync.Mutex
+		captured = []inspectedRequest{}
+		done     = make(chan struct{})
+	)
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ir, err := captureRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		if *count > 0 && len(captured) == *count {
+			mu.Unlock()
+			http.Error(w, "enough requests were captured", http.StatusServiceUnavailable)
+			return
+		}
+		captured = append(captured, ir)
+		n := len(captured)
+		printRequest(n, ir)
+		mu.Unlock()
+
+		w.WriteHeader(*status)
+		if n == *count {
+			close(done)
+		}
+	})}
+	go srv.Serve(l)
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	var expired <-chan time.Time
+	if *timeout > 0 {
+		expired = time.After(*timeout)
+	}
+	code := 0
+	select {
+	case <-done:
+	case <-interrupt:
+		if *count > 0 {
+			code = 1
+		}
+	case <-expired:
+		log.Printf("timed out waiting for requests")
+		code = 1
+	}
+
+	// let the last response go out
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	srv.Shutdown(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(captured); err != nil {
+		log.Print(err)
+		return 1
+	}
+	return code
+}
+
+// captureRequest reads the body of r and records it with the rest of r.
+func captureRequest(r *http.Request) (inspectedRequest, error) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return inspectedRequest{}, fmt.Errorf("failed to read body: %v", err)
+	}
+	ir := inspectedRequest{
+		Time:       time.Now().UTC().Format(time.RFC3339Nano),
+		RemoteAddr: r.RemoteAddr,
+		Method:     r.Method,
+		URL:        r.RequestURI,
+		Proto:      r.Proto,
+		Host:       r.Host,
+		Headers:    r.Header,
+		Body:       string(body),
+	}
+	if len(r.Trailer) > 0 {
+		ir.Trailers = r.Trailer
+	}
+	if !utf8.Valid(body) {
+		ir.Body, ir.BodyBase64 = base64.StdEncoding.EncodeToString(body), true
+	}
+	return ir, nil
+}
+
+// printRequest prints the nth captured request to stderr much as it came
+// over the wire, with sorted headers.
+func printRequest(n int, ir inspectedRequest) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- request %d from %s at %s\n", n, ir.RemoteAddr, ir.Time)
+	fmt.Fprintf(&b, "%s %s %s\nHost: %s\n", ir.Method, ir.URL, ir.Proto, ir.Host)
+	writeHeaders(&b, ir.Headers)
+	b.WriteString("\n")
+	switch {
+	case ir.BodyBase64:
+		raw, _ := base64.StdEncoding.DecodeString(ir.Body)
+		fmt.Fprintf(&b, "[%d bytes of binary body]\n", len(raw))
+	case ir.Body != "":
+		b.WriteString(ir.Body)
+		if !strings.HasSuffix(ir.Body, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	if len(ir.Trailers) > 0 {
+		b.WriteString("\n")
+		writeHeaders(&b, ir.Trailers)
+	}
+	fmt.Fprint(os.Stderr, b.String())
+}
+
+func writeHeaders(b *strings.Builder, h map[string][]string) {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range h[k] {
+			fmt.Fprintf(b, "%s: %s\n", k, v)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// shutdownTimeout is how long requests in flight get to finish when the
+// server exits after -exit-after or -exit-idle, or on SIGTERM or SIGINT.
+const shutdownTimeout = 5 * time.Second
+
+// activityHandler counts the requests h is serving and records when the
+// last one ended, so -exit-idle can tell how long the server was idle.
+type activityHandler struct {
+	h        http.Handler
+	inflight int64
+	last     int64 // unix nanoseconds
+}
+
+func newActivityHandler(h http.Handler) *activityHandler {
+	return &activityHandler{h: h, last: time.Now().UnixNano()}
+}
+
+func (a *activityHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt64(&a.inflight, 1)
+	defer func() {
+		atomic.StoreInt64(&a.last, time.Now().UnixNano())
+		atomic.AddInt64(&a.inflight, -1)
+	}()
+	a.h.ServeHTTP(w, r)
+}
+
+// idle returns how long no request has been served for at now.
+func (a *activityHandler) idle(now time.Time) time.Duration {
+	if atomic.LoadInt64(&a.inflight) > 0 {
+		return 0
+	}
+	return now.Sub(time.Unix(0, atomic.LoadInt64(&a.last)))
+}
+
+// exitWhenDone waits until the server started at start has run for after,
+// or a has been idle for idle, zero disabling either, then shuts srvs down
+// and exits.
+func exitWhenDone(srvs []*http.Server, a *activityHandler, start time.Time, after, idle time.Duration) {
+	for {
+		now := time.Now()
+		if after > 0 && now.Sub(start) >= after {
+			log.Printf("shutting down after running for %v", after)
+			break
+		}
+		if idle > 0 && a.idle(now) >= idle {
+			log.Printf("shutting down after %v without requests", idle)
+			break
+		}
+
+		wait := time.Duration(1<<63 - 1)
+		if after > 0 {
+			wait = start.Add(after).Sub(now)
+		}
+		if idle > 0 && idle-a.idle(now) < wait {
+			wait = idle - a.idle(now)
+		}
+		time.Sleep(wait)
+	}
+	shutdown(srvs)
+}
+
+// exitOnSignal waits for SIGTERM or SIGINT, then shuts srvs down and exits.
+func exitOnSignal(srvs []*http.Server) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGTERM, os.Interrupt)
+	log.Printf("shutting down on %v", <-c)
+	shutdown(srvs)
+}
+
+// shutdown shuts srvs down, letting requests in flight finish for up to
+// shutdownTimeout, and exits.
+func shutdown(srvs []*http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	var wg sync.WaitGroup
+	for _, srv := range srvs {
+		wg.Add(1)
+		go func(srv *http.Server) {
+			defer wg.Done()
+			if err := srv.Shutdown(ctx); err != nil {
+				log.Printf("shutting down %s: %v", srv.Addr, err)
+			}
+		}(srv)
+	}
+	wg.Wait()
+	os.Exit(0)
+}
+
+// run serves srv, exiting if that fails, until srv is shut down.
+func run(srv *http.Server, useTLS bool) {
+	if err := serve(srv, useTLS); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
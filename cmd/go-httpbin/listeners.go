@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// listenFDsStart is the first file descriptor passed by socket activation.
+const listenFDsStart = 3
+
+// passedSocket is a listening socket the process was started with.
+type passedSocket struct {
+	name string
+	fd   int
+	file *os.File
+}
+
+// passedSockets are the sockets passed by systemd socket activation, in
+// order.
+var passedSockets = inheritSockets()
+
+// inheritSockets returns the sockets passed as in sd_listen_fds(3): the
+// LISTEN_FDS descriptors from 3 on, named by LISTEN_FDNAMES, if LISTEN_PID
+// is this process. The variables are unset so child processes don't take
+// the sockets for theirs.
+func inheritSockets() []passedSocket {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	for _, k := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+		os.Unsetenv(k)
+	}
+
+	sockets := make([]passedSocket, n)
+	for i := range sockets {
+		fd := listenFDsStart + i
+		name := strconv.Itoa(fd)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		sockets[i] = passedSocket{name: name, fd: fd, file: os.NewFile(uintptr(fd), name)}
+	}
+	return sockets
+}
+
+// listen listens on addr, a TCP <host:port> or, for a socket the process
+// was started with, fd:<name> with the name socket activation gave it or
+// fd:<number> with its file descriptor, which need not come from socket
+// activation.
+func listen(addr string) (net.Listener, error) {
+	if !strings.HasPrefix(addr, "fd:") {
+		return net.Listen("tcp", addr)
+	}
+	name := strings.TrimPrefix(addr, "fd:")
+	for _, s := range passedSockets {
+		if s.name == name || strconv.Itoa(s.fd) == name {
+			return net.FileListener(s.file)
+		}
+	}
+	fd, err := strconv.Atoi(name)
+	if err != nil || fd < listenFDsStart {
+		return nil, fmt.Errorf("%s: no socket named %q was passed", addr, name)
+	}
+	f := os.NewFile(uintptr(fd), addr)
+	defer f.Close()
+	l, err := net.FileListener(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", addr, err)
+	}
+	return l, nil
+}
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"text/template"
+	"time"
+
+	"github.com/ahmetb/go-httpbin"
+)
+
+var (
+	host            = flag.String("host", ":8080", "<host:port>, or fd:<name|number> for a socket the process was started with (default: the first socket-activated one, if any)")
+	delayMax        = flag.Duration("delay-max", 10*time.Second, "longest an endpoint delays or stalls a response")
+	maxBody         = flag.Int64("max-body", 0, "largest request body, in bytes, larger ones get 413 (default: no limit)")
+	mirrorTemplates = flag.String("mirror-templates", "", "glob of the Go template files /mirror renders, each named by its file name, e.g. \"templates/*.tmpl\"")
+	prefix          = flag.String("prefix", "", "URL path prefix to serve the endpoints under, e.g. /httpbin behind a reverse proxy")
+	strictMethods   = flag.Bool("strict-methods", false, "respond 405 to unsupported methods on known paths")
+	connect         = flag.Bool("connect", false, "accept CONNECT requests, acting as a tunneling proxy")
+	connectAllow    = flag.String("connect-allow", "", "comma-separated <host:port> CONNECT targets to tunnel to (default: echo tunnel only)")
+	configToken     = flag.String("config-token", "", "bearer token required to read /config (default: open)")
+	partitionToken  = flag.String("partition-token", "", "bearer token required to start and end network partitions at /partition (default: partitions can't be started over HTTP)")
+	maintToken      = flag.String("maintenance-token", "", "bearer token required to start and end maintenance mode at /maintenance (default: maintenance mode can't be started over HTTP)")
+	fixturesToken   = flag.String("fixtures-token", "", "bearer token required to PUT and DELETE fixtures at /serve/:name (default: fixtures can't be changed over HTTP)")
+	jobCallbacks    = flag.Bool("job-callbacks", false, "let POST /jobs ask for the finished job to be POSTed to a callback URL")
+	profiling       = flag.Bool("pprof", false, "serve runtime profiles under /debug/pprof/ and per-route allocations at /debug/handler-allocs")
+	decompress      = flag.Bool("decompress-requests", false, "decompress gzip and deflate request bodies, refusing bodies over -decompress-max bytes or -decompress-ratio times their compressed size with 413")
+	decompressMax   = flag.Int64("decompress-max", 16<<20, "largest decompressed request body, in bytes")
+	decompressRatio = flag.Float64("decompress-ratio", 100, "largest ratio of decompressed to compressed request body bytes")
+	configFile      = flag.String("config", "", "TOML file, or YAML file with a .yaml or .yml extension, of flag values keyed by flag name, under those on the command line; reloaded on SIGHUP")
+	disableRoutes   = flag.String("disable-routes", "", "comma-separated names of routes to leave out, as listed in the endpoints of /config")
+	stubsDir        = flag.String("stubs", "", "directory of JSON or YAML stub rule files whose canned responses take precedence over the endpoints; reloaded on change")
+	stubsInterval   = flag.Duration("stubs-interval", 2*time.Second, "how often to check the -stubs directory for changes")
+	exitAfter       = flag.Duration("exit-after", 0, "shut down after running for this long (default: never)")
+	exitIdle        = flag.Duration("exit-idle", 0, "shut down after serving no requests for this long (default: never)")
+	trace           = flag.Bool("trace-requests", false, "send a trace of the processing of requests with an X-Httpbin-Trace header")
+	latency         = flag.String("latency", "", "semicolon-separated <path pattern>=<distribution> latencies, e.g. \"/get=lognormal(50ms, 20ms)\"")
+	instanceID      = flag.String("instance-id", "", "ID of the instance reported by /sticky (default: random)")
+	region          = flag.String("region", "", "region label of the instance, e.g. us-east-1, sent in the X-Httpbin-Region header of every response")
+	zone            = flag.String("zone", "", "zone label of the instance, e.g. us-east-1a, sent in the X-Httpbin-Zone header of every response")
+	latencyProfiles = flag.String("latency-profiles", "", "semicolon-separated <name>=<distribution> latency profiles requests pick with the X-Httpbin-Latency-Profile header, e.g. \"slow=lognormal(1s, 300ms)\"")
+	regionLatency   = flag.String("region-latency", "", "distribution of the base latency added to every request, e.g. \"normal(80ms, 10ms)\", on top of -latency")
+	https           = flag.String("https", "", "<host:port> to also serve HTTPS on")
+	tlsCert         = flag.String("tls-cert", "", "certificate file for -https (default: self-signed for -tls-hosts)")
+	tlsKey          = flag.String("tls-key", "", "private key file for -https")
+	tlsAuto         = flag.Bool("tls-auto", false, "serve HTTPS with a self-signed certificate for -tls-hosts generated at startup, on -https or :8443")
+	tlsHosts        = flag.String("tls-hosts", "localhost,127.0.0.1,::1", "comma-separated host names and IP addresses of the self-signed certificate of -https")
+	tlsAutoPEM      = flag.String("tls-auto-pem", "", "file to write the self-signed certificate of -https to, in PEM, for clients to trust")
+	tlsMin          = flag.String("tls-min", "", "minimum TLS version for -https: 1.0, 1.1, 1.2 or 1.3")
+	tlsMax          = flag.String("tls-max", "", "maximum TLS version for -https: 1.0, 1.1, 1.2 or 1.3")
+	tlsCiphers      = flag.String("tls-ciphers", "", "comma-separated cipher suites for -https, e.g. TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA; TLS 1.3 suites are not configurable")
+	tlsCurves       = flag.String("tls-curves", "", "comma-separated curves for -https, in order of preference: X25519, P256, P384, P521")
+	imageCC         = flag.String("image-cache-control", "public, max-age=86400", "Cache-Control header of /image/* responses (empty: none)")
+	staticCC        = flag.String("static-cache-control", "public, max-age=300", "Cache-Control header of the home page and other HTML pages (empty: none)")
+	badTLSCA        = flag.String("bad-tls-ca", "", "file to write the root CA certificate of the -bad-tls listeners to, in PEM")
+	dualStack       = flag.String("dual-stack", "", "[localhost]:<port> to also serve on, with separate IPv4 and IPv6 listeners faulting as -ipv4-faults and -ipv6-faults ask, to test Happy Eyeballs clients")
+	ipv4Faults      = flag.String("ipv4-faults", "", "comma-separated faults of the -dual-stack IPv4 listener: refuse, blackhole (connection attempts time out), reset[=<fraction>] or delay=<duration>")
+	ipv6Faults      = flag.String("ipv6-faults", "", "comma-separated faults of the -dual-stack IPv6 listener, as for -ipv4-faults")
+	profiles        profileFlag
+	quotas          quotaFlag
+	badTLS          badTLSFlag
+	mounts          staticFlag
+)
+
+func init() {
+	flag.Var(&badTLS, "bad-tls", "<mode>=<host:port> additional HTTPS listener serving a certificate with a problem: "+strings.Join(badTLSModes, ", ")+"; repeatable")
+	flag.Var(&quotas, "quota", "<api key>=<requests>/<bytes> daily quota of requests with the X-Api-Key, or of those without a known key for *, 0 for unlimited; repeatable")
+	flag.Var(&mounts, "static", "<url path>=<directory> directory to serve the files of under the URL path, ahead of the endpoints; repeatable")
+	flag.Var(&profiles, "profile", "<fast|slow|flaky>=<host:port> additional listener serving a behavior profile, advertised by /alt-svc; repeatable")
+}
+
+// profileFlag collects -profile flags.
+type profileFlag [][2]string
+
+func (p *profileFlag) String() string { return "" }
+
+func (p *profileFlag) Set(s string) error {
+	i := strings.IndexByte(s, '=')
+	if i < 0 {
+		return errors.New("want <profile>=<host:port>")
+	}
+	*p = append(*p, [2]string{s[:i], s[i+1:]})
+	return nil
+}
+
+// quotaFlag collects -quota flags.
+type quotaFlag map[string]httpbin.Quota
+
+func (q *quotaFlag) String() string { return "" }
+
+func (q *quotaFlag) Set(s string) error {
+	i := strings.IndexByte(s, '=')
+	j := strings.IndexByte(s, '/')
+	if i < 0 || j < i {
+		return errors.New("want <api key>=<requests>/<bytes>")
+	}
+	requests, err := strconv.ParseInt(s[i+1:j], 10, 64)
+	if err != nil {
+		return errors.New("want <api key>=<requests>/<bytes>")
+	}
+	bytes, err := strconv.ParseInt(s[j+1:], 10, 64)
+	if err != nil {
+		return errors.New("want <api key>=<requests>/<bytes>")
+	}
+	if *q == nil {
+		*q = make(quotaFlag)
+	}
+	(*q)[s[:i]] = httpbin.Quota{Requests: requests, Bytes: bytes}
+	return nil
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "inspect" {
+		os.Exit(inspect(os.Args[2:]))
+	}
+
+	flag.Parse()
+	cmdline := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { cmdline[f.Name] = true })
+	if err := applyEnv(cmdline); err != nil {
+		log.Fatal(err)
+	}
+	if *configFile != "" {
+		if err := applyConfigFile(*configFile, cmdline, false); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if len(passedSockets) > 0 && !cmdline["host"] && *host == ":8080" {
+		*host = "fd:" + passedSockets[0].name
+	}
+
+	var h swapHandler
+	mux, err := newHandler()
+	if err != nil {
+		log.Fatal(err)
+	}
+	h.v.Store(mux)
+	if *configFile != "" {
+		go reloadOnHUP(*configFile, cmdline, &h)
+	}
+	start := time.Now()
+	act := newActivityHandler(&h)
+	var srvs []*http.Server
+
+	for _, p := range profiles {
+		name, addr := p[0], p[1]
+		ph, err := httpbin.ProfileHandler(act, name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		srv := &http.Server{
+			Addr:        addr,
+			Handler:     ph,
+			ConnState:   httpbin.ConnState,
+			ConnContext: httpbin.ConnContext,
+		}
+		log.Printf("go-httpbin (%s) listening on %s", name, addr)
+		srvs = append(srvs, srv)
+		go run(srv, false)
+	}
+
+	if *tlsAuto {
+		if *tlsCert != "" || *tlsKey != "" {
+			log.Fatal("-tls-auto generates the certificate, -tls-cert and -tls-key can't be set with it")
+		}
+		if *https == "" {
+			*https = ":8443"
+		}
+	}
+	if *https != "" {
+		cfg, h2, err := tlsConfig(*tlsCert, *tlsKey, *tlsHosts, *tlsMin, *tlsMax, *tlsCiphers, *tlsCurves)
+		if err != nil {
+			log.Fatal(err)
+		}
+		srv := &http.Server{
+			Addr:        *https,
+			Handler:     act,
+			TLSConfig:   cfg,
+			ConnState:   httpbin.ConnState,
+			ConnContext: httpbin.ConnContext,
+		}
+		if !h2 {
+			srv.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){} // disables HTTP/2
+		}
+		log.Printf("go-httpbin listening on %s (HTTPS)", *https)
+		srvs = append(srvs, srv)
+		go run(srv, true)
+	}
+
+	if len(badTLS) > 0 {
+		pki, err := newTestPKI()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if *badTLSCA != "" {
+			if err := pki.writeRoot(*badTLSCA); err != nil {
+				log.Fatal(err)
+			}
+		}
+		for _, b := range badTLS {
+			mode, addr := b[0], b[1]
+			cert, err := pki.certificate(mode)
+			if err != nil {
+				log.Fatal(err)
+			}
+			srv := &http.Server{
+				Addr:        addr,
+				Handler:     act,
+				TLSConfig:   &tls.Config{Certificates: []tls.Certificate{cert}},
+				ConnState:   httpbin.ConnState,
+				ConnContext: httpbin.ConnContext,
+			}
+			log.Printf("go-httpbin (%s certificate) listening on %s", mode, addr)
+			srvs = append(srvs, srv)
+			go run(srv, true)
+		}
+	}
+
+	if *dualStack != "" {
+		ds, err := serveDualStack(*dualStack, *ipv4Faults, *ipv6Faults, act)
+		if err != nil {
+			log.Fatal(err)
+		}
+		srvs = append(srvs, ds...)
+	}
+
+	srv := &http.Server{
+		Addr:        *host,
+		Handler:     act,
+		ConnState:   httpbin.ConnState,
+		ConnContext: httpbin.ConnContext,
+	}
+	srvs = append(srvs, srv)
+	if *exitAfter > 0 || *exitIdle > 0 {
+		go exitWhenDone(srvs, act, start, *exitAfter, *exitIdle)
+	}
+	go exitOnSignal(srvs)
+	log.Printf("go-httpbin listening on %s", *host)
+	run(srv, false)
+	select {} // until exitWhenDone or exitOnSignal exits
+}
+
+// bin is the httpbin server configured from the flags. Reloading the config
+// file reconfigures it, keeping the jobs, sessions and other state its
+// clients created.
+var bin *httpbin.HTTPBin
+
+// newHandler configures bin from the flags and returns the handler to serve.
+func newHandler() (http.Handler, error) {
+	lp, err := httpbin.ParseLatencyProfiles(*latencyProfiles)
+	if err != nil {
+		return nil, err
+	}
+	altServices := make(map[string]string, len(profiles))
+	for _, p := range profiles {
+		altServices[p[0]] = p[1]
+	}
+	opts := []httpbin.Option{
+		httpbin.WithPrefix(*prefix),
+		httpbin.WithDelayMax(*delayMax),
+		httpbin.WithMaxBodySize(*maxBody),
+		httpbin.WithLatencyProfiles(lp),
+		httpbin.WithStrictMethods(*strictMethods),
+		httpbin.WithConfigToken(*configToken),
+		httpbin.WithFixtureToken(*fixturesToken),
+		httpbin.WithMaintenanceToken(*maintToken),
+		httpbin.WithJobCallbacks(*jobCallbacks),
+		httpbin.WithProfiling(*profiling),
+		httpbin.WithImageCacheControl(*imageCC),
+		httpbin.WithStaticCacheControl(*staticCC),
+		httpbin.WithDecompressRequests(*decompress),
+		httpbin.WithRequestDecompressedMax(*decompressMax),
+		httpbin.WithRequestDecompressionRatioMax(*decompressRatio),
+		httpbin.WithTraceRequests(*trace),
+		httpbin.WithQuotas(quotas),
+		httpbin.WithRegion(*region, *zone),
+		httpbin.WithAltServices(altServices),
+		httpbin.WithPartitionToken(*partitionToken),
+	}
+	if *latency != "" {
+		l, err := httpbin.ParseRouteLatencies(*latency)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, httpbin.WithRouteLatencies(l))
+	}
+	if *instanceID != "" {
+		opts = append(opts, httpbin.WithInstanceID(*instanceID))
+	}
+	if *regionLatency != "" {
+		d, err := httpbin.ParseLatencyDistribution(*regionLatency)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, httpbin.WithRegionLatency(d))
+	}
+	if *disableRoutes != "" {
+		disabled := make(map[string]bool)
+		for _, name := range strings.Split(*disableRoutes, ",") {
+			disabled[strings.TrimSpace(name)] = true
+		}
+		opts = append(opts, httpbin.WithDisabledRoutes(disabled))
+	}
+	if *mirrorTemplates != "" {
+		t, err := template.New("").Funcs(httpbin.MirrorFuncs()).ParseGlob(*mirrorTemplates)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, httpbin.WithMirrorTemplates(t))
+	}
+
+	if err := watchStubs(); err != nil {
+		return nil, err
+	}
+
+	if bin == nil {
+		bin = httpbin.New(opts...)
+	} else {
+		bin = bin.Reconfigure(opts...)
+	}
+	h := mountStatic(bin.Handler(), mounts)
+	if stubs != nil {
+		h = httpbin.StubHandler(h, stubs)
+	}
+	if *connect {
+		var allow []string
+		if *connectAllow != "" {
+			allow = strings.Split(*connectAllow, ",")
+		}
+		h = httpbin.ConnectHandler(h, allow...)
+	}
+	return h, nil
+}
+
+// stubs are the rules of -stubs, watched for changes until stopStubs is
+// closed.
+var (
+	stubs     *httpbin.Stubs
+	stopStubs chan struct{}
+)
+
+// watchStubs loads the rules of -stubs and watches them for changes, unless
+// the directory is already watched.
+func watchStubs() error {
+	if stubs != nil && stubs.Dir() == *stubsDir {
+		return nil
+	}
+	if stopStubs != nil {
+		close(stopStubs)
+		stubs, stopStubs = nil, nil
+	}
+	if *stubsDir == "" {
+		return nil
+	}
+	s, err := httpbin.LoadStubs(*stubsDir)
+	if err != nil {
+		return err
+	}
+	log.Printf("loaded %d stubs from %s", len(s.Rules()), s.Dir())
+	stubs, stopStubs = s, make(chan struct{})
+	go s.Watch(*stubsInterval, stopStubs, func(err error) {
+		if err != nil {
+			log.Printf("failed to reload stubs: %v", err)
+			return
+		}
+		log.Printf("reloaded %d stubs from %s", len(s.Rules()), s.Dir())
+	})
+	return nil
+}
+
+// swapHandler serves requests with the handler last stored in it, so that
+// reloading the config file can replace the handler of running servers.
+type swapHandler struct {
+	v atomic.Value // http.Handler
+}
+
+func (s *swapHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.v.Load().(http.Handler).ServeHTTP(w, r)
+}
+
+// reloadOnHUP reapplies the config file at path and replaces the handler of
+// h on every SIGHUP. Settings of the listeners only change on a restart.
+func reloadOnHUP(path string, cmdline map[string]bool, h *swapHandler) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGHUP)
+	for range c {
+		if err := applyConfigFile(path, cmdline, true); err != nil {
+			log.Printf("failed to reload config: %v", err)
+			continue
+		}
+		mux, err := newHandler()
+		if err != nil {
+			log.Printf("failed to reload config: %v", err)
+			continue
+		}
+		h.v.Store(mux)
+		log.Printf("reloaded config from %s", path)
+	}
+}
+
+// serve serves srv on its address, see listen, through httpbin.Listener, so /post can
+// report how long reading request headers took.
+func serve(srv *http.Server, useTLS bool) error {
+	l, err := listen(srv.Addr)
+	if err != nil {
+		return err
+	}
+	l = httpbin.Listener(l)
+	if useTLS {
+		return srv.ServeTLS(l, "", "")
+	}
+	return srv.Serve(l)
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// staticFlag collects -static flags.
+type staticFlag [][2]string
+
+func (s *staticFlag) String() string { return "" }
+
+func (s *staticFlag) Set(v string) error {
+	i := strings.IndexByte(v, '=')
+	if i < 0 || !strings.HasPrefix(v, "/") || v[i+1:] == "" {
+		return errors.New("want <url path>=<directory>")
+	}
+	*s = append(*s, [2]string{strings.TrimSuffix(v[:i], "/"), v[i+1:]})
+	return nil
+}
+
+// mountStatic returns h with the files of the -static directories served
+// under their URL paths, the longest matching one first, ahead of the
+// endpoints.
+func mountStatic(h http.Handler, mounts staticFlag) http.Handler {
+	if len(mounts) == 0 {
+		return h
+	}
+	mounts = append(staticFlag(nil), mounts...)
+	sort.SliceStable(mounts, func(i, j int) bool { return len(mounts[i][0]) > len(mounts[j][0]) })
+	servers := make([]http.Handler, len(mounts))
+	for i, m := range mounts {
+		servers[i] = http.StripPrefix(m[0], http.FileServer(http.Dir(m[1])))
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i, m := range mounts {
+			if r.URL.Path == m[0] || strings.HasPrefix(r.URL.Path, m[0]+"/") {
+				servers[i].ServeHTTP(w, r)
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"strings"
+
+	"github.com/ahmetb/go-httpbin"
+)
+
+var versionIDs = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var curveIDs = map[string]tls.CurveID{
+	"X25519": tls.X25519,
+	"P256":   tls.CurveP256,
+	"P384":   tls.CurveP384,
+	"P521":   tls.CurveP521,
+}
+
+// tlsConfig returns the TLS configuration of the -https listener, with the
+// given versions (e.g. "1.2"), comma-separated cipher suite names and
+// comma-separated curve names, each empty for Go's defaults. Without a
+// certificate file it serves a self-signed certificate for the
+// comma-separated hosts, localhost by default. It
+// also reports whether the configuration allows HTTP/2, which requires TLS
+// 1.2 or later and, if the cipher suites are restricted, one of the suites
+// HTTP/2 requires.
+func tlsConfig(certFile, keyFile, hosts, minVersion, maxVersion, ciphers, curves string) (cfg *tls.Config, h2 bool, err error) {
+	cfg = &tls.Config{}
+	var cert tls.Certificate
+	if certFile != "" || keyFile != "" {
+		cert, err = tls.LoadX509KeyPair(certFile, keyFile)
+	} else {
+		cert, err = selfSignedCert(hosts)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	cfg.Certificates = []tls.Certificate{cert}
+
+	for _, v := range []struct {
+		name string
+		dst  *uint16
+	}{{minVersion, &cfg.MinVersion}, {maxVersion, &cfg.MaxVersion}} {
+		if v.name == "" {
+			continue
+		}
+		version, ok := versionIDs[v.name]
+		if !ok {
+			return nil, false, fmt.Errorf("unknown TLS version %q, want 1.0, 1.1, 1.2 or 1.3", v.name)
+		}
+		*v.dst = version
+	}
+
+	if ciphers != "" {
+		ids := make(map[string]uint16)
+		for _, cs := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+			ids[cs.Name] = cs.ID
+		}
+		for _, name := range strings.Split(ciphers, ",") {
+			id, ok := ids[strings.TrimSpace(name)]
+			if !ok {
+				return nil, false, fmt.Errorf("unknown cipher suite %q", name)
+			}
+			cfg.CipherSuites = append(cfg.CipherSuites, id)
+		}
+	}
+
+	if curves != "" {
+		for _, name := range strings.Split(curves, ",") {
+			id, ok := curveIDs[strings.TrimSpace(name)]
+			if !ok {
+				return nil, false, fmt.Errorf("unknown curve %q, want X25519, P256, P384 or P521", name)
+			}
+			cfg.CurvePreferences = append(cfg.CurvePreferences, id)
+		}
+	}
+
+	h2 = cfg.MaxVersion == 0 || cfg.MaxVersion >= tls.VersionTLS12
+	if len(cfg.CipherSuites) > 0 {
+		ok := false
+		for _, id := range cfg.CipherSuites {
+			ok = ok || id == tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256 || id == tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256
+		}
+		h2 = h2 && ok
+	}
+	return cfg, h2, nil
+}
+
+// selfSignedCert generates a self-signed certificate for the comma-separated
+// hosts, logging its fingerprint and writing it to -tls-auto-pem, if set,
+// for clients to trust.
+func selfSignedCert(hosts string) (tls.Certificate, error) {
+	var names []string
+	for _, h := range strings.Split(hosts, ",") {
+		if h = strings.TrimSpace(h); h != "" {
+			names = append(names, h)
+		}
+	}
+	cert, err := httpbin.SelfSignedCertificate(names...)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	sum := sha256.Sum256(cert.Leaf.Raw)
+	log.Printf("generated a self-signed certificate for %s, SHA-256 fingerprint %s", strings.Join(append(cert.Leaf.DNSNames, ipStrings(cert.Leaf.IPAddresses)...), ", "), hex.EncodeToString(sum[:]))
+	if *tlsAutoPEM != "" {
+		if err := ioutil.WriteFile(*tlsAutoPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Leaf.Raw}), 0644); err != nil {
+			return tls.Certificate{}, err
+		}
+	}
+	return cert, nil
+}
+
+func ipStrings(ips []net.IP) []string {
+	s := make([]string, len(ips))
+	for i, ip := range ips {
+		s[i] = ip.String()
+	}
+	return s
+}
@@ -0,0 +1,209 @@
+package httpbin
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultResponseBufferMax is the size up to which responses are buffered
+// before being committed.
+const defaultResponseBufferMax = 1 << 20
+
+// WithResponseBufferMax sets the size up to which responses are buffered
+// before being committed, so that a handler failing partway through can
+// replace what it wrote with a clean error response. Larger responses, and
+// streamed ones, which commit when flushed, are aborted on failure instead,
+// leaving the client with a truncated response rather than one with an
+// error appended. It defaults to 1 MiB.
+func WithResponseBufferMax(n int) Option {
+	return func(h *HTTPBin) { h.responseBufferMax = n }
+}
+
+// bufferedResponse buffers the status and body of a response until the
+// handler returns, flushes, hijacks the connection or writes more than max
+// bytes. It also reports the request's events to the hooks of the HTTPBin
+// serving it.
+type bufferedResponse struct {
+	w         http.ResponseWriter
+	r         *http.Request
+	max       int
+	hooks     Events // nil for none
+	status    int
+	body      bytes.Buffer
+	committed bool
+	hijacked  bool
+
+	written int64 // body bytes written, buffered or not
+	flushed int64 // value of written at the last flush
+}
+
+// bufferedHandler serves h with its response buffered and its events
+// reported to the hooks of the HTTPBin serving it.
+func bufferedHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hb := instance(r)
+		br := &bufferedResponse{w: w, r: r, max: hb.responseBufferMax, hooks: hb.hooks}
+		if hooks := br.hooks; hooks != nil {
+			start := time.Now()
+			hooks.OnRequestStart(r)
+			defer func() { // also when the response is aborted
+				if r.Context().Err() != nil {
+					hooks.OnClientDisconnect(r)
+				}
+				status := br.status
+				if br.hijacked {
+					status = 0
+				}
+				hooks.OnResponseComplete(r, status, br.written, time.Since(start))
+			}()
+		}
+		h.ServeHTTP(br, r)
+		traceEventf(r, "handler returned")
+		br.commit()
+	})
+}
+
+func (br *bufferedResponse) Header() http.Header { return br.w.Header() }
+
+func (br *bufferedResponse) WriteHeader(status int) {
+	switch {
+	case br.committed, status >= 100 && status < 200 && status != http.StatusSwitchingProtocols:
+		br.w.WriteHeader(status) // informational responses go out right away
+	case br.status == 0:
+		br.status = status
+	}
+}
+
+func (br *bufferedResponse) Write(b []byte) (int, error) {
+	if !br.committed && br.body.Len()+len(b) > br.max {
+		br.commit()
+	}
+	if br.committed {
+		n, err := br.w.Write(b)
+		br.written += int64(n)
+		return n, err
+	}
+	if br.status == 0 {
+		br.status = http.StatusOK
+	}
+	n, err := br.body.Write(b)
+	br.written += int64(n)
+	return n, err
+}
+
+// commit sends the buffered status and body.
+func (br *bufferedResponse) commit() {
+	if br.committed {
+		return
+	}
+	br.committed = true
+	if br.status == 0 {
+		br.status = http.StatusOK
+	}
+	traceEventf(br.r, "response committed: status %d, %d body bytes buffered", br.status, br.body.Len())
+	writeTrace(br)
+	br.w.WriteHeader(br.status)
+	br.w.Write(br.body.Bytes())
+	br.body.Reset()
+}
+
+// discard drops the buffered status and body, and the headers describing
+// the body, reporting false if it is too late as the response is committed.
+func (br *bufferedResponse) discard() bool {
+	if br.committed {
+		return false
+	}
+	if br.status != 0 {
+		for _, k := range []string{"Content-Type", "Content-Length", "Content-Encoding", "Content-Disposition"} {
+			br.w.Header().Del(k)
+		}
+	}
+	br.written -= int64(br.body.Len())
+	br.status = 0
+	br.body.Reset()
+	return true
+}
+
+func (br *bufferedResponse) Flush() {
+	br.commit()
+	if f, ok := br.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	if n := br.written - br.flushed; n > 0 && br.hooks != nil {
+		br.hooks.OnStreamChunk(br.r, int(n))
+	}
+	br.flushed = br.written
+}
+
+func (br *bufferedResponse) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := br.w.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("connection does not support hijacking")
+	}
+	conn, rw, err := hj.Hijack()
+	if err == nil {
+		traceEventf(br.r, "connection hijacked")
+		br.committed, br.hijacked = true, true // the handler owns the connection now
+	}
+	return conn, rw, err
+}
+
+func (br *bufferedResponse) Push(target string, opts *http.PushOptions) error {
+	if p, ok := br.w.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// Unwrap returns the underlying ResponseWriter, for http.ResponseController.
+func (br *bufferedResponse) Unwrap() http.ResponseWriter { return br.w }
+
+// unencodable fails to encode as JSON.
+type unencodable struct{}
+
+func (unencodable) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("forced encoding failure")
+}
+
+// JSONFailHandler writes a JSON array of 'items' objects (default 3) and then
+// fails to encode the next one. The response is buffered, so the client gets
+// a clean 500 error response, unless 'stream=true', when each object is
+// flushed as it is written and the failure aborts the response, leaving the
+// client with truncated JSON.
+func JSONFailHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	items := 3
+	if s := q.Get("items"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n > 1000 {
+			writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("'items' must be between 0 and 1000"))
+			return
+		}
+		items = n
+	}
+	stream, _ := strconv.ParseBool(q.Get("stream"))
+
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, "[\n")
+	for i := 0; i < items; i++ {
+		if err := writeJSON(w, map[string]int{"id": i}); err != nil {
+			writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+			return
+		}
+		fmt.Fprint(w, ",\n")
+		if f, ok := w.(http.Flusher); ok && stream {
+			f.Flush()
+		}
+	}
+	if err := writeJSON(w, unencodable{}); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+		return
+	}
+	fmt.Fprint(w, "]\n")
+}
@@ -0,0 +1,53 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONFail_buffered(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/json/fail?items=2")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	require.NotEqual(t, "application/json", resp.Header.Get("Content-Type"))
+
+	var v struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	require.Contains(t, v.Error.Message, "forced encoding failure")
+}
+
+func TestJSONFail_streamed(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/json/fail?items=2&stream=true")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	b, err := ioutil.ReadAll(resp.Body)
+	require.NotNil(t, err, "response should be aborted")
+	require.True(t, strings.HasPrefix(string(b), "[\n{\n  \"id\": 0\n}\n,\n"))
+	require.NotContains(t, string(b), "error")
+}
+
+func TestResponseBuffer_largeResponses(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	b := get(t, srv.URL+"/bytes/3000000?seed=1")
+	require.Len(t, b, 3000000)
+}
@@ -0,0 +1,45 @@
+package httpbin
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// conditionalMaxSize is the largest body /conditional serves.
+const conditionalMaxSize = 1 << 20
+
+// conditionalLastModified is the Last-Modified of /conditional responses.
+var conditionalLastModified = time.Date(2015, time.October, 21, 7, 28, 0, 0, time.UTC)
+
+// ConditionalHandler serves a body of 'size' bytes (default 1024) that never
+// changes, with a Last-Modified of Wed, 21 Oct 2015 07:28:00 GMT and an ETag
+// of "httpbin-<size>", weak if 'weak=true'. It evaluates the RFC 7232
+// preconditions If-Match, If-Unmodified-Since, If-None-Match and
+// If-Modified-Since, and Range requests along with If-Range (by ETag, which
+// has to be strong to match, or by date), responding with 200, 206, 304, 412
+// or 416 as the RFCs require.
+func ConditionalHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	size := 1024
+	if s := q.Get("size"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n > conditionalMaxSize {
+			writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("'size' must be between 0 and %d", conditionalMaxSize))
+			return
+		}
+		size = n
+	}
+	weak, _ := strconv.ParseBool(q.Get("weak"))
+
+	etag := `"httpbin-` + strconv.Itoa(size) + `"`
+	if weak {
+		etag = "W/" + etag
+	}
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	body := bytes.Repeat([]byte("0123456789abcdef"), size/16+1)[:size]
+	http.ServeContent(w, r, "", conditionalLastModified, bytes.NewReader(body))
+}
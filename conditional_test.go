@@ -0,0 +1,63 @@
+package httpbin_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditional(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	const (
+		lastModified = "Wed, 21 Oct 2015 07:28:00 GMT"
+		before       = "Tue, 20 Oct 2015 07:28:00 GMT"
+		after        = "Thu, 22 Oct 2015 07:28:00 GMT"
+		etag         = `"httpbin-64"`
+	)
+	for _, tc := range []struct {
+		name    string
+		query   string
+		headers map[string]string
+		status  int
+		body    string
+	}{
+		{"plain", "", nil, http.StatusOK, ""},
+		{"if-match", "", map[string]string{"If-Match": etag}, http.StatusOK, ""},
+		{"if-match mismatch", "", map[string]string{"If-Match": `"other"`}, http.StatusPreconditionFailed, ""},
+		{"if-match weak", "&weak=true", map[string]string{"If-Match": "W/" + etag}, http.StatusPreconditionFailed, ""},
+		{"if-unmodified-since after", "", map[string]string{"If-Unmodified-Since": after}, http.StatusOK, ""},
+		{"if-unmodified-since before", "", map[string]string{"If-Unmodified-Since": before}, http.StatusPreconditionFailed, ""},
+		{"if-none-match", "", map[string]string{"If-None-Match": etag}, http.StatusNotModified, ""},
+		{"if-modified-since", "", map[string]string{"If-Modified-Since": lastModified}, http.StatusNotModified, ""},
+		{"range", "", map[string]string{"Range": "bytes=0-3"}, http.StatusPartialContent, "0123"},
+		{"range unsatisfiable", "", map[string]string{"Range": "bytes=100-"}, http.StatusRequestedRangeNotSatisfiable, ""},
+		{"if-range etag", "", map[string]string{"Range": "bytes=16-19", "If-Range": etag}, http.StatusPartialContent, "0123"},
+		{"if-range etag mismatch", "", map[string]string{"Range": "bytes=16-19", "If-Range": `"other"`}, http.StatusOK, ""},
+		{"if-range weak etag", "&weak=true", map[string]string{"Range": "bytes=16-19", "If-Range": "W/" + etag}, http.StatusOK, ""},
+		{"if-range date", "", map[string]string{"Range": "bytes=-2", "If-Range": lastModified}, http.StatusPartialContent, "ef"},
+		{"if-range old date", "", map[string]string{"Range": "bytes=-2", "If-Range": before}, http.StatusOK, ""},
+		{"if-unmodified-since with range", "", map[string]string{"Range": "bytes=0-0", "If-Unmodified-Since": before}, http.StatusPreconditionFailed, ""},
+	} {
+		r, _ := http.NewRequest(http.MethodGet, srv.URL+"/conditional?size=64"+tc.query, nil)
+		for k, v := range tc.headers {
+			r.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(r)
+		require.Nil(t, err, tc.name)
+		b, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.Nil(t, err, tc.name)
+		require.Equal(t, tc.status, resp.StatusCode, tc.name)
+		if tc.status == http.StatusOK {
+			require.Len(t, b, 64, tc.name)
+			require.Equal(t, lastModified, resp.Header.Get("Last-Modified"), tc.name)
+		}
+		if tc.body != "" {
+			require.Equal(t, tc.body, string(b), tc.name)
+		}
+	}
+}
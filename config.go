@@ -0,0 +1,123 @@
+package httpbin
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WithConfigToken sets the bearer token /config requires in the
+// Authorization header, none if empty, the default.
+func WithConfigToken(token string) Option {
+	return func(h *HTTPBin) { h.configToken = token }
+}
+
+// ConfigHandler reports the effective configuration of the HTTPBin serving
+// the request: its limits, feature flags and the endpoints it serves.
+// Secrets are reported as whether they are set, never by value. If it has a
+// config token, requests without it as a bearer token get 401.
+func ConfigHandler(w http.ResponseWriter, r *http.Request) {
+	h := instance(r)
+	if h.configToken != "" && !checkBearerToken(w, r, h.configToken, "httpbin config") {
+		return
+	}
+
+	connections.mu.Lock()
+	tracking := connections.enabled
+	connections.mu.Unlock()
+
+	v := configResponse{
+		Limits: configLimits{
+			BinaryChunkSize:    h.binaryChunkSize,
+			DelayMax:           h.delayMax.String(),
+			StreamInterval:     h.streamInterval.String(),
+			MaxBodySize:        h.maxBodySize,
+			ResponseCacheSize:  h.responseCacheSize,
+			ResponseBufferMax:  h.responseBufferMax,
+			PushMax:            h.pushMax,
+			EchoBodyMax:        h.echoBodyMax,
+			OnceTokensMax:      h.onceTokensMax,
+			WebhookTolerance:   h.webhookTolerance.String(),
+			ConnectDialTimeout: connectDialTimeout.String(),
+		},
+		Features: configFeatures{
+			StrictMethods:       h.strictMethods,
+			ConnectionTracking:  tracking,
+			Hooks:               h.hooks != nil,
+			RouteLatencies:      make(map[string]string, len(h.routeLatencies)),
+			AltServices:         h.altServices,
+			SigV4AccessKeys:     make([]string, 0, len(h.sigV4Credentials)),
+			WebhookSecretSet:    h.webhookSecret != "",
+			ConfigTokenSet:      h.configToken != "",
+			DNSRecords:          len(h.dnsRecords),
+			DecompressRequests:  h.decompressRequests,
+			TraceRequests:       h.traceRequests,
+			QuotaKeys:           len(h.quotas),
+			Fixtures:            h.FixtureNames(),
+			FixtureTokenSet:     h.fixtureToken != "",
+			PartitionTokenSet:   h.partitionToken != "",
+			JobCallbacks:        h.jobCallbacks,
+			MaintenanceTokenSet: h.maintenanceToken != "",
+			Region:              h.region,
+			Zone:                h.zone,
+			Prefix:              h.prefix,
+			LatencyProfiles:     make(map[string]string, len(h.latencyProfiles)),
+			MirrorTemplates:     mirrorTemplateNames(h.mirrorTemplates),
+		},
+	}
+	if h.regionLatency != nil {
+		v.Features.RegionLatency = describeLatency(h.regionLatency)
+	}
+	for name, d := range h.latencyProfiles {
+		v.Features.LatencyProfiles[name] = describeLatency(d)
+	}
+	for pattern, d := range h.routeLatencies {
+		v.Features.RouteLatencies[pattern] = describeLatency(d)
+	}
+	for k := range h.sigV4Credentials {
+		v.Features.SigV4AccessKeys = append(v.Features.SigV4AccessKeys, k)
+	}
+	sort.Strings(v.Features.SigV4AccessKeys)
+	for _, rt := range h.routeTable(nil) {
+		v.Endpoints = append(v.Endpoints, rt.name)
+	}
+
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
+
+// checkBearerToken reports whether r has token as its bearer token,
+// responding with a 401 challenge for realm if not.
+func checkBearerToken(w http.ResponseWriter, r *http.Request, token, realm string) bool {
+	auth := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if len(auth) < len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) ||
+		subtle.ConstantTimeCompare([]byte(auth[len(prefix):]), []byte(token)) != 1 {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="`+realm+`"`)
+		writeErrorJSONStatus(w, http.StatusUnauthorized, errors.New("a valid bearer token is required"))
+		return false
+	}
+	return true
+}
+
+// describeLatency writes d in the syntax of ParseLatencyDistribution.
+func describeLatency(d LatencyDistribution) string {
+	switch d := d.(type) {
+	case Fixed:
+		return fmt.Sprintf("fixed(%v)", time.Duration(d))
+	case Uniform:
+		return fmt.Sprintf("uniform(%v, %v)", d.Min, d.Max)
+	case Normal:
+		return fmt.Sprintf("normal(%v, %v)", d.Mean, d.StdDev)
+	case LogNormal:
+		return fmt.Sprintf("lognormal(%v, %v)", d.Mean, d.StdDev)
+	case Exponential:
+		return fmt.Sprintf("exponential(%v)", d.Mean)
+	}
+	return fmt.Sprintf("%T", d)
+}
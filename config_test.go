@@ -0,0 +1,101 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig(t *testing.T) {
+	latencies := map[string]httpbin.LatencyDistribution{
+		"/get": httpbin.LogNormal{50 * time.Millisecond, 20 * time.Millisecond},
+	}
+	srv := httptest.NewServer(httpbin.New(httpbin.WithRouteLatencies(latencies)).Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/config")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var v struct {
+		Limits struct {
+			DelayMax string `json:"delay_max"`
+		} `json:"limits"`
+		Features struct {
+			RouteLatencies map[string]string `json:"route_latencies"`
+		} `json:"features"`
+		Endpoints []string `json:"endpoints"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	require.Equal(t, "10s", v.Limits.DelayMax)
+	require.Contains(t, v.Endpoints, "config")
+	require.Contains(t, v.Endpoints, "get")
+
+	d, err := httpbin.ParseLatencyDistribution(v.Features.RouteLatencies["/get"])
+	require.Nil(t, err)
+	require.Equal(t, latencies["/get"], d)
+}
+
+func TestConfig_token(t *testing.T) {
+	srv := httptest.NewServer(httpbin.New(httpbin.WithConfigToken("s3cret")).Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/config")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	require.NotEmpty(t, resp.Header.Get("WWW-Authenticate"))
+
+	req, _ := http.NewRequest("GET", srv.URL+"/config", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	resp, err = http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestConfig_instance(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(httpbin.New(
+		httpbin.WithDelayMax(time.Second),
+		httpbin.WithRouteLatencies(map[string]httpbin.LatencyDistribution{"/ip": httpbin.Fixed(0)}),
+		httpbin.WithRegion("eu-west-1", "eu-west-1b"),
+		httpbin.WithTraceRequests(true),
+		httpbin.WithProfiling(true),
+		httpbin.WithConfigToken("s3cret"),
+	).Handler())
+	defer srv.Close()
+
+	req, _ := http.NewRequest("GET", srv.URL+"/config", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	var v struct {
+		Limits struct {
+			DelayMax string `json:"delay_max"`
+		} `json:"limits"`
+		Features struct {
+			RouteLatencies map[string]string `json:"route_latencies"`
+			TraceRequests  bool              `json:"trace_requests"`
+			ConfigTokenSet bool              `json:"config_token_set"`
+			Region         string            `json:"region"`
+			Zone           string            `json:"zone"`
+		} `json:"features"`
+		Endpoints []string `json:"endpoints"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	require.Equal(t, "1s", v.Limits.DelayMax)
+	require.Equal(t, map[string]string{"/ip": "fixed(0s)"}, v.Features.RouteLatencies)
+	require.True(t, v.Features.TraceRequests)
+	require.True(t, v.Features.ConfigTokenSet)
+	require.Equal(t, "eu-west-1", v.Features.Region)
+	require.Equal(t, "eu-west-1b", v.Features.Zone)
+	require.Contains(t, v.Endpoints, "pprof")
+}
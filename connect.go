@@ -0,0 +1,104 @@
+package httpbin
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// connectDialTimeout is the maximum time spent connecting to a CONNECT
+// tunnel target.
+const connectDialTimeout = 10 * time.Second
+
+// ConnectHandler wraps h so that CONNECT requests establish a tunnel, letting
+// the server stand in for a proxy that clients tunnel through. Other requests
+// are passed to h.
+//
+// If allow is empty, every tunnel is an echo tunnel: bytes the client sends
+// are written back to it and no outbound connection is made. Otherwise
+// tunnels are only opened to the listed host:port targets and requests for
+// any other target are refused with 403 Forbidden.
+func ConnectHandler(h http.Handler, allow ...string) http.Handler {
+	allowed := make(map[string]bool, len(allow))
+	for _, a := range allow {
+		allowed[a] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		var target net.Conn
+		if len(allowed) > 0 {
+			if !allowed[r.Host] {
+				connectError(w, http.StatusForbidden, fmt.Errorf("tunnel target %q not allowed", r.Host))
+				return
+			}
+			var err error
+			target, err = net.DialTimeout("tcp", r.Host, connectDialTimeout)
+			if err != nil {
+				connectError(w, http.StatusBadGateway, fmt.Errorf("failed to dial tunnel target: %w", err))
+				return
+			}
+			defer target.Close()
+		}
+
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			connectError(w, http.StatusInternalServerError, errors.New("connection does not support hijacking"))
+			return
+		}
+		conn, bw, err := hj.Hijack()
+		if err != nil {
+			connectError(w, http.StatusInternalServerError, fmt.Errorf("failed to hijack connection: %w", err))
+			return
+		}
+		defer conn.Close()
+
+		if _, err := io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n"); err != nil {
+			return
+		}
+		if target == nil {
+			io.Copy(conn, bw.Reader)
+			return
+		}
+		tunnel(conn, bw.Reader, target)
+	})
+}
+
+// tunnel copies data between the client and the target until both
+// directions are done. client is read through br so that bytes buffered
+// before the connection was hijacked are not lost.
+func tunnel(client net.Conn, br *bufio.Reader, target net.Conn) {
+	done := make(chan struct{})
+	go func() {
+		io.Copy(target, br)
+		closeWrite(target)
+		close(done)
+	}()
+	io.Copy(client, target)
+	closeWrite(client)
+	<-done
+}
+
+// closeWrite half-closes c if it supports it, signalling EOF to the peer.
+func closeWrite(c net.Conn) {
+	if cw, ok := c.(interface {
+		CloseWrite() error
+	}); ok {
+		cw.CloseWrite()
+	}
+}
+
+// connectError responds to a CONNECT request that cannot be tunneled. It is
+// served ahead of the mux, which sets the Content-Type of the endpoints.
+func connectError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	writeErrorJSONStatus(w, status, err)
+}
@@ -0,0 +1,104 @@
+package httpbin_test
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+func connect(t *testing.T, proxy *httptest.Server, target string) (net.Conn, *http.Response) {
+	u, err := url.Parse(proxy.URL)
+	require.Nil(t, err)
+	conn, err := net.Dial("tcp", u.Host)
+	require.Nil(t, err)
+
+	fmt.Fprintf(conn, "CONNECT %s HTTP/1.1\r\nHost: %s\r\n\r\n", target, target)
+	resp, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: http.MethodConnect})
+	require.Nil(t, err)
+	return conn, resp
+}
+
+func TestConnect_echo(t *testing.T) {
+	srv := httptest.NewServer(httpbin.ConnectHandler(httpbin.GetMux()))
+	defer srv.Close()
+
+	conn, resp := connect(t, srv, "example.com:443")
+	defer conn.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, err := io.WriteString(conn, "ping")
+	require.Nil(t, err)
+	b := make([]byte, 4)
+	_, err = io.ReadFull(conn, b)
+	require.Nil(t, err)
+	require.Equal(t, "ping", string(b))
+}
+
+func TestConnect_allowed(t *testing.T) {
+	target := httptest.NewTLSServer(httpbin.GetMux())
+	defer target.Close()
+	targetURL, err := url.Parse(target.URL)
+	require.Nil(t, err)
+
+	srv := httptest.NewServer(httpbin.ConnectHandler(httpbin.GetMux(), targetURL.Host))
+	defer srv.Close()
+	proxyURL, err := url.Parse(srv.URL)
+	require.Nil(t, err)
+
+	cl := target.Client()
+	cl.Transport.(*http.Transport).Proxy = http.ProxyURL(proxyURL)
+	resp, err := cl.Get(target.URL + "/ip")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	b, err := ioutil.ReadAll(resp.Body)
+	require.Nil(t, err)
+	require.Contains(t, string(b), "origin")
+}
+
+func TestConnect_notAllowed(t *testing.T) {
+	srv := httptest.NewServer(httpbin.ConnectHandler(httpbin.GetMux(), "127.0.0.1:1"))
+	defer srv.Close()
+
+	conn, resp := connect(t, srv, "example.com:443")
+	defer conn.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	b, err := ioutil.ReadAll(resp.Body)
+	require.Nil(t, err)
+	require.Contains(t, string(b), `"error"`)
+}
+
+func TestConnect_dialFailure(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	addr := l.Addr().String()
+	l.Close()
+	srv := httptest.NewServer(httpbin.ConnectHandler(httpbin.GetMux(), addr))
+	defer srv.Close()
+
+	conn, resp := connect(t, srv, addr)
+	defer conn.Close()
+	require.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	b, err := ioutil.ReadAll(resp.Body)
+	require.Nil(t, err)
+	require.Contains(t, string(b), "failed to dial tunnel target")
+}
+
+func TestConnect_otherMethods(t *testing.T) {
+	srv := httptest.NewServer(httpbin.ConnectHandler(httpbin.GetMux()))
+	defer srv.Close()
+
+	b := get(t, srv.URL+"/ip")
+	require.Contains(t, string(b), "origin")
+}
@@ -0,0 +1,140 @@
+package httpbin
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// connections tracks the connections of servers that use ConnState.
+var connections = &connTracker{conns: make(map[net.Conn]*connInfo)}
+
+type connTracker struct {
+	mu      sync.Mutex
+	enabled bool
+	conns   map[net.Conn]*connInfo
+}
+
+type connInfo struct {
+	remote string
+	state  http.ConnState
+	proto  string
+
+	closeAfterIdle time.Duration // close the connection when idle this long
+	idleTimer      *time.Timer
+}
+
+type connContextKey struct{}
+
+// ConnContext stores the connection in the request context so handlers can
+// act on it, as /idle-close does. Set it as the ConnContext hook of the
+// http.Server serving GetMux, along with ConnState.
+func ConnContext(ctx context.Context, c net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey{}, c)
+}
+
+// ConnState tracks connection state changes for /connections. Set it as the
+// ConnState hook of the http.Server serving GetMux:
+//
+//	srv := &http.Server{Handler: httpbin.GetMux(), ConnState: httpbin.ConnState}
+func ConnState(c net.Conn, state http.ConnState) {
+	if state == http.StateIdle {
+		arrivals.arm(c.RemoteAddr().String())
+	}
+
+	connections.mu.Lock()
+	defer connections.mu.Unlock()
+	connections.enabled = true
+
+	switch state {
+	case http.StateClosed, http.StateHijacked:
+		if ci, ok := connections.conns[c]; ok && ci.idleTimer != nil {
+			ci.idleTimer.Stop()
+		}
+		delete(connections.conns, c)
+		return
+	}
+	ci, ok := connections.conns[c]
+	if !ok {
+		host, _, err := net.SplitHostPort(c.RemoteAddr().String())
+		if err != nil {
+			host = c.RemoteAddr().String()
+		}
+		ci = &connInfo{remote: host, proto: "HTTP/1.1"}
+		connections.conns[c] = ci
+	}
+	ci.state = state
+	if ci.idleTimer != nil {
+		ci.idleTimer.Stop()
+		ci.idleTimer = nil
+	}
+	if state == http.StateIdle && ci.closeAfterIdle > 0 {
+		ci.idleTimer = time.AfterFunc(ci.closeAfterIdle, func() { c.Close() })
+	}
+	if tc, ok := c.(*tls.Conn); ok && state == http.StateActive {
+		// the handshake is done by the time the connection becomes active
+		if tc.ConnectionState().NegotiatedProtocol == "h2" {
+			ci.proto = "HTTP/2.0"
+		}
+	}
+}
+
+// ConnectionsHandler reports the server's open connections by state, remote
+// host and protocol, as tracked by ConnState.
+func ConnectionsHandler(w http.ResponseWriter, r *http.Request) {
+	connections.mu.Lock()
+	v := connectionsResponse{
+		Tracking:  connections.enabled,
+		Open:      len(connections.conns),
+		States:    make(map[string]int),
+		Remotes:   make(map[string]int),
+		Protocols: make(map[string]int),
+	}
+	for _, ci := range connections.conns {
+		v.States[ci.state.String()]++
+		v.Remotes[ci.remote]++
+		v.Protocols[ci.proto]++
+	}
+	connections.mu.Unlock()
+
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
+
+// IdleCloseHandler responds like /get and has the server close the
+// connection once it has been idle for the number of seconds given by the
+// 'after' query parameter (default 1), to test how clients cope with pooled
+// connections going stale. It needs both the ConnState and ConnContext hooks,
+// and responds 409 without them.
+func IdleCloseHandler(w http.ResponseWriter, r *http.Request) {
+	after := time.Second
+	if s := r.URL.Query().Get("after"); s != "" {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || !(f > 0) || math.IsInf(f, 0) {
+			writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("'after' must be a positive number of seconds"))
+			return
+		}
+		after = time.Duration(f * float64(time.Second))
+	}
+
+	c, _ := r.Context().Value(connContextKey{}).(net.Conn)
+	connections.mu.Lock()
+	ci, ok := connections.conns[c]
+	if ok {
+		ci.closeAfterIdle = after
+	}
+	connections.mu.Unlock()
+	if !ok {
+		writeErrorJSONStatus(w, http.StatusConflict, errors.New("the server does not track connections, it needs the httpbin.ConnState and httpbin.ConnContext hooks"))
+		return
+	}
+	GetHandler(w, r)
+}
@@ -0,0 +1,118 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+type connectionsResponse struct {
+	Tracking  bool           `json:"tracking"`
+	Open      int            `json:"open"`
+	States    map[string]int `json:"states"`
+	Remotes   map[string]int `json:"remotes"`
+	Protocols map[string]int `json:"protocols"`
+}
+
+func getConnections(t *testing.T, cl *http.Client, u string) connectionsResponse {
+	resp, err := cl.Get(u + "/connections")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var v connectionsResponse
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	return v
+}
+
+func TestConnections(t *testing.T) {
+	srv := httptest.NewUnstartedServer(httpbin.GetMux())
+	srv.Config.ConnState = httpbin.ConnState
+	srv.Start()
+	defer srv.Close()
+
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	cl := &http.Client{Transport: tr}
+	v := getConnections(t, cl, srv.URL)
+	require.True(t, v.Tracking)
+	require.Equal(t, 1, v.Open)
+	require.Equal(t, 1, v.States["active"])
+	require.Equal(t, 1, v.Remotes["127.0.0.1"])
+	require.Equal(t, 1, v.Protocols["HTTP/1.1"])
+
+	// a second client opens a second connection, the first one becomes idle
+	// once the server is done with the response
+	tr2 := &http.Transport{}
+	defer tr2.CloseIdleConnections()
+	cl2 := &http.Client{Transport: tr2}
+	for i := 0; i < 100; i++ {
+		v = getConnections(t, cl2, srv.URL)
+		if v.States["idle"] == 1 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, 2, v.Open)
+	require.Equal(t, 1, v.States["idle"])
+	require.Equal(t, 2, v.Remotes["127.0.0.1"])
+}
+
+func TestConnections_http2(t *testing.T) {
+	srv := httptest.NewUnstartedServer(httpbin.GetMux())
+	srv.Config.ConnState = httpbin.ConnState
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	cl := srv.Client()
+	v := getConnections(t, cl, srv.URL)
+	require.Equal(t, 1, v.Protocols["HTTP/2.0"])
+}
+
+func TestIdleClose(t *testing.T) {
+	srv := httptest.NewUnstartedServer(httpbin.GetMux())
+	srv.Config.ConnState = httpbin.ConnState
+	srv.Config.ConnContext = httpbin.ConnContext
+	srv.Start()
+	defer srv.Close()
+
+	tr := &http.Transport{}
+	defer tr.CloseIdleConnections()
+	resp, err := (&http.Client{Transport: tr}).Get(srv.URL + "/idle-close?after=0.1")
+	require.Nil(t, err)
+	_, err = ioutil.ReadAll(resp.Body)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// only the connection of the /connections request remains
+	time.Sleep(300 * time.Millisecond)
+	tr2 := &http.Transport{}
+	defer tr2.CloseIdleConnections()
+	v := getConnections(t, &http.Client{Transport: tr2}, srv.URL)
+	require.Equal(t, 1, v.Open)
+
+	for _, after := range []string{"0", "-1", "x", "NaN", "Inf"} {
+		resp, err := http.Get(srv.URL + "/idle-close?after=" + after)
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, "after=%s", after)
+	}
+}
+
+func TestIdleClose_notTracked(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/idle-close")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+}
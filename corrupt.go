@@ -0,0 +1,85 @@
+package httpbin
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// GZIPCorruptHandler serves the /gzip response with its gzip stream damaged,
+// to test how clients handle decompression errors. See writeCorrupt.
+func GZIPCorruptHandler(w http.ResponseWriter, r *http.Request) {
+	writeCorrupt(w, r, "gzip")
+}
+
+// DeflateCorruptHandler serves the /deflate response with its deflate stream
+// damaged, to test how clients handle decompression errors. See
+// writeCorrupt.
+func DeflateCorruptHandler(w http.ResponseWriter, r *http.Request) {
+	writeCorrupt(w, r, "deflate")
+}
+
+// writeCorrupt compresses the response of /gzip or /deflate with the given
+// encoding and, depending on the 'mode' query parameter, flips the bits of
+// the byte at offset 'at' ("flip", the default) or cuts the stream off
+// there ("truncate"). 'at' defaults to the middle of the stream; the gzip
+// header takes its first 10 bytes and the CRC-32 and size its last 8.
+// Content-Length matches what is sent, so the damage is only detected by
+// decoding.
+func writeCorrupt(w http.ResponseWriter, r *http.Request, encoding string) {
+	h, _, _ := net.SplitHostPort(r.RemoteAddr)
+	var (
+		v   interface{}
+		buf bytes.Buffer
+		zw  io.WriteCloser
+	)
+	if encoding == "gzip" {
+		v = gzipResponse{headersResponse: headersResponse{getHeaders(r)}, ipResponse: ipResponse{h}, Gzipped: true}
+		zw = gzip.NewWriter(&buf)
+	} else {
+		v = deflateResponse{headersResponse: headersResponse{getHeaders(r)}, ipResponse: ipResponse{h}, Deflated: true}
+		zw, _ = flate.NewWriter(&buf, flate.BestCompression)
+	}
+	if err := writeJSON(zw, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+		return
+	}
+	zw.Close()
+	b := buf.Bytes()
+
+	q := r.URL.Query()
+	mode := q.Get("mode")
+	if mode == "" {
+		mode = "flip"
+	}
+	at := len(b) / 2
+	if s := q.Get("at"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n >= len(b) {
+			writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("'at' must be an offset below the stream length %d", len(b)))
+			return
+		}
+		at = n
+	}
+	switch mode {
+	case "flip":
+		b[at] ^= 0xff
+	case "truncate":
+		b = b[:at]
+	default:
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("unknown mode %q, must be flip or truncate", mode))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Encoding", encoding)
+	w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+	w.Header().Set("X-Corrupt-Mode", mode)
+	w.Header().Set("X-Corrupt-Offset", strconv.Itoa(at))
+	w.Write(b)
+}
@@ -0,0 +1,56 @@
+package httpbin_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func getRaw(t *testing.T, url string) (*http.Response, []byte) {
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Set("Accept-Encoding", "gzip, deflate") // keep the transport from decoding it
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	require.Nil(t, err)
+	return resp, b
+}
+
+func TestGZIPCorrupt(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for _, q := range []string{"", "?mode=truncate", "?at=3", "?mode=truncate&at=10"} {
+		resp, b := getRaw(t, srv.URL+"/gzip/corrupt"+q)
+		require.Equal(t, http.StatusOK, resp.StatusCode, q)
+		require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"), q)
+		require.Equal(t, []byte{0x1f, 0x8b}, b[:2], q)
+
+		zr, err := gzip.NewReader(bytes.NewReader(b))
+		if err == nil {
+			_, err = ioutil.ReadAll(zr)
+		}
+		require.NotNil(t, err, q)
+	}
+
+	resp, _ := getRaw(t, srv.URL+"/gzip/corrupt?at=100000")
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, _ = getRaw(t, srv.URL+"/gzip/corrupt?mode=shuffle")
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestDeflateCorrupt(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, b := getRaw(t, srv.URL+"/deflate/corrupt?mode=truncate")
+	require.Equal(t, "deflate", resp.Header.Get("Content-Encoding"))
+	_, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(b)))
+	require.NotNil(t, err)
+}
@@ -0,0 +1,203 @@
+package httpbin
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	// defaultRequestDecompressedMax is the largest a decompressed request
+	// body may be.
+	defaultRequestDecompressedMax int64 = 16 << 20
+
+	// defaultRequestDecompressionRatioMax is the largest ratio of
+	// decompressed to compressed request body bytes, checked once
+	// decompressionRatioMinBytes have been decompressed.
+	defaultRequestDecompressionRatioMax = 100.0
+)
+
+// WithDecompressRequests makes request bodies with a Content-Encoding of
+// gzip or deflate be decompressed before they reach the handlers, within the
+// limits of WithRequestDecompressedMax and WithRequestDecompressionRatioMax.
+// Bodies in other encodings are refused with 415 Unsupported Media Type. It
+// defaults to false.
+func WithDecompressRequests(enabled bool) Option {
+	return func(h *HTTPBin) { h.decompressRequests = enabled }
+}
+
+// WithRequestDecompressedMax sets the largest a decompressed request body
+// may be. It defaults to 16 MiB.
+func WithRequestDecompressedMax(n int64) Option {
+	return func(h *HTTPBin) { h.requestDecompressedMax = n }
+}
+
+// WithRequestDecompressionRatioMax sets the largest ratio of decompressed to
+// compressed request body bytes. It defaults to 100.
+func WithRequestDecompressionRatioMax(ratio float64) Option {
+	return func(h *HTTPBin) { h.requestDecompressionRatioMax = ratio }
+}
+
+// decompressionRatioMinBytes is how much of a body is decompressed before
+// its ratio is checked, so that small, very compressible bodies pass.
+const decompressionRatioMinBytes = 64 << 10
+
+// decompressionTracker counts the request bodies decompressed and rejected.
+type decompressionTracker struct {
+	mu sync.Mutex
+	v  decompressionStats
+}
+
+func (t *decompressionTracker) add(f func(*decompressionStats)) {
+	t.mu.Lock()
+	f(&t.v)
+	t.mu.Unlock()
+}
+
+// decompressionError is the error reading a decompressed request body
+// returns once it exceeds a limit. writeErrorJSONStatus reports it as 413.
+type decompressionError struct {
+	limit                    string // "size" or "ratio"
+	maxBytes                 int64
+	maxRatio                 float64
+	compressed, decompressed int64
+}
+
+func (e *decompressionError) Error() string {
+	if e.limit == "size" {
+		return fmt.Sprintf("decompressed request body exceeds %d bytes", e.maxBytes)
+	}
+	return fmt.Sprintf("request body decompresses more than %g times", e.maxRatio)
+}
+
+func (e *decompressionError) response() decompressionErrorResponse {
+	v := decompressionErrorResponse{
+		Error:             errObj{e.Error()},
+		Limit:             e.limit,
+		MaxBytes:          e.maxBytes,
+		MaxRatio:          e.maxRatio,
+		CompressedBytes:   e.compressed,
+		DecompressedBytes: e.decompressed,
+	}
+	if e.compressed > 0 {
+		v.Ratio = float64(e.decompressed) / float64(e.compressed)
+	}
+	return v
+}
+
+// decompressHandler decompresses the request bodies of h as described by
+// WithDecompressRequests, within the limits of the HTTPBin serving them.
+func decompressHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		enc := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+		if enc == "" || enc == "identity" || r.Body == nil {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		cr := &countingReader{r: r.Body}
+		var (
+			zr  io.Reader
+			err error
+		)
+		switch enc {
+		case "gzip", "x-gzip":
+			zr, err = gzip.NewReader(cr)
+		case "deflate":
+			zr, err = zlib.NewReader(cr)
+		default:
+			traceEventf(r, "decompress: refused Content-Encoding %q", enc)
+			w.Header().Set("Accept-Encoding", "gzip, deflate")
+			writeErrorJSONStatus(w, http.StatusUnsupportedMediaType, fmt.Errorf("unsupported Content-Encoding %q", enc))
+			return
+		}
+		if err != nil {
+			writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("malformed %s request body: %w", enc, err))
+			return
+		}
+
+		traceEventf(r, "decompress: decoding %s request body", enc)
+		hb := instance(r)
+		body := &decompressingBody{
+			zr:       zr,
+			cr:       cr,
+			closer:   r.Body,
+			maxBytes: hb.requestDecompressedMax,
+			maxRatio: hb.requestDecompressionRatioMax,
+		}
+		defer func() {
+			hb.decompression.add(func(s *decompressionStats) {
+				s.Requests++
+				s.CompressedBytes += cr.n
+				s.DecompressedBytes += body.n
+				switch {
+				case body.err == nil:
+				case body.err.limit == "size":
+					s.RejectedSize++
+				default:
+					s.RejectedRatio++
+				}
+			})
+		}()
+		r.Body = body
+		r.Header.Del("Content-Encoding")
+		r.Header.Del("Content-Length")
+		r.ContentLength = -1
+		h.ServeHTTP(w, r)
+	})
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// decompressingBody is a request body decompressed from zr, reading the
+// compressed bytes from cr, that fails once it exceeds maxBytes or
+// maxRatio.
+type decompressingBody struct {
+	zr       io.Reader
+	cr       *countingReader
+	closer   io.Closer
+	maxBytes int64
+	maxRatio float64
+	n        int64
+	err      *decompressionError
+}
+
+func (b *decompressingBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if left := b.maxBytes - b.n + 1; int64(len(p)) > left {
+		p = p[:left] // to notice going over the limit without reading far past it
+	}
+	n, err := b.zr.Read(p)
+	b.n += int64(n)
+	switch {
+	case b.n > b.maxBytes:
+		b.err = &decompressionError{limit: "size"}
+	case b.n >= decompressionRatioMinBytes && float64(b.n) > b.maxRatio*float64(b.cr.n):
+		b.err = &decompressionError{limit: "ratio"}
+	default:
+		return n, err
+	}
+	b.err.maxBytes, b.err.maxRatio = b.maxBytes, b.maxRatio
+	b.err.compressed, b.err.decompressed = b.cr.n, b.n
+	return 0, b.err
+}
+
+func (b *decompressingBody) Close() error {
+	return b.closer.Close()
+}
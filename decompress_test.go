@@ -0,0 +1,115 @@
+package httpbin_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+func decompressServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(httpbin.New(httpbin.WithDecompressRequests(true)).Handler())
+}
+
+func postGzip(t *testing.T, url string, body []byte) *http.Response {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(body)
+	zw.Close()
+	req, _ := http.NewRequest("POST", url, &buf)
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	return resp
+}
+
+func TestDecompressRequests(t *testing.T) {
+	srv := decompressServer(t)
+	defer srv.Close()
+
+	resp := postGzip(t, srv.URL+"/post", []byte(`{"a": 1}`))
+	var v struct {
+		Data string                 `json:"data"`
+		JSON map[string]interface{} `json:"json"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, `{"a": 1}`, v.Data)
+	require.EqualValues(t, 1, v.JSON["a"])
+
+	req, _ := http.NewRequest("POST", srv.URL+"/post", strings.NewReader("x"))
+	req.Header.Set("Content-Encoding", "br")
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+	require.Equal(t, "gzip, deflate", resp.Header.Get("Accept-Encoding"))
+}
+
+type decompressionError struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+	Limit             string  `json:"limit"`
+	CompressedBytes   int64   `json:"compressed_bytes"`
+	DecompressedBytes int64   `json:"decompressed_bytes"`
+	Ratio             float64 `json:"ratio"`
+}
+
+func TestDecompressRequests_limits(t *testing.T) {
+	h := httpbin.New(httpbin.WithDecompressRequests(true))
+	srv := httptest.NewServer(h.Handler())
+	defer srv.Close()
+	// sharing the stats of h
+	small := httptest.NewServer(h.Reconfigure(httpbin.WithDecompressRequests(true), httpbin.WithRequestDecompressedMax(10)).Handler())
+	defer small.Close()
+
+	var before struct {
+		Decompression struct {
+			Requests      int64 `json:"requests"`
+			RejectedSize  int64 `json:"rejected_size"`
+			RejectedRatio int64 `json:"rejected_ratio"`
+		} `json:"decompression"`
+	}
+	after := before
+	getJSON(t, srv.URL+"/stats", &before)
+
+	// a megabyte of zeros compresses a thousandfold
+	resp := postGzip(t, srv.URL+"/post", make([]byte, 1<<20))
+	var v decompressionError
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	resp.Body.Close()
+	require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	require.Equal(t, "ratio", v.Limit)
+	require.True(t, v.Ratio > 100, "ratio %v", v.Ratio)
+	require.True(t, v.DecompressedBytes > v.CompressedBytes)
+	require.NotEmpty(t, v.Error.Message)
+
+	resp = postGzip(t, small.URL+"/post", []byte(`{"too": "long"}`))
+	v = decompressionError{}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	resp.Body.Close()
+	require.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	require.Equal(t, "size", v.Limit)
+
+	getJSON(t, srv.URL+"/stats", &after)
+	require.Equal(t, before.Decompression.Requests+2, after.Decompression.Requests)
+	require.Equal(t, before.Decompression.RejectedSize+1, after.Decompression.RejectedSize)
+	require.Equal(t, before.Decompression.RejectedRatio+1, after.Decompression.RejectedRatio)
+}
+
+func getJSON(t *testing.T, url string, v interface{}) {
+	resp, err := http.Get(url)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(v))
+}
@@ -0,0 +1,136 @@
+package httpbin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultDedupeWindow is how long after a request /dedupe reports the
+	// same request again as a duplicate.
+	defaultDedupeWindow = time.Minute
+
+	// defaultDedupeFingerprintsMax is the maximum number of /dedupe
+	// fingerprints remembered.
+	defaultDedupeFingerprintsMax = 10000
+)
+
+// WithDedupeWindow sets how long after a request /dedupe reports the same
+// request again as a duplicate, unless the 'window' query parameter says
+// otherwise. It defaults to a minute.
+func WithDedupeWindow(d time.Duration) Option {
+	return func(h *HTTPBin) { h.dedupeWindow = d }
+}
+
+// WithDedupeFingerprintsMax sets the maximum number of /dedupe fingerprints
+// remembered; seeing more forgets the oldest ones. It defaults to 10000.
+func WithDedupeFingerprintsMax(n int) Option {
+	return func(h *HTTPBin) { h.dedupeFingerprintsMax = n }
+}
+
+// dedupeStore tracks the fingerprints of the requests /dedupe has seen.
+type dedupeStore struct {
+	mu    sync.Mutex
+	seen  map[string]*dedupeEntry
+	order []string // first seen first
+}
+
+type dedupeEntry struct {
+	first, last time.Time
+	count       int
+}
+
+// see records a request with fingerprint fp at now, starting over if the
+// last one was longer than window ago, and returns a copy of its entry and
+// when it was last seen before, zero if it was not. It remembers at most max
+// fingerprints.
+func (s *dedupeStore) see(fp string, now time.Time, window time.Duration, max int) (dedupeEntry, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.seen[fp]
+	if !ok {
+		e = &dedupeEntry{}
+		s.seen[fp] = e
+		s.order = append(s.order, fp)
+		for len(s.order) > max {
+			delete(s.seen, s.order[0])
+			s.order = s.order[1:]
+		}
+	}
+	var prev time.Time
+	if e.count > 0 && now.Sub(e.last) <= window {
+		prev = e.last
+	} else {
+		e.first, e.count = now, 0
+	}
+	e.last = now
+	e.count++
+	return *e, prev
+}
+
+// DedupeHandler fingerprints the request by its method, path and the SHA-256
+// hash of its body, and reports whether a request with the same fingerprint
+// was seen within the last 'window' seconds (default the dedupe window of the
+// HTTPBin, DedupeWindow unless set with WithDedupeWindow), when it
+// was first seen and how many times, so that retries and double submits
+// show up. With 'reject' set to true, duplicates get 409 Conflict.
+func DedupeHandler(w http.ResponseWriter, r *http.Request) {
+	h := instance(r)
+	q := r.URL.Query()
+	window := h.dedupeWindow
+	if s := q.Get("window"); s != "" {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || !(f > 0) {
+			writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("'window' must be a positive number of seconds"))
+			return
+		}
+		window = time.Duration(f * float64(time.Second))
+	}
+	reject := q.Get("reject") == "true"
+
+	body := sha256.New()
+	if r.Body != nil {
+		if _, err := io.Copy(body, r.Body); err != nil {
+			writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("failed to read body: %w", err))
+			return
+		}
+	}
+	bodySum := hex.EncodeToString(body.Sum(nil))
+	fp := sha256.Sum256([]byte(r.Method + " " + r.URL.Path + "\n" + bodySum))
+
+	now := time.Now()
+	e, prev := h.dedupeSeen.see(hex.EncodeToString(fp[:]), now, window, h.dedupeFingerprintsMax)
+	v := dedupeResponse{
+		Fingerprint: hex.EncodeToString(fp[:]),
+		Method:      r.Method,
+		Path:        r.URL.Path,
+		BodySHA256:  bodySum,
+		Duplicate:   !prev.IsZero(),
+		Count:       e.count,
+		FirstSeen:   e.first.UTC().Format(time.RFC3339Nano),
+		Window:      window.Seconds(),
+	}
+	if v.Duplicate {
+		v.PreviousSeen = prev.UTC().Format(time.RFC3339Nano)
+		v.SincePreviousMS = milliseconds(now.Sub(prev))
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Httpbin-Fingerprint", v.Fingerprint)
+	if v.Duplicate && reject {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		_ = writeJSON(w, v) // status already written, nothing else to do
+		return
+	}
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
@@ -0,0 +1,80 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type dedupeStatus struct {
+	Fingerprint  string
+	Duplicate    bool
+	Count        int
+	FirstSeen    string `json:"first_seen"`
+	PreviousSeen string `json:"previous_seen"`
+}
+
+func dedupe(t *testing.T, method, url, body string, wantStatus int) dedupeStatus {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	require.Nil(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, wantStatus, resp.StatusCode)
+	var v dedupeStatus
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	require.Equal(t, v.Fingerprint, resp.Header.Get("X-Httpbin-Fingerprint"))
+	return v
+}
+
+func TestDedupe(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+	url := srv.URL + "/dedupe/" + unique("orders")
+
+	first := dedupe(t, "POST", url, `{"id":1}`, http.StatusOK)
+	require.False(t, first.Duplicate)
+	require.Equal(t, 1, first.Count)
+
+	v := dedupe(t, "POST", url, `{"id":1}`, http.StatusOK)
+	require.True(t, v.Duplicate)
+	require.Equal(t, 2, v.Count)
+	require.Equal(t, first.Fingerprint, v.Fingerprint)
+	require.Equal(t, first.FirstSeen, v.FirstSeen)
+	require.Equal(t, first.FirstSeen, v.PreviousSeen)
+
+	for _, other := range []struct{ method, url, body string }{
+		{"POST", url, `{"id":2}`},
+		{"PUT", url, `{"id":1}`},
+		{"POST", url + "/1", `{"id":1}`},
+	} {
+		v := dedupe(t, other.method, other.url, other.body, http.StatusOK)
+		require.False(t, v.Duplicate, other)
+		require.NotEqual(t, first.Fingerprint, v.Fingerprint)
+	}
+
+	v = dedupe(t, "POST", url+"?reject=true", `{"id":1}`, http.StatusConflict)
+	require.Equal(t, 3, v.Count)
+}
+
+func TestDedupe_window(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+	url := srv.URL + "/dedupe/" + unique("window") + "?window=0.1"
+
+	dedupe(t, "POST", url, "a", http.StatusOK)
+	require.True(t, dedupe(t, "POST", url, "a", http.StatusOK).Duplicate)
+	time.Sleep(150 * time.Millisecond)
+	v := dedupe(t, "POST", url, "a", http.StatusOK)
+	require.False(t, v.Duplicate)
+	require.Equal(t, 1, v.Count)
+
+	resp, err := http.Get(srv.URL + "/dedupe?window=0")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
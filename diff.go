@@ -0,0 +1,292 @@
+package httpbin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// diffInputMax is the largest body /diff compares, in bytes.
+	diffInputMax = 1 << 20
+	// diffEditsMax is the most line edits /diff searches for the shortest
+	// diff; beyond it the differing lines are reported as one change.
+	diffEditsMax = 2000
+	// diffRangesMax is the most differing byte ranges /diff reports.
+	diffRangesMax = 1000
+	// diffContext is the number of unchanged lines around each hunk.
+	diffContext = 3
+)
+
+// DiffHandler compares the two bodies of a multipart/form-data upload, a and
+// b, or the fixture registered under the name in the fixture field and b.
+// Text bodies are compared line by line and reported as unified diff hunks,
+// binary ones byte by byte and reported as the ranges that differ.
+func DiffHandler(w http.ResponseWriter, r *http.Request) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("request must be multipart/form-data"))
+		return
+	}
+	parts := make(map[string][]byte)
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("failed to read multipart body: %w", err))
+			return
+		}
+		b, err := ioutil.ReadAll(io.LimitReader(p, diffInputMax+1))
+		if err != nil {
+			writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("failed to read multipart body: %w", err))
+			return
+		}
+		if len(b) > diffInputMax {
+			writeErrorJSONStatus(w, http.StatusRequestEntityTooLarge, fmt.Errorf("'%s' must be at most %d bytes", p.FormName(), diffInputMax))
+			return
+		}
+		parts[p.FormName()] = b
+	}
+
+	a, ok := parts["a"]
+	if name, isFixture := parts["fixture"]; isFixture && !ok {
+		f, found := instance(r).fixtures.get(string(name))
+		if !found {
+			writeErrorJSONStatus(w, http.StatusNotFound, fmt.Errorf("no fixture named %q", name))
+			return
+		}
+		a, ok = f.Body, true
+	}
+	b, okB := parts["b"]
+	if !ok || !okB {
+		writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("'a', or 'fixture', and 'b' are required"))
+		return
+	}
+
+	v := diffResponse{Equal: bytes.Equal(a, b), ASize: len(a), BSize: len(b)}
+	if isText(a) && isText(b) {
+		v.Hunks = diffHunks(diffLines(splitLines(string(a)), splitLines(string(b))))
+	} else {
+		v.Binary = true
+		v.Ranges, v.Truncated = diffBytes(a, b)
+	}
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
+
+// isText reports whether b is UTF-8 text without NUL bytes.
+func isText(b []byte) bool {
+	return utf8.Valid(b) && bytes.IndexByte(b, 0) < 0
+}
+
+// splitLines splits s into lines, keeping their line feeds so that a
+// missing final one counts as a difference.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffOp is a line of a diff: ' ' for a line of a kept in b, '-' for a line
+// of a removed and '+' for a line of b added.
+type diffOp struct {
+	kind byte
+	a, b int // line indexes in a and b, before the op for the other side
+	line string
+}
+
+// diffLines returns the shortest edit script from a to b (Myers, 1986),
+// giving up on finding the shortest after diffEditsMax edits.
+func diffLines(a, b []string) []diffOp {
+	var ops []diffOp
+	// common prefix and suffix
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		ops = append(ops, diffOp{' ', pre, pre, a[pre]})
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+
+	ops = append(ops, myers(a[pre:len(a)-suf], b[pre:len(b)-suf], pre)...)
+	for i := 0; i < suf; i++ {
+		ops = append(ops, diffOp{' ', len(a) - suf + i, len(b) - suf + i, a[len(a)-suf+i]})
+	}
+	return ops
+}
+
+// myers diffs a and b, lines from off on of the whole inputs.
+func myers(a, b []string, off int) []diffOp {
+	n, m := len(a), len(b)
+	var (
+		v     = map[int]int{1: 0} // furthest x on each diagonal k = x - y
+		trace []map[int]int
+	)
+	done := false
+	for d := 0; d <= n+m && d <= diffEditsMax && !done; d++ {
+		next := make(map[int]int, d+1)
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[k-1] < v[k+1] {
+				x = v[k+1]
+			} else {
+				x = v[k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x, y = x+1, y+1
+			}
+			next[k] = x
+			if x >= n && y >= m {
+				done = true
+				break
+			}
+		}
+		trace = append(trace, next)
+		v = next
+	}
+
+	var ops []diffOp
+	if !done {
+		// too different to search: everything in a replaced by b
+		for i := range a {
+			ops = append(ops, diffOp{'-', off + i, off, a[i]})
+		}
+		for j := range b {
+			ops = append(ops, diffOp{'+', off + n, off + j, b[j]})
+		}
+		return ops
+	}
+
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		k := x - y
+		prevK := k - 1
+		if k == -d || k != d && prev[k-1] < prev[k+1] {
+			prevK = k + 1
+		}
+		prevX := prev[prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x, y = x-1, y-1
+			ops = append(ops, diffOp{' ', off + x, off + y, a[x]})
+		}
+		if x == prevX {
+			ops = append(ops, diffOp{'+', off + x, off + prevY, b[prevY]})
+		} else {
+			ops = append(ops, diffOp{'-', off + prevX, off + y, a[prevX]})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		x, y = x-1, y-1
+		ops = append(ops, diffOp{' ', off + x, off + y, a[x]})
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// diffHunks groups the changes of ops with diffContext lines around them
+// into hunks, numbering lines from 1.
+func diffHunks(ops []diffOp) []diffHunk {
+	hunks := []diffHunk{}
+	lastChange := -1
+	for i, op := range ops {
+		if op.kind == ' ' {
+			continue
+		}
+		if lastChange >= 0 && i-lastChange <= 2*diffContext {
+			lastChange = i
+			continue
+		}
+		if lastChange >= 0 {
+			hunks[len(hunks)-1].end = lastChange + diffContext + 1
+		}
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		hunks = append(hunks, diffHunk{start: start})
+		lastChange = i
+	}
+	if lastChange >= 0 {
+		hunks[len(hunks)-1].end = lastChange + diffContext + 1
+	}
+	for i := range hunks {
+		h := &hunks[i]
+		if h.end > len(ops) {
+			h.end = len(ops)
+		}
+		h.AStart, h.BStart = ops[h.start].a+1, ops[h.start].b+1
+		for _, op := range ops[h.start:h.end] {
+			switch op.kind {
+			case ' ':
+				h.ALines++
+				h.BLines++
+			case '-':
+				h.ALines++
+			case '+':
+				h.BLines++
+			}
+			h.Lines = append(h.Lines, string(op.kind)+strings.TrimSuffix(op.line, "\n"))
+		}
+	}
+	return hunks
+}
+
+// diffBytes returns the ranges of bytes that differ between a and b, with
+// the bytes only one of them has as a last range.
+func diffBytes(a, b []byte) ([]diffRange, bool) {
+	ranges := []diffRange{}
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		j := i
+		for j < n && a[j] != b[j] {
+			j++
+		}
+		if len(ranges) == diffRangesMax {
+			return ranges, true
+		}
+		ranges = append(ranges, diffRange{Offset: i, Length: j - i})
+		i = j
+	}
+	if len(a) != len(b) {
+		if len(ranges) == diffRangesMax {
+			return ranges, true
+		}
+		if l := len(ranges); l > 0 && ranges[l-1].Offset+ranges[l-1].Length == n {
+			ranges[l-1].Length += abs(len(a) - len(b))
+		} else {
+			ranges = append(ranges, diffRange{Offset: n, Length: abs(len(a) - len(b))})
+		}
+	}
+	return ranges, false
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
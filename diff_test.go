@@ -0,0 +1,98 @@
+package httpbin_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"testing"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+type diffResult struct {
+	Equal  bool `json:"equal"`
+	Binary bool `json:"binary"`
+	Hunks  []struct {
+		AStart int      `json:"a_start"`
+		ALines int      `json:"a_lines"`
+		BStart int      `json:"b_start"`
+		BLines int      `json:"b_lines"`
+		Lines  []string `json:"lines"`
+	} `json:"hunks"`
+	Ranges []struct {
+		Offset int `json:"offset"`
+		Length int `json:"length"`
+	} `json:"ranges"`
+}
+
+func postDiff(t *testing.T, url string, parts map[string]string) (int, diffResult) {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for name, v := range parts {
+		fw, err := mw.CreateFormFile(name, name)
+		require.Nil(t, err)
+		fw.Write([]byte(v))
+	}
+	mw.Close()
+	resp, err := http.Post(url+"/diff", mw.FormDataContentType(), &body)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	var v diffResult
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	return resp.StatusCode, v
+}
+
+func TestDiff_text(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	status, v := postDiff(t, srv.URL, map[string]string{
+		"a": "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+		"b": "1\ntwo\n3\n4\n5\n6\n7\n8\n9\n10\n11\n",
+	})
+	require.EqualValues(t, http.StatusOK, status)
+	require.False(t, v.Equal)
+	require.False(t, v.Binary)
+	require.Len(t, v.Hunks, 2)
+	require.EqualValues(t, []string{" 1", "-2", "+two", " 3", " 4", " 5"}, v.Hunks[0].Lines)
+	require.EqualValues(t, 1, v.Hunks[0].AStart)
+	require.EqualValues(t, 5, v.Hunks[0].ALines)
+	require.EqualValues(t, []string{" 8", " 9", " 10", "+11"}, v.Hunks[1].Lines)
+	require.EqualValues(t, 8, v.Hunks[1].BStart)
+	require.EqualValues(t, 4, v.Hunks[1].BLines)
+
+	_, v = postDiff(t, srv.URL, map[string]string{"a": "same\n", "b": "same\n"})
+	require.True(t, v.Equal)
+	require.Empty(t, v.Hunks)
+}
+
+func TestDiff_binary(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	_, v := postDiff(t, srv.URL, map[string]string{"a": "abc\x00def", "b": "aXc\x00deXYZ"})
+	require.True(t, v.Binary)
+	require.Len(t, v.Ranges, 2)
+	require.EqualValues(t, 1, v.Ranges[0].Offset)
+	require.EqualValues(t, 1, v.Ranges[0].Length)
+	require.EqualValues(t, 6, v.Ranges[1].Offset)
+	require.EqualValues(t, 3, v.Ranges[1].Length)
+}
+
+func TestDiff_fixture(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+	httpbin.SetFixture("expected", httpbin.Fixture{Body: []byte("a\nb\n")})
+	defer httpbin.RemoveFixture("expected")
+
+	_, v := postDiff(t, srv.URL, map[string]string{"fixture": "expected", "b": "a\nc\n"})
+	require.Len(t, v.Hunks, 1)
+	require.EqualValues(t, []string{" a", "-b", "+c"}, v.Hunks[0].Lines)
+
+	status, _ := postDiff(t, srv.URL, map[string]string{"fixture": "missing", "b": "x"})
+	require.EqualValues(t, http.StatusNotFound, status)
+	status, _ = postDiff(t, srv.URL, map[string]string{"a": "x"})
+	require.EqualValues(t, http.StatusBadRequest, status)
+}
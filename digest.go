@@ -0,0 +1,223 @@
+package httpbin
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultDigestNonceTTL is how long a /digest-auth nonce is accepted.
+const defaultDigestNonceTTL = 5 * time.Minute
+
+// WithDigestNonceTTL sets how long a /digest-auth nonce is accepted.
+// Requests with an older one are challenged again with stale=true, so
+// clients can retry with the new nonce without asking for the password
+// again. It defaults to 5 minutes.
+func WithDigestNonceTTL(d time.Duration) Option {
+	return func(h *HTTPBin) { h.digestNonceTTL = d }
+}
+
+const digestRealm = "go-httpbin"
+
+// digestKey signs the /digest-auth nonces, so they need no server state.
+var digestKey = func() []byte {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}()
+
+// digestAlgorithms are the hashes of the RFC 7616 algorithms /digest-auth
+// supports, without their -sess suffix.
+var digestAlgorithms = map[string]func() hash.Hash{
+	"MD5":     md5.New,
+	"SHA-256": sha256.New,
+}
+
+// DigestAuthHandler challenges HTTP Digest Auth (RFC 7616) with the given
+// username and password, quality of protection (auth or auth-int) and
+// algorithm (MD5, the default, SHA-256 or their -sess variants). Nonces
+// are signed timestamps, so nonce counts are not checked for replays.
+func DigestAuthHandler(w http.ResponseWriter, r *http.Request) {
+	vars := routeVars(r)
+	user, pass, qop := vars["u"], vars["p"], vars["qop"]
+	algorithm := vars["algorithm"]
+	if algorithm == "" {
+		algorithm = "MD5"
+	}
+
+	info, stale, err := checkDigestAuth(r, user, pass, qop, algorithm)
+	if err != nil {
+		challenge := fmt.Sprintf(`Digest realm="%s", qop="%s", algorithm=%s, nonce="%s", opaque="%s"`,
+			digestRealm, qop, algorithm, newDigestNonce(time.Now()), digestOpaque())
+		if stale {
+			challenge += ", stale=true"
+		}
+		w.Header().Set("WWW-Authenticate", challenge)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("Authentication-Info", info)
+
+	v := basicAuthResponse{
+		Authenticated: true,
+		User:          user,
+	}
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
+
+// checkDigestAuth verifies the Digest credentials of r, returning the
+// Authentication-Info to respond with if they are correct, and otherwise
+// whether they were only wrong for their expired nonce.
+func checkDigestAuth(r *http.Request, user, pass, qop, algorithm string) (info string, stale bool, err error) {
+	auth := r.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "Digest ") {
+		return "", false, errors.New("no Digest credentials")
+	}
+	p, err := parseDigestParams(auth[7:])
+	if err != nil {
+		return "", false, err
+	}
+	for _, k := range []string{"username", "realm", "nonce", "uri", "response", "qop", "nc", "cnonce"} {
+		if p[k] == "" {
+			return "", false, fmt.Errorf("missing %s", k)
+		}
+	}
+	switch {
+	case p["username"] != user:
+		return "", false, errors.New("unknown user")
+	case p["realm"] != digestRealm:
+		return "", false, errors.New("wrong realm")
+	case p["opaque"] != digestOpaque():
+		return "", false, errors.New("wrong opaque")
+	case p["qop"] != qop:
+		return "", false, errors.New("wrong qop")
+	case !strings.EqualFold(p["algorithm"], algorithm) && !(p["algorithm"] == "" && algorithm == "MD5"):
+		return "", false, errors.New("wrong algorithm")
+	case p["uri"] != r.RequestURI:
+		return "", false, errors.New("uri does not match the request")
+	}
+
+	newHash := digestAlgorithms[strings.TrimSuffix(algorithm, "-sess")]
+	h := func(s string) string {
+		d := newHash()
+		d.Write([]byte(s))
+		return hex.EncodeToString(d.Sum(nil))
+	}
+	ha1 := h(user + ":" + digestRealm + ":" + pass)
+	if strings.HasSuffix(algorithm, "-sess") {
+		ha1 = h(ha1 + ":" + p["nonce"] + ":" + p["cnonce"])
+	}
+	a2 := ":" + p["uri"]
+	if qop == "auth-int" {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to read body: %w", err)
+		}
+		a2 += ":" + h(string(body))
+	}
+	kd := func(ha2 string) string {
+		return h(ha1 + ":" + p["nonce"] + ":" + p["nc"] + ":" + p["cnonce"] + ":" + qop + ":" + ha2)
+	}
+	if subtle.ConstantTimeCompare([]byte(strings.ToLower(p["response"])), []byte(kd(h(r.Method+a2)))) != 1 {
+		return "", false, errors.New("wrong response")
+	}
+
+	issued, err := parseDigestNonce(p["nonce"])
+	if err != nil {
+		return "", false, err
+	}
+	if time.Since(issued) > instance(r).digestNonceTTL {
+		return "", true, errors.New("stale nonce")
+	}
+
+	// rspauth proves the server knows the password too
+	info = fmt.Sprintf(`qop=%s, rspauth="%s", cnonce="%s", nc=%s`, qop, kd(h(a2)), p["cnonce"], p["nc"])
+	return info, false, nil
+}
+
+// newDigestNonce returns a nonce issued at t: the timestamp followed by its
+// HMAC, in base64url.
+func newDigestNonce(t time.Time) string {
+	b := make([]byte, 8, 8+sha256.Size)
+	binary.BigEndian.PutUint64(b, uint64(t.UnixNano()))
+	return base64.RawURLEncoding.EncodeToString(append(b, digestMAC(b)...))
+}
+
+// parseDigestNonce verifies a nonce of newDigestNonce and returns when it
+// was issued.
+func parseDigestNonce(nonce string) (time.Time, error) {
+	b, err := base64.RawURLEncoding.DecodeString(nonce)
+	if err != nil || len(b) != 8+sha256.Size || !hmac.Equal(b[8:], digestMAC(b[:8])) {
+		return time.Time{}, errors.New("invalid nonce")
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(b[:8]))), nil
+}
+
+func digestMAC(b []byte) []byte {
+	h := hmac.New(sha256.New, digestKey)
+	h.Write(b)
+	return h.Sum(nil)
+}
+
+// digestOpaque is the opaque value of the challenges, which clients must
+// send back unchanged.
+func digestOpaque() string {
+	return hex.EncodeToString(digestMAC([]byte("opaque"))[:16])
+}
+
+// parseDigestParams parses the comma-separated name=value pairs of Digest
+// credentials, where values may be quoted strings with backslash escapes.
+func parseDigestParams(s string) (map[string]string, error) {
+	params := make(map[string]string)
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			return params, nil
+		}
+		i := strings.IndexByte(s, '=')
+		if i <= 0 {
+			return nil, errors.New("malformed Digest credentials")
+		}
+		name := strings.ToLower(strings.TrimSpace(s[:i]))
+		s = strings.TrimLeft(s[i+1:], " \t")
+
+		var value strings.Builder
+		if strings.HasPrefix(s, `"`) {
+			j := 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				value.WriteByte(s[j])
+			}
+			if j == len(s) {
+				return nil, errors.New("unterminated quoted string in Digest credentials")
+			}
+			s = s[j+1:]
+		} else {
+			j := strings.IndexByte(s, ',')
+			if j < 0 {
+				j = len(s)
+			}
+			value.WriteString(strings.TrimSpace(s[:j]))
+			s = s[j:]
+		}
+		params[name] = value.String()
+	}
+}
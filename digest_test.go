@@ -0,0 +1,95 @@
+package httpbin_test
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+var digestParam = regexp.MustCompile(`(\w+)=(?:"([^"]*)"|([^,\s]*))`)
+
+// digestRequest answers the Digest challenge of a 401 from path with the
+// given password and body.
+func digestRequest(t *testing.T, srvURL, path, pass, body string) *http.Response {
+	resp, err := http.Get(srvURL + path)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusUnauthorized, resp.StatusCode)
+	challenge := resp.Header.Get("WWW-Authenticate")
+	require.True(t, strings.HasPrefix(challenge, "Digest "), challenge)
+	c := make(map[string]string)
+	for _, m := range digestParam.FindAllStringSubmatch(challenge, -1) {
+		c[m[1]] = m[2] + m[3]
+	}
+
+	var newHash func() hash.Hash = md5.New
+	if strings.HasPrefix(c["algorithm"], "SHA-256") {
+		newHash = sha256.New
+	}
+	h := func(s string) string {
+		d := newHash()
+		d.Write([]byte(s))
+		return hex.EncodeToString(d.Sum(nil))
+	}
+	const cnonce, nc = "0a4f113b", "00000001"
+	ha1 := h("user:" + c["realm"] + ":" + pass)
+	if strings.HasSuffix(c["algorithm"], "-sess") {
+		ha1 = h(ha1 + ":" + c["nonce"] + ":" + cnonce)
+	}
+	a2 := "POST:" + path
+	if c["qop"] == "auth-int" {
+		a2 += ":" + h(body)
+	}
+	response := h(ha1 + ":" + c["nonce"] + ":" + nc + ":" + cnonce + ":" + c["qop"] + ":" + h(a2))
+
+	req, _ := http.NewRequest(http.MethodPost, srvURL+path, strings.NewReader(body))
+	req.Header.Set("Authorization", fmt.Sprintf(
+		`Digest username="user", realm="%s", nonce="%s", uri="%s", algorithm=%s, qop=%s, nc=%s, cnonce="%s", response="%s", opaque="%s"`,
+		c["realm"], c["nonce"], path, c["algorithm"], c["qop"], nc, cnonce, response, c["opaque"]))
+	resp, err = http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	return resp
+}
+
+func TestDigestAuth(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for _, path := range []string{
+		"/digest-auth/auth/user/passwd",
+		"/digest-auth/auth-int/user/passwd",
+		"/digest-auth/auth/user/passwd/SHA-256",
+		"/digest-auth/auth-int/user/passwd/SHA-256-sess",
+		"/digest-auth/auth/user/passwd/MD5-sess",
+	} {
+		resp := digestRequest(t, srv.URL, path, "passwd", "some body")
+		resp.Body.Close()
+		require.EqualValues(t, http.StatusOK, resp.StatusCode, path)
+		require.Contains(t, resp.Header.Get("Authentication-Info"), `rspauth="`, path)
+
+		resp = digestRequest(t, srv.URL, path, "wrong", "some body")
+		resp.Body.Close()
+		require.EqualValues(t, http.StatusUnauthorized, resp.StatusCode, path)
+		require.NotContains(t, resp.Header.Get("WWW-Authenticate"), "stale", path)
+	}
+}
+
+func TestDigestAuth_stale(t *testing.T) {
+	srv := httptest.NewServer(httpbin.New(httpbin.WithDigestNonceTTL(0)).Handler())
+	defer srv.Close()
+
+	resp := digestRequest(t, srv.URL, "/digest-auth/auth/user/passwd", "passwd", "")
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusUnauthorized, resp.StatusCode)
+	require.Contains(t, resp.Header.Get("WWW-Authenticate"), "stale=true")
+}
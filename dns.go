@@ -0,0 +1,229 @@
+package httpbin
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DNSRecord is a canned resource record /dns-query answers with.
+type DNSRecord struct {
+	Name  string // domain name, with or without the trailing dot
+	Type  string // A, AAAA, CNAME, NS, PTR or TXT
+	TTL   uint32
+	Value string // an IP address, a domain name or text, depending on Type
+}
+
+// defaultDNSRecords are the records /dns-query answers from.
+var defaultDNSRecords = []DNSRecord{
+	{Name: "example.com", Type: "A", TTL: 300, Value: "93.184.216.34"},
+	{Name: "example.com", Type: "AAAA", TTL: 300, Value: "2606:2800:220:1:248:1893:25c8:1946"},
+	{Name: "example.com", Type: "TXT", TTL: 300, Value: "v=spf1 -all"},
+	{Name: "www.example.com", Type: "CNAME", TTL: 300, Value: "example.com"},
+}
+
+// WithDNSRecords sets the records /dns-query answers from. Queries for other
+// names get NXDOMAIN. It defaults to A, AAAA and TXT records of example.com
+// and a CNAME of www.example.com to it.
+func WithDNSRecords(records []DNSRecord) Option {
+	return func(h *HTTPBin) { h.dnsRecords = records }
+}
+
+const dnsMessageType = "application/dns-message"
+
+var dnsTypes = map[string]uint16{
+	"A":     1,
+	"NS":    2,
+	"CNAME": 5,
+	"PTR":   12,
+	"TXT":   16,
+	"AAAA":  28,
+}
+
+const (
+	dnsTypeANY   = 255
+	dnsClassIN   = 1
+	dnsRcodeOK   = 0
+	dnsRcodeFail = 1 // FORMERR
+	dnsRcodeNX   = 3
+	dnsRcodeImpl = 4 // NOTIMP
+)
+
+// DNSQueryHandler answers RFC 8484 DNS-over-HTTPS queries, sent either
+// base64url-encoded in the 'dns' query parameter of a GET or as the body of a
+// POST with Content-Type application/dns-message, from the DNS records. A CNAME
+// record answers queries of any type for its name.
+func DNSQueryHandler(w http.ResponseWriter, r *http.Request) {
+	var msg []byte
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(r.URL.Query().Get("dns"), "="))
+		if err != nil || len(b) == 0 {
+			writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("missing or invalid base64url 'dns' parameter"))
+			return
+		}
+		msg = b
+	case http.MethodPost:
+		if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt != dnsMessageType {
+			writeErrorJSONStatus(w, http.StatusUnsupportedMediaType, fmt.Errorf("Content-Type must be %s", dnsMessageType))
+			return
+		}
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeErrorJSON(w, fmt.Errorf("failed to read body: %w", err))
+			return
+		}
+		msg = b
+	}
+
+	resp, ttl, err := dnsAnswer(msg, instance(r).dnsRecords)
+	if err != nil {
+		writeErrorJSONStatus(w, http.StatusBadRequest, err)
+		return
+	}
+	w.Header().Set("Content-Type", dnsMessageType)
+	w.Header().Set("Cache-Control", "max-age="+strconv.FormatUint(uint64(ttl), 10))
+	w.Header().Set("Content-Length", strconv.Itoa(len(resp)))
+	w.Write(resp)
+}
+
+// dnsAnswer builds the response to the DNS query msg from records and
+// returns it with the smallest TTL of its answers.
+func dnsAnswer(msg []byte, records []DNSRecord) ([]byte, uint32, error) {
+	if len(msg) < 12 {
+		return nil, 0, errors.New("DNS message too short")
+	}
+	if msg[2]&0x80 != 0 {
+		return nil, 0, errors.New("DNS message is not a query")
+	}
+	name, end, err := readDNSName(msg, 12)
+	if err != nil {
+		return nil, 0, err
+	}
+	if end+4 > len(msg) {
+		return nil, 0, errors.New("DNS question truncated")
+	}
+	qtype := binary.BigEndian.Uint16(msg[end:])
+	qclass := binary.BigEndian.Uint16(msg[end+2:])
+	question := msg[12 : end+4]
+
+	resp := make([]byte, 12, 512)
+	copy(resp, msg[:2])          // ID
+	resp[2] = 0x80 | msg[2]&0x79 // QR, opcode and RD from the query
+	resp[3] = 0x80               // RA
+	binary.BigEndian.PutUint16(resp[4:], 1)
+	resp = append(resp, question...)
+
+	opcode := msg[2] >> 3 & 0xf
+	switch {
+	case opcode != 0:
+		resp[3] |= dnsRcodeImpl
+		return resp, 0, nil
+	case binary.BigEndian.Uint16(msg[4:]) != 1:
+		resp[3] |= dnsRcodeFail
+		return resp, 0, nil
+	}
+
+	var ancount uint16
+	var ttl uint32
+	known := false
+	for _, rr := range records {
+		if !strings.EqualFold(strings.TrimSuffix(rr.Name, "."), name) {
+			continue
+		}
+		known = true
+		t := dnsTypes[strings.ToUpper(rr.Type)]
+		if t == 0 || qclass != dnsClassIN && qclass != dnsTypeANY || t != qtype && qtype != dnsTypeANY && t != dnsTypes["CNAME"] {
+			continue
+		}
+		rdata, err := dnsRData(t, rr.Value)
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid %s record for %s: %w", rr.Type, rr.Name, err)
+		}
+		resp = append(resp, 0xc0, 12) // pointer to the question name
+		resp = appendUint16(resp, t)
+		resp = appendUint16(resp, dnsClassIN)
+		resp = append(resp, byte(rr.TTL>>24), byte(rr.TTL>>16), byte(rr.TTL>>8), byte(rr.TTL))
+		resp = appendUint16(resp, uint16(len(rdata)))
+		resp = append(resp, rdata...)
+		if ancount == 0 || rr.TTL < ttl {
+			ttl = rr.TTL
+		}
+		ancount++
+	}
+	binary.BigEndian.PutUint16(resp[6:], ancount)
+	if !known {
+		resp[3] |= dnsRcodeNX
+	}
+	return resp, ttl, nil
+}
+
+// readDNSName reads the uncompressed name starting at off, returning it
+// without the trailing dot and the offset following it.
+func readDNSName(msg []byte, off int) (string, int, error) {
+	var labels []string
+	for {
+		if off >= len(msg) {
+			return "", 0, errors.New("DNS name truncated")
+		}
+		n := int(msg[off])
+		off++
+		if n == 0 {
+			return strings.Join(labels, "."), off, nil
+		}
+		if n&0xc0 != 0 || off+n > len(msg) {
+			return "", 0, errors.New("invalid DNS name")
+		}
+		labels = append(labels, string(msg[off:off+n]))
+		off += n
+	}
+}
+
+func appendDNSName(b []byte, name string) []byte {
+	for _, l := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		if l == "" {
+			continue
+		}
+		b = append(b, byte(len(l)))
+		b = append(b, l...)
+	}
+	return append(b, 0)
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+// dnsRData encodes the record data of value for the record type t.
+func dnsRData(t uint16, value string) ([]byte, error) {
+	switch t {
+	case dnsTypes["A"]:
+		ip := net.ParseIP(value).To4()
+		if ip == nil {
+			return nil, fmt.Errorf("%q is not an IPv4 address", value)
+		}
+		return ip, nil
+	case dnsTypes["AAAA"]:
+		ip := net.ParseIP(value)
+		if ip == nil || ip.To4() != nil {
+			return nil, fmt.Errorf("%q is not an IPv6 address", value)
+		}
+		return ip.To16(), nil
+	case dnsTypes["TXT"]:
+		var b []byte
+		for len(value) > 255 {
+			b = append(append(b, 255), value[:255]...)
+			value = value[255:]
+		}
+		return append(append(b, byte(len(value))), value...), nil
+	default: // CNAME, NS, PTR
+		return appendDNSName(nil, value), nil
+	}
+}
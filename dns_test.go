@@ -0,0 +1,87 @@
+package httpbin_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func dnsQuery(id uint16, name string, qtype uint16) []byte {
+	b := []byte{byte(id >> 8), byte(id), 0x01, 0, 0, 1, 0, 0, 0, 0, 0, 0}
+	for _, l := range bytes.Split([]byte(name), []byte(".")) {
+		b = append(append(b, byte(len(l))), l...)
+	}
+	return append(b, 0, byte(qtype>>8), byte(qtype), 0, 1)
+}
+
+func TestDNSQuery_get(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	q := dnsQuery(0xbeef, "example.com", 1)
+	resp, err := http.Get(srv.URL + "/dns-query?dns=" + base64.RawURLEncoding.EncodeToString(q))
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/dns-message", resp.Header.Get("Content-Type"))
+	require.Equal(t, "max-age=300", resp.Header.Get("Cache-Control"))
+
+	b, err := ioutil.ReadAll(resp.Body)
+	require.Nil(t, err)
+	require.Equal(t, uint16(0xbeef), binary.BigEndian.Uint16(b))
+	require.Equal(t, byte(0x81), b[2])                          // QR, RD
+	require.Equal(t, byte(0x80), b[3])                          // RA, NOERROR
+	require.Equal(t, uint16(1), binary.BigEndian.Uint16(b[6:])) // ANCOUNT
+	require.Equal(t, []byte{93, 184, 216, 34}, b[len(b)-4:])
+}
+
+func TestDNSQuery_post(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		name    string
+		qtype   uint16
+		rcode   byte
+		answers uint16
+	}{
+		{"example.com", 28, 0, 1},
+		{"WWW.example.com", 1, 0, 1}, // CNAME
+		{"example.com", 15, 0, 0},    // MX, no records
+		{"nope.example.com", 1, 3, 0},
+	} {
+		resp, err := http.Post(srv.URL+"/dns-query", "application/dns-message", bytes.NewReader(dnsQuery(0, tc.name, tc.qtype)))
+		require.Nil(t, err)
+		b, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.Nil(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode, tc.name)
+		require.Equal(t, tc.rcode, b[3]&0xf, tc.name)
+		require.Equal(t, tc.answers, binary.BigEndian.Uint16(b[6:]), tc.name)
+	}
+}
+
+func TestDNSQuery_badRequest(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/dns-query?dns=!!")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, err = http.Post(srv.URL+"/dns-query", "application/octet-stream", bytes.NewReader(dnsQuery(0, "example.com", 1)))
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+
+	resp, err = http.Post(srv.URL+"/dns-query", "application/dns-message", bytes.NewReader([]byte{1, 2, 3}))
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,112 @@
+package httpbin
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	pdfPagesMax   = 100
+	zipEntriesMax = 1000
+	zipSizeMax    = 16 << 20 // total uncompressed size
+)
+
+// zipModified is the modification time of the entries of /zip and
+// /archive/:format.
+var zipModified = time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// PDFHandler returns a PDF document of 'pages' pages (default 1), each
+// saying its page number.
+func PDFHandler(w http.ResponseWriter, r *http.Request) {
+	pages := 1
+	if !intParam(w, r, "pages", 1, pdfPagesMax, &pages) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	serveMedia(w, r, pdfFile(pages))
+}
+
+// ZIPHandler returns a ZIP archive of 'entries' files (default 3) of 'size'
+// bytes (default 1024) of deterministic text, deflated unless
+// 'method=store'.
+func ZIPHandler(w http.ResponseWriter, r *http.Request) {
+	entries, size := 3, 1024
+	if !intParam(w, r, "entries", 0, zipEntriesMax, &entries) || !intParam(w, r, "size", 0, zipSizeMax, &size) {
+		return
+	}
+	if entries*size > zipSizeMax {
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("entries*size must be at most %d", zipSizeMax))
+		return
+	}
+	method := zip.Deflate
+	switch r.URL.Query().Get("method") {
+	case "", "deflate":
+	case "store":
+		method = zip.Store
+	default:
+		writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("'method' must be deflate or store"))
+		return
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for i := 0; i < entries; i++ {
+		f, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     fmt.Sprintf("file-%d.txt", i+1),
+			Method:   method,
+			Modified: zipModified,
+		})
+		if err != nil {
+			writeErrorJSON(w, fmt.Errorf("failed to create zip entry: %w", err))
+			return
+		}
+		line := fmt.Sprintf("httpbin file %d\n", i+1)
+		f.Write(bytes.Repeat([]byte(line), size/len(line)+1)[:size])
+	}
+	if err := zw.Close(); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write zip: %w", err))
+		return
+	}
+	w.Header().Set("Content-Type", "application/zip")
+	serveMedia(w, r, buf.Bytes())
+}
+
+// pdfFile writes a PDF 1.4 document of n pages, each showing its number in
+// Helvetica.
+func pdfFile(n int) []byte {
+	var b bytes.Buffer
+	var offsets []int
+	obj := func(s string) {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", len(offsets), s)
+	}
+
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	// objects 1 and 2 are the catalog and the page tree, 3 the font, then
+	// each page is followed by its content stream
+	kids := make([]string, n)
+	for i := range kids {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	obj("<< /Type /Catalog /Pages 2 0 R >>")
+	obj(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), n))
+	obj("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>")
+	for i := 0; i < n; i++ {
+		obj(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>", 5+2*i))
+		content := fmt.Sprintf("BT /F1 24 Tf 72 720 Td (httpbin page %d of %d) Tj ET", i+1, n)
+		obj(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return b.Bytes()
+}
@@ -0,0 +1,64 @@
+package httpbin_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPDF(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/pdf?pages=3")
+	require.Nil(t, err)
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Nil(t, err)
+	require.Equal(t, "application/pdf", resp.Header.Get("Content-Type"))
+	doc := string(b)
+	require.True(t, strings.HasPrefix(doc, "%PDF-1.4\n"))
+	require.True(t, strings.HasSuffix(doc, "%%EOF\n"))
+	require.Contains(t, doc, "/Count 3")
+	require.Contains(t, doc, "(httpbin page 3 of 3)")
+
+	resp, err = http.Get(srv.URL + "/pdf?pages=0")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestZIP(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for _, method := range []string{"deflate", "store"} {
+		resp, err := http.Get(srv.URL + "/zip?entries=4&size=100&method=" + method)
+		require.Nil(t, err)
+		b, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.Nil(t, err)
+		require.Equal(t, "application/zip", resp.Header.Get("Content-Type"))
+
+		zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+		require.Nil(t, err)
+		require.Len(t, zr.File, 4)
+		require.Equal(t, "file-4.txt", zr.File[3].Name)
+		f, err := zr.File[0].Open()
+		require.Nil(t, err)
+		content, err := ioutil.ReadAll(f)
+		require.Nil(t, err)
+		require.Len(t, content, 100)
+		require.True(t, strings.HasPrefix(string(content), "httpbin file 1\n"))
+	}
+
+	resp, err := http.Get(srv.URL + "/zip?entries=1000&size=1048576")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,92 @@
+package httpbin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	// defaultEchoBodyMax is the size of the largest request body echoed
+	// back by /post.
+	defaultEchoBodyMax = 1 << 20
+
+	// defaultEchoDigestEdge is the number of leading and trailing bytes
+	// reported for bodies too large to echo.
+	defaultEchoDigestEdge = 64
+)
+
+// WithEchoBodyMax sets the size of the largest request body echoed back by
+// /post. Larger bodies are streamed through a SHA-256 hash instead of being
+// held in memory, and reported by their size, digest and first and last
+// bytes. It defaults to 1 MiB.
+func WithEchoBodyMax(n int) Option {
+	return func(h *HTTPBin) { h.echoBodyMax = n }
+}
+
+// WithEchoDigestEdge sets the number of leading and trailing bytes reported
+// for bodies too large to echo. It defaults to 64.
+func WithEchoDigestEdge(n int) Option {
+	return func(h *HTTPBin) { h.echoDigestEdge = n }
+}
+
+// readEcho reads the request body for echoing: the body itself if it is at
+// most the echo body limit of the HTTPBin serving r, and otherwise its
+// digest.
+func readEcho(r *http.Request) ([]byte, *bodyDigest, error) {
+	if r.Body == nil {
+		return nil, nil, nil
+	}
+	defer r.Body.Close()
+
+	hb := instance(r)
+	var buf bytes.Buffer
+	n, err := io.Copy(&buf, io.LimitReader(r.Body, int64(hb.echoBodyMax)+1))
+	if err != nil {
+		return nil, nil, err
+	}
+	if n <= int64(hb.echoBodyMax) {
+		return buf.Bytes(), nil, nil
+	}
+
+	h := sha256.New()
+	tail := &tailWriter{n: hb.echoDigestEdge}
+	w := io.MultiWriter(h, tail)
+	head := buf.Bytes()
+	if len(head) > hb.echoDigestEdge {
+		head = head[:hb.echoDigestEdge]
+	}
+	w.Write(buf.Bytes())
+	size, err := io.Copy(w, r.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read body: %w", err)
+	}
+	return nil, &bodyDigest{
+		Size:       n + size,
+		SHA256:     hex.EncodeToString(h.Sum(nil)),
+		HeadBase64: base64.StdEncoding.EncodeToString(head),
+		TailBase64: base64.StdEncoding.EncodeToString(tail.buf),
+	}, nil
+}
+
+// tailWriter keeps the last n bytes written to it.
+type tailWriter struct {
+	n   int
+	buf []byte
+}
+
+func (t *tailWriter) Write(b []byte) (int, error) {
+	if len(b) >= t.n {
+		t.buf = append(t.buf[:0], b[len(b)-t.n:]...)
+		return len(b), nil
+	}
+	if keep := t.n - len(b); len(t.buf) > keep {
+		t.buf = append(t.buf[:0], t.buf[len(t.buf)-keep:]...)
+	}
+	t.buf = append(t.buf, b...)
+	return len(b), nil
+}
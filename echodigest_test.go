@@ -0,0 +1,51 @@
+package httpbin_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPost_digest(t *testing.T) {
+	srv := httptest.NewServer(httpbin.New(httpbin.WithEchoBodyMax(1000)).Handler())
+	defer srv.Close()
+
+	body := make([]byte, 5000)
+	for i := range body {
+		body[i] = byte(i * 7)
+	}
+	resp, err := http.Post(srv.URL+"/post", "application/octet-stream", bytes.NewReader(body))
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	var v struct {
+		Data       string `json:"data"`
+		BodyDigest struct {
+			Size       int64  `json:"size"`
+			SHA256     string `json:"sha256"`
+			HeadBase64 string `json:"head_base64"`
+			TailBase64 string `json:"tail_base64"`
+		} `json:"body_digest"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	require.Empty(t, v.Data)
+	sum := sha256.Sum256(body)
+	require.Equal(t, int64(len(body)), v.BodyDigest.Size)
+	require.Equal(t, hex.EncodeToString(sum[:]), v.BodyDigest.SHA256)
+	require.Equal(t, base64.StdEncoding.EncodeToString(body[:64]), v.BodyDigest.HeadBase64)
+	require.Equal(t, base64.StdEncoding.EncodeToString(body[len(body)-64:]), v.BodyDigest.TailBase64)
+
+	resp, err = http.Post(srv.URL+"/post", "text/plain", bytes.NewReader(body[:1000]))
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	var small map[string]interface{}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&small))
+	require.NotContains(t, small, "body_digest")
+}
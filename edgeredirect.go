@@ -0,0 +1,67 @@
+package httpbin
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// edgeRedirect is a case of /redirect/edge: the Location header values sent
+// and what a client following RFC 3986 resolves them to, relative to the
+// server root, or "" if the redirect cannot be followed.
+type edgeRedirect struct {
+	locations []string
+	expected  string
+}
+
+// edgeRedirects are the cases of /redirect/edge, with paths relative to the
+// prefix of the HTTPBin. Locations starting with "//" are sent with the
+// request's host and the expected path appended.
+var edgeRedirects = map[string]edgeRedirect{
+	"relative_no_slash":  {[]string{"edge?case=fragment"}, "/redirect/edge?case=fragment"},
+	"dot_segments":       {[]string{"./../a/../get?case=dot_segments"}, "/get?case=dot_segments"},
+	"query_only":         {[]string{"?case=fragment"}, "/redirect/edge?case=fragment"},
+	"schemeless":         {[]string{"//"}, "/get?case=schemeless"},
+	"fragment":           {[]string{"/get?case=fragment#section"}, "/get?case=fragment#section"},
+	"backslash":          {[]string{`/\get?case=backslash`}, "/%5Cget?case=backslash"},
+	"utf8_location":      {[]string{"/get?case=utf8_location&name=café"}, "/get?case=utf8_location&name=caf%C3%A9"},
+	"missing_location":   {nil, ""},
+	"multiple_locations": {[]string{"/get?location=1", "/get?location=2"}, ""},
+}
+
+// EdgeRedirectHandler returns a 302 with a legal or borderline Location
+// header chosen by the 'case' query parameter, to test how clients resolve
+// them. The resolution RFC 3986 calls for is given in the
+// X-Httpbin-Expected-Location header, which is absent when the redirect
+// cannot be followed (missing_location, multiple_locations).
+func EdgeRedirectHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("case")
+	c, ok := edgeRedirects[name]
+	if !ok {
+		names := make([]string, 0, len(edgeRedirects))
+		for n := range edgeRedirects {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("unknown case %q, want one of %s", name, strings.Join(names, ", ")))
+		return
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	for _, loc := range c.locations {
+		if loc == "//" {
+			loc = "//" + r.Host + prefixed(r, c.expected)
+		} else if strings.HasPrefix(loc, "/") {
+			loc = prefixed(r, loc)
+		}
+		w.Header().Add("Location", loc)
+	}
+	if c.expected != "" {
+		w.Header().Set("X-Httpbin-Expected-Location", scheme+"://"+r.Host+prefixed(r, c.expected))
+	}
+	w.WriteHeader(http.StatusFound)
+}
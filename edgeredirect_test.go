@@ -0,0 +1,84 @@
+package httpbin_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEdgeRedirect(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for _, c := range []string{"relative_no_slash", "dot_segments", "query_only", "schemeless", "fragment", "backslash"} {
+		u := srv.URL + "/redirect/edge?case=" + c
+		resp, err := noFollowGet(noRedirectClient(), u)
+		require.Nil(t, err, c)
+		resp.Body.Close()
+		require.Equal(t, http.StatusFound, resp.StatusCode, c)
+
+		base, _ := url.Parse(u)
+		loc, err := url.Parse(resp.Header.Get("Location"))
+		require.Nil(t, err, c)
+		require.Equal(t, resp.Header.Get("X-Httpbin-Expected-Location"), base.ResolveReference(loc).String(), c)
+	}
+}
+
+func TestEdgeRedirect_prefix(t *testing.T) {
+	srv := httptest.NewServer(httpbin.New(httpbin.WithPrefix("/hb")).Handler())
+	defer srv.Close()
+
+	for c, want := range map[string]string{
+		"relative_no_slash": "/hb/redirect/edge?case=fragment",
+		"dot_segments":      "/hb/get?case=dot_segments",
+		"query_only":        "/hb/redirect/edge?case=fragment",
+		"schemeless":        "/hb/get?case=schemeless",
+		"fragment":          "/hb/get?case=fragment#section",
+		"backslash":         "/hb/%5Cget?case=backslash",
+	} {
+		u := srv.URL + "/hb/redirect/edge?case=" + c
+		resp, err := noFollowGet(noRedirectClient(), u)
+		require.Nil(t, err, c)
+		resp.Body.Close()
+		require.Equal(t, http.StatusFound, resp.StatusCode, c)
+		require.Equal(t, srv.URL+want, resp.Header.Get("X-Httpbin-Expected-Location"), c)
+
+		base, _ := url.Parse(u)
+		loc, err := url.Parse(resp.Header.Get("Location"))
+		require.Nil(t, err, c)
+		require.Equal(t, srv.URL+want, base.ResolveReference(loc).String(), c)
+	}
+}
+
+func TestEdgeRedirect_borderline(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := noFollowGet(noRedirectClient(), srv.URL+"/redirect/edge?case=utf8_location")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, "/get?case=utf8_location&name=café", resp.Header.Get("Location"))
+	require.Equal(t, srv.URL+"/get?case=utf8_location&name=caf%C3%A9", resp.Header.Get("X-Httpbin-Expected-Location"))
+
+	resp, err = noFollowGet(noRedirectClient(), srv.URL+"/redirect/edge?case=missing_location")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	require.Empty(t, resp.Header["Location"])
+	require.Empty(t, resp.Header.Get("X-Httpbin-Expected-Location"))
+
+	resp, err = noFollowGet(noRedirectClient(), srv.URL+"/redirect/edge?case=multiple_locations")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Len(t, resp.Header["Location"], 2)
+	require.Empty(t, resp.Header.Get("X-Httpbin-Expected-Location"))
+
+	resp, err = http.Get(srv.URL + "/redirect/edge?case=nope")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,46 @@
+package httpbin
+
+import (
+	"net/http"
+	"time"
+)
+
+// WithHooks sets the Events notified of the server-side life cycle of
+// requests to httpbin routes, so tests embedding httpbin can assert on what
+// the server observed, none if nil, the default. Methods are called from the
+// goroutines serving the requests, so they may be called concurrently.
+func WithHooks(e Events) Option {
+	return func(h *HTTPBin) { h.hooks = e }
+}
+
+// Events receives the server-side events of requests. Embed NopEvents to
+// implement only some of the methods.
+type Events interface {
+	// OnRequestStart is called before a request is handled.
+	OnRequestStart(r *http.Request)
+	// OnStreamChunk is called when a streaming response flushes a chunk of
+	// n body bytes to the client.
+	OnStreamChunk(r *http.Request, n int)
+	// OnClientDisconnect is called when the client went away before the
+	// response was complete.
+	OnClientDisconnect(r *http.Request)
+	// OnResponseComplete is called once a request has been handled, with
+	// the response status (0 if the handler hijacked the connection), the
+	// number of body bytes written and how long handling took.
+	OnResponseComplete(r *http.Request, status int, bytes int64, d time.Duration)
+}
+
+// NopEvents implements Events doing nothing.
+type NopEvents struct{}
+
+// OnRequestStart implements Events.
+func (NopEvents) OnRequestStart(*http.Request) {}
+
+// OnStreamChunk implements Events.
+func (NopEvents) OnStreamChunk(*http.Request, int) {}
+
+// OnClientDisconnect implements Events.
+func (NopEvents) OnClientDisconnect(*http.Request) {}
+
+// OnResponseComplete implements Events.
+func (NopEvents) OnResponseComplete(*http.Request, int, int64, time.Duration) {}
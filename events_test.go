@@ -0,0 +1,112 @@
+package httpbin_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+type recordedEvents struct {
+	httpbin.NopEvents
+	mu          sync.Mutex
+	started     []string
+	chunks      []int
+	disconnects []string
+	statuses    []int
+	bytes       []int64
+	done        chan struct{}
+}
+
+func (e *recordedEvents) OnRequestStart(r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.started = append(e.started, r.URL.Path)
+}
+
+func (e *recordedEvents) OnStreamChunk(r *http.Request, n int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.chunks = append(e.chunks, n)
+}
+
+func (e *recordedEvents) OnClientDisconnect(r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.disconnects = append(e.disconnects, r.URL.Path)
+}
+
+func (e *recordedEvents) OnResponseComplete(r *http.Request, status int, bytes int64, d time.Duration) {
+	e.mu.Lock()
+	e.statuses = append(e.statuses, status)
+	e.bytes = append(e.bytes, bytes)
+	e.mu.Unlock()
+	e.done <- struct{}{}
+}
+
+func withEvents(t *testing.T) (*recordedEvents, *httptest.Server) {
+	e := &recordedEvents{done: make(chan struct{}, 10)}
+	return e, httptest.NewServer(httpbin.New(httpbin.WithHooks(e)).Handler())
+}
+
+func TestEvents(t *testing.T) {
+	e, srv := withEvents(t)
+	defer srv.Close()
+
+	b := get(t, srv.URL+"/get")
+	<-e.done
+	require.Equal(t, []string{"/get"}, e.started)
+	require.Equal(t, []int{http.StatusOK}, e.statuses)
+	require.Equal(t, []int64{int64(len(b))}, e.bytes)
+	require.Empty(t, e.chunks)
+	require.Empty(t, e.disconnects)
+}
+
+func TestEvents_streamChunks(t *testing.T) {
+	e, srv := withEvents(t)
+	defer srv.Close()
+
+	r, _ := http.NewRequest(http.MethodGet, srv.URL+"/gzip/stream?n=3&interval=0", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(r)
+	require.Nil(t, err)
+	_, err = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Nil(t, err)
+	<-e.done
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	require.Len(t, e.chunks, 3)
+	var total int64
+	for _, n := range e.chunks {
+		total += int64(n)
+	}
+	require.True(t, total <= e.bytes[0]) // the gzip footer is written after the last flush
+}
+
+func TestEvents_clientDisconnect(t *testing.T) {
+	e, srv := withEvents(t)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	r, _ := http.NewRequest(http.MethodGet, srv.URL+"/delay/0.3", nil)
+	_, err := http.DefaultClient.Do(r.WithContext(ctx))
+	require.NotNil(t, err)
+
+	select {
+	case <-e.done:
+	case <-time.After(time.Second):
+		t.Fatal("response did not complete")
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	require.Equal(t, []string{"/delay/0.3"}, e.disconnects)
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"time"
 
 	httpbin "github.com/ahmetb/go-httpbin"
 )
@@ -29,6 +30,20 @@ func ExampleGetMux_httptest() {
 	// Output: Retrieved 65536 bytes.
 }
 
+func ExampleNew() {
+	srv := httptest.NewServer(httpbin.New(httpbin.WithDelayMax(100 * time.Millisecond)).Handler())
+	defer srv.Close()
+
+	start := time.Now()
+	resp, err := http.Get(srv.URL + "/delay/10")
+	if err != nil {
+		log.Fatal(err)
+	}
+	resp.Body.Close()
+	fmt.Println(time.Since(start) < time.Second)
+	// Output: true
+}
+
 func ExampleGetMux_server() {
 	log.Fatal(http.ListenAndServe(":8080", httpbin.GetMux()))
 }
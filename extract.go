@@ -0,0 +1,95 @@
+package httpbin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ExtractHandler applies the expression in the 'path' query parameter to the
+// JSON body and returns what it selects, with the number of nodes each step
+// of the expression selected to show where a path stops matching. 'syntax'
+// is jsonpath (the default), for the subset parseJSONPath accepts, or
+// jmespath, for the identifiers, indexes and wildcards of JMESPath.
+func ExtractHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	expr := q.Get("path")
+	if expr == "" {
+		writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("missing 'path' query parameter"))
+		return
+	}
+	v := extractResponse{Expression: expr, Syntax: q.Get("syntax"), JSONPath: expr}
+	switch v.Syntax {
+	case "":
+		v.Syntax = "jsonpath"
+	case "jsonpath":
+	case "jmespath":
+		var err error
+		if v.JSONPath, err = jmesPathToJSONPath(expr); err != nil {
+			writeErrorJSONStatus(w, http.StatusBadRequest, err)
+			return
+		}
+	default:
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("unsupported syntax %q, want jsonpath or jmespath", v.Syntax))
+		return
+	}
+	p, err := parseJSONPath(v.JSONPath)
+	if err != nil {
+		writeErrorJSONStatus(w, http.StatusBadRequest, err)
+		return
+	}
+
+	body, err := parseData(r)
+	if err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to read body: %w", err))
+		return
+	}
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber() // echo numbers as sent
+	if err := dec.Decode(&doc); err != nil {
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("body is not JSON: %w", err))
+		return
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("body is not JSON: trailing data after the document"))
+		return
+	}
+
+	v.Steps = []extractStep{}
+	v.Matches = p.trace(doc, func(src string, n int) {
+		v.Steps = append(v.Steps, extractStep{Step: src, Matched: n})
+	})
+	if v.Matches == nil {
+		v.Matches = []interface{}{}
+	}
+	v.Count = len(v.Matches)
+	if v.Definite = p.definite(); !v.Definite {
+		v.Value = v.Matches
+	} else if v.Count > 0 {
+		v.Value = v.Matches[0]
+	}
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
+
+// jmesPathToJSONPath translates a JMESPath expression of identifiers,
+// indexes and wildcards, such as a.b[0] or a[*].b, to JSONPath.
+func jmesPathToJSONPath(expr string) (string, error) {
+	expr = strings.TrimSpace(expr)
+	switch {
+	case expr == "@":
+		return "$", nil
+	case expr == "" || strings.Contains(expr, "..") || strings.Contains(expr, "[]") ||
+		strings.ContainsAny(expr, "$@|&!?:(){}<>=,`'\" "):
+		return "", fmt.Errorf("jmespath %q: only identifiers, indexes and wildcards are supported", expr)
+	case strings.HasPrefix(expr, "["):
+		return "$" + expr, nil
+	}
+	return "$." + expr, nil
+}
@@ -0,0 +1,90 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtract(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	const doc = `{"a":{"b":[{"c":1},{"c":2},{"d":3}]},"big":12345678901234567890}`
+	for _, tc := range []struct {
+		query, want string
+	}{
+		{"path=$.a.b[1].c", `2`},
+		{"path=$.a.b[-1]", `{"d":3}`},
+		{"path=$.a.b[*].c", `[1,2]`},
+		{"path=$..c", `[1,2]`},
+		{"path=$.big", `12345678901234567890`},
+		{"path=$.a.x.c", `null`},
+		{"path=a.b[0].c&syntax=jmespath", `1`},
+		{"path=a.b[*].c&syntax=jmespath", `[1,2]`},
+	} {
+		resp, err := http.Post(srv.URL+"/extract?"+strings.Replace(tc.query, "$", url.QueryEscape("$"), -1), "text/plain", strings.NewReader(doc))
+		require.Nil(t, err)
+		var v struct {
+			Value json.RawMessage
+			Count int
+			Steps []struct {
+				Step    string
+				Matched int
+			}
+		}
+		err = json.NewDecoder(resp.Body).Decode(&v)
+		resp.Body.Close()
+		require.Nil(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode, tc.query)
+		require.JSONEq(t, tc.want, string(v.Value), tc.query)
+		require.NotEmpty(t, v.Steps, tc.query)
+	}
+}
+
+func TestExtract_steps(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/extract?path="+url.QueryEscape("$.a.x.c"), "text/plain", strings.NewReader(`{"a":{"b":1}}`))
+	require.Nil(t, err)
+	var v struct {
+		Count int
+		Steps []struct {
+			Step    string
+			Matched int
+		}
+	}
+	err = json.NewDecoder(resp.Body).Decode(&v)
+	resp.Body.Close()
+	require.Nil(t, err)
+	require.Equal(t, 0, v.Count)
+	require.Len(t, v.Steps, 3)
+	require.Equal(t, ".a", v.Steps[0].Step)
+	require.Equal(t, 1, v.Steps[0].Matched)
+	require.Equal(t, ".x", v.Steps[1].Step)
+	require.Equal(t, 0, v.Steps[1].Matched)
+}
+
+func TestExtract_badRequest(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for _, tc := range []struct{ query, body string }{
+		{"", `{}`},
+		{"path=a.b", `{}`},
+		{"path=a|b&syntax=jmespath", `{}`},
+		{"path=%24.a&syntax=xpath", `{}`},
+		{"path=%24.a", `{"a":`},
+		{"path=%24.a", `{} {}`},
+	} {
+		resp, err := http.Post(srv.URL+"/extract?"+tc.query, "text/plain", strings.NewReader(tc.body))
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, tc.query)
+	}
+}
@@ -0,0 +1,219 @@
+package httpbin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fixtureMax is the largest body a fixture uploaded to /serve/:name may have.
+const fixtureMax = 10 << 20
+
+// WithFixtureToken sets the bearer token PUT and DELETE requests to
+// /serve/:name require to register and remove fixtures. If empty, the
+// default, fixtures can only be registered with SetFixture.
+func WithFixtureToken(token string) Option {
+	return func(h *HTTPBin) { h.fixtureToken = token }
+}
+
+// Fixture is a canned response /serve/:name serves, such as a golden file
+// client tests compare against.
+type Fixture struct {
+	// Status is the response status, 200 if zero. 200 responses support
+	// conditional and Range requests.
+	Status int
+	// Header is set on the response. Its ETag defaults to a hash of Body and
+	// its Content-Type to one sniffed from Body.
+	Header http.Header
+	Body   []byte
+	// ModTime is the Last-Modified of the response, when the fixture was
+	// registered if zero.
+	ModTime time.Time
+}
+
+// fixtureStore holds the fixtures registered by name.
+type fixtureStore struct {
+	mu       sync.RWMutex
+	fixtures map[string]Fixture
+}
+
+// SetFixture registers f to be served at /serve/name by the handler of
+// GetMux, replacing any fixture of that name. f.Body must not be modified
+// afterwards. Use the SetFixture method for an HTTPBin from New.
+func SetFixture(name string, f Fixture) {
+	defaultBin().fixtures.set(name, f)
+}
+
+// RemoveFixture removes the fixture served at /serve/name by the handler of
+// GetMux, reporting whether there was one.
+func RemoveFixture(name string) bool {
+	return defaultBin().fixtures.remove(name)
+}
+
+// FixtureNames returns the names of the fixtures registered with the handler
+// of GetMux, sorted.
+func FixtureNames() []string {
+	return defaultBin().fixtures.names()
+}
+
+// SetFixture is like the SetFixture function for h.
+func (h *HTTPBin) SetFixture(name string, f Fixture) {
+	h.fixtures.set(name, f)
+}
+
+// RemoveFixture is like the RemoveFixture function for h.
+func (h *HTTPBin) RemoveFixture(name string) bool {
+	return h.fixtures.remove(name)
+}
+
+// FixtureNames is like the FixtureNames function for h.
+func (h *HTTPBin) FixtureNames() []string {
+	return h.fixtures.names()
+}
+
+// set registers f with its defaults filled in, returning it and whether it
+// replaced a fixture.
+func (s *fixtureStore) set(name string, f Fixture) (Fixture, bool) {
+	if f.Status == 0 {
+		f.Status = http.StatusOK
+	}
+	f.Header = f.Header.Clone()
+	if f.Header == nil {
+		f.Header = make(http.Header)
+	}
+	if f.Header.Get("ETag") == "" {
+		sum := sha256.Sum256(f.Body)
+		f.Header.Set("ETag", `"`+hex.EncodeToString(sum[:8])+`"`)
+	}
+	if f.ModTime.IsZero() {
+		f.ModTime = time.Now()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, replaced := s.fixtures[name]
+	s.fixtures[name] = f
+	return f, replaced
+}
+
+func (s *fixtureStore) remove(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.fixtures[name]
+	delete(s.fixtures, name)
+	return ok
+}
+
+func (s *fixtureStore) get(name string) (Fixture, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	f, ok := s.fixtures[name]
+	return f, ok
+}
+
+func (s *fixtureStore) names() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.fixtures))
+	for name := range s.fixtures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ServeFixtureHandler serves the fixture registered under the name in the
+// path, or 404. With the fixture token as a bearer token, PUT registers the
+// request body as the fixture, with the request's Content-Type, the status
+// in the 'status' query parameter and the headers in 'header' parameters of
+// the form Name:value, and DELETE removes it.
+func ServeFixtureHandler(w http.ResponseWriter, r *http.Request) {
+	name, hb := routeVars(r)["name"], instance(r)
+	switch r.Method {
+	case http.MethodPut, http.MethodDelete:
+		if hb.fixtureToken == "" {
+			writeErrorJSONStatus(w, http.StatusForbidden, errors.New("fixtures can't be changed over HTTP"))
+			return
+		}
+		if !checkBearerToken(w, r, hb.fixtureToken, "httpbin fixtures") {
+			return
+		}
+		if r.Method == http.MethodPut {
+			putFixture(w, r, name)
+		} else if !instance(r).RemoveFixture(name) {
+			writeErrorJSONStatus(w, http.StatusNotFound, fmt.Errorf("no fixture named %q", name))
+		} else {
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return
+	}
+
+	f, ok := instance(r).fixtures.get(name)
+	if !ok {
+		writeErrorJSONStatus(w, http.StatusNotFound, fmt.Errorf("no fixture named %q", name))
+		return
+	}
+	for k, v := range f.Header {
+		w.Header()[k] = v
+	}
+	if f.Status == http.StatusOK {
+		http.ServeContent(w, r, "", f.ModTime, bytes.NewReader(f.Body))
+		return
+	}
+	w.Header().Set("Last-Modified", f.ModTime.UTC().Format(http.TimeFormat))
+	w.WriteHeader(f.Status)
+	if r.Method != http.MethodHead {
+		w.Write(f.Body)
+	}
+}
+
+// putFixture registers the body of r as the fixture name.
+func putFixture(w http.ResponseWriter, r *http.Request, name string) {
+	q := r.URL.Query()
+	f := Fixture{Status: http.StatusOK, Header: make(http.Header)}
+	if s := q.Get("status"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 100 || n > 999 {
+			writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("'status' must be between 100 and 999"))
+			return
+		}
+		f.Status = n
+	}
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		f.Header.Set("Content-Type", ct)
+	}
+	for _, h := range q["header"] {
+		i := strings.IndexByte(h, ':')
+		if i <= 0 {
+			writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("'header' must be of the form Name:value"))
+			return
+		}
+		f.Header.Add(strings.TrimSpace(h[:i]), strings.TrimSpace(h[i+1:]))
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, fixtureMax))
+	if err != nil {
+		writeErrorJSONStatus(w, http.StatusRequestEntityTooLarge, fmt.Errorf("fixtures must be at most %d bytes", fixtureMax))
+		return
+	}
+	f.Body = body
+	f, replaced := instance(r).fixtures.set(name, f)
+	if !replaced {
+		w.WriteHeader(http.StatusCreated)
+	}
+	v := fixtureResponse{
+		Name:   name,
+		URL:    prefixed(r, "/serve/"+name),
+		Status: f.Status,
+		Size:   len(f.Body),
+		ETag:   f.Header.Get("ETag"),
+	}
+	_ = writeJSON(w, v) // status may be written already, nothing else to do
+}
@@ -0,0 +1,91 @@
+package httpbin_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeFixture(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+	httpbin.SetFixture("golden.bin", httpbin.Fixture{
+		Header: http.Header{"Content-Type": {"application/octet-stream"}},
+		Body:   []byte("0123456789"),
+	})
+	defer httpbin.RemoveFixture("golden.bin")
+
+	resp, err := http.Get(srv.URL + "/serve/golden.bin")
+	require.Nil(t, err)
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, "0123456789", string(b))
+	require.EqualValues(t, "application/octet-stream", resp.Header.Get("Content-Type"))
+	etag := resp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/serve/golden.bin", nil)
+	req.Header.Set("If-None-Match", etag)
+	resp, err = http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusNotModified, resp.StatusCode)
+
+	req, _ = http.NewRequest(http.MethodGet, srv.URL+"/serve/golden.bin", nil)
+	req.Header.Set("Range", "bytes=2-4")
+	resp, err = http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	b, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusPartialContent, resp.StatusCode)
+	require.EqualValues(t, "234", string(b))
+
+	resp, err = http.Get(srv.URL + "/serve/missing")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestServeFixture_put(t *testing.T) {
+	h := httpbin.New()
+	unset := httptest.NewServer(h.Handler())
+	defer unset.Close()
+	srv := httptest.NewServer(h.Reconfigure(httpbin.WithFixtureToken("secret")).Handler())
+	defer srv.Close()
+	do := func(srv *httptest.Server, method, url, token, body string) *http.Response {
+		req, _ := http.NewRequest(method, srv.URL+url, strings.NewReader(body))
+		req.Header.Set("Content-Type", "text/csv")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	// not allowed without a token configured
+	require.EqualValues(t, http.StatusForbidden, do(unset, http.MethodPut, "/serve/data", "", "a,b").StatusCode)
+
+	require.EqualValues(t, http.StatusUnauthorized, do(srv, http.MethodPut, "/serve/data", "wrong", "a,b").StatusCode)
+	require.EqualValues(t, http.StatusCreated, do(srv, http.MethodPut, "/serve/data?status=202&header=X-Fixture:%201", "secret", "a,b").StatusCode)
+	require.EqualValues(t, http.StatusOK, do(srv, http.MethodPut, "/serve/data?status=202&header=X-Fixture:%201", "secret", "a,b,c").StatusCode)
+
+	resp, err := http.Get(srv.URL + "/serve/data")
+	require.Nil(t, err)
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusAccepted, resp.StatusCode)
+	require.EqualValues(t, "a,b,c", string(b))
+	require.EqualValues(t, "text/csv", resp.Header.Get("Content-Type"))
+	require.EqualValues(t, "1", resp.Header.Get("X-Fixture"))
+
+	require.EqualValues(t, http.StatusNoContent, do(srv, http.MethodDelete, "/serve/data", "secret", "").StatusCode)
+	require.EqualValues(t, http.StatusNotFound, do(srv, http.MethodDelete, "/serve/data", "secret", "").StatusCode)
+}
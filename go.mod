@@ -0,0 +1,20 @@
+module github.com/ahmetb/go-httpbin
+
+go 1.23
+
+require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/andybalholm/brotli v1.1.1
+	github.com/cespare/xxhash v1.1.0
+	github.com/gen2brain/avif v0.4.4
+	github.com/klauspost/compress v1.18.0
+	github.com/stretchr/testify v1.2.1
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/ebitengine/purego v0.8.3 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/tetratelabs/wazero v1.9.0 // indirect
+)
@@ -0,0 +1,415 @@
+//go:build !httpbin_nowebsocket
+// +build !httpbin_nowebsocket
+
+package httpbin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+func init() {
+	featureRoutes = append(featureRoutes,
+		route{name: "graphql", path: `/graphql`, methods: []string{http.MethodGet}, params: []string{"count", "interval"}, description: "A GraphQL over WebSocket (graphql-transport-ws or graphql-ws) endpoint streaming synthetic events to subscriptions.", handler: http.HandlerFunc(GraphQLHandler)},
+	)
+}
+
+// GraphQL over WebSocket subprotocols: graphql-transport-ws of the graphql-ws
+// library and graphql-ws of the older subscriptions-transport-ws.
+const (
+	gqlTransportWS = "graphql-transport-ws"
+	gqlWS          = "graphql-ws"
+)
+
+// gqlProtocol names the message types of a GraphQL over WebSocket
+// subprotocol that differ between the two.
+type gqlProtocol struct {
+	subscribe string // client: start a subscription
+	stop      string // client: stop a subscription
+	next      string // server: an event of a subscription
+}
+
+var gqlProtocols = map[string]gqlProtocol{
+	gqlTransportWS: {subscribe: "subscribe", stop: "complete", next: "next"},
+	gqlWS:          {subscribe: "start", stop: "stop", next: "data"},
+}
+
+// gqlMessage is a message of either subprotocol.
+type gqlMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// GraphQLHandler is a GraphQL over WebSocket endpoint serving subscriptions,
+// and only subscriptions, with synthetic events, so that subscription
+// clients can be tested without a GraphQL server. It speaks both the
+// graphql-transport-ws and legacy graphql-ws subprotocols.
+//
+// Each subscription gets 'count' events (default 10, 0 for no limit), one
+// every 'interval' seconds (default 1, capped at the delay limit), and completes.
+// The events are objects under the key of the subscription's first field,
+// with their sequence number, the subscription's id and a timestamp; the
+// rest of the query is not looked at.
+func GraphQLHandler(w http.ResponseWriter, r *http.Request) {
+	s := &gqlSession{count: 10, interval: time.Second, subs: make(map[string]chan struct{})}
+	if !intParam(w, r, "count", 0, 1<<20, &s.count) || !secondsParam(w, r, "interval", &s.interval) {
+		return
+	}
+	c, protocol := upgradeWebSocket(w, r, []string{gqlTransportWS, gqlWS})
+	if c == nil {
+		return
+	}
+	defer c.conn.Close()
+	if protocol == "" {
+		protocol = gqlTransportWS
+	}
+	s.c, s.legacy, s.protocol = c, protocol == gqlWS, gqlProtocols[protocol]
+	s.serve()
+}
+
+// gqlSession is a GraphQL over WebSocket connection.
+type gqlSession struct {
+	c        *wsConn
+	legacy   bool // graphql-ws rather than graphql-transport-ws
+	protocol gqlProtocol
+	count    int
+	interval time.Duration
+
+	mu   sync.Mutex
+	subs map[string]chan struct{} // closed to stop a subscription, by id
+	wg   sync.WaitGroup
+}
+
+// serve handles the client's messages until the connection is closed.
+func (s *gqlSession) serve() {
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		s.wg.Wait()
+	}()
+	// pongs keep the client from idling out while it only listens
+	go s.c.ping(s.c.idleTimeout/2, done)
+
+	acked := false
+	for {
+		op, msg, err := s.c.readMessage()
+		if err != nil {
+			return
+		}
+		var m gqlMessage
+		if op != wsText || json.Unmarshal(msg, &m) != nil || m.Type == "" {
+			s.c.close(4400, "Invalid message")
+			return
+		}
+
+		switch {
+		case m.Type == "connection_init":
+			if acked {
+				s.c.close(4429, "Too many initialisation requests")
+				return
+			}
+			acked = true
+			s.send("", "connection_ack", nil)
+			if s.legacy {
+				s.send("", "ka", nil)
+				go s.keepAlive(done)
+			}
+		case m.Type == "ping" && !s.legacy:
+			s.send("", "pong", m.Payload)
+		case m.Type == "pong" && !s.legacy:
+		case m.Type == "connection_terminate" && s.legacy:
+			s.c.close(1000, "")
+			return
+		case m.Type == s.protocol.subscribe:
+			if !acked {
+				s.c.close(4401, "Unauthorized")
+				return
+			}
+			if !s.subscribe(m, done) {
+				return
+			}
+		case m.Type == s.protocol.stop:
+			s.mu.Lock()
+			if stop, ok := s.subs[m.ID]; ok {
+				close(stop)
+				delete(s.subs, m.ID)
+			}
+			s.mu.Unlock()
+		default:
+			s.c.close(4400, "Unknown message type "+m.Type)
+			return
+		}
+	}
+}
+
+// subscribe starts streaming the events of the subscription m, or sends an
+// error if its query is not a subscription. It reports false if it closed
+// the connection.
+func (s *gqlSession) subscribe(m gqlMessage, done <-chan struct{}) bool {
+	var payload struct {
+		Query         string `json:"query"`
+		OperationName string `json:"operationName"`
+	}
+	if m.ID == "" || json.Unmarshal(m.Payload, &payload) != nil {
+		s.c.close(4400, "Invalid "+m.Type+" message")
+		return false
+	}
+	s.mu.Lock()
+	_, exists := s.subs[m.ID]
+	if exists && !s.legacy {
+		s.mu.Unlock()
+		s.c.close(4409, "Subscriber for "+m.ID+" already exists")
+		return false
+	}
+	if exists {
+		close(s.subs[m.ID]) // the legacy protocol restarts it
+	}
+	stop := make(chan struct{})
+	s.subs[m.ID] = stop
+	s.mu.Unlock()
+
+	field, err := subscriptionField(payload.Query, payload.OperationName)
+	if err != nil {
+		s.finish(m.ID, stop)
+		var errs interface{} = []map[string]string{{"message": err.Error()}}
+		if s.legacy {
+			errs = map[string]string{"message": err.Error()}
+		}
+		s.send(m.ID, "error", errs)
+		return true
+	}
+	s.wg.Add(1)
+	go s.stream(m.ID, field, stop, done)
+	return true
+}
+
+// stream sends the events of the subscription id until there are count of
+// them, it is stopped, or the connection is done.
+func (s *gqlSession) stream(id, field string, stop chan struct{}, done <-chan struct{}) {
+	defer s.wg.Done()
+	for n := 1; s.count == 0 || n <= s.count; n++ {
+		t := time.NewTimer(s.interval)
+		select {
+		case <-t.C:
+		case <-stop:
+			t.Stop()
+			return
+		case <-done:
+			t.Stop()
+			return
+		}
+		event := map[string]interface{}{"sequence": n, "subscription": id, "timestamp": time.Now().UTC().Format(time.RFC3339Nano)}
+		if s.send(id, s.protocol.next, map[string]interface{}{"data": map[string]interface{}{field: event}}) != nil {
+			return
+		}
+	}
+	if s.finish(id, stop) {
+		s.send(id, "complete", nil)
+	}
+}
+
+// finish forgets the subscription id, reporting false if the client
+// stopped it or started another under the same id already.
+func (s *gqlSession) finish(id string, stop chan struct{}) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.subs[id] != stop {
+		return false
+	}
+	delete(s.subs, id)
+	return true
+}
+
+// keepAlive sends the legacy protocol's keep-alive messages until done is
+// closed.
+func (s *gqlSession) keepAlive(done <-chan struct{}) {
+	t := time.NewTicker(s.c.idleTimeout / 2)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if s.send("", "ka", nil) != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// send sends a message with the JSON encoding of payload, if it is set.
+func (s *gqlSession) send(id, typ string, payload interface{}) error {
+	m := gqlMessage{ID: id, Type: typ}
+	switch p := payload.(type) {
+	case nil:
+	case json.RawMessage:
+		m.Payload = p
+	default:
+		b, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		m.Payload = b
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return s.c.writeFrame(true, wsText, b)
+}
+
+// subscriptionField returns the response key, alias or name, of the first
+// field selected by the subscription operationName of a GraphQL document,
+// or its only operation if operationName is empty.
+func subscriptionField(query, operationName string) (string, error) {
+	toks, err := gqlTokens(query)
+	if err != nil {
+		return "", err
+	}
+	type operation struct{ kind, name, field string }
+	var ops []operation
+	for i := 0; i < len(toks); {
+		op := operation{kind: "query"} // the shorthand of a selection set alone
+		if toks[i] != "{" {
+			if op.kind = toks[i]; op.kind != "query" && op.kind != "mutation" && op.kind != "subscription" && op.kind != "fragment" {
+				return "", fmt.Errorf("syntax error: unexpected %q", op.kind)
+			}
+			if i++; i < len(toks) && isGQLName(toks[i]) {
+				op.name = toks[i]
+				i++
+			}
+		}
+		// skip variable definitions, type conditions and directives
+		for depth := 0; i < len(toks) && (depth > 0 || toks[i] != "{"); i++ {
+			switch toks[i] {
+			case "(", "[":
+				depth++
+			case ")", "]":
+				depth--
+			}
+		}
+		if i == len(toks) {
+			return "", errors.New("syntax error: expected a selection set")
+		}
+		if i+1 < len(toks) && isGQLName(toks[i+1]) {
+			op.field = toks[i+1]
+		}
+		depth := 0
+		for ; i < len(toks); i++ {
+			switch toks[i] {
+			case "{":
+				depth++
+			case "}":
+				depth--
+			}
+			if depth == 0 {
+				i++
+				break
+			}
+		}
+		if depth != 0 {
+			return "", errors.New("syntax error: unbalanced braces")
+		}
+		if op.kind != "fragment" {
+			ops = append(ops, op)
+		}
+	}
+
+	var op *operation
+	for i := range ops {
+		if ops[i].name == operationName || operationName == "" && len(ops) == 1 {
+			op = &ops[i]
+			break
+		}
+	}
+	switch {
+	case op == nil && operationName != "":
+		return "", fmt.Errorf("unknown operation named %q", operationName)
+	case op == nil && len(ops) == 0:
+		return "", errors.New("the document has no operations")
+	case op == nil:
+		return "", errors.New("operationName is required for documents with several operations")
+	case op.kind != "subscription":
+		return "", fmt.Errorf("only subscriptions are supported, not %s operations", op.kind)
+	case op.field == "":
+		return "", errors.New("the subscription must select a field first")
+	}
+	return op.field, nil
+}
+
+// gqlTokens splits a GraphQL document into its names, punctuators, numbers
+// and strings, dropping whitespace and comments.
+func gqlTokens(src string) ([]string, error) {
+	src = strings.TrimPrefix(src, "\ufeff")
+	var toks []string
+	for i := 0; i < len(src); {
+		c := src[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+			continue
+		case c == '#':
+			for i < len(src) && src[i] != '\n' && src[i] != '\r' {
+				i++
+			}
+			continue
+		case strings.HasPrefix(src[i:], `"""`):
+			end := strings.Index(src[i+3:], `"""`)
+			for end > 0 && src[i+3+end-1] == '\\' { // an escaped \"""
+				next := strings.Index(src[i+3+end+3:], `"""`)
+				if next < 0 {
+					end = -1
+					break
+				}
+				end += 3 + next
+			}
+			if end < 0 {
+				return nil, errors.New("syntax error: unterminated block string")
+			}
+			i += 3 + end + 3
+		case c == '"':
+			for i++; i < len(src) && src[i] != '"'; i++ {
+				if src[i] == '\\' {
+					i++
+				} else if src[i] == '\n' || src[i] == '\r' {
+					break
+				}
+			}
+			if i >= len(src) || src[i] != '"' {
+				return nil, errors.New("syntax error: unterminated string")
+			}
+			i++
+		case strings.HasPrefix(src[i:], "..."):
+			i += 3
+		case strings.IndexByte("!$&()=:@[]{|}", c) >= 0:
+			i++
+		case c == '-' || c >= '0' && c <= '9':
+			for i++; i < len(src) && (isGQLNameByte(src[i]) || src[i] == '.' || src[i] == '+' || src[i] == '-'); i++ {
+			}
+		case isGQLNameByte(c):
+			for i++; i < len(src) && isGQLNameByte(src[i]); i++ {
+			}
+		default:
+			return nil, fmt.Errorf("syntax error: unexpected character %q", c)
+		}
+		toks = append(toks, src[start:i])
+	}
+	return toks, nil
+}
+
+func isGQLNameByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// isGQLName reports whether tok is a name, rather than a punctuator, number
+// or string.
+func isGQLName(tok string) bool {
+	return tok != "" && isGQLNameByte(tok[0]) && (tok[0] < '0' || tok[0] > '9')
+}
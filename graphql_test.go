@@ -0,0 +1,124 @@
+//go:build !httpbin_nowebsocket
+// +build !httpbin_nowebsocket
+
+package httpbin_test
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type gqlMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+func (c *wsClient) send(t *testing.T, m gqlMessage) {
+	b, err := json.Marshal(m)
+	require.Nil(t, err)
+	c.write(t, true, 0x1, b)
+}
+
+// receive reads the next GraphQL message, skipping pings.
+func (c *wsClient) receive(t *testing.T) gqlMessage {
+	for {
+		_, op, p := c.read(t)
+		if op == 0x9 {
+			continue
+		}
+		require.Equal(t, byte(0x1), op, string(p))
+		var m gqlMessage
+		require.Nil(t, json.Unmarshal(p, &m))
+		return m
+	}
+}
+
+func TestGraphQL_subscription(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+	c, protocol := dialWebSocketPath(t, srv.URL, "/graphql?count=2&interval=0", "graphql-transport-ws")
+	defer c.conn.Close()
+	require.Equal(t, "graphql-transport-ws", protocol)
+
+	c.send(t, gqlMessage{Type: "connection_init"})
+	require.Equal(t, "connection_ack", c.receive(t).Type)
+	c.send(t, gqlMessage{Type: "ping"})
+	require.Equal(t, "pong", c.receive(t).Type)
+
+	c.send(t, gqlMessage{ID: "1", Type: "subscribe", Payload: json.RawMessage(`{"query":"subscription { t: ticks }"}`)})
+	for n := 1; n <= 2; n++ {
+		m := c.receive(t)
+		require.Equal(t, "next", m.Type)
+		require.Equal(t, "1", m.ID)
+		var v struct {
+			Data map[string]struct {
+				Sequence int `json:"sequence"`
+			} `json:"data"`
+		}
+		require.Nil(t, json.Unmarshal(m.Payload, &v))
+		require.Equal(t, n, v.Data["t"].Sequence)
+	}
+	require.Equal(t, gqlMessage{ID: "1", Type: "complete"}, c.receive(t))
+
+	c.send(t, gqlMessage{ID: "2", Type: "subscribe", Payload: json.RawMessage(`{"query":"{ ticks }"}`)})
+	m := c.receive(t)
+	require.Equal(t, "error", m.Type)
+	require.Contains(t, string(m.Payload), "only subscriptions are supported")
+
+	c.send(t, gqlMessage{Type: "connection_init"})
+	_, op, p := c.read(t)
+	require.Equal(t, byte(0x8), op)
+	require.Equal(t, uint16(4429), binary.BigEndian.Uint16(p))
+}
+
+func TestGraphQL_legacy(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+	c, protocol := dialWebSocketPath(t, srv.URL, "/graphql?count=0&interval=0.01", "graphql-ws")
+	defer c.conn.Close()
+	require.Equal(t, "graphql-ws", protocol)
+
+	c.send(t, gqlMessage{Type: "connection_init"})
+	require.Equal(t, "connection_ack", c.receive(t).Type)
+	require.Equal(t, "ka", c.receive(t).Type)
+
+	c.send(t, gqlMessage{ID: "a", Type: "start", Payload: json.RawMessage(`{"query":"subscription Ticks { ticks }"}`)})
+	require.Equal(t, "data", c.receive(t).Type)
+	c.send(t, gqlMessage{ID: "a", Type: "stop"})
+	c.send(t, gqlMessage{Type: "connection_terminate"})
+	for {
+		_, op, p := c.read(t)
+		if op == 0x8 {
+			require.Equal(t, uint16(1000), binary.BigEndian.Uint16(p))
+			break
+		}
+	}
+}
+
+func TestGraphQL_handshake(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	c, _ := dialWebSocketPath(t, srv.URL, "/graphql", "")
+	c.send(t, gqlMessage{ID: "1", Type: "subscribe", Payload: json.RawMessage(`{"query":"subscription { ticks }"}`)})
+	_, op, p := c.read(t)
+	require.Equal(t, byte(0x8), op)
+	require.Equal(t, uint16(4401), binary.BigEndian.Uint16(p))
+	c.conn.Close()
+
+	req, _ := http.NewRequest("GET", srv.URL+"/graphql", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Sec-WebSocket-Protocol", "mqtt")
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
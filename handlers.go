@@ -3,356 +3,251 @@
 package httpbin
 
 import (
+	"bytes"
 	"compress/flate"
 	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"image"
-	"image/color"
-	"image/gif"
-	"image/jpeg"
-	"image/png"
 	"io"
 	"io/ioutil"
-	"math"
 	"math/rand"
+	"mime"
+	"mime/multipart"
 	"net"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
-
-	"github.com/gorilla/mux"
-	"github.com/pkg/errors"
 )
 
-var (
-	// BinaryChunkSize is buffer length used for stuff like generating
+const (
+	// defaultBinaryChunkSize is buffer length used for stuff like generating
 	// large blobs.
-	BinaryChunkSize = 64 * 1024
+	defaultBinaryChunkSize = 64 * 1024
 
-	// DelayMax is the maximum execution time for /delay endpoint.
-	DelayMax = 10 * time.Second
+	// defaultDelayMax is the maximum execution time for /delay endpoint.
+	defaultDelayMax = 10 * time.Second
 
-	// StreamInterval is the default interval between writing objects to the stream.
-	StreamInterval = 1 * time.Second
+	// defaultStreamInterval is the default interval between writing objects
+	// to the stream.
+	defaultStreamInterval = 1 * time.Second
 )
 
-// GetMux returns the mux with handlers for httpbin endpoints registered.
-func GetMux() *mux.Router {
-
-	r := mux.NewRouter()
-	r.HandleFunc(`/`, HomeHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/ip`, IPHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/user-agent`, UserAgentHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/headers`, HeadersHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/get`, GetHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/post`, PostHandler).Methods(http.MethodPost)
-	r.HandleFunc(`/redirect/{n:[\d]+}`, RedirectHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/absolute-redirect/{n:[\d]+}`, AbsoluteRedirectHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/redirect-to`, RedirectToHandler).Methods(http.MethodGet, http.MethodHead).Queries("url", "{url:.+}")
-	r.HandleFunc(`/status/{code:[\d]+}`, StatusHandler)
-	r.HandleFunc(`/bytes/{n:[\d]+}`, BytesHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/delay/{n:\d+(?:\.\d+)?}`, DelayHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/stream/{n:[\d]+}`, StreamHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/drip`, DripHandler).Methods(http.MethodGet, http.MethodHead).Queries(
-		"numbytes", `{numbytes:\d+}`,
-		"duration", `{duration:\d+(?:\.\d+)?}`)
-	r.HandleFunc(`/cookies`, CookiesHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/cookies/set`, SetCookiesHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/cookies/delete`, DeleteCookiesHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/cache`, CacheHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/cache/{n:[\d]+}`, SetCacheHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/gzip`, GZIPHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/deflate`, DeflateHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/html`, HTMLHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/xml`, XMLHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/robots.txt`, RobotsTXTHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/deny`, DenyHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/basic-auth/{u}/{p}`, BasicAuthHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/hidden-basic-auth/{u}/{p}`, HiddenBasicAuthHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/image/gif`, GIFHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/image/png`, PNGHandler).Methods(http.MethodGet, http.MethodHead)
-	r.HandleFunc(`/image/jpeg`, JPEGHandler).Methods(http.MethodGet, http.MethodHead)
+// GetMux returns the handler of the httpbin endpoints, with the default
+// limits and the state shared with the package-level functions like
+// SetFixture; New returns a server with limits and state of its own. Routes
+// are named, so RouteName identifies the endpoint a request was routed to.
+func GetMux() http.Handler {
+	return defaultBin().Handler()
+}
+
+// mux returns the router of the endpoints of h.
+func (h *HTTPBin) mux() http.Handler {
+	r := &routeMux{}
+	for _, rt := range h.routeTable(r) {
+		rt.register(r, h)
+	}
+	r.notFound = notFoundHandler(r)
+	withLatency(r)
+	if h.traceRequests {
+		withTrace(r)
+	}
 	return r
 }
 
-// HomeHandler serves static HTML content for the index page.
-func HomeHandler(w http.ResponseWriter, r *http.Request) {
-	fmt.Fprintf(w, `<!DOCTYPE html>
-<html>
-<head>
-  <meta http-equiv='content-type' value='text/html;charset=utf8'>
-  <meta name='generator' value='Ronn/v0.7.3 (http://github.com/rtomayko/ronn/tree/0.7.3)'>
-  <title>go-httpbin(1): HTTP Client Testing Service</title>
-  <style type='text/css' media='all'>
-  /* style: man */
-  body#manpage {margin:0}
-  .mp {max-width:100ex;padding:0 9ex 1ex 4ex}
-  .mp p,.mp pre,.mp ul,.mp ol,.mp dl {margin:0 0 20px 0}
-  .mp h2 {margin:10px 0 0 0}
-  .mp > p,.mp > pre,.mp > ul,.mp > ol,.mp > dl {margin-left:8ex}
-  .mp h3 {margin:0 0 0 4ex}
-  .mp dt {margin:0;clear:left}
-  .mp dt.flush {float:left;width:8ex}
-  .mp dd {margin:0 0 0 9ex}
-  .mp h1,.mp h2,.mp h3,.mp h4 {clear:left}
-  .mp pre {margin-bottom:20px}
-  .mp pre+h2,.mp pre+h3 {margin-top:22px}
-  .mp h2+pre,.mp h3+pre {margin-top:5px}
-  .mp img {display:block;margin:auto}
-  .mp h1.man-title {display:none}
-  .mp,.mp code,.mp pre,.mp tt,.mp kbd,.mp samp,.mp h3,.mp h4 {font-family:monospace;font-size:14px;line-height:1.42857142857143}
-  .mp h2 {font-size:16px;line-height:1.25}
-  .mp h1 {font-size:20px;line-height:2}
-  .mp {text-align:justify;background:#fff}
-  .mp,.mp code,.mp pre,.mp pre code,.mp tt,.mp kbd,.mp samp {color:#131211}
-  .mp h1,.mp h2,.mp h3,.mp h4 {color:#030201}
-  .mp u {text-decoration:underline}
-  .mp code,.mp strong,.mp b {font-weight:bold;color:#131211}
-  .mp em,.mp var {font-style:italic;color:#232221;text-decoration:none}
-  .mp a,.mp a:link,.mp a:hover,.mp a code,.mp a pre,.mp a tt,.mp a kbd,.mp a samp {color:#0000ff}
-  .mp b.man-ref {font-weight:normal;color:#434241}
-  .mp pre {padding:0 4ex}
-  .mp pre code {font-weight:normal;color:#434241}
-  .mp h2+pre,h3+pre {padding-left:0}
-  ol.man-decor,ol.man-decor li {margin:3px 0 10px 0;padding:0;float:left;width:33%;list-style-type:none;text-transform:uppercase;color:#999;letter-spacing:1px}
-  ol.man-decor {width:100%}
-  ol.man-decor li.tl {text-align:left}
-  ol.man-decor li.tc {text-align:center;letter-spacing:4px}
-  ol.man-decor li.tr {text-align:right;float:right}
-  </style>
-  <style type='text/css' media='all'>
-  /* style: 80c */
-  .mp {max-width:86ex}
-  ul {list-style: None; margin-left: 1em!important}
-  .man-navigation {left:101ex}
-  </style>
-</head>
-
-<body id='manpage'>
-
-
-<div class='mp'>
-<h1>go-httpbin(1)</h1>
-<p>A golang port of the venerable <a href="https://httpbin.org/">httpbin.org</a> HTTP request &amp; response testing service.</p>
-
-<h2 id="ENDPOINTS">ENDPOINTS</h2>
-
-<ul>
-<li><a href="/" data-bare-link="true"><code>/</code></a> This page.</li>
-<li><a href="ip" data-bare-link="true"><code>/ip</code></a> Returns Origin IP.</li>
-<li><a href="user-agent" data-bare-link="true"><code>/user-agent</code></a> Returns user-agent.</li>
-<li><a href="headers" data-bare-link="true"><code>/headers</code></a> Returns header dict.</li>
-<li><a href="get" data-bare-link="true"><code>/get</code></a> Returns GET data.</li>
-<li><code>post</code> Returns POST data.</li>
-<li><code>patch</code> Returns PATCH data.</li>
-<li><code>put</code> Returns PUT data.</li>
-<li><code>delete</code> Returns DELETE data</li>
-<li><a href="encoding/utf8"><code>/encoding/utf8</code></a> Returns page containing UTF-8 data.</li>
-<li><a href="gzip" data-bare-link="true"><code>/gzip</code></a> Returns gzip-encoded data.</li>
-<li><a href="deflate" data-bare-link="true"><code>/deflate</code></a> Returns deflate-encoded data.</li>
-<li><del><a href="brotli" data-bare-link="true"><code>/brotli</code></a> Returns brotli-encoded data.</del> <i>Not implemented!</i></li>
-<li><a href="status/418"><code>/status/:code</code></a> Returns given HTTP Status code.</li>
-<li><a href="response-headers?Server=httpbin&amp;Content-Type=text%2Fplain%3B+charset%3DUTF-8"><code>/response-headers?key=val</code></a> Returns given response headers.</li>
-<li><a href="redirect/6"><code>/redirect/:n</code></a> 302 Redirects <em>n</em> times.</li>
-<li><a href="redirect-to?url=http%3A%2F%2Fexample.com%2F"><code>/redirect-to?url=foo</code></a> 302 Redirects to the <em>foo</em> URL.</li>
-<li><a href="redirect-to?status_code=307&amp;url=http%3A%2F%2Fexample.com%2F"><code>/redirect-to?url=foo&status_code=307</code></a> 307 Redirects to the <em>foo</em> URL.</li>
-<li><a href="relative-redirect/6"><code>/relative-redirect/:n</code></a> 302 Relative redirects <em>n</em> times.</li>
-<li><a href="absolute-redirect/6"><code>/absolute-redirect/:n</code></a> 302 Absolute redirects <em>n</em> times.</li>
-<li><a href="cookies" data-bare-link="true"><code>/cookies</code></a> Returns cookie data.</li>
-<li><a href="cookies/set?k1=v1&amp;k2=v2"><code>/cookies/set?name=value</code></a> Sets one or more simple cookies.</li>
-<li><a href="cookies/delete?k1=&amp;k2="><code>/cookies/delete?name</code></a> Deletes one or more simple cookies.</li>
-<li><a href="basic-auth/user/passwd"><code>/basic-auth/:user/:passwd</code></a> Challenges HTTPBasic Auth.</li>
-<li><a href="hidden-basic-auth/user/passwd"><code>/hidden-basic-auth/:user/:passwd</code></a> 404'd BasicAuth.</li>
-<li><a href="digest-auth/auth/user/passwd/MD5"><code>/digest-auth/:qop/:user/:passwd/:algorithm</code></a> Challenges HTTP Digest Auth.</li>
-<li><a href="digest-auth/auth/user/passwd/MD5"><code>/digest-auth/:qop/:user/:passwd</code></a> Challenges HTTP Digest Auth.</li>
-<li><a href="stream/20"><code>/stream/:n</code></a> Streams <em>min(n, 100)</em> lines.</li>
-<li><a href="delay/3"><code>/delay/:n</code></a> Delays responding for <em>min(n, 10)</em> seconds.</li>
-<li><a href="drip?code=200&amp;numbytes=5&amp;duration=5"><code>/drip?numbytes=n&amp;duration=s&amp;delay=s&amp;code=code</code></a> Drips data over a duration after an optional initial delay, then (optionally) returns with the given status code.</li>
-<li><a href="range/1024"><code>/range/1024?duration=s&amp;chunk_size=code</code></a> Streams <em>n</em> bytes, and allows specifying a <em>Range</em> header to select a subset of the data. Accepts a <em>chunk_size</em> and request <em>duration</em> parameter.</li>
-<li><a href="html" data-bare-link="true"><code>/html</code></a> Renders an HTML Page.</li>
-<li><a href="robots.txt" data-bare-link="true"><code>/robots.txt</code></a> Returns some robots.txt rules.</li>
-<li><a href="deny" data-bare-link="true"><code>/deny</code></a> Denied by robots.txt file.</li>
-<li><a href="cache" data-bare-link="true"><code>/cache</code></a> Returns 200 unless an If-Modified-Since or If-None-Match header is provided, when it returns a 304.</li>
-<li><a href="etag/etag"><code>/etag/:etag</code></a> Assumes the resource has the given etag and responds to If-None-Match header with a 200 or 304 and If-Match with a 200 or 412 as appropriate.</li>
-<li><a href="cache/60"><code>/cache/:n</code></a> Sets a Cache-Control header for <em>n</em> seconds.</li>
-<li><a href="bytes/1024"><code>/bytes/:n</code></a> Generates <em>n</em> random bytes of binary data, accepts optional <em>seed</em> integer parameter.</li>
-<li><a href="stream-bytes/1024"><code>/stream-bytes/:n</code></a> Streams <em>n</em> random bytes of binary data, accepts optional <em>seed</em> and <em>chunk_size</em> integer parameters.</li>
-<li><a href="links/10"><code>/links/:n</code></a> Returns page containing <em>n</em> HTML links.</li>
-<li><a href="image"><code>/image</code></a> Returns page containing an image based on sent Accept header.</li>
-<li><a href="image/png"><code>/image/png</code></a> Returns a PNG image.</li>
-<li><a href="image/jpeg"><code>/image/jpeg</code></a> Returns a JPEG image.</li>
-<li><a href="image/webp"><code>/image/webp</code></a> Returns a WEBP image.</li>
-<li><a href="image/svg"><code>/image/svg</code></a> Returns a SVG image.</li>
-<li><a href="forms/post" data-bare-link="true"><code>/forms/post</code></a> HTML form that submits to <em>/post</em></li>
-<li><a href="xml" data-bare-link="true"><code>/xml</code></a> Returns some XML</li>
-</ul>
-
-<h2 id="DESCRIPTION">DESCRIPTION</h2>
-
-<p>Testing an HTTP Library can become difficult sometimes. <a href="http://requestb.in">RequestBin</a> is fantastic for testing POST requests, but doesn't let you control the response. This exists to cover all kinds of HTTP scenarios. Additional endpoints are being considered.</p>
-
-<p>All endpoint responses are JSON-encoded.</p>
-
-<h2 id="EXAMPLES">EXAMPLES</h2>
-
-<h3 id="-curl-http-httpbin-org-ip">$ curl http://httpbin.org/ip</h3>
-
-<pre><code>{"origin": "24.127.96.129"}
-</code></pre>
-
-<h3 id="-curl-http-httpbin-org-user-agent">$ curl http://httpbin.org/user-agent</h3>
-
-<pre><code>{"user-agent": "curl/7.19.7 (universal-apple-darwin10.0) libcurl/7.19.7 OpenSSL/0.9.8l zlib/1.2.3"}
-</code></pre>
-
-<h3 id="-curl-http-httpbin-org-get">$ curl http://httpbin.org/get</h3>
-
-<pre><code>{
-   "args": {},
-   "headers": {
-      "Accept": "*/*",
-      "Connection": "close",
-      "Content-Length": "",
-      "Content-Type": "",
-      "Host": "httpbin.org",
-      "User-Agent": "curl/7.19.7 (universal-apple-darwin10.0) libcurl/7.19.7 OpenSSL/0.9.8l zlib/1.2.3"
-   },
-   "origin": "24.127.96.129",
-   "url": "http://httpbin.org/get"
-}
-</code></pre>
-
-<h3 id="-curl-I-http-httpbin-org-status-418">$ curl -I http://httpbin.org/status/418</h3>
-
-<pre><code>HTTP/1.1 418 I'M A TEAPOT
-Server: nginx/0.7.67
-Date: Mon, 13 Jun 2011 04:25:38 GMT
-Connection: close
-x-more-info: http://tools.ietf.org/html/rfc2324
-Content-Length: 135
-</code></pre>
-
-<h3 id="-curl-https-httpbin-org-get-show_env-1">$ curl https://httpbin.org/get?show_env=1</h3>
-
-<pre><code>{
-  "headers": {
-    "Content-Length": "",
-    "Accept-Language": "en-US,en;q=0.8",
-    "Accept-Encoding": "gzip,deflate,sdch",
-    "X-Forwarded-Port": "443",
-    "X-Forwarded-For": "109.60.101.240",
-    "Host": "httpbin.org",
-    "Accept": "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
-    "User-Agent": "Mozilla/5.0 (X11; Linux i686) AppleWebKit/535.11 (KHTML, like Gecko) Chrome/17.0.963.83 Safari/535.11",
-    "X-Request-Start": "1350053933441",
-    "Accept-Charset": "ISO-8859-1,utf-8;q=0.7,*;q=0.3",
-    "Connection": "keep-alive",
-    "X-Forwarded-Proto": "https",
-    "Cookie": "_gauges_unique_day=1; _gauges_unique_month=1; _gauges_unique_year=1; _gauges_unique=1; _gauges_unique_hour=1",
-    "Content-Type": ""
-  },
-  "args": {
-    "show_env": "1"
-  },
-  "origin": "109.60.101.240",
-  "url": "http://httpbin.org/get?show_env=1"
-}
-</code></pre>
-
-
-<h2 id="AUTHOR">AUTHOR</h2>
-
-<p>Ported to Go by <a href="https://github.com/mccutchen">Will McCutchen</a>.</p>
-<p>From <a href="https://httpbin.org/">the original</a> <a href="http://kennethreitz.com/">Kenneth Reitz</a> project.</p>
-
-<h2 id="SEE-ALSO">SEE ALSO</h2>
-
-<p><a href="https://httpbin.org/">httpbin.org</a> &mdash; the original httpbin</p>
-
-</div>
-
-<a href="https://github.com/mccutchen/go-httpbin"><img style="position: absolute; top: 0; right: 0; border: 0;" src="https://camo.githubusercontent.com/38ef81f8aca64bb9a64448d0d70f1308ef5341ab/68747470733a2f2f73332e616d617a6f6e6177732e636f6d2f6769746875622f726962626f6e732f666f726b6d655f72696768745f6461726b626c75655f3132313632312e706e67" alt="Fork me on GitHub" data-canonical-src="https://s3.amazonaws.com/github/ribbons/forkme_right_darkblue_121621.png"></a>
-
-</body>
-</html>
-`)
+// methodsHandler reports which methods the router supports on the path given
+// in the 'path' route variable, evaluated with the request's query string.
+func methodsHandler(router *routeMux) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		u := *r.URL
+		u.Path = "/" + routeVars(r)["path"]
+		u.RawPath = ""
+
+		v := methodsResponse{
+			Path:    u.Path,
+			Methods: allowedMethods(router, &u),
+		}
+		if err := writeJSON(w, v); err != nil {
+			writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+		}
+	}
+}
+
+// notFoundHandler responds with 405 and the list of supported methods when
+// strict methods are enabled and the path is served for other methods, and
+// with 404 otherwise.
+func notFoundHandler(router *routeMux) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !instance(r).strictMethods {
+			http.NotFound(w, r)
+			return
+		}
+		methods := allowedMethods(router, r.URL)
+		if len(methods) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Allow", strings.Join(methods, ", "))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		v := methodNotAllowedResponse{
+			Error:   errObj{fmt.Sprintf("method %s not allowed", r.Method)},
+			Methods: methods,
+		}
+		_ = writeJSON(w, v) // status already written, nothing else to do
+	}
 }
 
 // IPHandler returns Origin IP.
 func IPHandler(w http.ResponseWriter, r *http.Request) {
 	h, _, _ := net.SplitHostPort(r.RemoteAddr)
 	if err := writeJSON(w, ipResponse{h}); err != nil {
-		writeErrorJSON(w, errors.Wrap(err, "failed to write json")) // TODO handle this error in writeJSON(w,v)
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err)) // TODO handle this error in writeJSON(w,v)
 	}
 }
 
 // UserAgentHandler returns user agent.
 func UserAgentHandler(w http.ResponseWriter, r *http.Request) {
 	if err := writeJSON(w, userAgentResponse{r.UserAgent()}); err != nil {
-		writeErrorJSON(w, errors.Wrap(err, "failed to write json"))
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
 	}
 }
 
 // HeadersHandler returns user agent.
 func HeadersHandler(w http.ResponseWriter, r *http.Request) {
 	if err := writeJSON(w, headersResponse{getHeaders(r)}); err != nil {
-		writeErrorJSON(w, errors.Wrap(err, "failed to write json"))
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
 	}
 }
 
-// GetHandler returns user agent.
+// GetHandler returns user agent. It responds in CBOR if the client prefers
+// application/cbor to application/json.
 func GetHandler(w http.ResponseWriter, r *http.Request) {
 	h, _, _ := net.SplitHostPort(r.RemoteAddr)
 
 	v := getResponse{
 		headersResponse: headersResponse{getHeaders(r)},
 		ipResponse:      ipResponse{h},
+		queryResponse:   getQuery(r),
 		Args:            flattenValues(r.URL.Query()),
 	}
+	if r.URL.Query().Get("history") != "" {
+		if h, err := redirectHistory(w, r); err == nil {
+			v.RedirectHistory = &h
+		}
+	}
 
-	if err := writeJSON(w, v); err != nil {
-		writeErrorJSON(w, errors.Wrap(err, "failed to write json"))
+	if err := writeNegotiated(w, r, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
 	}
 }
 
-// PostHandler accept a post and echo its data back
+// PostHandler accept a post and echo its data back. It also serves /put,
+// /patch and /delete, which echo the same way. JSON and CBOR bodies are
+// decoded into the json field, form bodies into the form and files fields,
+// and the response is in CBOR if the client prefers application/cbor to
+// application/json. Bodies larger than the echo limit are reported in
+// body_digest instead of being echoed.
 func PostHandler(w http.ResponseWriter, r *http.Request) {
-	h, _, _ := net.SplitHostPort(r.RemoteAddr)
+	v, err := newPostResponse(r)
+	if err != nil {
+		writeErrorJSON(w, err)
+		return
+	}
+	if err := writeNegotiated(w, r, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
 
-	data, err := parseData(r)
+// AnythingHandler echoes a request of any method back like PostHandler,
+// along with its method.
+func AnythingHandler(w http.ResponseWriter, r *http.Request) {
+	v, err := newPostResponse(r)
 	if err != nil {
-		writeErrorJSON(w, errors.Wrap(err, "failed to read body"))
+		writeErrorJSON(w, err)
 		return
 	}
+	if err := writeNegotiated(w, r, anythingResponse{Method: r.Method, postResponse: v}); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
 
-	var jsonPayload interface{}
-	if strings.Contains(r.Header.Get("Content-Type"), "json") {
-		err := json.Unmarshal(data, &jsonPayload)
-		if err != nil {
-			writeErrorJSON(w, errors.Wrap(err, "failed to read body"))
-			return
-		}
+// newPostResponse reads the body of r to echo it back.
+func newPostResponse(r *http.Request) (postResponse, error) {
+	start := time.Now()
+	h, _, _ := net.SplitHostPort(r.RemoteAddr)
+
+	data, digest, err := readEcho(r)
+	bodyRead := time.Since(start)
+	if err != nil {
+		return postResponse{}, fmt.Errorf("failed to read body: %w", err)
 	}
 
 	v := postResponse{
 		headersResponse: headersResponse{getHeaders(r)},
 		ipResponse:      ipResponse{h},
+		queryResponse:   getQuery(r),
 		Args:            flattenValues(r.URL.Query()),
 		Data:            string(data),
-		JSON:            jsonPayload,
+		Files:           map[string]string{},
+		Form:            map[string]interface{}{},
+		BodyDigest:      digest,
+	}
+	mt, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch {
+	case digest != nil:
+		// too large to decode
+	case strings.Contains(mt, "json"):
+		if err := json.Unmarshal(data, &v.JSON); err != nil {
+			return postResponse{}, fmt.Errorf("failed to read body: %w", err)
+		}
+	case mt == cborContentType:
+		if v.JSON, err = decodeCBOR(data); err != nil {
+			return postResponse{}, fmt.Errorf("failed to read body: %w", err)
+		}
+	case mt == "application/x-www-form-urlencoded":
+		form, err := url.ParseQuery(string(data))
+		if err != nil {
+			return postResponse{}, fmt.Errorf("failed to parse form: %w", err)
+		}
+		v.Form = flattenValues(form)
+	case mt == "multipart/form-data":
+		form, err := multipart.NewReader(bytes.NewReader(data), params["boundary"]).ReadForm(int64(instance(r).echoBodyMax))
+		if err != nil {
+			return postResponse{}, fmt.Errorf("failed to parse form: %w", err)
+		}
+		defer form.RemoveAll()
+		v.Form = flattenValues(form.Value)
+		for name, fhs := range form.File {
+			f, err := fhs[0].Open()
+			if err != nil {
+				return postResponse{}, fmt.Errorf("failed to read form file: %w", err)
+			}
+			b, err := ioutil.ReadAll(f)
+			f.Close()
+			if err != nil {
+				return postResponse{}, fmt.Errorf("failed to read form file: %w", err)
+			}
+			v.Files[name] = string(b)
+		}
 	}
 
-	if err := writeJSON(w, v); err != nil {
-		writeErrorJSON(w, errors.Wrap(err, "failed to write json"))
+	size := int64(len(data))
+	if digest != nil {
+		size = digest.Size
 	}
+	v.Timings = newRequestTimings(r, start, bodyRead, size)
+	return v, nil
 }
 
 // RedirectHandler returns a 302 Found response if n=1 pointing
-// to /get, otherwise to /redirect/(n-1)
+// to /get, otherwise to /redirect/(n-1), carrying the signed history
+// of the hops taken so far if one was asked for
 func RedirectHandler(w http.ResponseWriter, r *http.Request) {
-	n := mux.Vars(r)["n"]
+	n := routeVars(r)["n"]
 	i, _ := strconv.Atoi(n) // shouldn't fail due to route pattern
 
 	var loc string
@@ -361,14 +256,23 @@ func RedirectHandler(w http.ResponseWriter, r *http.Request) {
 	} else {
 		loc = fmt.Sprintf("/redirect/%d", i-1)
 	}
-	w.Header().Set("Location", loc)
+	redirectWithHistory(w, r, prefixed(r, loc))
+}
+
+// InfiniteRedirectHandler returns a 302 Found response pointing to
+// /redirect/infinite/(n+1), so the chain never ends, with X-Redirect-Count
+// set to the number of hops taken so far
+func InfiniteRedirectHandler(w http.ResponseWriter, r *http.Request) {
+	i, _ := strconv.Atoi(routeVars(r)["n"]) // 0 for /redirect/infinite
+	w.Header().Set("X-Redirect-Count", strconv.Itoa(i+1))
+	w.Header().Set("Location", prefixed(r, fmt.Sprintf("/redirect/infinite/%d", i+1)))
 	w.WriteHeader(http.StatusFound)
 }
 
 // AbsoluteRedirectHandler returns a 302 Found response if n=1 pointing
 // to /host/get, otherwise to /host/absolute-redirect/(n-1)
 func AbsoluteRedirectHandler(w http.ResponseWriter, r *http.Request) {
-	n := mux.Vars(r)["n"]
+	n := routeVars(r)["n"]
 	i, _ := strconv.Atoi(n) // shouldn't fail due to route pattern
 
 	var loc string
@@ -378,22 +282,26 @@ func AbsoluteRedirectHandler(w http.ResponseWriter, r *http.Request) {
 		loc = fmt.Sprintf("/absolute-redirect/%d", i-1)
 	}
 
-	w.Header().Set("Location", "http://"+r.Host+loc)
+	w.Header().Set("Location", "http://"+r.Host+prefixed(r, loc))
 	w.WriteHeader(http.StatusFound)
 }
 
 // RedirectToHandler returns a 302 Found response pointing to
 // the url query parameter
 func RedirectToHandler(w http.ResponseWriter, r *http.Request) {
-	u := mux.Vars(r)["url"]
+	u := routeVars(r)["url"]
 	w.Header().Set("Location", u)
 	w.WriteHeader(http.StatusFound)
 }
 
 // StatusHandler returns a proper response for provided status code
 func StatusHandler(w http.ResponseWriter, r *http.Request) {
-	code, _ := strconv.Atoi(mux.Vars(r)["code"])
+	code, _ := strconv.Atoi(routeVars(r)["code"])
+	writeStatus(w, r, code)
+}
 
+// writeStatus writes the response /status/:code returns for the code.
+func writeStatus(w http.ResponseWriter, r *http.Request, code int) {
 	statusWritten := false
 	switch code {
 	case http.StatusMovedPermanently,
@@ -401,7 +309,7 @@ func StatusHandler(w http.ResponseWriter, r *http.Request) {
 		http.StatusSeeOther,
 		http.StatusUseProxy,
 		http.StatusTemporaryRedirect:
-		w.Header().Set("Location", "/redirect/1")
+		w.Header().Set("Location", prefixed(r, "/redirect/1"))
 	case http.StatusUnauthorized: // 401
 		w.Header().Set("WWW-Authenticate", `Basic realm="Fake Realm"`)
 	case http.StatusPaymentRequired: // 402
@@ -437,7 +345,7 @@ func StatusHandler(w http.ResponseWriter, r *http.Request) {
 // BytesHandler returns n random bytes of binary data and accepts an
 // optional 'seed' integer query parameter.
 func BytesHandler(w http.ResponseWriter, r *http.Request) {
-	n, _ := strconv.Atoi(mux.Vars(r)["n"]) // shouldn't fail due to route pattern
+	n, _ := strconv.Atoi(routeVars(r)["n"]) // shouldn't fail due to route pattern
 
 	seedStr := r.URL.Query().Get("seed")
 	if seedStr == "" {
@@ -446,7 +354,7 @@ func BytesHandler(w http.ResponseWriter, r *http.Request) {
 
 	seed, _ := strconv.ParseInt(seedStr, 10, 64) // shouldn't fail due to route pattern
 	rnd := rand.New(rand.NewSource(seed))
-	buf := make([]byte, BinaryChunkSize)
+	buf := make([]byte, instance(r).binaryChunkSize)
 	for n > 0 {
 		rnd.Read(buf) // will never return err
 		if n >= len(buf) {
@@ -460,15 +368,59 @@ func BytesHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// streamBytesMax is the most bytes /stream-bytes/:n streams.
+const streamBytesMax = 100 << 20
+
+// StreamBytesHandler streams n random bytes, from the optional 'seed'
+// integer query parameter, in 'chunk_size' byte chunks (default 10240), each
+// flushed as it is written so the response is chunked without a
+// Content-Length.
+func StreamBytesHandler(w http.ResponseWriter, r *http.Request) {
+	n, _ := strconv.Atoi(routeVars(r)["n"]) // shouldn't fail due to route pattern
+	if n > streamBytesMax {
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("n must be at most %d", streamBytesMax))
+		return
+	}
+	chunkSize := 10 * 1024
+	if !intParam(w, r, "chunk_size", 1, 1<<20, &chunkSize) {
+		return
+	}
+	seed := time.Now().UnixNano()
+	if s := r.URL.Query().Get("seed"); s != "" {
+		var err error
+		if seed, err = strconv.ParseInt(s, 10, 64); err != nil {
+			writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("'seed' must be an integer"))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	rnd := rand.New(rand.NewSource(seed))
+	buf := make([]byte, chunkSize)
+	for n > 0 {
+		if n < len(buf) {
+			buf = buf[:n]
+		}
+		rnd.Read(buf) // will never return err
+		n -= len(buf)
+		if _, err := w.Write(buf); err != nil {
+			return
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+}
+
 // DelayHandler delays responding for min(n, 10) seconds and responds
 // with /get endpoint
 func DelayHandler(w http.ResponseWriter, r *http.Request) {
-	n, _ := strconv.ParseFloat(mux.Vars(r)["n"], 64) // shouldn't fail due to route pattern
+	n, _ := strconv.ParseFloat(routeVars(r)["n"], 64) // shouldn't fail due to route pattern
 
 	// allow only millisecond precision
 	duration := time.Millisecond * time.Duration(n*float64(time.Second/time.Millisecond))
-	if duration > DelayMax {
-		duration = DelayMax
+	if max := instance(r).delayMax; duration > max {
+		duration = max
 	}
 	time.Sleep(duration)
 	GetHandler(w, r)
@@ -476,11 +428,12 @@ func DelayHandler(w http.ResponseWriter, r *http.Request) {
 
 // StreamHandler writes a json object to a new line every second.
 func StreamHandler(w http.ResponseWriter, r *http.Request) {
-	n, _ := strconv.Atoi(mux.Vars(r)["n"]) // shouldn't fail due to route pattern
+	n, _ := strconv.Atoi(routeVars(r)["n"]) // shouldn't fail due to route pattern
 	nl := []byte{'\n'}
+	interval := instance(r).streamInterval
 	// allow only millisecond precision
 	for i := 0; i < n; i++ {
-		time.Sleep(StreamInterval)
+		time.Sleep(interval)
 		b, _ := json.Marshal(struct {
 			N    int       `json:"n"`
 			Time time.Time `json:"time"`
@@ -496,7 +449,7 @@ func StreamHandler(w http.ResponseWriter, r *http.Request) {
 // CookiesHandler returns the cookies provided in the request.
 func CookiesHandler(w http.ResponseWriter, r *http.Request) {
 	if err := writeJSON(w, cookiesResponse{getCookies(r.Cookies())}); err != nil {
-		writeErrorJSON(w, errors.Wrap(err, "failed to write json"))
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
 	}
 }
 
@@ -508,10 +461,10 @@ func SetCookiesHandler(w http.ResponseWriter, r *http.Request) {
 		http.SetCookie(w, &http.Cookie{
 			Name:  k,
 			Value: v,
-			Path:  "/",
+			Path:  prefixed(r, "/"),
 		})
 	}
-	w.Header().Set("Location", "/cookies")
+	w.Header().Set("Location", prefixed(r, "/cookies"))
 	w.WriteHeader(http.StatusFound)
 }
 
@@ -523,12 +476,12 @@ func DeleteCookiesHandler(w http.ResponseWriter, r *http.Request) {
 		http.SetCookie(w, &http.Cookie{
 			Name:    k,
 			Value:   "",
-			Path:    "/",
+			Path:    prefixed(r, "/"),
 			Expires: time.Unix(0, 0),
 			MaxAge:  0,
 		})
 	}
-	w.Header().Set("Location", "/cookies")
+	w.Header().Set("Location", prefixed(r, "/cookies"))
 	w.WriteHeader(http.StatusFound)
 }
 
@@ -539,8 +492,8 @@ func DripHandler(w http.ResponseWriter, r *http.Request) {
 
 	retCodeStr := r.URL.Query().Get("code")
 	delayStr := r.URL.Query().Get("delay")
-	durationSec, _ := strconv.ParseFloat(mux.Vars(r)["duration"], 32) // shouldn't fail due to route pattern
-	numBytes, _ := strconv.Atoi(mux.Vars(r)["numbytes"])              // shouldn't fail due to route pattern
+	durationSec, _ := strconv.ParseFloat(routeVars(r)["duration"], 32) // shouldn't fail due to route pattern
+	numBytes, _ := strconv.Atoi(routeVars(r)["numbytes"])              // shouldn't fail due to route pattern
 
 	if retCodeStr != "" { // optional: status code
 		var err error
@@ -573,7 +526,7 @@ func DripHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 // CacheHandler returns 200 with the response of /get unless an If-Modified-Since
-//or If-None-Match header is provided, when it returns a 304.
+// or If-None-Match header is provided, when it returns a 304.
 func CacheHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Header.Get("If-Modified-Since") != "" || r.Header.Get("If-None-Match") != "" {
 		w.WriteHeader(http.StatusNotModified)
@@ -585,11 +538,64 @@ func CacheHandler(w http.ResponseWriter, r *http.Request) {
 // SetCacheHandler sets a Cache-Control header for n seconds and returns with
 // the /get response.
 func SetCacheHandler(w http.ResponseWriter, r *http.Request) {
-	n, _ := strconv.Atoi(mux.Vars(r)["n"]) // shouldn't fail due to route pattern
+	n, _ := strconv.Atoi(routeVars(r)["n"]) // shouldn't fail due to route pattern
 	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", n))
 	GetHandler(w, r)
 }
 
+const (
+	leakyCacheETag         = `"httpbin-leaky-cache"`
+	leakyCacheLastModified = "Sat, 29 Oct 1994 19:43:31 GMT"
+)
+
+// LeakyCacheHandler behaves like /cache but, when asked to via the 'body' and
+// 'forbidden_headers' query parameters, responds to conditional requests with
+// a non-conformant 304 that carries a body and/or representation headers
+// RFC 7232 says a 304 must not have. Without those parameters the 304 is
+// conformant.
+func LeakyCacheHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("If-Modified-Since") == "" && r.Header.Get("If-None-Match") == "" {
+		w.Header().Set("ETag", leakyCacheETag)
+		w.Header().Set("Last-Modified", leakyCacheLastModified)
+		GetHandler(w, r)
+		return
+	}
+
+	body := r.URL.Query().Get("body")
+	forbidden, _ := strconv.ParseBool(r.URL.Query().Get("forbidden_headers"))
+	if body == "" && !forbidden {
+		w.Header().Set("ETag", leakyCacheETag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	// net/http refuses to write a body for 304 responses, so the response
+	// has to be written on the raw connection.
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		writeErrorJSON(w, errors.New("connection does not support hijacking"))
+		return
+	}
+	conn, bw, err := hj.Hijack()
+	if err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to hijack connection: %w", err))
+		return
+	}
+	defer conn.Close()
+
+	fmt.Fprint(bw, "HTTP/1.1 304 Not Modified\r\n")
+	fmt.Fprintf(bw, "Date: %s\r\n", time.Now().UTC().Format(http.TimeFormat))
+	fmt.Fprintf(bw, "ETag: %s\r\n", leakyCacheETag)
+	if forbidden {
+		fmt.Fprint(bw, "Content-Type: text/plain; charset=utf-8\r\n")
+		fmt.Fprint(bw, "Content-Language: en\r\n")
+		fmt.Fprintf(bw, "Content-Length: %d\r\n", len(body))
+	}
+	fmt.Fprint(bw, "Connection: close\r\n\r\n")
+	fmt.Fprint(bw, body)
+	bw.Flush()
+}
+
 // GZIPHandler returns a GZIP-encoded response
 func GZIPHandler(w http.ResponseWriter, r *http.Request) {
 	h, _, _ := net.SplitHostPort(r.RemoteAddr)
@@ -605,7 +611,69 @@ func GZIPHandler(w http.ResponseWriter, r *http.Request) {
 	ww := gzip.NewWriter(w)
 	defer ww.Close() // flush
 	if err := writeJSON(ww, v); err != nil {
-		writeErrorJSON(w, errors.Wrap(err, "failed to write json"))
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
+
+// GZIPStreamHandler streams 'n' (default 10) lines of NDJSON gzip-encoded,
+// flushing the compressor and the connection every 'every' lines (default
+// 1), 'interval' seconds apart (default: the stream interval), so each batch of
+// lines can be decoded as soon as it arrives.
+func GZIPStreamHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	n, every, interval := 10, 1, instance(r).streamInterval
+	if s := q.Get("n"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 {
+			writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("failed to parse 'n'"))
+			return
+		}
+		n = v
+	}
+	if s := q.Get("every"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 1 {
+			writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("failed to parse 'every'"))
+			return
+		}
+		every = v
+	}
+	if s := q.Get("interval"); s != "" {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || f < 0 {
+			writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("failed to parse 'interval'"))
+			return
+		}
+		interval = time.Duration(f * float64(time.Second))
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Encoding", "gzip")
+	ww := gzip.NewWriter(w)
+	defer ww.Close()
+	for i := 0; i < n; i++ {
+		b, _ := json.Marshal(struct {
+			N    int       `json:"n"`
+			Time time.Time `json:"time"`
+		}{i, time.Now().UTC()})
+		ww.Write(append(b, '\n'))
+		if (i+1)%every != 0 && i+1 != n {
+			continue
+		}
+		ww.Flush() // emit a sync flush point ending the batch
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		if i+1 == n {
+			break
+		}
+		t := time.NewTimer(interval)
+		select {
+		case <-t.C:
+		case <-r.Context().Done():
+			t.Stop()
+			return
+		}
 	}
 }
 
@@ -623,7 +691,7 @@ func DeflateHandler(w http.ResponseWriter, r *http.Request) {
 	ww, _ := flate.NewWriter(w, flate.BestCompression)
 	defer ww.Close() // flush
 	if err := writeJSON(ww, v); err != nil {
-		writeErrorJSON(w, errors.Wrap(err, "failed to write json"))
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
 	}
 }
 
@@ -662,8 +730,8 @@ func HiddenBasicAuthHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func basicAuthHandler(w http.ResponseWriter, r *http.Request, status int) {
-	user := mux.Vars(r)["u"]
-	pass := mux.Vars(r)["p"]
+	user := routeVars(r)["u"]
+	pass := routeVars(r)["p"]
 
 	inUser, inPass, ok := r.BasicAuth()
 	if !ok || inUser != user || inPass != pass {
@@ -674,162 +742,71 @@ func basicAuthHandler(w http.ResponseWriter, r *http.Request, status int) {
 			User:          user,
 		}
 		if err := writeJSON(w, v); err != nil {
-			writeErrorJSON(w, errors.Wrap(err, "failed to write json"))
+			writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
 		}
 	}
 }
 
-// HTMLHandler returns some HTML response.
-func HTMLHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/html")
-	fmt.Fprint(w, htmlData)
-}
-
-// XMLHandler returns some XML response.
-func XMLHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/xml")
-	fmt.Fprint(w, xmlData)
-}
-
-type circle struct {
-	X, Y, R float64
-}
-
-func (c *circle) Brightness(x, y float64) uint8 {
-	var dx, dy float64 = c.X - x, c.Y - y
-	d := math.Sqrt(dx*dx+dy*dy) / c.R
-	if d > 1 {
-		return 0
+// BearerHandler challenges with 'WWW-Authenticate: Bearer' unless the
+// request carries a bearer token, which it echoes back. Any token is
+// accepted.
+func BearerHandler(w http.ResponseWriter, r *http.Request) {
+	auth := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if len(auth) <= len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) ||
+		strings.TrimSpace(auth[len(prefix):]) == "" {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	v := bearerResponse{
+		Authenticated: true,
+		Token:         strings.TrimSpace(auth[len(prefix):]),
+	}
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
 	}
-	return 255
 }
 
-// GIFHandler returns an animated GIF image.
-// Source: http://tech.nitoyon.com/en/blog/2016/01/07/go-animated-gif-gen/
-func GIFHandler(rw http.ResponseWriter, r *http.Request) {
-	var w, h int = 240, 240
-	var hw, hh float64 = float64(w / 2), float64(h / 2)
-	circles := []*circle{{}, {}, {}}
+// ProxyAuthHandler challenges with given username and password the way an
+// authenticating proxy does: 407 Proxy Authentication Required with a
+// Proxy-Authenticate header until a matching Proxy-Authorization is sent.
+// With the 'echo' query parameter set, authenticated requests get the /get
+// response instead, as if the proxy had forwarded them.
+func ProxyAuthHandler(w http.ResponseWriter, r *http.Request) {
+	user := routeVars(r)["u"]
+	pass := routeVars(r)["p"]
 
-	var palette = []color.Color{
-		color.RGBA{0x00, 0x00, 0x00, 0xff},
-		color.RGBA{0x00, 0x00, 0xff, 0xff},
-		color.RGBA{0x00, 0xff, 0x00, 0xff},
-		color.RGBA{0x00, 0xff, 0xff, 0xff},
-		color.RGBA{0xff, 0x00, 0x00, 0xff},
-		color.RGBA{0xff, 0x00, 0xff, 0xff},
-		color.RGBA{0xff, 0xff, 0x00, 0xff},
-		color.RGBA{0xff, 0xff, 0xff, 0xff},
+	inUser, inPass, ok := parseBasicAuth(r.Header.Get("Proxy-Authorization"))
+	if !ok || inUser != user || inPass != pass {
+		w.Header().Set("Proxy-Authenticate", `Basic realm="Fake Proxy"`)
+		w.WriteHeader(http.StatusProxyAuthRequired)
+		return
 	}
 
-	var images []*image.Paletted
-	var delays []int
-	steps := 20
-	for step := 0; step < steps; step++ {
-		img := image.NewPaletted(image.Rect(0, 0, w, h), palette)
-		images = append(images, img)
-		delays = append(delays, 0)
-
-		θ := 2.0 * math.Pi / float64(steps) * float64(step)
-		for i, circle := range circles {
-			θ0 := 2 * math.Pi / 3 * float64(i)
-			circle.X = hw - 40*math.Sin(θ0) - 20*math.Sin(θ0+θ)
-			circle.Y = hh - 40*math.Cos(θ0) - 20*math.Cos(θ0+θ)
-			circle.R = 50
-		}
-
-		for x := 0; x < w; x++ {
-			for y := 0; y < h; y++ {
-				img.Set(x, y, color.RGBA{
-					circles[0].Brightness(float64(x), float64(y)),
-					circles[1].Brightness(float64(x), float64(y)),
-					circles[2].Brightness(float64(x), float64(y)),
-					255,
-				})
-			}
-		}
+	if echo, _ := strconv.ParseBool(r.URL.Query().Get("echo")); echo {
+		GetHandler(w, r)
+		return
+	}
+	v := basicAuthResponse{
+		Authenticated: true,
+		User:          user,
+	}
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
 	}
-
-	gif.EncodeAll(rw, &gif.GIF{
-		Image: images,
-		Delay: delays,
-	})
-}
-
-// JPEGHandler returns a JPEG image.
-func JPEGHandler(w http.ResponseWriter, r *http.Request) {
-	jpeg.Encode(w, getImg(), nil)
 }
 
-// PNGHandler returns a PNG image.
-func PNGHandler(w http.ResponseWriter, r *http.Request) {
-	png.Encode(w, getImg())
+// HTMLHandler returns some HTML response.
+func HTMLHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+	fmt.Fprint(w, htmlData)
 }
 
-func getImg() image.Image {
-	const n = 512
-	img := image.NewRGBA(image.Rect(0, 0, n, n))
-	abs := func(n int) int {
-		if n < 0 {
-			return -n
-		}
-		return n
-	}
-	sq := func(i int) int { return i * i }
-
-	for x := 0; x <= n; x++ {
-		for y := 0; y <= n; y++ {
-			if x == n/2 && y == n/2 {
-				continue
-			}
-			d := math.Sqrt(float64(sq(abs(x-n/2)) + sq(abs(y-n/2))))
-			if d > n/2 {
-				continue
-			}
-
-			sin := float64(y-n/2) / d
-			deg := math.Asin(sin)/math.Pi*359.0 + 180
-			sec := int(deg) / 60
-
-			var fix, mod *uint8
-			var inc bool
-
-			c := color.RGBA{0, 0, 0, 0xFF}
-			switch sec {
-			case 0:
-				fix, mod = &c.R, &c.G
-				inc = true
-			case 1:
-				fix, mod = &c.G, &c.R
-				inc = false
-			case 2:
-				fix, mod = &c.G, &c.B
-				inc = true
-			case 3:
-				fix, mod = &c.B, &c.G
-				inc = false
-			case 4:
-				fix, mod = &c.B, &c.R
-				inc = true
-			case 5:
-				fix, mod = &c.R, &c.B
-				inc = false
-			default:
-				panic(fmt.Sprintf("deg=%f sec=%d", deg, sec))
-			}
-
-			v := uint8((int(deg) % 60) * 255.0 / 60.0)
-			*fix = 255
-			if inc {
-				*mod = v
-			} else {
-				*mod = 255 - v
-			}
-			img.Set(x, y, c)
-
-		}
-	}
-	return img
+// XMLHandler returns some XML response.
+func XMLHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/xml")
+	fmt.Fprint(w, xmlData)
 }
 
 func parseData(r *http.Request) ([]byte, error) {
@@ -0,0 +1,58 @@
+package httpbin
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"time"
+)
+
+// hashAlgorithms are the hashes /hash/:alg computes. xxhash, for XXH64, is
+// added by hash_xxhash.go unless built with the httpbin_noxxhash tag.
+var hashAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+	"crc32":  func() hash.Hash { return crc32.NewIEEE() },
+}
+
+// HashHandler streams the request body through the hash named in the path
+// (md5, sha1, sha256, sha512, crc32 for IEEE CRC-32 or xxhash for XXH64)
+// and returns its digest, the body's length and how fast it was read, so
+// clients can check their own checksums against it.
+func HashHandler(w http.ResponseWriter, r *http.Request) {
+	alg := routeVars(r)["alg"]
+	h := hashAlgorithms[alg]() // known due to route pattern
+
+	start := time.Now()
+	n, err := io.Copy(h, r.Body)
+	elapsed := time.Since(start)
+	if err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to read body: %w", err))
+		return
+	}
+
+	sum := h.Sum(nil)
+	v := hashResponse{
+		Algorithm:    alg,
+		Digest:       hex.EncodeToString(sum),
+		DigestBase64: base64.StdEncoding.EncodeToString(sum),
+		Length:       n,
+		ElapsedMS:    milliseconds(elapsed),
+	}
+	if elapsed > 0 {
+		v.BytesPerSecond = float64(n) / elapsed.Seconds()
+	}
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
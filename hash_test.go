@@ -0,0 +1,43 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHash(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for _, tt := range []struct {
+		alg, body, digest string
+	}{
+		{"md5", "abc", "900150983cd24fb0d6963f7d28e17f72"},
+		{"sha1", "abc", "a9993e364706816aba3e25717850c26c9cd0d89d"},
+		{"sha256", "abc", "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"},
+		{"crc32", "123456789", "cbf43926"},
+	} {
+		resp, err := http.Post(srv.URL+"/hash/"+tt.alg, "text/plain", strings.NewReader(tt.body))
+		require.Nil(t, err)
+		var v struct {
+			Algorithm string `json:"algorithm"`
+			Digest    string `json:"digest"`
+			Length    int64  `json:"length"`
+		}
+		require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+		resp.Body.Close()
+		require.EqualValues(t, http.StatusOK, resp.StatusCode, tt.alg)
+		require.EqualValues(t, tt.alg, v.Algorithm)
+		require.EqualValues(t, tt.digest, v.Digest, tt.alg)
+		require.EqualValues(t, len(tt.body), v.Length)
+	}
+
+	resp, err := http.Post(srv.URL+"/hash/whirlpool", "text/plain", strings.NewReader("abc"))
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,18 @@
+//go:build !httpbin_noxxhash
+// +build !httpbin_noxxhash
+
+package httpbin
+
+import (
+	"hash"
+	"net/http"
+
+	"github.com/cespare/xxhash"
+)
+
+func init() {
+	hashAlgorithms["xxhash"] = func() hash.Hash { return xxhash.New() }
+	featureRoutes = append(featureRoutes,
+		route{name: "hash-xxhash", path: `/hash/{alg:xxhash}`, methods: []string{http.MethodPost, http.MethodPut}, description: "Streams the request body through the XXH64 hash and returns its digest, length and throughput.", handler: http.HandlerFunc(HashHandler)},
+	)
+}
@@ -0,0 +1,30 @@
+//go:build !httpbin_noxxhash
+// +build !httpbin_noxxhash
+
+package httpbin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHash_xxhash(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/hash/xxhash", "text/plain", strings.NewReader("abc"))
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	var v struct {
+		Algorithm string `json:"algorithm"`
+		Digest    string `json:"digest"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	require.EqualValues(t, "xxhash", v.Algorithm)
+	require.EqualValues(t, "44bc2cf5ad770999", v.Digest)
+}
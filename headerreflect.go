@@ -0,0 +1,125 @@
+package httpbin
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// reflectProtected are the headers /headers/reflect does not let the query
+// set, as they frame the response.
+var reflectProtected = map[string]bool{
+	"Connection":        true,
+	"Content-Length":    true,
+	"Content-Type":      true,
+	"Trailer":           true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+}
+
+// HeadersReflectHandler sets each query parameter as a response header,
+// after sanitizing it the way RFC 9110 allows, and reports how: names that
+// are not tokens are not sent, CR, LF and NUL in values are replaced with a
+// space, other control characters but HTAB are removed, and leading and
+// trailing whitespace is trimmed. Each change is listed with the offset of
+// the character in the raw value, so injection defenses can be compared
+// against it.
+func HeadersReflectHandler(w http.ResponseWriter, r *http.Request) {
+	v := headersReflectResponse{Headers: []reflectedHeader{}}
+	for _, kv := range strings.Split(r.URL.RawQuery, "&") {
+		if kv == "" {
+			continue
+		}
+		name, value := kv, ""
+		if i := strings.IndexByte(kv, '='); i >= 0 {
+			name, value = kv[:i], kv[i+1:]
+		}
+		var err error
+		if name, err = url.QueryUnescape(name); err != nil {
+			writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("failed to unescape %q: %w", kv, err))
+			return
+		}
+		if value, err = url.QueryUnescape(value); err != nil {
+			writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("failed to unescape %q: %w", kv, err))
+			return
+		}
+
+		h := reflectedHeader{Name: name, Value: value, Changes: []headerChange{}}
+		h.Sanitized, h.Changes = sanitizeHeaderValue(value)
+		h.NameValid = isToken(name)
+		switch {
+		case !h.NameValid:
+			h.Dropped = "name is not a token"
+		case reflectProtected[http.CanonicalHeaderKey(name)]:
+			h.Dropped = "header frames the response"
+		default:
+			w.Header().Add(name, h.Sanitized)
+			h.Sent = true
+		}
+		v.Headers = append(v.Headers, h)
+	}
+
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
+
+// sanitizeHeaderValue returns the value with CR, LF and NUL replaced with a
+// space, other control characters but HTAB removed and surrounding
+// whitespace trimmed, and the changes made.
+func sanitizeHeaderValue(value string) (string, []headerChange) {
+	changes := []headerChange{}
+	var b []byte
+	var src []int // offset in value of each byte of b
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == '\r' || c == '\n' || c == 0:
+			b, src = append(b, ' '), append(src, i)
+			changes = append(changes, headerChange{Offset: i, Char: quoteByte(c), Action: "replaced with SP"})
+		case c < ' ' && c != '\t' || c == 0x7f:
+			changes = append(changes, headerChange{Offset: i, Char: quoteByte(c), Action: "removed"})
+		default:
+			b, src = append(b, c), append(src, i)
+		}
+	}
+	start, end := 0, len(b)
+	for start < end && (b[start] == ' ' || b[start] == '\t') {
+		start++
+	}
+	for end > start && (b[end-1] == ' ' || b[end-1] == '\t') {
+		end--
+	}
+	if start > 0 {
+		changes = append(changes, headerChange{Offset: src[0], Char: quoteByte(b[0]), Action: "leading whitespace trimmed"})
+	}
+	if end < len(b) {
+		changes = append(changes, headerChange{Offset: src[len(b)-1], Char: quoteByte(b[len(b)-1]), Action: "trailing whitespace trimmed"})
+	}
+	return string(b[start:end]), changes
+}
+
+// quoteByte writes c as a Go character literal without the quotes, e.g. \r.
+func quoteByte(c byte) string {
+	q := strconv.QuoteRune(rune(c))
+	return q[1 : len(q)-1]
+}
+
+// isToken reports whether s is an RFC 9110 token, as header names must be.
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
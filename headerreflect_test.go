@@ -0,0 +1,45 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeadersReflect(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/headers/reflect?X-Test=a%0D%0ASet-Cookie:%20injected=1&X-Bell=%07ding%20&Bad%20Name=x&Content-Length=1")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "a  Set-Cookie: injected=1", resp.Header.Get("X-Test"))
+	require.Empty(t, resp.Header.Get("Set-Cookie"))
+	require.Equal(t, "ding", resp.Header.Get("X-Bell"))
+
+	type change struct {
+		Offset int    `json:"offset"`
+		Char   string `json:"char"`
+		Action string `json:"action"`
+	}
+	var v struct {
+		Headers []struct {
+			Name      string   `json:"name"`
+			Sanitized string   `json:"sanitized"`
+			NameValid bool     `json:"name_valid"`
+			Sent      bool     `json:"sent"`
+			Changes   []change `json:"changes"`
+		} `json:"headers"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	require.Len(t, v.Headers, 4)
+	require.Equal(t, []change{{1, `\r`, "replaced with SP"}, {2, `\n`, "replaced with SP"}}, v.Headers[0].Changes)
+	require.Equal(t, []change{{0, `\a`, "removed"}, {5, " ", "trailing whitespace trimmed"}}, v.Headers[1].Changes)
+	require.False(t, v.Headers[2].NameValid)
+	require.False(t, v.Headers[2].Sent)
+	require.True(t, v.Headers[3].NameValid)
+	require.False(t, v.Headers[3].Sent)
+}
@@ -0,0 +1,93 @@
+package httpbin
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// HTTP2Handler reports the HTTP/2 details of the request that net/http makes
+// available: the pseudo-header fields, the RFC 9218 Priority header, the
+// trailers sent after the body, the size of the header list as defined for
+// SETTINGS_MAX_HEADER_LIST_SIZE (what the HPACK dynamic table accounts for,
+// before compression) and whether the server can push on the connection.
+// net/http does not expose stream IDs or the deprecated RFC 7540 stream
+// priorities, so they are not reported.
+func HTTP2Handler(w http.ResponseWriter, r *http.Request) {
+	// trailers are only known once the body has been read
+	if _, err := io.Copy(ioutil.Discard, r.Body); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to read body: %w", err))
+		return
+	}
+
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	pseudo := map[string]string{
+		":method":    r.Method,
+		":scheme":    scheme,
+		":authority": r.Host,
+		":path":      r.URL.RequestURI(),
+	}
+	size := 0
+	for k, v := range pseudo {
+		size += len(k) + len(v) + 32
+	}
+	for k, vs := range r.Header {
+		for _, v := range vs {
+			size += len(k) + len(v) + 32
+		}
+	}
+
+	v := http2Response{
+		Protocol:       r.Proto,
+		HTTP2:          r.ProtoMajor == 2,
+		PseudoHeaders:  pseudo,
+		HeaderListSize: size,
+		Priority:       parsePriority(r.Header.Get("Priority")),
+		UsedTrailers:   len(r.Trailer) > 0,
+		Trailers:       make(map[string]string, len(r.Trailer)),
+	}
+	if !v.HTTP2 {
+		v.PseudoHeaders = nil
+	}
+	for k, vs := range r.Trailer {
+		v.Trailers[k] = strings.Join(vs, ", ")
+	}
+	if _, ok := w.(http.Pusher); ok && v.HTTP2 {
+		v.PushSupported = true
+	}
+
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
+
+// parsePriority parses the urgency and incremental parameters of an RFC 9218
+// Priority header, returning nil if there is none.
+func parsePriority(s string) *http2Priority {
+	if s == "" {
+		return nil
+	}
+	p := &http2Priority{Urgency: 3, Raw: s}
+	for _, param := range strings.Split(s, ",") {
+		param = strings.TrimSpace(param)
+		k, val := param, ""
+		if i := strings.IndexByte(param, '='); i >= 0 {
+			k, val = param[:i], param[i+1:]
+		}
+		switch k {
+		case "u":
+			if u, err := strconv.Atoi(val); err == nil && u >= 0 && u <= 7 {
+				p.Urgency = u
+			}
+		case "i":
+			p.Incremental = val == "" || val == "?1"
+		}
+	}
+	return p
+}
@@ -0,0 +1,69 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+type http2Details struct {
+	Protocol       string            `json:"protocol"`
+	HTTP2          bool              `json:"http2"`
+	PseudoHeaders  map[string]string `json:"pseudo_headers"`
+	HeaderListSize int               `json:"header_list_size"`
+	Priority       *struct {
+		Urgency     int  `json:"urgency"`
+		Incremental bool `json:"incremental"`
+	} `json:"priority"`
+	UsedTrailers  bool              `json:"used_trailers"`
+	Trailers      map[string]string `json:"trailers"`
+	PushSupported bool              `json:"push_supported"`
+}
+
+func TestHTTP2(t *testing.T) {
+	srv := httptest.NewUnstartedServer(httpbin.GetMux())
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/http2?a=1", strings.NewReader("body"))
+	req.Header.Set("Priority", "u=1, i")
+	req.Trailer = http.Header{"X-Checksum": {"abc"}}
+	resp, err := srv.Client().Do(req)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+
+	var v http2Details
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	require.Equal(t, "HTTP/2.0", v.Protocol)
+	require.True(t, v.HTTP2)
+	require.Equal(t, "POST", v.PseudoHeaders[":method"])
+	require.Equal(t, "https", v.PseudoHeaders[":scheme"])
+	require.Equal(t, "/http2?a=1", v.PseudoHeaders[":path"])
+	require.True(t, v.HeaderListSize > 4*32)
+	require.NotNil(t, v.Priority)
+	require.Equal(t, 1, v.Priority.Urgency)
+	require.True(t, v.Priority.Incremental)
+	require.True(t, v.UsedTrailers)
+	require.Equal(t, "abc", v.Trailers["X-Checksum"])
+	require.True(t, v.PushSupported)
+}
+
+func TestHTTP2_http1(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	var v http2Details
+	require.Nil(t, json.Unmarshal(get(t, srv.URL+"/http2"), &v))
+	require.Equal(t, "HTTP/1.1", v.Protocol)
+	require.False(t, v.HTTP2)
+	require.Nil(t, v.PseudoHeaders)
+	require.Nil(t, v.Priority)
+	require.False(t, v.UsedTrailers)
+	require.False(t, v.PushSupported)
+}
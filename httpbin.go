@@ -0,0 +1,308 @@
+package httpbin
+
+import (
+	"container/list"
+	"context"
+	"crypto/rsa"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// HTTPBin is an httpbin server with its own limits and state, so that
+// instances with different limits can serve side by side, e.g. in parallel
+// tests, without seeing each other's jobs, sessions or statistics.
+type HTTPBin struct {
+	prefix             string
+	maxBodySize        int64
+	disabledRoutes     map[string]bool
+	strictMethods      bool
+	profiling          bool
+	traceRequests      bool
+	decompressRequests bool
+	quotas             map[string]Quota
+	hooks              Events
+
+	region, zone    string
+	regionLatency   LatencyDistribution
+	routeLatencies  map[string]LatencyDistribution
+	latencyProfiles map[string]LatencyDistribution
+
+	binaryChunkSize              int
+	delayMax                     time.Duration
+	streamInterval               time.Duration
+	responseCacheSize            int
+	responseBufferMax            int
+	requestDecompressedMax       int64
+	requestDecompressionRatioMax float64
+	echoBodyMax                  int
+	echoDigestEdge               int
+	dedupeWindow                 time.Duration
+	dedupeFingerprintsMax        int
+	digestNonceTTL               time.Duration
+	pushMax                      int
+	onceTokensMax                int
+	orderedGroupsMax             int
+	stickyKeysMax                int
+	jobsMax                      int
+	jobDurationMax               time.Duration
+	loginSessionsMax             int
+	webSocketIdleTimeout         time.Duration
+	webSocketMessageMax          int
+
+	staticCacheControl      string
+	imageCacheControl       string
+	mirrorTemplates         *template.Template
+	dnsRecords              []DNSRecord
+	wellKnown               map[string]WellKnownResource
+	altServices             map[string]string
+	instanceID              string
+	jobCallbacks            bool
+	ntlmChallenge           []byte
+	negotiateTokens         [][]byte
+	maintenanceBypassHeader string
+
+	configToken      string
+	fixtureToken     string
+	maintenanceToken string
+	partitionToken   string
+	webhookSecret    string
+	webhookTolerance time.Duration
+	loginUsers       map[string]string
+	oidcClients      map[string]string
+	oidcSigningKey   *rsa.PrivateKey
+	oidcTokenTTL     time.Duration
+	sigV4Credentials map[string]string
+
+	*instanceState
+	router http.Handler
+}
+
+// instanceState is what the endpoints of an HTTPBin remember between
+// requests.
+type instanceState struct {
+	responses     *responseCache
+	decompression *decompressionTracker
+	routeStats    *statsTracker
+	handlerAllocs *allocTracker
+	quotaUsed     *quotaTracker
+	maintenance   *maintenanceState
+	fixtures      *fixtureStore
+	jobs          *jobStore
+	sessions      *sessionStore
+	flows         *authFlows
+	oidc          *oidcState
+	onceTokens    *onceStore
+	orderedGroups *orderedGroupSet
+	stickyKeys    *stickyKeyCounter
+	dedupeSeen    *dedupeStore
+	redirectKey   []byte // signs /redirect/:n history tokens
+}
+
+func newInstanceState() *instanceState {
+	return &instanceState{
+		responses:     &responseCache{items: make(map[string]*list.Element), lru: list.New()},
+		decompression: &decompressionTracker{},
+		routeStats:    &statsTracker{routes: make(map[string]*routeStatsEntry)},
+		handlerAllocs: &allocTracker{routes: make(map[string]*handlerAllocsStats)},
+		quotaUsed:     &quotaTracker{used: make(map[string]*quotaUsage)},
+		maintenance:   &maintenanceState{},
+		fixtures:      &fixtureStore{fixtures: make(map[string]Fixture)},
+		jobs:          &jobStore{jobs: make(map[string]*job)},
+		sessions:      &sessionStore{sessions: make(map[string]*session)},
+		flows:         &authFlows{m: make(map[string][]string)},
+		oidc:          &oidcState{codes: make(map[string]*oidcCode)},
+		onceTokens:    &onceStore{tokens: make(map[string]*onceToken)},
+		orderedGroups: &orderedGroupSet{groups: make(map[string]*orderedGroup)},
+		stickyKeys:    &stickyKeyCounter{requests: make(map[string]int)},
+		dedupeSeen:    &dedupeStore{seen: make(map[string]*dedupeEntry)},
+		redirectKey:   newRedirectHistoryKey(),
+	}
+}
+
+// Option configures an HTTPBin.
+type Option func(*HTTPBin)
+
+// WithBinaryChunkSize sets the buffer length used to generate large bodies,
+// in bytes. It defaults to 64 KiB.
+func WithBinaryChunkSize(n int) Option {
+	return func(h *HTTPBin) { h.binaryChunkSize = n }
+}
+
+// WithDelayMax sets the longest an endpoint delays or stalls a response. It
+// defaults to 10 seconds.
+func WithDelayMax(d time.Duration) Option {
+	return func(h *HTTPBin) { h.delayMax = d }
+}
+
+// WithStreamInterval sets the default interval between the objects of
+// streaming endpoints. It defaults to a second.
+func WithStreamInterval(d time.Duration) Option {
+	return func(h *HTTPBin) { h.streamInterval = d }
+}
+
+// WithStrictMethods makes requests to a known path with a method it does
+// not support fail with 405 and an Allow header instead of 404. It defaults
+// to false.
+func WithStrictMethods(enabled bool) Option {
+	return func(h *HTTPBin) { h.strictMethods = enabled }
+}
+
+// WithPrefix mounts the endpoints under the URL path prefix, e.g. /httpbin,
+// for when h is served behind a reverse proxy that passes on the full path.
+// Requests outside of it get 404, and the Location headers, cookie paths and
+// page links the endpoints generate are under it.
+func WithPrefix(path string) Option {
+	return func(h *HTTPBin) {
+		h.prefix = strings.TrimRight(path, "/")
+		if h.prefix != "" && h.prefix[0] != '/' {
+			h.prefix = "/" + h.prefix
+		}
+	}
+}
+
+// WithMaxBodySize limits request bodies to n bytes, responding 413 to
+// larger ones. It defaults to 0, for no limit.
+func WithMaxBodySize(n int64) Option {
+	return func(h *HTTPBin) { h.maxBodySize = n }
+}
+
+// WithLatencyProfiles names latency distributions that requests pick with
+// the X-Httpbin-Latency-Profile header, so that test orchestration can vary
+// the latency per request flow without changing URLs. A profile's latency
+// adds to those of WithRegionLatency and WithRouteLatencies.
+func WithLatencyProfiles(profiles map[string]LatencyDistribution) Option {
+	return func(h *HTTPBin) { h.latencyProfiles = profiles }
+}
+
+// New returns an HTTPBin with the defaults as changed by opts, and a state
+// of its own.
+func New(opts ...Option) *HTTPBin {
+	return newHTTPBin(newInstanceState(), opts)
+}
+
+// Reconfigure returns an HTTPBin configured by opts like New, but sharing
+// the state of h: its cached responses, statistics, jobs, sessions,
+// fixtures and so on. It lets a server change its configuration without
+// its clients losing what they created.
+func (h *HTTPBin) Reconfigure(opts ...Option) *HTTPBin {
+	return newHTTPBin(h.instanceState, opts)
+}
+
+func newHTTPBin(s *instanceState, opts []Option) *HTTPBin {
+	h := defaultHTTPBin()
+	h.instanceState = s
+	for _, opt := range opts {
+		opt(h)
+	}
+	h.router = h.mux()
+	return h
+}
+
+// Handler returns the handler serving the httpbin endpoints with the limits
+// of h, under its prefix.
+func (h *HTTPBin) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if h.maxBodySize > 0 && r.Body != nil {
+			if r.ContentLength > h.maxBodySize {
+				writeErrorJSONStatus(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body larger than %d bytes", h.maxBodySize))
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, h.maxBodySize)
+		}
+		if h.prefix != "" {
+			p := r.URL.Path
+			if p != h.prefix && !strings.HasPrefix(p, h.prefix+"/") {
+				http.NotFound(w, r)
+				return
+			}
+			r2 := new(http.Request)
+			*r2 = *r
+			u := *r.URL
+			u.Path = strings.TrimPrefix(p, h.prefix)
+			u.RawPath = strings.TrimPrefix(r.URL.RawPath, h.prefix)
+			r2.URL = &u
+			r = r2
+		}
+		h.router.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), httpbinContextKey{}, h)))
+	})
+}
+
+type httpbinContextKey struct{}
+
+var (
+	defaultOnce sync.Once
+	defaultInst *HTTPBin
+	// newDefault is New, set from init rather than initialized, as the
+	// route table refers to handlers that use the default instance.
+	newDefault func(...Option) *HTTPBin
+)
+
+func init() { newDefault = New }
+
+// defaultBin returns the HTTPBin of GetMux, of handlers served on their own
+// and of the package-level functions like SetFixture. It is built on first
+// use, once the optional features have registered their routes.
+func defaultBin() *HTTPBin {
+	defaultOnce.Do(func() { defaultInst = newDefault() })
+	return defaultInst
+}
+
+// defaultHTTPBin returns an HTTPBin with the defaults, without a state or a
+// router.
+func defaultHTTPBin() *HTTPBin {
+	return &HTTPBin{
+		binaryChunkSize:              defaultBinaryChunkSize,
+		delayMax:                     defaultDelayMax,
+		streamInterval:               defaultStreamInterval,
+		responseCacheSize:            defaultResponseCacheSize,
+		responseBufferMax:            defaultResponseBufferMax,
+		requestDecompressedMax:       defaultRequestDecompressedMax,
+		requestDecompressionRatioMax: defaultRequestDecompressionRatioMax,
+		echoBodyMax:                  defaultEchoBodyMax,
+		echoDigestEdge:               defaultEchoDigestEdge,
+		dedupeWindow:                 defaultDedupeWindow,
+		dedupeFingerprintsMax:        defaultDedupeFingerprintsMax,
+		digestNonceTTL:               defaultDigestNonceTTL,
+		pushMax:                      defaultPushMax,
+		onceTokensMax:                defaultOnceTokensMax,
+		orderedGroupsMax:             defaultOrderedGroupsMax,
+		stickyKeysMax:                defaultStickyKeysMax,
+		jobsMax:                      defaultJobsMax,
+		jobDurationMax:               defaultJobDurationMax,
+		loginSessionsMax:             defaultLoginSessionsMax,
+		webSocketIdleTimeout:         defaultWebSocketIdleTimeout,
+		webSocketMessageMax:          defaultWebSocketMessageMax,
+		staticCacheControl:           defaultStaticCacheControl,
+		imageCacheControl:            defaultImageCacheControl,
+		dnsRecords:                   defaultDNSRecords,
+		instanceID:                   defaultInstanceID,
+		ntlmChallenge:                defaultNTLMChallenge,
+		negotiateTokens:              defaultNegotiateTokens,
+		maintenanceBypassHeader:      defaultMaintenanceBypassHeader,
+		webhookSecret:                defaultWebhookSecret,
+		webhookTolerance:             defaultWebhookTolerance,
+		loginUsers:                   defaultLoginUsers,
+		oidcClients:                  defaultOIDCClients,
+		oidcTokenTTL:                 defaultOIDCTokenTTL,
+		sigV4Credentials:             defaultSigV4Credentials,
+	}
+}
+
+// instance returns the HTTPBin serving r, or that of GetMux if r is served
+// by a handler on its own.
+func instance(r *http.Request) *HTTPBin {
+	if h, ok := r.Context().Value(httpbinContextKey{}).(*HTTPBin); ok {
+		return h
+	}
+	return defaultBin()
+}
+
+// prefixed returns the path p of an endpoint as requested from the HTTPBin
+// serving r, under its prefix.
+func prefixed(r *http.Request, p string) string {
+	return instance(r).prefix + p
+}
@@ -0,0 +1,143 @@
+package httpbin_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_limits(t *testing.T) {
+	t.Parallel()
+	fast := httptest.NewServer(httpbin.New(httpbin.WithDelayMax(100 * time.Millisecond)).Handler())
+	defer fast.Close()
+	slow := httptest.NewServer(httpbin.New(httpbin.WithDelayMax(400 * time.Millisecond)).Handler())
+	defer slow.Close()
+
+	for _, tc := range []struct {
+		srv  *httptest.Server
+		want time.Duration
+	}{{fast, 100 * time.Millisecond}, {slow, 400 * time.Millisecond}} {
+		start := time.Now()
+		resp, err := http.Get(tc.srv.URL + "/delay/5")
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.InDelta(t, tc.want.Seconds(), time.Since(start).Seconds(), 0.09)
+	}
+}
+
+func TestNew_binaryChunkSize(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(httpbin.New(httpbin.WithBinaryChunkSize(10), httpbin.WithStreamInterval(0)).Handler())
+	defer srv.Close()
+
+	for _, path := range []string{"/bytes/25", "/stream/3"} {
+		resp, err := http.Get(srv.URL + path)
+		require.Nil(t, err)
+		b, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.Nil(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode, path)
+		if path == "/bytes/25" {
+			require.Len(t, b, 25)
+		}
+	}
+
+	resp, err := http.Get(srv.URL + "/config")
+	require.Nil(t, err)
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Nil(t, err)
+	require.Contains(t, string(b), `"binary_chunk_size": 10`)
+	require.Contains(t, string(b), `"stream_interval": "0s"`)
+}
+
+func TestNew_prefix(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(httpbin.New(httpbin.WithPrefix("/httpbin/")).Handler())
+	defer srv.Close()
+	c := noRedirectClient()
+
+	for path, want := range map[string]int{
+		"/httpbin/get": http.StatusOK,
+		"/httpbin/":    http.StatusOK,
+		"/get":         http.StatusNotFound,
+		"/httpbinget":  http.StatusNotFound,
+	} {
+		resp, err := noFollowGet(c, srv.URL+path)
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, want, resp.StatusCode, path)
+	}
+
+	for path, want := range map[string]string{
+		"/httpbin":                     "/httpbin/",
+		"/httpbin/redirect/2":          "/httpbin/redirect/1",
+		"/httpbin/absolute-redirect/2": srv.URL + "/httpbin/absolute-redirect/1",
+		"/httpbin/status/302":          "/httpbin/redirect/1",
+		"/httpbin/links/3":             "/httpbin/links/3/0",
+		"/httpbin/cookies/set?k=v":     "/httpbin/cookies",
+	} {
+		resp, err := noFollowGet(c, srv.URL+path)
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.True(t, strings.HasPrefix(resp.Header.Get("Location"), want), "%s: %s", path, resp.Header.Get("Location"))
+	}
+
+	resp, err := noFollowGet(c, srv.URL+"/httpbin/cookies/set?k=v")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Contains(t, resp.Header.Get("Set-Cookie"), "Path=/httpbin/")
+
+	b := string(get(t, srv.URL+"/httpbin/links/3/0"))
+	require.Contains(t, b, `<a href="/httpbin/links/3/1">1</a>`)
+}
+
+func TestNew_maxBodySize(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(httpbin.New(httpbin.WithMaxBodySize(10)).Handler())
+	defer srv.Close()
+
+	for body, want := range map[string]int{
+		"0123456789":  http.StatusOK,
+		"01234567890": http.StatusRequestEntityTooLarge,
+	} {
+		resp, err := http.Post(srv.URL+"/post", "text/plain", strings.NewReader(body))
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, want, resp.StatusCode, body)
+
+		// without a Content-Length, the limit applies as the body is read
+		resp, err = http.Post(srv.URL+"/post", "text/plain", ioutil.NopCloser(strings.NewReader(body)))
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, want, resp.StatusCode, body)
+	}
+}
+
+func TestNew_state(t *testing.T) {
+	t.Parallel()
+	h := httpbin.New()
+	srv := httptest.NewServer(h.Handler())
+	defer srv.Close()
+	other := httptest.NewServer(httpbin.New().Handler())
+	defer other.Close()
+	name := unique("state")
+
+	h.SetFixture(name, httpbin.Fixture{Body: []byte("mine")})
+	require.Equal(t, []string{name}, h.FixtureNames())
+	require.Equal(t, "mine", string(get(t, srv.URL+"/serve/"+name)))
+	require.NotContains(t, httpbin.FixtureNames(), name)
+
+	resp, err := http.Get(other.URL + "/serve/" + name)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	require.True(t, h.RemoveFixture(name))
+	require.Empty(t, h.FixtureNames())
+}
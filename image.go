@@ -0,0 +1,297 @@
+//go:build !httpbin_noimage
+// +build !httpbin_noimage
+
+package httpbin
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	featureRoutes = append(featureRoutes,
+		route{name: "image", path: `/image`, methods: getHead, description: "Returns an image in the format preferred by the Accept header.", example: "image", handler: http.HandlerFunc(ImageHandler), cacheKey: imageCacheKey},
+		route{name: "image-gif", path: `/image/gif`, methods: getHead, description: "Returns an animated GIF image.", example: "image/gif", handler: http.HandlerFunc(GIFHandler), cacheKey: constantCacheKey},
+		route{name: "image-png", path: `/image/png`, methods: getHead, description: "Returns a PNG image.", example: "image/png", handler: http.HandlerFunc(PNGHandler), cacheKey: constantCacheKey},
+		route{name: "image-jpeg", path: `/image/jpeg`, methods: getHead, description: "Returns a JPEG image.", example: "image/jpeg", handler: http.HandlerFunc(JPEGHandler), cacheKey: constantCacheKey},
+		route{name: "image-webp", path: `/image/webp`, methods: getHead, description: "Returns a lossless WebP image.", example: "image/webp", handler: http.HandlerFunc(WebPHandler), cacheKey: constantCacheKey},
+		route{name: "image-svg", path: `/image/svg`, methods: getHead, description: "Returns an SVG image.", example: "image/svg", handler: http.HandlerFunc(SVGHandler), cacheKey: constantCacheKey},
+	)
+	truncatePayloads["image"] = truncatePayload{"image/png", func() []byte {
+		var buf bytes.Buffer
+		png.Encode(&buf, getImg())
+		return buf.Bytes()
+	}}
+}
+
+// imageFormat is an image format /image can respond with.
+type imageFormat struct {
+	mediaType string
+	encode    func(w io.Writer, m image.Image) error
+}
+
+// imageFormats are the formats /image negotiates, in order of preference
+// among those the client accepts equally and names as specifically. They
+// are fixed once the package is initialized, since /image responses are
+// cached by the negotiated format; image_avif.go puts AVIF first when
+// built with the httpbin_avif tag.
+var imageFormats = []imageFormat{
+	{"image/png", png.Encode},
+	{"image/jpeg", func(w io.Writer, m image.Image) error { return jpeg.Encode(w, m, nil) }},
+	{"image/gif", func(w io.Writer, m image.Image) error { return gif.Encode(w, m, nil) }},
+	{"image/webp", encodeWebP},
+	{"image/svg+xml", encodeSVG},
+}
+
+// ImageHandler returns an image in the format the Accept header prefers, by
+// quality, then by how specifically it names the format, so that
+// "image/webp, */*" gets WebP, and then by the order of imageFormats, or
+// 406 Not Acceptable if it accepts none of them.
+func ImageHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept")
+	f, ok := negotiateImage(r.Header.Get("Accept"))
+	if !ok {
+		var types []string
+		for _, f := range imageFormats {
+			types = append(types, f.mediaType)
+		}
+		writeErrorJSONStatus(w, http.StatusNotAcceptable, fmt.Errorf("no acceptable image format, have %s", strings.Join(types, ", ")))
+		return
+	}
+	var buf bytes.Buffer
+	if err := f.encode(&buf, getImg()); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to encode %s: %w", f.mediaType, err))
+		return
+	}
+	w.Header().Set("Content-Type", f.mediaType)
+	serveImage(w, r, buf.Bytes())
+}
+
+// imageCacheKey keys /image responses by the negotiated format.
+func imageCacheKey(r *http.Request) (string, bool) {
+	f, ok := negotiateImage(r.Header.Get("Accept"))
+	return f.mediaType, ok
+}
+
+// negotiateImage picks the image format accept prefers. Each format gets
+// the quality of the most specific media range matching it, and wins ties
+// with formats matched less specifically.
+func negotiateImage(accept string) (imageFormat, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return imageFormats[0], true
+	}
+	type mediaRange struct {
+		mt string
+		q  float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if s, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				q = f
+			}
+		}
+		ranges = append(ranges, mediaRange{mt, q})
+	}
+
+	best, bestQ, bestSpecificity := imageFormat{}, 0.0, -1
+	for _, f := range imageFormats {
+		q, specificity := 0.0, -1
+		for _, mr := range ranges {
+			s := -1
+			switch {
+			case mr.mt == f.mediaType:
+				s = 2
+			case strings.HasSuffix(mr.mt, "/*") && strings.HasPrefix(f.mediaType, mr.mt[:len(mr.mt)-1]):
+				s = 1
+			case mr.mt == "*/*":
+				s = 0
+			}
+			if s > specificity {
+				q, specificity = mr.q, s
+			}
+		}
+		if q > bestQ || q == bestQ && q > 0 && specificity > bestSpecificity {
+			best, bestQ, bestSpecificity = f, q, specificity
+		}
+	}
+	return best, bestQ > 0
+}
+
+type circle struct {
+	X, Y, R float64
+}
+
+func (c *circle) Brightness(x, y float64) uint8 {
+	var dx, dy float64 = c.X - x, c.Y - y
+	d := math.Sqrt(dx*dx+dy*dy) / c.R
+	if d > 1 {
+		return 0
+	}
+	return 255
+}
+
+// GIFHandler returns an animated GIF image.
+// Source: http://tech.nitoyon.com/en/blog/2016/01/07/go-animated-gif-gen/
+func GIFHandler(rw http.ResponseWriter, r *http.Request) {
+	var w, h int = 240, 240
+	var hw, hh float64 = float64(w / 2), float64(h / 2)
+	circles := []*circle{{}, {}, {}}
+
+	var palette = []color.Color{
+		color.RGBA{0x00, 0x00, 0x00, 0xff},
+		color.RGBA{0x00, 0x00, 0xff, 0xff},
+		color.RGBA{0x00, 0xff, 0x00, 0xff},
+		color.RGBA{0x00, 0xff, 0xff, 0xff},
+		color.RGBA{0xff, 0x00, 0x00, 0xff},
+		color.RGBA{0xff, 0x00, 0xff, 0xff},
+		color.RGBA{0xff, 0xff, 0x00, 0xff},
+		color.RGBA{0xff, 0xff, 0xff, 0xff},
+	}
+
+	var images []*image.Paletted
+	var delays []int
+	steps := 20
+	for step := 0; step < steps; step++ {
+		img := image.NewPaletted(image.Rect(0, 0, w, h), palette)
+		images = append(images, img)
+		delays = append(delays, 0)
+
+		θ := 2.0 * math.Pi / float64(steps) * float64(step)
+		for i, circle := range circles {
+			θ0 := 2 * math.Pi / 3 * float64(i)
+			circle.X = hw - 40*math.Sin(θ0) - 20*math.Sin(θ0+θ)
+			circle.Y = hh - 40*math.Cos(θ0) - 20*math.Cos(θ0+θ)
+			circle.R = 50
+		}
+
+		for x := 0; x < w; x++ {
+			for y := 0; y < h; y++ {
+				img.Set(x, y, color.RGBA{
+					circles[0].Brightness(float64(x), float64(y)),
+					circles[1].Brightness(float64(x), float64(y)),
+					circles[2].Brightness(float64(x), float64(y)),
+					255,
+				})
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	gif.EncodeAll(&buf, &gif.GIF{
+		Image: images,
+		Delay: delays,
+	})
+	serveImage(rw, r, buf.Bytes())
+}
+
+// JPEGHandler returns a JPEG image.
+func JPEGHandler(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	jpeg.Encode(&buf, getImg(), nil)
+	serveImage(w, r, buf.Bytes())
+}
+
+// WebPHandler returns a lossless WebP image.
+func WebPHandler(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	encodeWebP(&buf, getImg())
+	serveImage(w, r, buf.Bytes())
+}
+
+// SVGHandler returns an SVG image.
+func SVGHandler(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	encodeSVG(&buf, getImg())
+	w.Header().Set("Content-Type", "image/svg+xml")
+	serveImage(w, r, buf.Bytes())
+}
+
+// PNGHandler returns a PNG image.
+func PNGHandler(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	png.Encode(&buf, getImg())
+	serveImage(w, r, buf.Bytes())
+}
+
+func getImg() image.Image {
+	const n = 512
+	img := image.NewRGBA(image.Rect(0, 0, n, n))
+	abs := func(n int) int {
+		if n < 0 {
+			return -n
+		}
+		return n
+	}
+	sq := func(i int) int { return i * i }
+
+	for x := 0; x <= n; x++ {
+		for y := 0; y <= n; y++ {
+			if x == n/2 && y == n/2 {
+				continue
+			}
+			d := math.Sqrt(float64(sq(abs(x-n/2)) + sq(abs(y-n/2))))
+			if d > n/2 {
+				continue
+			}
+
+			sin := float64(y-n/2) / d
+			deg := math.Asin(sin)/math.Pi*359.0 + 180
+			sec := int(deg) / 60
+
+			var fix, mod *uint8
+			var inc bool
+
+			c := color.RGBA{0, 0, 0, 0xFF}
+			switch sec {
+			case 0:
+				fix, mod = &c.R, &c.G
+				inc = true
+			case 1:
+				fix, mod = &c.G, &c.R
+				inc = false
+			case 2:
+				fix, mod = &c.G, &c.B
+				inc = true
+			case 3:
+				fix, mod = &c.B, &c.G
+				inc = false
+			case 4:
+				fix, mod = &c.B, &c.R
+				inc = true
+			case 5:
+				fix, mod = &c.R, &c.B
+				inc = false
+			default:
+				panic(fmt.Sprintf("deg=%f sec=%d", deg, sec))
+			}
+
+			v := uint8((int(deg) % 60) * 255.0 / 60.0)
+			*fix = 255
+			if inc {
+				*mod = v
+			} else {
+				*mod = 255 - v
+			}
+			img.Set(x, y, c)
+
+		}
+	}
+	return img
+}
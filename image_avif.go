@@ -0,0 +1,38 @@
+//go:build httpbin_avif && !httpbin_noimage
+// +build httpbin_avif,!httpbin_noimage
+
+package httpbin
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+	"net/http"
+
+	"github.com/gen2brain/avif"
+)
+
+func init() {
+	imageFormats = append([]imageFormat{{"image/avif", encodeAVIF}}, imageFormats...)
+	featureRoutes = append(featureRoutes,
+		route{name: "image-avif", path: `/image/avif`, methods: getHead, description: "Returns an AVIF image.", example: "image/avif", handler: http.HandlerFunc(AVIFHandler), cacheKey: constantCacheKey},
+	)
+}
+
+// encodeAVIF encodes m as AVIF with the encoder's defaults, which favour
+// speed, since images are encoded on request.
+func encodeAVIF(w io.Writer, m image.Image) error {
+	return avif.Encode(w, m)
+}
+
+// AVIFHandler returns an AVIF image.
+func AVIFHandler(w http.ResponseWriter, r *http.Request) {
+	var buf bytes.Buffer
+	if err := encodeAVIF(&buf, getImg()); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to encode image/avif: %w", err))
+		return
+	}
+	w.Header().Set("Content-Type", "image/avif")
+	serveImage(w, r, buf.Bytes())
+}
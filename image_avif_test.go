@@ -0,0 +1,47 @@
+//go:build httpbin_avif && !httpbin_noimage
+// +build httpbin_avif,!httpbin_noimage
+
+package httpbin_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAVIF(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/image/avif")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, "image/avif", resp.Header.Get("Content-Type"))
+	b, _ := ioutil.ReadAll(resp.Body)
+	require.EqualValues(t, "ftypavif", string(b[4:12]))
+}
+
+func TestImage_negotiatedAVIF(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for accept, want := range map[string]string{
+		"": "image/avif",
+		"image/avif,image/webp,image/*,*/*;q=0.8": "image/avif",
+		"image/webp,image/*;q=0.8":                "image/webp",
+		"image/avif;q=0.5, image/png":             "image/png",
+		"text/html, */*;q=0.1":                    "image/avif",
+	} {
+		req, _ := http.NewRequest("GET", srv.URL+"/image", nil)
+		req.Header.Set("Accept", accept)
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, accept)
+		require.Equal(t, want, resp.Header.Get("Content-Type"), accept)
+	}
+}
@@ -0,0 +1,167 @@
+//go:build !httpbin_noimage
+// +build !httpbin_noimage
+
+package httpbin_test
+
+import (
+	"encoding/binary"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJPEG(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/image/jpeg")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, "image/jpeg", resp.Header.Get("Content-Type"))
+}
+
+func TestGIF(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/image/gif")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, "image/gif", resp.Header.Get("Content-Type"))
+}
+
+func TestPNG(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/image/png")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, "image/png", resp.Header.Get("Content-Type"))
+}
+
+func TestWebP(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/image/webp")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, "image/webp", resp.Header.Get("Content-Type"))
+	b, _ := ioutil.ReadAll(resp.Body)
+	require.EqualValues(t, "RIFF", string(b[:4]))
+	require.EqualValues(t, "WEBPVP8L", string(b[8:16]))
+	require.EqualValues(t, len(b)-8, binary.LittleEndian.Uint32(b[4:]))
+}
+
+func TestSVG(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/image/svg")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, "image/svg+xml", resp.Header.Get("Content-Type"))
+	var svg struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2000/svg svg"`
+		Width   int      `xml:"width,attr"`
+	}
+	require.Nil(t, xml.NewDecoder(resp.Body).Decode(&svg))
+	require.EqualValues(t, 512, svg.Width)
+}
+
+func TestTruncate_image(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/truncate?of=image&bytes=8")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+	require.True(t, resp.ContentLength > 8)
+}
+
+func TestImage_conditional(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/image/png")
+	require.Nil(t, err)
+	resp.Body.Close()
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	require.NotEmpty(t, etag)
+	require.Equal(t, "public, max-age=86400", resp.Header.Get("Cache-Control"))
+	require.NotEmpty(t, lastModified)
+
+	// evaluated on cache misses and hits alike
+	for _, cache := range []string{"bypass", ""} {
+		req, _ := http.NewRequest("GET", srv.URL+"/image/png", nil)
+		req.Header.Set("X-Httpbin-Cache", cache)
+		req.Header.Set("If-None-Match", etag)
+		resp, err = http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusNotModified, resp.StatusCode, cache)
+		require.Equal(t, etag, resp.Header.Get("ETag"))
+
+		req, _ = http.NewRequest("GET", srv.URL+"/image/png", nil)
+		req.Header.Set("X-Httpbin-Cache", cache)
+		req.Header.Set("If-Modified-Since", lastModified)
+		resp, err = http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusNotModified, resp.StatusCode, cache)
+	}
+
+	req, _ := http.NewRequest("GET", srv.URL+"/image/png", nil)
+	req.Header.Set("If-None-Match", `"stale"`)
+	resp, err = http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestImage_negotiated(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	// preferences that do not depend on whether AVIF is built in
+	for accept, want := range map[string]string{
+		"image/webp,image/*;q=0.8":               "image/webp",
+		"image/webp,*/*":                         "image/webp",
+		"image/svg+xml,image/*":                  "image/svg+xml",
+		"image/jpeg,image/png":                   "image/png",
+		"image/avif;q=0.5, image/png":            "image/png",
+		"image/jpeg;q=0.9, image/png;q=0.5":      "image/jpeg",
+		"image/avif;q=0, image/png;q=0, image/*": "image/jpeg",
+	} {
+		req, _ := http.NewRequest("GET", srv.URL+"/image", nil)
+		req.Header.Set("Accept", accept)
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, accept)
+		require.Equal(t, want, resp.Header.Get("Content-Type"), accept)
+		require.Equal(t, "Accept", resp.Header.Get("Vary"))
+	}
+
+	req, _ := http.NewRequest("GET", srv.URL+"/image", nil)
+	req.Header.Set("Accept", "image/heic, text/html")
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotAcceptable, resp.StatusCode)
+}
@@ -0,0 +1,34 @@
+package httpbin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+// defaultImageCacheControl is the Cache-Control header of /image/*
+// responses.
+const defaultImageCacheControl = "public, max-age=86400"
+
+// WithImageCacheControl sets the Cache-Control header of /image/*
+// responses, none if empty. It defaults to "public, max-age=86400".
+func WithImageCacheControl(cc string) Option {
+	return func(h *HTTPBin) { h.imageCacheControl = cc }
+}
+
+// imageLastModified is the Last-Modified of /image/* responses.
+var imageLastModified = time.Date(2017, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// serveImage serves the encoded image b with the image Cache-Control of the
+// serving HTTPBin, a fixed Last-Modified and an ETag derived from its
+// content, evaluating conditional and Range requests against them.
+func serveImage(w http.ResponseWriter, r *http.Request, b []byte) {
+	sum := sha256.Sum256(b)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:8])+`"`)
+	if cc := instance(r).imageCacheControl; cc != "" {
+		w.Header().Set("Cache-Control", cc)
+	}
+	http.ServeContent(w, r, "", imageLastModified, bytes.NewReader(b))
+}
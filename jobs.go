@@ -0,0 +1,321 @@
+package httpbin
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultJobDurationMax caps how long the phases of a /jobs job last in
+	// total.
+	defaultJobDurationMax = 10 * time.Minute
+
+	// defaultJobsMax is the maximum number of /jobs jobs remembered.
+	defaultJobsMax = 10000
+)
+
+// WithJobCallbacks lets POST /jobs ask, with the 'callback' query
+// parameter, for the finished job to be POSTed to a URL. It defaults to
+// false, so that the server doesn't make requests on behalf of its clients.
+func WithJobCallbacks(enabled bool) Option {
+	return func(h *HTTPBin) { h.jobCallbacks = enabled }
+}
+
+// WithJobDurationMax caps how long the phases of a /jobs job last in total.
+// It defaults to 10 minutes.
+func WithJobDurationMax(d time.Duration) Option {
+	return func(h *HTTPBin) { h.jobDurationMax = d }
+}
+
+// WithJobsMax sets the maximum number of /jobs jobs remembered; creating
+// more forgets the oldest ones, which then get 404. It defaults to 10000.
+func WithJobsMax(n int) Option {
+	return func(h *HTTPBin) { h.jobsMax = n }
+}
+
+// jobCallbackTimeout is how long the delivery of a job callback may take.
+const jobCallbackTimeout = 10 * time.Second
+
+// jobStore tracks the jobs created by POST /jobs.
+type jobStore struct {
+	mu    sync.Mutex
+	jobs  map[string]*job
+	order []string // creation order, oldest first
+}
+
+// job goes through its phases, each lasting its duration from the end of the
+// one before, and ends in its outcome, unless cancelled first.
+type job struct {
+	store     *jobStore // whose mu guards the fields below
+	id        string
+	url       string // path of the job, under the prefix it was created under
+	phases    []jobPhase
+	outcome   string // succeeded or failed
+	created   time.Time
+	cancelled time.Time // zero unless cancelled
+	callback  string
+	secret    string      // the callback is signed with
+	timer     *time.Timer // delivering the callback
+	delivery  *jobCallback
+}
+
+type jobPhase struct {
+	name string
+	d    time.Duration
+}
+
+// parseJobPhases parses comma-separated <name>:<seconds> phases.
+func parseJobPhases(s string) ([]jobPhase, error) {
+	var phases []jobPhase
+	for _, p := range strings.Split(s, ",") {
+		i := strings.LastIndexByte(p, ':')
+		if i <= 0 {
+			return nil, fmt.Errorf("phase %q: want <name>:<seconds>", p)
+		}
+		name := strings.TrimSpace(p[:i])
+		switch name {
+		case "succeeded", "failed", "cancelled":
+			return nil, fmt.Errorf("phase %q: %s is a final status", p, name)
+		}
+		f, err := strconv.ParseFloat(strings.TrimSpace(p[i+1:]), 64)
+		if err != nil || f < 0 || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("phase %q: want a non-negative number of seconds", p)
+		}
+		phases = append(phases, jobPhase{name, time.Duration(f * float64(time.Second))})
+	}
+	return phases, nil
+}
+
+// duration is how long the phases of j last.
+func (j *job) duration() time.Duration {
+	var d time.Duration
+	for _, p := range j.phases {
+		d += p.d
+	}
+	return d
+}
+
+// status returns the status of j at now and when it started, and, while
+// the job runs, when it changes next.
+func (j *job) status(now time.Time) (status string, since, next time.Time) {
+	if !j.cancelled.IsZero() && !now.Before(j.cancelled) {
+		return "cancelled", j.cancelled, time.Time{}
+	}
+	since = j.created
+	for _, p := range j.phases {
+		end := since.Add(p.d)
+		if now.Before(end) {
+			return p.name, since, end
+		}
+		since = end
+	}
+	return j.outcome, since, time.Time{}
+}
+
+// response describes j at now.
+func (j *job) response(now time.Time) jobResponse {
+	status, since, next := j.status(now)
+	v := jobResponse{
+		ID:      j.id,
+		URL:     j.url,
+		Status:  status,
+		Done:    next.IsZero(),
+		Since:   since.UTC().Format(time.RFC3339Nano),
+		Created: j.created.UTC().Format(time.RFC3339Nano),
+		Phases:  make([]jobPhaseResponse, len(j.phases)),
+	}
+	for i, p := range j.phases {
+		v.Phases[i] = jobPhaseResponse{Name: p.name, Seconds: p.d.Seconds()}
+	}
+	switch total := j.duration(); {
+	case status == "cancelled":
+		v.Progress = math.Min(1, float64(j.cancelled.Sub(j.created))/float64(total))
+	case v.Done || total == 0:
+		v.Progress = 1
+	default:
+		v.Progress = float64(now.Sub(j.created)) / float64(total)
+	}
+	if !v.Done {
+		v.Next = next.UTC().Format(time.RFC3339Nano)
+	}
+	switch status {
+	case "succeeded":
+		v.Result = map[string]interface{}{"ok": true}
+	case "failed":
+		v.Error = "job failed"
+	}
+	if j.callback != "" {
+		v.Callback = &jobCallback{URL: j.callback}
+		if j.delivery != nil {
+			v.Callback = j.delivery
+		}
+	}
+	return v
+}
+
+// deliver POSTs the finished job to its callback URL, signed with its
+// secret as /webhook/verify?scheme=hmac checks, and records the
+// outcome.
+func (j *job) deliver() {
+	j.store.mu.Lock()
+	v := j.response(time.Now())
+	j.store.mu.Unlock()
+	v.Callback = nil
+	body, _ := json.Marshal(v)
+
+	d := &jobCallback{URL: j.callback, AttemptedAt: time.Now().UTC().Format(time.RFC3339Nano)}
+	req, err := http.NewRequest(http.MethodPost, j.callback, bytes.NewReader(body))
+	if err == nil {
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "go-httpbin")
+		req.Header.Set("X-Signature", hex.EncodeToString(webhookMAC(j.secret, "sha256", body)))
+		var resp *http.Response
+		client := &http.Client{Timeout: jobCallbackTimeout}
+		if resp, err = client.Do(req); err == nil {
+			resp.Body.Close()
+			d.StatusCode = resp.StatusCode
+			d.Delivered = resp.StatusCode/100 == 2
+		}
+	}
+	if err != nil {
+		d.Error = err.Error()
+	}
+
+	j.store.mu.Lock()
+	j.delivery = d
+	j.store.mu.Unlock()
+}
+
+// NewJobHandler creates a job that goes through the phases in the 'phases'
+// query parameter, comma-separated <name>:<seconds> (default
+// queued:1,running:3), then ends with the status in 'outcome', succeeded
+// (the default) or failed. It returns 202 with the job and its Location.
+// With job callbacks enabled, the finished, or cancelled, job is POSTed to the URL
+// in 'callback'.
+func NewJobHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	spec := q.Get("phases")
+	if spec == "" {
+		spec = "queued:1,running:3"
+	}
+	phases, err := parseJobPhases(spec)
+	if err != nil {
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("failed to parse 'phases': %w", err))
+		return
+	}
+	hb := instance(r)
+	jobs := hb.jobs
+	j := &job{store: jobs, phases: phases, outcome: q.Get("outcome"), created: time.Now(), secret: hb.webhookSecret}
+	if d := j.duration(); d > hb.jobDurationMax {
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("the phases last %v, longer than %v", d, hb.jobDurationMax))
+		return
+	}
+	switch j.outcome {
+	case "":
+		j.outcome = "succeeded"
+	case "succeeded", "failed":
+	default:
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("unsupported outcome %q, want succeeded or failed", j.outcome))
+		return
+	}
+	if j.callback = q.Get("callback"); j.callback != "" {
+		if !hb.jobCallbacks {
+			writeErrorJSONStatus(w, http.StatusForbidden, errors.New("job callbacks are disabled"))
+			return
+		}
+		if u, err := url.Parse(j.callback); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("'callback' must be an absolute http or https URL"))
+			return
+		}
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to generate job id: %w", err))
+		return
+	}
+	j.id = hex.EncodeToString(b)
+	j.url = prefixed(r, "/jobs/"+j.id)
+
+	jobs.mu.Lock()
+	jobs.jobs[j.id] = j
+	jobs.order = append(jobs.order, j.id)
+	for len(jobs.order) > hb.jobsMax {
+		if old := jobs.jobs[jobs.order[0]]; old.timer != nil {
+			old.timer.Stop()
+		}
+		delete(jobs.jobs, jobs.order[0])
+		jobs.order = jobs.order[1:]
+	}
+	if j.callback != "" {
+		j.timer = time.AfterFunc(j.duration(), j.deliver)
+	}
+	v := j.response(j.created)
+	_, _, next := j.status(j.created)
+	jobs.mu.Unlock()
+
+	w.Header().Set("Location", v.URL)
+	setJobRetryAfter(w, next, j.created)
+	w.WriteHeader(http.StatusAccepted)
+	_ = writeJSON(w, v) // status already written, nothing else to do
+}
+
+// JobHandler reports the status of a job created by POST /jobs, with a
+// Retry-After header until it is done, and DELETE cancels it. Unknown jobs
+// get 404.
+func JobHandler(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	jobs := instance(r).jobs
+	jobs.mu.Lock()
+	j, ok := jobs.jobs[routeVars(r)["id"]]
+	var (
+		v    jobResponse
+		next time.Time
+	)
+	if ok {
+		if r.Method == http.MethodDelete {
+			if _, _, next := j.status(now); !next.IsZero() {
+				j.cancelled = now
+				if j.timer != nil && j.timer.Stop() {
+					go j.deliver()
+				}
+			}
+		}
+		v = j.response(now)
+		_, _, next = j.status(now)
+	}
+	jobs.mu.Unlock()
+
+	if !ok {
+		writeErrorJSONStatus(w, http.StatusNotFound, errors.New("unknown job"))
+		return
+	}
+	setJobRetryAfter(w, next, now)
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
+
+// setJobRetryAfter tells clients polling a job that isn't done when its
+// status changes next, in whole seconds.
+func setJobRetryAfter(w http.ResponseWriter, next, now time.Time) {
+	if next.IsZero() {
+		return
+	}
+	secs := int(math.Ceil(next.Sub(now).Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+}
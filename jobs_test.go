@@ -0,0 +1,139 @@
+package httpbin_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+type jobStatus struct {
+	URL      string
+	Status   string
+	Done     bool
+	Progress float64
+	Result   map[string]interface{}
+	Error    string
+	Callback *struct {
+		Delivered  bool
+		StatusCode int `json:"status_code"`
+	}
+}
+
+func getJob(t *testing.T, method, url string) (*http.Response, jobStatus) {
+	req, err := http.NewRequest(method, url, nil)
+	require.Nil(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	var v jobStatus
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	return resp, v
+}
+
+func TestJobs(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, v := getJob(t, http.MethodPost, srv.URL+"/jobs?phases=queued:0.2,running:0.2&outcome=failed")
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	require.Equal(t, v.URL, resp.Header.Get("Location"))
+	require.Equal(t, "1", resp.Header.Get("Retry-After"))
+	require.Equal(t, "queued", v.Status)
+
+	time.Sleep(300 * time.Millisecond)
+	resp, v = getJob(t, http.MethodGet, srv.URL+v.URL)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "running", v.Status)
+	require.False(t, v.Done)
+	require.InDelta(t, 0.75, v.Progress, 0.25)
+
+	time.Sleep(200 * time.Millisecond)
+	resp, v = getJob(t, http.MethodGet, srv.URL+v.URL)
+	require.Equal(t, "failed", v.Status)
+	require.True(t, v.Done)
+	require.Equal(t, "job failed", v.Error)
+	require.Empty(t, resp.Header.Get("Retry-After"))
+}
+
+func TestJobs_cancel(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	_, v := getJob(t, http.MethodPost, srv.URL+"/jobs?phases=running:60")
+	_, v = getJob(t, http.MethodDelete, srv.URL+v.URL)
+	require.Equal(t, "cancelled", v.Status)
+	require.True(t, v.Done)
+
+	resp, err := http.Get(srv.URL + "/jobs/0123abcd")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestJobs_badRequest(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for _, query := range []string{"phases=queued", "phases=queued:-1", "phases=failed:1", "phases=running:3600", "outcome=maybe", "callback=http://example.com/"} {
+		resp, err := http.Post(srv.URL+"/jobs?"+query, "text/plain", nil)
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.NotEqual(t, http.StatusAccepted, resp.StatusCode, query)
+	}
+}
+
+func TestJobs_callback(t *testing.T) {
+	srv := httptest.NewServer(httpbin.New(httpbin.WithJobCallbacks(true)).Handler())
+	defer srv.Close()
+
+	got := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		got <- r
+		bodies <- b
+	}))
+	defer hook.Close()
+
+	_, v := getJob(t, http.MethodPost, srv.URL+"/jobs?phases=running:0.1&callback="+url.QueryEscape(hook.URL+"/done"))
+	var r *http.Request
+	select {
+	case r = <-got:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no callback")
+	}
+	body := <-bodies
+	require.Equal(t, "/done", r.URL.Path)
+	var done jobStatus
+	require.Nil(t, json.Unmarshal(body, &done))
+	require.Equal(t, "succeeded", done.Status)
+	require.Equal(t, true, done.Result["ok"])
+	_, err := hex.DecodeString(r.Header.Get("X-Signature"))
+	require.Nil(t, err)
+
+	// the signature checks out at /webhook/verify
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/webhook/verify?scheme=hmac", bytes.NewReader(body))
+	require.Nil(t, err)
+	req.Header.Set("X-Signature", r.Header.Get("X-Signature"))
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if _, v = getJob(t, http.MethodGet, srv.URL+v.URL); v.Callback.Delivered {
+			break
+		}
+	}
+	require.True(t, v.Callback.Delivered)
+	require.Equal(t, http.StatusOK, v.Callback.StatusCode)
+}
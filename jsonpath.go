@@ -0,0 +1,175 @@
+package httpbin
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a parsed JSONPath expression of the subset without filters,
+// unions and slices: $, .name, ['name'], [n] (negative from the end), .*,
+// [*] and .. for descendants.
+type jsonPath []jsonPathStep
+
+type jsonPathStep struct {
+	name       string // member name, unless index or wildcard
+	index      int
+	isIndex    bool
+	wildcard   bool
+	descendant bool // applies to the node and all its descendants
+	src        string
+}
+
+// parseJSONPath parses a JSONPath expression.
+func parseJSONPath(s string) (jsonPath, error) {
+	if !strings.HasPrefix(s, "$") {
+		return nil, fmt.Errorf("jsonpath %q: must start with $", s)
+	}
+	var p jsonPath
+	for i := 1; i < len(s); {
+		st, start := jsonPathStep{}, i
+		switch {
+		case strings.HasPrefix(s[i:], ".."):
+			st.descendant = true
+			i += 2
+		case s[i] == '.':
+			i++
+		case s[i] != '[':
+			return nil, fmt.Errorf("jsonpath %q: unexpected %q at %d", s, s[i], i)
+		}
+
+		switch q := s[i:]; {
+		case strings.HasPrefix(q, "['") || strings.HasPrefix(q, `["`):
+			// a quoted name, which may contain ] and .
+			end := strings.IndexByte(q[2:], q[1])
+			if end < 0 || !strings.HasPrefix(q[2+end+1:], "]") {
+				return nil, fmt.Errorf("jsonpath %q: unterminated name at %d", s, i)
+			}
+			st.name = q[2 : 2+end]
+			i += 2 + end + 2
+		case strings.HasPrefix(q, "["):
+			end := strings.IndexByte(q, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("jsonpath %q: missing ] after %d", s, i)
+			}
+			if sel := strings.TrimSpace(q[1:end]); sel == "*" {
+				st.wildcard = true
+			} else if n, err := strconv.Atoi(sel); err == nil {
+				st.index, st.isIndex = n, true
+			} else {
+				return nil, fmt.Errorf("jsonpath %q: unsupported selector [%s]", s, sel)
+			}
+			i += end + 1
+		default:
+			end := strings.IndexAny(q, ".[")
+			if end < 0 {
+				end = len(q)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("jsonpath %q: missing name at %d", s, i)
+			}
+			if st.name = q[:end]; st.name == "*" {
+				st.name, st.wildcard = "", true
+			}
+			i += end
+		}
+		st.src = s[start:i]
+		p = append(p, st)
+	}
+	return p, nil
+}
+
+// definite reports whether p selects at most one node, having no wildcard or
+// descendant steps.
+func (p jsonPath) definite() bool {
+	for _, st := range p {
+		if st.wildcard || st.descendant {
+			return false
+		}
+	}
+	return true
+}
+
+// eval returns the nodes of the decoded JSON document doc that p selects.
+func (p jsonPath) eval(doc interface{}) []interface{} {
+	return p.trace(doc, nil)
+}
+
+// trace is eval, calling step, if set, with each step of p and the number
+// of nodes selected once it is applied.
+func (p jsonPath) trace(doc interface{}, step func(src string, n int)) []interface{} {
+	nodes := []interface{}{doc}
+	for _, st := range p {
+		var next []interface{}
+		for _, n := range nodes {
+			if !st.descendant {
+				next = append(next, st.apply(n)...)
+				continue
+			}
+			for _, d := range jsonDescendants(n, nil) {
+				next = append(next, st.apply(d)...)
+			}
+		}
+		nodes = next
+		if step != nil {
+			step(st.src, len(nodes))
+		}
+	}
+	return nodes
+}
+
+// apply returns the children of n the step selects.
+func (st jsonPathStep) apply(n interface{}) []interface{} {
+	switch n := n.(type) {
+	case map[string]interface{}:
+		if st.wildcard {
+			return jsonChildren(n)
+		}
+		if v, ok := n[st.name]; ok && !st.isIndex {
+			return []interface{}{v}
+		}
+	case []interface{}:
+		if st.wildcard {
+			return n
+		}
+		i := st.index
+		if i < 0 {
+			i += len(n)
+		}
+		if st.isIndex && i >= 0 && i < len(n) {
+			return []interface{}{n[i]}
+		}
+	}
+	return nil
+}
+
+// jsonChildren returns the member values of m, in the order of their names.
+func jsonChildren(m map[string]interface{}) []interface{} {
+	names := make([]string, 0, len(m))
+	for k := range m {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	children := make([]interface{}, len(names))
+	for i, k := range names {
+		children[i] = m[k]
+	}
+	return children
+}
+
+// jsonDescendants appends n and its descendants, in document order, to all.
+func jsonDescendants(n interface{}, all []interface{}) []interface{} {
+	all = append(all, n)
+	switch n := n.(type) {
+	case map[string]interface{}:
+		for _, c := range jsonChildren(n) {
+			all = jsonDescendants(c, all)
+		}
+	case []interface{}:
+		for _, c := range n {
+			all = jsonDescendants(c, all)
+		}
+	}
+	return all
+}
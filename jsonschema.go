@@ -0,0 +1,525 @@
+package httpbin
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// schemaInputMax is the largest schema or document /validate/json-schema
+	// reads, in bytes.
+	schemaInputMax = 1 << 20
+	// schemaErrorsMax is the most errors /validate/json-schema reports.
+	schemaErrorsMax = 100
+	// schemaDepthMax bounds the nesting of subschemas, through $ref too.
+	schemaDepthMax = 64
+)
+
+// JSONSchemaHandler validates a JSON document against a JSON Schema: the
+// request body against the fixture named by the 'fixture' query parameter,
+// or the "document" part of a multipart/form-data body against its "schema"
+// part or the fixture. It responds with 200 if the document is valid and
+// 422 with the errors, by instance and schema JSON pointer, if it isn't.
+//
+// The keywords of drafts 7 to 2020-12 that apply to a single document are
+// supported, with $ref limited to pointers within the schema; format and
+// other annotations are not checked.
+func JSONSchemaHandler(w http.ResponseWriter, r *http.Request) {
+	var schemaJSON, docJSON []byte
+	var err error
+	if mt, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mt == "multipart/form-data" {
+		schemaJSON, docJSON, err = readSchemaParts(r)
+	} else {
+		docJSON, err = ioutil.ReadAll(io.LimitReader(r.Body, schemaInputMax+1))
+	}
+	if err != nil {
+		writeErrorJSONStatus(w, http.StatusBadRequest, err)
+		return
+	}
+	if name := r.URL.Query().Get("fixture"); name != "" && schemaJSON == nil {
+		f, ok := instance(r).fixtures.get(name)
+		if !ok {
+			writeErrorJSONStatus(w, http.StatusNotFound, fmt.Errorf("no fixture named %q", name))
+			return
+		}
+		schemaJSON = f.Body
+	}
+	switch {
+	case schemaJSON == nil:
+		writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("a schema is required, as the 'fixture' parameter or a 'schema' part"))
+		return
+	case len(schemaJSON) > schemaInputMax || len(docJSON) > schemaInputMax:
+		writeErrorJSONStatus(w, http.StatusRequestEntityTooLarge, fmt.Errorf("schemas and documents must be at most %d bytes", schemaInputMax))
+		return
+	}
+
+	var schema, doc interface{}
+	if err := json.Unmarshal(schemaJSON, &schema); err != nil {
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("malformed schema: %w", err))
+		return
+	}
+	if err := json.Unmarshal(docJSON, &doc); err != nil {
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("malformed document: %w", err))
+		return
+	}
+
+	v := &schemaValidator{root: schema, patterns: make(map[string]*regexp.Regexp)}
+	errs, err := v.check(schema, doc, "", "", 0)
+	if err != nil {
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("unsupported schema: %w", err))
+		return
+	}
+	resp := schemaResponse{Valid: len(errs) == 0, Errors: errs}
+	if resp.Errors == nil {
+		resp.Errors = []schemaError{}
+	}
+	if len(resp.Errors) > schemaErrorsMax {
+		resp.Errors, resp.Truncated = resp.Errors[:schemaErrorsMax], true
+	}
+	if !resp.Valid {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	_ = writeJSON(w, resp) // status may be written already, nothing else to do
+}
+
+// readSchemaParts reads the schema and document parts of a multipart body;
+// the schema is nil if there is no such part.
+func readSchemaParts(r *http.Request) (schema, doc []byte, err error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read multipart body: %w", err)
+	}
+	haveDoc := false
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read multipart body: %w", err)
+		}
+		b, err := ioutil.ReadAll(io.LimitReader(p, schemaInputMax+1))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read multipart body: %w", err)
+		}
+		switch p.FormName() {
+		case "schema":
+			schema = b
+		case "document":
+			doc, haveDoc = b, true
+		}
+	}
+	if !haveDoc {
+		return nil, nil, errors.New("a 'document' part is required")
+	}
+	return schema, doc, nil
+}
+
+// schemaValidator checks documents against a JSON Schema.
+type schemaValidator struct {
+	root     interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+// check returns the errors of inst, at the JSON pointer ipath, against
+// schema, at spath. It fails for schemas it can't apply.
+func (v *schemaValidator) check(schema, inst interface{}, ipath, spath string, depth int) ([]schemaError, error) {
+	if depth > schemaDepthMax {
+		return nil, fmt.Errorf("%s: subschemas nested more than %d deep", spath, schemaDepthMax)
+	}
+	switch s := schema.(type) {
+	case bool:
+		if s {
+			return nil, nil
+		}
+		return []schemaError{{ipath, spath, "false", "no value is allowed"}}, nil
+	case map[string]interface{}:
+		return v.checkObject(s, inst, ipath, spath, depth)
+	}
+	return nil, fmt.Errorf("%s: a schema must be an object or a boolean", spath)
+}
+
+func (v *schemaValidator) checkObject(s map[string]interface{}, inst interface{}, ipath, spath string, depth int) ([]schemaError, error) {
+	var errs []schemaError
+	failAt := func(ipath, keyword, format string, args ...interface{}) {
+		errs = append(errs, schemaError{ipath, spath + "/" + keyword, keyword, fmt.Sprintf(format, args...)})
+	}
+	fail := func(keyword, format string, args ...interface{}) {
+		failAt(ipath, keyword, format, args...)
+	}
+	// sub checks inst or one of its members against a subschema, adding
+	// its errors if add is set, and reports whether it is valid
+	var failure error
+	sub := func(schema, inst interface{}, ipath, spath string, add bool) bool {
+		if failure != nil {
+			return false
+		}
+		e, err := v.check(schema, inst, ipath, spath, depth+1)
+		if err != nil {
+			failure = err
+			return false
+		}
+		if add {
+			errs = append(errs, e...)
+		}
+		return len(e) == 0
+	}
+
+	if ref, ok := s["$ref"].(string); ok {
+		target, err := v.resolve(ref)
+		if err != nil {
+			return nil, fmt.Errorf("%s/$ref: %w", spath, err)
+		}
+		sub(target, inst, ipath, spath+"/$ref", true)
+	}
+
+	if t, ok := s["type"]; ok {
+		var types []string
+		switch t := t.(type) {
+		case string:
+			types = []string{t}
+		case []interface{}:
+			for _, t := range t {
+				if t, ok := t.(string); ok {
+					types = append(types, t)
+				}
+			}
+		}
+		match := false
+		for _, t := range types {
+			match = match || hasJSONType(inst, t)
+		}
+		if !match {
+			fail("type", "%s is not of type %s", jsonTypeOf(inst), strings.Join(types, " or "))
+		}
+	}
+	if enum, ok := s["enum"].([]interface{}); ok {
+		match := false
+		for _, e := range enum {
+			match = match || jsonEqual(inst, e)
+		}
+		if !match {
+			fail("enum", "value is not one of the enumerated values")
+		}
+	}
+	if c, ok := s["const"]; ok && !jsonEqual(inst, c) {
+		fail("const", "value is not the constant value")
+	}
+
+	for i, key := range []string{"allOf", "anyOf", "oneOf"} {
+		list, ok := s[key].([]interface{})
+		if !ok {
+			continue
+		}
+		valid := 0
+		for j, schema := range list {
+			if sub(schema, inst, ipath, spath+"/"+key+"/"+strconv.Itoa(j), i == 0) {
+				valid++
+			}
+		}
+		switch {
+		case key == "anyOf" && valid == 0:
+			fail(key, "value matches none of the schemas")
+		case key == "oneOf" && valid != 1:
+			fail(key, "value matches %d of the schemas instead of exactly one", valid)
+		}
+	}
+	if not, ok := s["not"]; ok && sub(not, inst, ipath, spath+"/not", false) {
+		fail("not", "value matches the schema it must not")
+	}
+	if cond, ok := s["if"]; ok {
+		if sub(cond, inst, ipath, spath+"/if", false) {
+			if then, ok := s["then"]; ok {
+				sub(then, inst, ipath, spath+"/then", true)
+			}
+		} else if els, ok := s["else"]; ok {
+			sub(els, inst, ipath, spath+"/else", true)
+		}
+	}
+
+	switch inst := inst.(type) {
+	case float64:
+		if m, ok := s["minimum"].(float64); ok {
+			if excl, _ := s["exclusiveMinimum"].(bool); excl && inst <= m {
+				fail("minimum", "%v is not greater than %v", inst, m)
+			} else if inst < m {
+				fail("minimum", "%v is less than %v", inst, m)
+			}
+		}
+		if m, ok := s["maximum"].(float64); ok {
+			if excl, _ := s["exclusiveMaximum"].(bool); excl && inst >= m {
+				fail("maximum", "%v is not less than %v", inst, m)
+			} else if inst > m {
+				fail("maximum", "%v is greater than %v", inst, m)
+			}
+		}
+		if m, ok := s["exclusiveMinimum"].(float64); ok && inst <= m {
+			fail("exclusiveMinimum", "%v is not greater than %v", inst, m)
+		}
+		if m, ok := s["exclusiveMaximum"].(float64); ok && inst >= m {
+			fail("exclusiveMaximum", "%v is not less than %v", inst, m)
+		}
+		if m, ok := s["multipleOf"].(float64); ok && m > 0 {
+			if q := inst / m; math.Abs(q-math.Round(q)) > 1e-9 {
+				fail("multipleOf", "%v is not a multiple of %v", inst, m)
+			}
+		}
+
+	case string:
+		n := float64(utf8.RuneCountInString(inst))
+		if m, ok := s["minLength"].(float64); ok && n < m {
+			fail("minLength", "string is shorter than %v characters", m)
+		}
+		if m, ok := s["maxLength"].(float64); ok && n > m {
+			fail("maxLength", "string is longer than %v characters", m)
+		}
+		if p, ok := s["pattern"].(string); ok {
+			re, err := v.pattern(p)
+			if err != nil {
+				return nil, fmt.Errorf("%s/pattern: %w", spath, err)
+			}
+			if !re.MatchString(inst) {
+				fail("pattern", "string does not match %s", p)
+			}
+		}
+
+	case []interface{}:
+		n := float64(len(inst))
+		if m, ok := s["minItems"].(float64); ok && n < m {
+			fail("minItems", "array has fewer than %v items", m)
+		}
+		if m, ok := s["maxItems"].(float64); ok && n > m {
+			fail("maxItems", "array has more than %v items", m)
+		}
+		if unique, _ := s["uniqueItems"].(bool); unique {
+		unique:
+			for i := range inst {
+				for j := i + 1; j < len(inst); j++ {
+					if jsonEqual(inst[i], inst[j]) {
+						fail("uniqueItems", "items %d and %d are equal", i, j)
+						break unique
+					}
+				}
+			}
+		}
+		// prefixItems (2020-12) or items as an array (draft 7) apply to the
+		// first items, and items or additionalItems to the rest
+		prefixKey, restKey := "prefixItems", "items"
+		prefix, ok := s[prefixKey].([]interface{})
+		if list, isList := s["items"].([]interface{}); !ok && isList {
+			prefix, prefixKey, restKey = list, "items", "additionalItems"
+		}
+		for i, item := range inst {
+			switch {
+			case i < len(prefix):
+				sub(prefix[i], item, ipath+"/"+strconv.Itoa(i), spath+"/"+prefixKey+"/"+strconv.Itoa(i), true)
+			case s[restKey] != nil:
+				if _, isList := s[restKey].([]interface{}); !isList {
+					sub(s[restKey], item, ipath+"/"+strconv.Itoa(i), spath+"/"+restKey, true)
+				}
+			}
+		}
+		if contains, ok := s["contains"]; ok {
+			matches := 0
+			for i, item := range inst {
+				if sub(contains, item, ipath+"/"+strconv.Itoa(i), spath+"/contains", false) {
+					matches++
+				}
+			}
+			min, max := 1.0, math.Inf(1)
+			if m, ok := s["minContains"].(float64); ok {
+				min = m
+			}
+			if m, ok := s["maxContains"].(float64); ok {
+				max = m
+			}
+			if float64(matches) < min || float64(matches) > max {
+				fail("contains", "array has %d items matching the schema", matches)
+			}
+		}
+
+	case map[string]interface{}:
+		n := float64(len(inst))
+		if m, ok := s["minProperties"].(float64); ok && n < m {
+			fail("minProperties", "object has fewer than %v properties", m)
+		}
+		if m, ok := s["maxProperties"].(float64); ok && n > m {
+			fail("maxProperties", "object has more than %v properties", m)
+		}
+		if required, ok := s["required"].([]interface{}); ok {
+			for _, name := range required {
+				if name, ok := name.(string); ok {
+					if _, ok := inst[name]; !ok {
+						fail("required", "property %q is missing", name)
+					}
+				}
+			}
+		}
+		if deps, ok := s["dependentRequired"].(map[string]interface{}); ok {
+			for name, required := range deps {
+				if _, ok := inst[name]; !ok {
+					continue
+				}
+				required, _ := required.([]interface{})
+				for _, r := range required {
+					if r, ok := r.(string); ok {
+						if _, ok := inst[r]; !ok {
+							fail("dependentRequired", "property %q is required by %q", r, name)
+						}
+					}
+				}
+			}
+		}
+
+		props, _ := s["properties"].(map[string]interface{})
+		patterns, _ := s["patternProperties"].(map[string]interface{})
+		names := make([]string, 0, len(inst))
+		for name := range inst {
+			names = append(names, name)
+		}
+		sort.Strings(names) // for a stable order of errors
+		for _, name := range names {
+			value, ipath := inst[name], ipath+"/"+escapeJSONPointer(name)
+			matched := false
+			if schema, ok := props[name]; ok {
+				matched = true
+				sub(schema, value, ipath, spath+"/properties/"+escapeJSONPointer(name), true)
+			}
+			for p, schema := range patterns {
+				re, err := v.pattern(p)
+				if err != nil {
+					return nil, fmt.Errorf("%s/patternProperties: %w", spath, err)
+				}
+				if re.MatchString(name) {
+					matched = true
+					sub(schema, value, ipath, spath+"/patternProperties/"+escapeJSONPointer(p), true)
+				}
+			}
+			if names, ok := s["propertyNames"]; ok {
+				sub(names, name, ipath, spath+"/propertyNames", true)
+			}
+			if additional, ok := s["additionalProperties"]; ok && !matched {
+				if additional == false {
+					failAt(ipath, "additionalProperties", "property %q is not allowed", name)
+				} else {
+					sub(additional, value, ipath, spath+"/additionalProperties", true)
+				}
+			}
+		}
+	}
+	return errs, failure
+}
+
+// resolve returns the subschema of the root schema a $ref points to.
+func (v *schemaValidator) resolve(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("only references within the schema are supported, not %q", ref)
+	}
+	ptr, err := url.PathUnescape(ref[1:])
+	if err != nil {
+		return nil, fmt.Errorf("malformed reference %q", ref)
+	}
+	node := v.root
+	if ptr == "" {
+		return node, nil
+	}
+	if !strings.HasPrefix(ptr, "/") {
+		return nil, fmt.Errorf("anchors are not supported, in %q", ref)
+	}
+	for _, tok := range strings.Split(ptr[1:], "/") {
+		tok = strings.NewReplacer("~1", "/", "~0", "~").Replace(tok)
+		switch n := node.(type) {
+		case map[string]interface{}:
+			node = n[tok]
+		case []interface{}:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(n) {
+				return nil, fmt.Errorf("reference %q points nowhere", ref)
+			}
+			node = n[i]
+		default:
+			node = nil
+		}
+		if node == nil {
+			return nil, fmt.Errorf("reference %q points nowhere", ref)
+		}
+	}
+	return node, nil
+}
+
+// pattern compiles a regular expression of the schema once.
+func (v *schemaValidator) pattern(p string) (*regexp.Regexp, error) {
+	if re, ok := v.patterns[p]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(p)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported pattern %q", p)
+	}
+	v.patterns[p] = re
+	return re, nil
+}
+
+// hasJSONType reports whether v, as decoded by encoding/json, is of the JSON
+// Schema type t.
+func hasJSONType(v interface{}, t string) bool {
+	if t == "integer" {
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	}
+	return jsonTypeOf(v) == t || t == "number" && jsonTypeOf(v) == "integer"
+}
+
+func jsonTypeOf(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+// jsonEqual reports whether a and b are equal JSON values.
+func jsonEqual(a, b interface{}) bool {
+	return reflect.DeepEqual(a, b)
+}
+
+// escapeJSONPointer escapes a reference token of a JSON pointer.
+func escapeJSONPointer(s string) string {
+	var buf bytes.Buffer
+	for _, c := range s {
+		switch c {
+		case '~':
+			buf.WriteString("~0")
+		case '/':
+			buf.WriteString("~1")
+		default:
+			buf.WriteRune(c)
+		}
+	}
+	return buf.String()
+}
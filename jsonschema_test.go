@@ -0,0 +1,102 @@
+package httpbin_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+type schemaResult struct {
+	Valid  bool `json:"valid"`
+	Errors []struct {
+		InstancePath string `json:"instance_path"`
+		SchemaPath   string `json:"schema_path"`
+		Keyword      string `json:"keyword"`
+	} `json:"errors"`
+}
+
+const testSchema = `{
+	"type": "object",
+	"required": ["name", "tags"],
+	"properties": {
+		"name": {"type": "string", "minLength": 1},
+		"age": {"$ref": "#/$defs/age"},
+		"tags": {"type": "array", "items": {"enum": ["a", "b"]}, "uniqueItems": true}
+	},
+	"additionalProperties": false,
+	"$defs": {"age": {"type": "integer", "minimum": 0}}
+}`
+
+func postSchema(t *testing.T, url, contentType, body string) (int, schemaResult) {
+	resp, err := http.Post(url, contentType, strings.NewReader(body))
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	var v schemaResult
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	return resp.StatusCode, v
+}
+
+func TestJSONSchema_fixture(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+	httpbin.SetFixture("person", httpbin.Fixture{Body: []byte(testSchema)})
+	defer httpbin.RemoveFixture("person")
+	url := srv.URL + "/validate/json-schema?fixture=person"
+
+	status, v := postSchema(t, url, "application/json", `{"name": "x", "age": 3, "tags": ["a"]}`)
+	require.EqualValues(t, http.StatusOK, status)
+	require.True(t, v.Valid)
+	require.Empty(t, v.Errors)
+
+	status, v = postSchema(t, url, "application/json", `{"name": "", "age": 1.5, "tags": ["a", "c", "a"], "x": 1}`)
+	require.EqualValues(t, http.StatusUnprocessableEntity, status)
+	require.False(t, v.Valid)
+	var got []string
+	for _, e := range v.Errors {
+		got = append(got, e.InstancePath+" "+e.SchemaPath)
+	}
+	require.EqualValues(t, []string{
+		"/age /properties/age/$ref/type",
+		"/name /properties/name/minLength",
+		"/tags /properties/tags/uniqueItems",
+		"/tags/1 /properties/tags/items/enum",
+		"/x /additionalProperties",
+	}, got)
+
+	status, _ = postSchema(t, srv.URL+"/validate/json-schema?fixture=missing", "application/json", `{}`)
+	require.EqualValues(t, http.StatusNotFound, status)
+	status, _ = postSchema(t, url, "application/json", `{`)
+	require.EqualValues(t, http.StatusBadRequest, status)
+}
+
+func TestJSONSchema_multipart(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	post := func(schema, doc string) (int, schemaResult) {
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		mw.WriteField("schema", schema)
+		mw.WriteField("document", doc)
+		mw.Close()
+		return postSchema(t, srv.URL+"/validate/json-schema", mw.FormDataContentType(), body.String())
+	}
+
+	status, v := post(`{"oneOf": [{"type": "integer"}, {"type": "number", "multipleOf": 0.5}]}`, `2`)
+	require.EqualValues(t, http.StatusUnprocessableEntity, status)
+	require.Len(t, v.Errors, 1)
+	require.EqualValues(t, "oneOf", v.Errors[0].Keyword)
+
+	status, v = post(`{"oneOf": [{"type": "integer"}, {"type": "number", "multipleOf": 0.5}]}`, `2.5`)
+	require.EqualValues(t, http.StatusOK, status)
+	require.True(t, v.Valid)
+
+	status, _ = post(`{"$ref": "https://example.com/schema.json"}`, `1`)
+	require.EqualValues(t, http.StatusBadRequest, status)
+}
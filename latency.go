@@ -0,0 +1,253 @@
+package httpbin
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WithRouteLatencies maps request path patterns, in the syntax of path.Match
+// (e.g. "/get" or "/status/*"), to the distribution of the artificial latency
+// added before handling requests to matching paths. When several patterns
+// match, the longest one wins. It defaults to none.
+func WithRouteLatencies(latencies map[string]LatencyDistribution) Option {
+	return func(h *HTTPBin) { h.routeLatencies = latencies }
+}
+
+// LatencyDistribution is a distribution latencies are sampled from.
+type LatencyDistribution interface {
+	Sample() time.Duration
+}
+
+// Fixed always returns d.
+type Fixed time.Duration
+
+// Sample implements LatencyDistribution.
+func (d Fixed) Sample() time.Duration { return time.Duration(d) }
+
+// Uniform is the uniform distribution over [Min, Max].
+type Uniform struct{ Min, Max time.Duration }
+
+// Sample implements LatencyDistribution.
+func (d Uniform) Sample() time.Duration {
+	return d.Min + time.Duration(rand.Int63n(int64(d.Max-d.Min)+1))
+}
+
+// Normal is the normal distribution, truncated at zero.
+type Normal struct{ Mean, StdDev time.Duration }
+
+// Sample implements LatencyDistribution.
+func (d Normal) Sample() time.Duration {
+	return nonNegative(float64(d.Mean) + rand.NormFloat64()*float64(d.StdDev))
+}
+
+// LogNormal is the log-normal distribution with the given mean and standard
+// deviation, the usual shape of service latencies.
+type LogNormal struct{ Mean, StdDev time.Duration }
+
+// Sample implements LatencyDistribution.
+func (d LogNormal) Sample() time.Duration {
+	if d.Mean <= 0 {
+		return 0
+	}
+	m, s := float64(d.Mean), float64(d.StdDev)
+	sigma2 := math.Log(1 + s*s/(m*m))
+	mu := math.Log(m) - sigma2/2
+	return nonNegative(math.Exp(mu + rand.NormFloat64()*math.Sqrt(sigma2)))
+}
+
+// Exponential is the exponential distribution with the given mean.
+type Exponential struct{ Mean time.Duration }
+
+// Sample implements LatencyDistribution.
+func (d Exponential) Sample() time.Duration {
+	return nonNegative(rand.ExpFloat64() * float64(d.Mean))
+}
+
+func nonNegative(f float64) time.Duration {
+	if f < 0 {
+		return 0
+	}
+	return time.Duration(f)
+}
+
+// ParseLatencyDistribution parses a distribution written as a duration
+// ("50ms", a fixed latency) or as one of fixed(d), uniform(min, max),
+// normal(mean, stddev), lognormal(mean, stddev) or exponential(mean).
+func ParseLatencyDistribution(s string) (LatencyDistribution, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexByte(s, '(')
+	if i < 0 {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid latency %q: %w", s, err)
+		}
+		return Fixed(d), nil
+	}
+	if !strings.HasSuffix(s, ")") {
+		return nil, fmt.Errorf("invalid latency distribution %q", s)
+	}
+	name := strings.TrimSpace(s[:i])
+	var args []time.Duration
+	for _, a := range strings.Split(s[i+1:len(s)-1], ",") {
+		d, err := time.ParseDuration(strings.TrimSpace(a))
+		if err != nil {
+			return nil, fmt.Errorf("invalid latency distribution %q: %w", s, err)
+		}
+		args = append(args, d)
+	}
+
+	want := map[string]int{"fixed": 1, "exponential": 1, "uniform": 2, "normal": 2, "lognormal": 2}
+	n, ok := want[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown latency distribution %q", name)
+	}
+	if len(args) != n {
+		return nil, fmt.Errorf("%s takes %d arguments, got %d", name, n, len(args))
+	}
+	switch name {
+	case "fixed":
+		return Fixed(args[0]), nil
+	case "exponential":
+		return Exponential{args[0]}, nil
+	case "uniform":
+		if args[1] < args[0] {
+			return nil, fmt.Errorf("uniform max %v is less than min %v", args[1], args[0])
+		}
+		return Uniform{args[0], args[1]}, nil
+	case "normal":
+		return Normal{args[0], args[1]}, nil
+	default:
+		return LogNormal{args[0], args[1]}, nil
+	}
+}
+
+// ParseRouteLatencies parses semicolon-separated pattern=distribution pairs,
+// e.g. "/get=lognormal(50ms, 20ms); /status/*=uniform(0s, 1s)", into a value
+// for WithRouteLatencies or RouteLatencies.
+func ParseRouteLatencies(s string) (map[string]LatencyDistribution, error) {
+	return parseLatencies(s, "route latency", "pattern", func(pattern string) error {
+		if _, err := path.Match(pattern, "/"); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		return nil
+	})
+}
+
+// ParseLatencyProfiles parses semicolon-separated name=distribution pairs,
+// e.g. "slow=lognormal(1s, 300ms); degraded=uniform(200ms, 2s)", into a
+// value for WithLatencyProfiles.
+func ParseLatencyProfiles(s string) (map[string]LatencyDistribution, error) {
+	return parseLatencies(s, "latency profile", "name", func(name string) error {
+		if name == "" {
+			return errors.New("latency profile without a name")
+		}
+		return nil
+	})
+}
+
+// parseLatencies parses semicolon-separated key=distribution pairs, with
+// their keys checked by check.
+func parseLatencies(s, what, key string, check func(string) error) (map[string]LatencyDistribution, error) {
+	m := make(map[string]LatencyDistribution)
+	for _, kv := range strings.Split(s, ";") {
+		if strings.TrimSpace(kv) == "" {
+			continue
+		}
+		i := strings.IndexByte(kv, '=')
+		if i < 0 {
+			return nil, fmt.Errorf("invalid %s %q, want %s=distribution", what, kv, key)
+		}
+		k := strings.TrimSpace(kv[:i])
+		if err := check(k); err != nil {
+			return nil, err
+		}
+		d, err := ParseLatencyDistribution(kv[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		m[k] = d
+	}
+	return m, nil
+}
+
+// routeLatency returns the distribution for the longest route latency
+// pattern of h matching p.
+func (h *HTTPBin) routeLatency(p string) LatencyDistribution {
+	var best string
+	var dist LatencyDistribution
+	for pattern, d := range h.routeLatencies {
+		if ok, _ := path.Match(pattern, p); !ok {
+			continue
+		}
+		if dist == nil || len(pattern) > len(best) || len(pattern) == len(best) && pattern < best {
+			best, dist = pattern, d
+		}
+	}
+	return dist
+}
+
+// LatencyProfileHeader is the request header naming the latency profile,
+// among those given to WithLatencyProfiles, to apply to the request.
+const LatencyProfileHeader = "X-Httpbin-Latency-Profile"
+
+// latencyHandler delays requests by a latency sampled from the region
+// latency, if set, plus one sampled from the matching route latency distribution, if
+// any, plus one from the latency profile named by the LatencyProfileHeader,
+// if any, before passing them to h. The delay is reported in the
+// X-Httpbin-Latency response header, and the profile applied echoed in the
+// LatencyProfileHeader. Unknown profiles get 400.
+func latencyHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hb := instance(r)
+		dists := []LatencyDistribution{hb.regionLatency, hb.routeLatency(r.URL.Path), nil}
+		if name := r.Header.Get(LatencyProfileHeader); name != "" {
+			profiles := hb.latencyProfiles
+			dist, ok := profiles[name]
+			if !ok {
+				names := make([]string, 0, len(profiles))
+				for n := range profiles {
+					names = append(names, n)
+				}
+				sort.Strings(names)
+				writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("unknown latency profile %q, want one of [%s]", name, strings.Join(names, ", ")))
+				return
+			}
+			traceEventf(r, "latency: profile %s", name)
+			w.Header().Set(LatencyProfileHeader, name)
+			dists[2] = dist
+		}
+		if dists[0] != nil || dists[1] != nil || dists[2] != nil {
+			var d time.Duration
+			for _, dist := range dists {
+				if dist != nil {
+					d += dist.Sample()
+				}
+			}
+			traceEventf(r, "latency: delaying %s", d)
+			w.Header().Set("X-Httpbin-Latency", d.String())
+			t := time.NewTimer(d)
+			select {
+			case <-t.C:
+			case <-r.Context().Done():
+				t.Stop()
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// withLatency wraps the handler of every route of the router with
+// latencyHandler.
+func withLatency(r *routeMux) {
+	for _, route := range r.routes {
+		route.handler = latencyHandler(route.handler)
+	}
+}
@@ -0,0 +1,109 @@
+package httpbin_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLatencyDistribution(t *testing.T) {
+	cases := map[string]httpbin.LatencyDistribution{
+		"50ms":                    httpbin.Fixed(50 * time.Millisecond),
+		"fixed(1s)":               httpbin.Fixed(time.Second),
+		"uniform(10ms, 20ms)":     httpbin.Uniform{10 * time.Millisecond, 20 * time.Millisecond},
+		"normal(50ms,5ms)":        httpbin.Normal{50 * time.Millisecond, 5 * time.Millisecond},
+		" lognormal(50ms, 20ms) ": httpbin.LogNormal{50 * time.Millisecond, 20 * time.Millisecond},
+		"exponential(100ms)":      httpbin.Exponential{100 * time.Millisecond},
+	}
+	for s, want := range cases {
+		d, err := httpbin.ParseLatencyDistribution(s)
+		require.Nil(t, err, s)
+		require.Equal(t, want, d, s)
+	}
+
+	for _, s := range []string{"", "fast", "pareto(1s)", "uniform(1s)", "uniform(2s, 1s)", "normal(1s, x)", "fixed(1s"} {
+		_, err := httpbin.ParseLatencyDistribution(s)
+		require.NotNil(t, err, s)
+	}
+}
+
+func TestLogNormal_mean(t *testing.T) {
+	d := httpbin.LogNormal{50 * time.Millisecond, 20 * time.Millisecond}
+	var sum time.Duration
+	const n = 20000
+	for i := 0; i < n; i++ {
+		sum += d.Sample()
+	}
+	require.InEpsilon(t, float64(50*time.Millisecond), float64(sum/n), 0.05)
+}
+
+func TestRouteLatencies(t *testing.T) {
+	latencies, err := httpbin.ParseRouteLatencies("/get=200ms; /status/*=0s; /status/4*=300ms")
+	require.Nil(t, err)
+	srv := httptest.NewServer(httpbin.New(httpbin.WithRouteLatencies(latencies)).Handler())
+	defer srv.Close()
+
+	s := time.Now()
+	resp, err := http.Get(srv.URL + "/get")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.True(t, time.Since(s) >= 200*time.Millisecond)
+	require.Equal(t, "200ms", resp.Header.Get("X-Httpbin-Latency"))
+
+	resp, err = http.Get(srv.URL + "/status/418")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, "300ms", resp.Header.Get("X-Httpbin-Latency"))
+
+	resp, err = http.Get(srv.URL + "/status/200")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, "0s", resp.Header.Get("X-Httpbin-Latency"))
+
+	resp, err = http.Get(srv.URL + "/ip")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Empty(t, resp.Header.Get("X-Httpbin-Latency"))
+}
+
+func TestLatencyProfiles(t *testing.T) {
+	profiles, err := httpbin.ParseLatencyProfiles("slow=250ms; fast=0s")
+	require.Nil(t, err)
+	srv := httptest.NewServer(httpbin.New(httpbin.WithLatencyProfiles(profiles)).Handler())
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		profile string
+		status  int
+		min     time.Duration
+	}{
+		{"", http.StatusOK, 0},
+		{"fast", http.StatusOK, 0},
+		{"slow", http.StatusOK, 250 * time.Millisecond},
+		{"unknown", http.StatusBadRequest, 0},
+	} {
+		req, err := http.NewRequest("GET", srv.URL+"/get", nil)
+		require.Nil(t, err)
+		if tc.profile != "" {
+			req.Header.Set(httpbin.LatencyProfileHeader, tc.profile)
+		}
+		start := time.Now()
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, tc.status, resp.StatusCode, tc.profile)
+		require.True(t, time.Since(start) >= tc.min, tc.profile)
+		if tc.status == http.StatusOK {
+			require.Equal(t, tc.profile, resp.Header.Get(httpbin.LatencyProfileHeader))
+		}
+	}
+
+	for _, s := range []string{"slow", "=1s", "slow=pareto(1s)"} {
+		_, err := httpbin.ParseLatencyProfiles(s)
+		require.NotNil(t, err, s)
+	}
+}
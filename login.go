@@ -0,0 +1,167 @@
+package httpbin
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultLoginUsers are the usernames and passwords /login accepts.
+var defaultLoginUsers = map[string]string{"user": "passwd"}
+
+// defaultLoginSessionsMax is the maximum number of /login sessions
+// remembered.
+const defaultLoginSessionsMax = 10000
+
+// WithLoginUsers sets the usernames and passwords /login accepts. It
+// defaults to user with the password passwd.
+func WithLoginUsers(users map[string]string) Option {
+	return func(h *HTTPBin) { h.loginUsers = users }
+}
+
+// WithLoginSessionsMax sets the maximum number of /login sessions
+// remembered; logging in more forgets the oldest ones, whose cookies then
+// get 401. It defaults to 10000.
+func WithLoginSessionsMax(n int) Option {
+	return func(h *HTTPBin) { h.loginSessionsMax = n }
+}
+
+// sessionCookie is the cookie holding the session token issued by /login.
+const sessionCookie = "httpbin_session"
+
+// sessionStore tracks the sessions issued by /login.
+type sessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*session
+	order    []string // login order, oldest first
+}
+
+type session struct {
+	user    string
+	created time.Time
+}
+
+// LoginHandler serves a login form on GET, and on POST checks the form's
+// username and password against the login users. On success it issues a session
+// cookie and redirects with 303 to the 'next' parameter (a local path,
+// default /me); on failure it serves the form again with 401.
+func LoginHandler(w http.ResponseWriter, r *http.Request) {
+	next := r.FormValue("next")
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		next = prefixed(r, "/me") // only redirect within this server
+	}
+	if r.Method != http.MethodPost {
+		writeLoginForm(w, http.StatusOK, next, "")
+		return
+	}
+
+	user, pass := r.PostFormValue("username"), r.PostFormValue("password")
+	hb := instance(r)
+	want, ok := hb.loginUsers[user]
+	if !ok || subtle.ConstantTimeCompare([]byte(pass), []byte(want)) != 1 {
+		writeLoginForm(w, http.StatusUnauthorized, next, "Invalid username or password.")
+		return
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to generate session token: %w", err))
+		return
+	}
+	token := hex.EncodeToString(b)
+	sessions := hb.sessions
+	sessions.mu.Lock()
+	sessions.sessions[token] = &session{user: user, created: time.Now()}
+	sessions.order = append(sessions.order, token)
+	for len(sessions.order) > hb.loginSessionsMax {
+		delete(sessions.sessions, sessions.order[0])
+		sessions.order = sessions.order[1:]
+	}
+	sessions.mu.Unlock()
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     prefixed(r, "/"),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	w.Header().Set("Location", next)
+	w.WriteHeader(http.StatusSeeOther)
+}
+
+func writeLoginForm(w http.ResponseWriter, status int, next, msg string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	if msg != "" {
+		msg = `<p class="error">` + html.EscapeString(msg) + "</p>\n"
+	}
+	fmt.Fprintf(w, `<!DOCTYPE html>
+<html>
+  <head><title>Log in</title></head>
+  <body>
+    %s<form method="post" action="login">
+      <input type="hidden" name="next" value="%s">
+      <label>Username <input type="text" name="username" autocomplete="username"></label>
+      <label>Password <input type="password" name="password" autocomplete="current-password"></label>
+      <button type="submit">Log in</button>
+    </form>
+  </body>
+</html>
+`, msg, html.EscapeString(next))
+}
+
+// MeHandler returns the user of the session cookie issued by /login, or 401
+// without a valid one.
+func MeHandler(w http.ResponseWriter, r *http.Request) {
+	var s session
+	ok := false
+	if c, err := r.Cookie(sessionCookie); err == nil {
+		sessions := instance(r).sessions
+		sessions.mu.Lock()
+		if p := sessions.sessions[c.Value]; p != nil {
+			s, ok = *p, true
+		}
+		sessions.mu.Unlock()
+	}
+	if !ok {
+		writeErrorJSONStatus(w, http.StatusUnauthorized, errors.New("not logged in, see /login"))
+		return
+	}
+	v := meResponse{
+		Authenticated: true,
+		User:          s.user,
+		LoggedInAt:    s.created.UTC().Format(time.RFC3339Nano),
+	}
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
+
+// LogoutHandler ends the session of the /login session cookie, if any,
+// deletes the cookie and redirects with 303 to /login.
+func LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	if c, err := r.Cookie(sessionCookie); err == nil {
+		sessions := instance(r).sessions
+		sessions.mu.Lock()
+		delete(sessions.sessions, c.Value) // its order entry goes when it is evicted
+		sessions.mu.Unlock()
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    "",
+		Path:     prefixed(r, "/"),
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	w.Header().Set("Location", prefixed(r, "/login"))
+	w.WriteHeader(http.StatusSeeOther)
+}
@@ -0,0 +1,72 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogin(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	jar, _ := cookiejar.New(nil)
+	c := &http.Client{Jar: jar}
+	me := func() int {
+		resp, err := c.Get(srv.URL + "/me")
+		require.Nil(t, err)
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			var v struct {
+				User string `json:"user"`
+			}
+			require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+			require.Equal(t, "user", v.User)
+		}
+		return resp.StatusCode
+	}
+	require.Equal(t, http.StatusUnauthorized, me())
+
+	resp, err := c.Get(srv.URL + "/login")
+	require.Nil(t, err)
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Contains(t, string(b), `name="password"`)
+
+	resp, err = c.PostForm(srv.URL+"/login", url.Values{"username": {"user"}, "password": {"wrong"}})
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, err = c.PostForm(srv.URL+"/login", url.Values{"username": {"user"}, "password": {"passwd"}})
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "/me", resp.Request.URL.Path, "should follow the redirect to /me")
+	require.Equal(t, http.StatusOK, me())
+
+	resp, err = c.Get(srv.URL + "/logout")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, me())
+}
+
+func TestLogin_next(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for next, want := range map[string]string{"/get": "/get", "//evil.example": "/me", "http://evil.example/": "/me"} {
+		resp, err := noRedirectClient().Post(srv.URL+"/login", "application/x-www-form-urlencoded",
+			strings.NewReader(url.Values{"username": {"user"}, "password": {"passwd"}, "next": {next}}.Encode()))
+		require.NotNil(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusSeeOther, resp.StatusCode, next)
+		require.Equal(t, want, resp.Header.Get("Location"), next)
+	}
+}
@@ -0,0 +1,185 @@
+package httpbin
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultMaintenanceBypassHeader is the request header that, with the
+// bypass value maintenance mode was started with, gets requests served as
+// usual.
+const defaultMaintenanceBypassHeader = "X-Maintenance-Bypass"
+
+// WithMaintenanceToken sets the bearer token POST and DELETE requests to
+// /maintenance require to start and end maintenance mode. If empty, the
+// default, maintenance mode can only be started with StartMaintenance.
+func WithMaintenanceToken(token string) Option {
+	return func(h *HTTPBin) { h.maintenanceToken = token }
+}
+
+// WithMaintenanceBypassHeader sets the request header that, with the bypass
+// value, gets requests served as usual during maintenance mode. It defaults
+// to X-Maintenance-Bypass.
+func WithMaintenanceBypassHeader(name string) Option {
+	return func(h *HTTPBin) { h.maintenanceBypassHeader = name }
+}
+
+// maintenanceRetryAfter is the Retry-After of maintenance mode started
+// without one or a duration.
+const maintenanceRetryAfter = 60 * time.Second
+
+// maintenanceState is the maintenance mode in progress, if any.
+type maintenanceState struct {
+	mu         sync.Mutex
+	active     bool
+	ends       time.Time // zero until ended
+	retryAfter time.Duration
+	bypass     string // empty for no bypass
+}
+
+// StartMaintenance makes every endpoint but /maintenance respond 503 with a
+// Retry-After of retryAfter, or the time left if zero, for d, or until
+// EndMaintenance if zero. Requests with bypass, if set, in the maintenance
+// bypass header are served as usual. It applies to the handler of
+// GetMux; use the StartMaintenance method for an HTTPBin from New.
+func StartMaintenance(retryAfter, d time.Duration, bypass string) {
+	defaultBin().maintenance.start(retryAfter, d, bypass)
+}
+
+// EndMaintenance ends the maintenance mode of the handler of GetMux, if in
+// progress.
+func EndMaintenance() {
+	defaultBin().maintenance.end()
+}
+
+// StartMaintenance is like the StartMaintenance function for h.
+func (h *HTTPBin) StartMaintenance(retryAfter, d time.Duration, bypass string) {
+	h.maintenance.start(retryAfter, d, bypass)
+}
+
+// EndMaintenance is like the EndMaintenance function for h.
+func (h *HTTPBin) EndMaintenance() {
+	h.maintenance.end()
+}
+
+func (m *maintenanceState) start(retryAfter, d time.Duration, bypass string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.active, m.ends, m.retryAfter, m.bypass = true, time.Time{}, retryAfter, bypass
+	if d > 0 {
+		m.ends = time.Now().Add(d)
+	}
+}
+
+func (m *maintenanceState) end() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.reset()
+}
+
+// reset ends maintenance mode, with m.mu held.
+func (m *maintenanceState) reset() {
+	m.active, m.ends, m.retryAfter, m.bypass = false, time.Time{}, 0, ""
+}
+
+// state returns whether maintenance mode is on at now, ending it if it is
+// over, and its Retry-After.
+func (m *maintenanceState) state(now time.Time) (bool, time.Duration) {
+	if m.active && !m.ends.IsZero() && !now.Before(m.ends) {
+		m.reset()
+	}
+	retryAfter := m.retryAfter
+	if retryAfter == 0 {
+		if retryAfter = maintenanceRetryAfter; !m.ends.IsZero() {
+			retryAfter = m.ends.Sub(now)
+		}
+	}
+	return m.active, retryAfter
+}
+
+// maintenanceHandler responds 503 with a Retry-After header to the requests
+// to h during maintenance mode, unless they are to /maintenance or carry the
+// bypass value.
+func maintenanceHandler(name string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hb := instance(r)
+		maintenance := hb.maintenance
+		maintenance.mu.Lock()
+		active, retryAfter := maintenance.state(time.Now())
+		bypass := maintenance.bypass
+		maintenance.mu.Unlock()
+		if !active || name == "maintenance" || bypass != "" &&
+			subtle.ConstantTimeCompare([]byte(r.Header.Get(hb.maintenanceBypassHeader)), []byte(bypass)) == 1 {
+			h.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Retry-After", strconv.FormatInt(int64((retryAfter+time.Second-1)/time.Second), 10))
+		w.Header().Set("Cache-Control", "no-store")
+		writeErrorJSONStatus(w, http.StatusServiceUnavailable, errors.New("down for maintenance"))
+	})
+}
+
+// MaintenanceHandler reports maintenance mode. With the maintenance token as
+// a bearer token, POST starts it with the Retry-After in seconds of the
+// 'retry_after' query parameter (default the time left, or 60), for the
+// number of seconds in 'duration' (default until ended) and letting the
+// requests with the 'bypass' value in the maintenance bypass header through,
+// and DELETE ends it.
+func MaintenanceHandler(w http.ResponseWriter, r *http.Request) {
+	hb := instance(r)
+	switch r.Method {
+	case http.MethodPost, http.MethodDelete:
+		if hb.maintenanceToken == "" {
+			writeErrorJSONStatus(w, http.StatusForbidden, errors.New("maintenance mode can't be started over HTTP"))
+			return
+		}
+		if !checkBearerToken(w, r, hb.maintenanceToken, "httpbin maintenance") {
+			return
+		}
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		q := r.URL.Query()
+		var retryAfter, d time.Duration
+		for _, p := range []struct {
+			name string
+			d    *time.Duration
+		}{{"retry_after", &retryAfter}, {"duration", &d}} {
+			if s := q.Get(p.name); s != "" {
+				n, err := strconv.ParseUint(s, 10, 32)
+				if err != nil {
+					writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("'%s' must be a whole number of seconds", p.name))
+					return
+				}
+				*p.d = time.Duration(n) * time.Second
+			}
+		}
+		hb.StartMaintenance(retryAfter, d, q.Get("bypass"))
+	case http.MethodDelete:
+		hb.EndMaintenance()
+	}
+
+	now := time.Now()
+	maintenance := hb.maintenance
+	maintenance.mu.Lock()
+	active, retryAfter := maintenance.state(now)
+	v := maintenanceResponse{Active: active, BypassHeader: hb.maintenanceBypassHeader}
+	if active {
+		v.RetryAfter = int64((retryAfter + time.Second - 1) / time.Second)
+		v.BypassSet = maintenance.bypass != ""
+		if !maintenance.ends.IsZero() {
+			v.Ends = maintenance.ends.UTC().Format(time.RFC3339Nano)
+			v.RemainingMS = milliseconds(maintenance.ends.Sub(now))
+		}
+	}
+	maintenance.mu.Unlock()
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
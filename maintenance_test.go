@@ -0,0 +1,101 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintenance(t *testing.T) {
+	srv := httptest.NewServer(httpbin.New(httpbin.WithMaintenanceToken("secret")).Handler())
+	defer srv.Close()
+
+	do := func(method, path, bypass string) *http.Response {
+		req, err := http.NewRequest(method, srv.URL+path, nil)
+		require.Nil(t, err)
+		req.Header.Set("Authorization", "Bearer secret")
+		if bypass != "" {
+			req.Header.Set("X-Maintenance-Bypass", bypass)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.Nil(t, err)
+		return resp
+	}
+
+	resp := do(http.MethodPost, "/maintenance?retry_after=120&bypass=let-me-in", "")
+	var v struct {
+		Active     bool
+		RetryAfter int64 `json:"retry_after"`
+		BypassSet  bool  `json:"bypass_set"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.True(t, v.Active)
+	require.EqualValues(t, 120, v.RetryAfter)
+	require.True(t, v.BypassSet)
+
+	resp = do(http.MethodGet, "/get", "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.Equal(t, "120", resp.Header.Get("Retry-After"))
+
+	for _, bypass := range []string{"wrong", "let-me-in"} {
+		resp = do(http.MethodGet, "/get", bypass)
+		resp.Body.Close()
+		require.Equal(t, bypass == "let-me-in", resp.StatusCode == http.StatusOK, bypass)
+	}
+
+	resp = do(http.MethodDelete, "/maintenance", "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp = do(http.MethodGet, "/get", "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestMaintenance_duration(t *testing.T) {
+	defer httpbin.EndMaintenance()
+	srv := testServer()
+	defer srv.Close()
+
+	httpbin.StartMaintenance(0, time.Second, "")
+	resp, err := http.Get(srv.URL + "/get")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.Equal(t, "1", resp.Header.Get("Retry-After"))
+
+	time.Sleep(time.Second)
+	resp, err = http.Get(srv.URL + "/get")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestMaintenance_token(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/maintenance", "text/plain", nil)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	srv = httptest.NewServer(httpbin.New(httpbin.WithMaintenanceToken("secret")).Handler())
+	defer srv.Close()
+	resp, err = http.Post(srv.URL+"/maintenance", "text/plain", nil)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, err = http.Get(srv.URL + "/get")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
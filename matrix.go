@@ -0,0 +1,122 @@
+package httpbin
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// matrixOutcome is one entry of a /matrix spec.
+type matrixOutcome struct {
+	name    string // status code, "timeout", "reset" or "close"
+	status  int
+	latency time.Duration
+	weight  float64
+}
+
+// parseMatrixSpec parses comma-separated outcome[@latency][:weight] entries,
+// e.g. "200@10ms:0.8,500@5ms:0.1,timeout:0.1". Weights default to 1 and are
+// relative to their sum.
+func parseMatrixSpec(spec string) ([]matrixOutcome, error) {
+	var outcomes []matrixOutcome
+	var total float64
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		o := matrixOutcome{weight: 1}
+		if i := strings.LastIndexByte(entry, ':'); i >= 0 {
+			w, err := strconv.ParseFloat(entry[i+1:], 64)
+			if err != nil || w < 0 {
+				return nil, fmt.Errorf("invalid weight in %q", entry)
+			}
+			o.weight, entry = w, entry[:i]
+		}
+		if i := strings.IndexByte(entry, '@'); i >= 0 {
+			d, err := time.ParseDuration(entry[i+1:])
+			if err != nil || d < 0 {
+				return nil, fmt.Errorf("invalid latency in %q", entry)
+			}
+			o.latency, entry = d, entry[:i]
+		}
+		o.name = entry
+		switch entry {
+		case "timeout", "reset", "close":
+		default:
+			code, err := strconv.Atoi(entry)
+			if err != nil || code < 100 || code > 999 {
+				return nil, fmt.Errorf("invalid outcome %q, want a status code, timeout, reset or close", entry)
+			}
+			o.status = code
+		}
+		outcomes = append(outcomes, o)
+		total += o.weight
+	}
+	if total <= 0 {
+		return nil, errors.New("spec has no outcome with a positive weight")
+	}
+	return outcomes, nil
+}
+
+func sampleMatrix(outcomes []matrixOutcome) matrixOutcome {
+	var total float64
+	for _, o := range outcomes {
+		total += o.weight
+	}
+	x := rand.Float64() * total
+	for _, o := range outcomes {
+		if x < o.weight {
+			return o
+		}
+		x -= o.weight
+	}
+	return outcomes[len(outcomes)-1]
+}
+
+// MatrixHandler samples an outcome from the 'spec' query parameter, e.g.
+// "200@10ms:0.8,500@5ms:0.1,timeout:0.1", waits for its latency and then
+// responds as /status/:code would, or with one of the special behaviors:
+// "timeout" holds the request until the client gives up (or the delay
+// limit passes, then responds 504), "reset" resets the connection and "close"
+// closes it without a response. The outcome is reported in the
+// X-Httpbin-Outcome header.
+func MatrixHandler(w http.ResponseWriter, r *http.Request) {
+	outcomes, err := parseMatrixSpec(r.URL.Query().Get("spec"))
+	if err != nil {
+		writeErrorJSONStatus(w, http.StatusBadRequest, err)
+		return
+	}
+	o := sampleMatrix(outcomes)
+
+	t := time.NewTimer(o.latency)
+	select {
+	case <-t.C:
+	case <-r.Context().Done():
+		t.Stop()
+		return
+	}
+
+	switch o.name {
+	case "timeout":
+		t := time.NewTimer(instance(r).delayMax)
+		defer t.Stop()
+		select {
+		case <-t.C:
+			w.Header().Set("X-Httpbin-Outcome", o.name)
+			w.WriteHeader(http.StatusGatewayTimeout)
+		case <-r.Context().Done():
+		}
+	case "reset", "close":
+		if err := closeConn(w, o.name == "reset"); err != nil {
+			writeErrorJSON(w, err)
+		}
+	default:
+		w.Header().Set("X-Httpbin-Outcome", o.name)
+		writeStatus(w, r, o.status)
+	}
+}
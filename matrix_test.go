@@ -0,0 +1,66 @@
+package httpbin_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatrix_status(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	start := time.Now()
+	resp, err := http.Get(srv.URL + "/matrix?spec=200:0,503@50ms:1")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	require.Equal(t, "503", resp.Header.Get("X-Httpbin-Outcome"))
+	require.True(t, time.Since(start) >= 50*time.Millisecond)
+}
+
+func TestMatrix_weights(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	counts := make(map[int]int)
+	for i := 0; i < 200; i++ {
+		resp, err := http.Get(srv.URL + "/matrix?spec=200:3,500")
+		require.Nil(t, err)
+		resp.Body.Close()
+		counts[resp.StatusCode]++
+	}
+	require.Len(t, counts, 2)
+	require.True(t, counts[200] > counts[500])
+}
+
+func TestMatrix_failures(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for _, spec := range []string{"reset", "close"} {
+		_, err := http.Get(srv.URL + "/matrix?spec=" + spec)
+		require.NotNil(t, err, spec)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/matrix?spec=timeout", nil)
+	_, err := http.DefaultClient.Do(req.WithContext(ctx))
+	require.NotNil(t, err)
+}
+
+func TestMatrix_badSpec(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for _, spec := range []string{"", "abc", "200@x", "200:-1", "200:0"} {
+		resp, err := http.Get(srv.URL + "/matrix?spec=" + spec)
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, spec)
+	}
+}
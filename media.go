@@ -0,0 +1,331 @@
+package httpbin
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// mediaDurationMax is the longest /video/mp4 and /audio/wav generate.
+const mediaDurationMax = 30 * time.Second
+
+const (
+	wavSampleRate = 8000
+
+	// mp4 video is mp4Size pixels square, at mp4FPS frames per second
+	mp4Size = 64
+	mp4FPS  = 10
+)
+
+// WAVHandler returns a mono 16-bit PCM WAV file with a sine tone of 'freq'
+// Hz (default 440) lasting 'duration' seconds (default 1).
+func WAVHandler(w http.ResponseWriter, r *http.Request) {
+	d, ok := mediaDuration(w, r)
+	if !ok {
+		return
+	}
+	freq := 440
+	if !intParam(w, r, "freq", 1, wavSampleRate/2, &freq) {
+		return
+	}
+	w.Header().Set("Content-Type", "audio/wav")
+	serveMedia(w, r, wavFile(d, freq))
+}
+
+// MP4Handler returns an MP4 file of a 64x64 H.264 video of a moving
+// gradient lasting 'duration' seconds (default 1), at 10 frames per second.
+func MP4Handler(w http.ResponseWriter, r *http.Request) {
+	d, ok := mediaDuration(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "video/mp4")
+	serveMedia(w, r, mp4File(d))
+}
+
+// mediaCacheKey caches generated media and documents by their parameters.
+func mediaCacheKey(r *http.Request) (string, bool) {
+	return r.URL.Query().Encode(), true
+}
+
+// mediaDuration parses the 'duration' query parameter, in seconds, writing a
+// 400 and reporting false if it is not a positive number up to
+// mediaDurationMax.
+func mediaDuration(w http.ResponseWriter, r *http.Request) (time.Duration, bool) {
+	s := r.URL.Query().Get("duration")
+	if s == "" {
+		return time.Second, true
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || !(f > 0) || f > mediaDurationMax.Seconds() {
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("'duration' must be a number of seconds up to %v", mediaDurationMax.Seconds()))
+		return 0, false
+	}
+	return time.Duration(f * float64(time.Second)), true
+}
+
+// serveMedia serves b with an ETag derived from its content, answering
+// conditional and Range requests.
+func serveMedia(w http.ResponseWriter, r *http.Request, b []byte) {
+	sum := sha256.Sum256(b)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:8])+`"`)
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(b))
+}
+
+// wavFile encodes a tone of freq Hz lasting d.
+func wavFile(d time.Duration, freq int) []byte {
+	n := int(d.Seconds() * wavSampleRate)
+	b := make([]byte, 44+2*n)
+	le := binary.LittleEndian
+	copy(b, "RIFF")
+	le.PutUint32(b[4:], uint32(len(b)-8))
+	copy(b[8:], "WAVEfmt ")
+	le.PutUint32(b[16:], 16)              // fmt chunk size
+	le.PutUint16(b[20:], 1)               // PCM
+	le.PutUint16(b[22:], 1)               // mono
+	le.PutUint32(b[24:], wavSampleRate)   // sample rate
+	le.PutUint32(b[28:], 2*wavSampleRate) // byte rate
+	le.PutUint16(b[32:], 2)               // block align
+	le.PutUint16(b[34:], 16)              // bits per sample
+	copy(b[36:], "data")
+	le.PutUint32(b[40:], uint32(2*n))
+	for i := 0; i < n; i++ {
+		v := 0.5 * math.Sin(2*math.Pi*float64(freq)*float64(i)/wavSampleRate)
+		le.PutUint16(b[44+2*i:], uint16(int16(v*math.MaxInt16)))
+	}
+	return b
+}
+
+// mp4File encodes a video lasting d. Every frame is an IDR picture of I_PCM
+// macroblocks, H.264's uncompressed coding, which keeps the encoder trivial.
+func mp4File(d time.Duration) []byte {
+	n := int(math.Ceil(d.Seconds() * mp4FPS))
+	sps, pps := h264SPS(), h264PPS()
+	var mdat []byte
+	sizes := make([]uint32, n)
+	for i := range sizes {
+		nal := h264IDRFrame(i)
+		sizes[i] = uint32(4 + len(nal))
+		mdat = append(mdat, u32(uint32(len(nal)))...)
+		mdat = append(mdat, nal...)
+	}
+
+	ftyp := mp4Box("ftyp", []byte("isom"), u32(0x200), []byte("isomiso2avc1mp41"))
+	moov := mp4Moov(n, sizes, sps, pps, 0)
+	// the samples are in the single chunk that is mdat's payload
+	moov = mp4Moov(n, sizes, sps, pps, uint32(len(ftyp)+len(moov)+8))
+
+	out := append(ftyp, moov...)
+	return append(out, mp4Box("mdat", mdat)...)
+}
+
+func mp4Moov(n int, sizes []uint32, sps, pps []byte, offset uint32) []byte {
+	const timescale = 1000
+	duration := u32(uint32(n * timescale / mp4FPS))
+	matrix := bytes.Join([][]byte{u32(0x10000), u32(0), u32(0), u32(0), u32(0x10000), u32(0), u32(0), u32(0), u32(0x40000000)}, nil)
+
+	mvhd := mp4FullBox("mvhd", 0, 0, u32(0), u32(0), u32(timescale), duration,
+		u32(0x10000), u16(0x100), make([]byte, 10), matrix, make([]byte, 24), u32(2))
+	tkhd := mp4FullBox("tkhd", 0, 3, u32(0), u32(0), u32(1), u32(0), duration,
+		make([]byte, 8), u16(0), u16(0), u16(0), u16(0), matrix, u32(mp4Size<<16), u32(mp4Size<<16))
+	mdhd := mp4FullBox("mdhd", 0, 0, u32(0), u32(0), u32(timescale), duration, u16(0x55c4), u16(0)) // und
+	hdlr := mp4FullBox("hdlr", 0, 0, u32(0), []byte("vide"), make([]byte, 12), []byte("VideoHandler\x00"))
+	vmhd := mp4FullBox("vmhd", 0, 1, make([]byte, 8))
+	dinf := mp4Box("dinf", mp4FullBox("dref", 0, 0, u32(1), mp4FullBox("url ", 0, 1)))
+
+	avcC := mp4Box("avcC", []byte{1, sps[1], sps[2], sps[3], 0xff, 0xe1}, u16(uint16(len(sps))), sps,
+		[]byte{1}, u16(uint16(len(pps))), pps)
+	compressor := make([]byte, 32)
+	avc1 := mp4Box("avc1", make([]byte, 6), u16(1), make([]byte, 16), u16(mp4Size), u16(mp4Size),
+		u32(0x480000), u32(0x480000), u32(0), u16(1), compressor, u16(0x18), u16(0xffff), avcC)
+	stsd := mp4FullBox("stsd", 0, 0, u32(1), avc1)
+	stts := mp4FullBox("stts", 0, 0, u32(1), u32(uint32(n)), u32(timescale/mp4FPS))
+	stsc := mp4FullBox("stsc", 0, 0, u32(1), u32(1), u32(uint32(n)), u32(1))
+	var sz []byte
+	for _, s := range sizes {
+		sz = append(sz, u32(s)...)
+	}
+	stsz := mp4FullBox("stsz", 0, 0, u32(0), u32(uint32(n)), sz)
+	stco := mp4FullBox("stco", 0, 0, u32(1), u32(offset))
+	stbl := mp4Box("stbl", stsd, stts, stsc, stsz, stco)
+
+	minf := mp4Box("minf", vmhd, dinf, stbl)
+	mdia := mp4Box("mdia", mdhd, hdlr, minf)
+	return mp4Box("moov", mvhd, mp4Box("trak", tkhd, mdia))
+}
+
+func mp4Box(typ string, parts ...[]byte) []byte {
+	b := append(u32(0), typ...)
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	binary.BigEndian.PutUint32(b, uint32(len(b)))
+	return b
+}
+
+func mp4FullBox(typ string, version byte, flags uint32, parts ...[]byte) []byte {
+	vf := u32(flags)
+	vf[0] = version
+	return mp4Box(typ, append([][]byte{vf}, parts...)...)
+}
+
+func u32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+func u16(v uint16) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, v)
+	return b
+}
+
+// h264SPS returns the sequence parameter set NAL unit: Baseline profile,
+// level 1.3, picture order count type 2, mp4Size pixels square.
+func h264SPS() []byte {
+	var bw bitWriter
+	bw.bits(66, 8)   // profile_idc: Baseline
+	bw.bits(0xc0, 8) // constraint_set0_flag, constraint_set1_flag
+	bw.bits(13, 8)   // level_idc
+	bw.ue(0)         // seq_parameter_set_id
+	bw.ue(0)         // log2_max_frame_num_minus4
+	bw.ue(2)         // pic_order_cnt_type
+	bw.ue(1)         // max_num_ref_frames
+	bw.bits(0, 1)    // gaps_in_frame_num_value_allowed_flag
+	bw.ue(mp4Size/16 - 1)
+	bw.ue(mp4Size/16 - 1)
+	bw.bits(1, 1) // frame_mbs_only_flag
+	bw.bits(1, 1) // direct_8x8_inference_flag
+	bw.bits(0, 1) // frame_cropping_flag
+	bw.bits(0, 1) // vui_parameters_present_flag
+	return h264NAL(3, 7, bw.trailing())
+}
+
+// h264PPS returns the picture parameter set NAL unit, for CAVLC.
+func h264PPS() []byte {
+	var bw bitWriter
+	bw.ue(0)      // pic_parameter_set_id
+	bw.ue(0)      // seq_parameter_set_id
+	bw.bits(0, 1) // entropy_coding_mode_flag
+	bw.bits(0, 1) // bottom_field_pic_order_in_frame_present_flag
+	bw.ue(0)      // num_slice_groups_minus1
+	bw.ue(0)      // num_ref_idx_l0_default_active_minus1
+	bw.ue(0)      // num_ref_idx_l1_default_active_minus1
+	bw.bits(0, 1) // weighted_pred_flag
+	bw.bits(0, 2) // weighted_bipred_idc
+	bw.se(0)      // pic_init_qp_minus26
+	bw.se(0)      // pic_init_qs_minus26
+	bw.se(0)      // chroma_qp_index_offset
+	bw.bits(0, 1) // deblocking_filter_control_present_flag
+	bw.bits(0, 1) // constrained_intra_pred_flag
+	bw.bits(0, 1) // redundant_pic_cnt_present_flag
+	return h264NAL(3, 8, bw.trailing())
+}
+
+// h264IDRFrame returns frame i as an IDR slice NAL unit of I_PCM
+// macroblocks, a diagonal gradient moving with i.
+func h264IDRFrame(i int) []byte {
+	var bw bitWriter
+	bw.ue(0)           // first_mb_in_slice
+	bw.ue(7)           // slice_type: I
+	bw.ue(0)           // pic_parameter_set_id
+	bw.bits(0, 4)      // frame_num
+	bw.ue(uint(i % 2)) // idr_pic_id, differing between consecutive IDRs
+	bw.bits(0, 1)      // no_output_of_prior_pics_flag
+	bw.bits(0, 1)      // long_term_reference_flag
+	bw.se(0)           // slice_qp_delta
+
+	const mbs = mp4Size / 16
+	for mb := 0; mb < mbs*mbs; mb++ {
+		x0, y0 := mb%mbs*16, mb/mbs*16
+		bw.ue(25) // mb_type: I_PCM
+		bw.align()
+		for y := 0; y < 16; y++ {
+			for x := 0; x < 16; x++ {
+				bw.bits(uint(16+(x0+x+y0+y+4*i)*219/(2*mp4Size)%220), 8)
+			}
+		}
+		for c := 0; c < 2; c++ {
+			for y := 0; y < 8; y++ {
+				for x := 0; x < 8; x++ {
+					bw.bits(uint(64+c*64+(x0/2+x)), 8)
+				}
+			}
+		}
+	}
+	return h264NAL(3, 5, bw.trailing())
+}
+
+// h264NAL wraps an RBSP into a NAL unit, with emulation prevention bytes so
+// that it never contains a start code.
+func h264NAL(refIdc, typ byte, rbsp []byte) []byte {
+	b := []byte{refIdc<<5 | typ}
+	zeros := 0
+	for _, c := range rbsp {
+		if zeros >= 2 && c <= 3 {
+			b = append(b, 3)
+			zeros = 0
+		}
+		b = append(b, c)
+		if c == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+	}
+	return b
+}
+
+// bitWriter writes the bit fields of H.264 syntax structures.
+type bitWriter struct {
+	b []byte
+	n uint // bits used in the last byte, 0 if it is full
+}
+
+func (bw *bitWriter) bits(v uint, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if bw.n == 0 {
+			bw.b = append(bw.b, 0)
+		}
+		bw.b[len(bw.b)-1] |= byte(v>>uint(i)&1) << (7 - bw.n)
+		bw.n = (bw.n + 1) % 8
+	}
+}
+
+// ue writes v as an unsigned Exp-Golomb code.
+func (bw *bitWriter) ue(v uint) {
+	n := 0
+	for x := v + 1; x > 1; x >>= 1 {
+		n++
+	}
+	bw.bits(0, n)
+	bw.bits(v+1, n+1)
+}
+
+// se writes v as a signed Exp-Golomb code.
+func (bw *bitWriter) se(v int) {
+	if v > 0 {
+		bw.ue(uint(2*v - 1))
+	} else {
+		bw.ue(uint(-2 * v))
+	}
+}
+
+// align pads with zero bits to a byte boundary.
+func (bw *bitWriter) align() {
+	bw.n = 0
+}
+
+// trailing writes the RBSP trailing bits and returns the RBSP.
+func (bw *bitWriter) trailing() []byte {
+	bw.bits(1, 1)
+	bw.align()
+	return bw.b
+}
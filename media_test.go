@@ -0,0 +1,62 @@
+package httpbin_test
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWAV(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/audio/wav?duration=0.5&freq=1000")
+	require.Nil(t, err)
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Nil(t, err)
+	require.Equal(t, "audio/wav", resp.Header.Get("Content-Type"))
+	require.Equal(t, "RIFF", string(b[:4]))
+	require.Equal(t, "WAVEfmt ", string(b[8:16]))
+	require.Equal(t, uint32(8000), binary.LittleEndian.Uint32(b[24:]))
+	require.Equal(t, 44+2*4000, len(b))
+
+	resp, err = http.Get(srv.URL + "/audio/wav?duration=31")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestMP4(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/video/mp4?duration=2")
+	require.Nil(t, err)
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Nil(t, err)
+	require.Equal(t, "video/mp4", resp.Header.Get("Content-Type"))
+
+	// the top-level boxes span the file
+	var boxes []string
+	for rest := b; len(rest) > 0; {
+		size := binary.BigEndian.Uint32(rest)
+		require.True(t, size >= 8 && int(size) <= len(rest))
+		boxes = append(boxes, string(rest[4:8]))
+		rest = rest[size:]
+	}
+	require.Equal(t, []string{"ftyp", "moov", "mdat"}, boxes)
+
+	req, _ := http.NewRequest("GET", srv.URL+"/video/mp4?duration=2", nil)
+	req.Header.Set("Range", "bytes=4-7")
+	resp, err = http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	part, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	require.Equal(t, "ftyp", string(part))
+}
@@ -0,0 +1,123 @@
+package httpbin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http"
+	"net/textproto"
+	"strconv"
+	"strings"
+)
+
+// mimeAttachmentMax is the largest attachment /mime generates.
+const mimeAttachmentMax = 1 << 20
+
+// MIMEHandler returns an email-style MIME message, the same for the same
+// parameters: a multipart/mixed message with a multipart/alternative text
+// and HTML body followed by a base64-encoded attachment. The 'parts' query
+// parameter selects which of text, html and attachment to include (default
+// all), 'size' the attachment's size in bytes (default 1024) and 'filename'
+// its name. With 'format=multipart' the multipart body is returned with a
+// multipart/mixed Content-Type; by default the whole message, headers
+// included, is returned as message/rfc822.
+func MIMEHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	parts := map[string]bool{"text": true, "html": true, "attachment": true}
+	if s := q.Get("parts"); s != "" {
+		parts = make(map[string]bool)
+		for _, p := range strings.Split(s, ",") {
+			switch p = strings.TrimSpace(p); p {
+			case "text", "html", "attachment":
+				parts[p] = true
+			default:
+				writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("unknown part %q, want text, html or attachment", p))
+				return
+			}
+		}
+	}
+	size := 1024
+	if s := q.Get("size"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 || n > mimeAttachmentMax {
+			writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("'size' must be between 0 and %d", mimeAttachmentMax))
+			return
+		}
+		size = n
+	}
+	filename := q.Get("filename")
+	if filename == "" {
+		filename = "attachment.bin"
+	}
+	format := q.Get("format")
+	if format != "" && format != "rfc822" && format != "multipart" {
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("unknown format %q, want rfc822 or multipart", format))
+		return
+	}
+
+	var body bytes.Buffer
+	mixed := multipart.NewWriter(&body)
+	mixed.SetBoundary("httpbin-mixed-boundary")
+	if parts["text"] || parts["html"] {
+		alt := &bytes.Buffer{}
+		aw := multipart.NewWriter(alt)
+		aw.SetBoundary("httpbin-alternative-boundary")
+		if parts["text"] {
+			writeQuotedPrintablePart(aw, "text/plain; charset=utf-8", "Hello from go-httpbin.\r\n\r\nThis is the plain text part.\r\n")
+		}
+		if parts["html"] {
+			writeQuotedPrintablePart(aw, "text/html; charset=utf-8", "<!DOCTYPE html>\r\n<html><body><p>Hello from <b>go-httpbin</b>.</p><p>This is the HTML part.</p></body></html>\r\n")
+		}
+		aw.Close()
+		pw, _ := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {"multipart/alternative; boundary=" + aw.Boundary()},
+		})
+		pw.Write(alt.Bytes())
+	}
+	if parts["attachment"] {
+		pw, _ := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {"application/octet-stream"},
+			"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", filename)},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		data := make([]byte, size)
+		for i := range data {
+			data[i] = byte(i)
+		}
+		enc := base64.StdEncoding.EncodeToString(data)
+		for len(enc) > 76 {
+			fmt.Fprintf(pw, "%s\r\n", enc[:76])
+			enc = enc[76:]
+		}
+		fmt.Fprintf(pw, "%s\r\n", enc)
+	}
+	mixed.Close()
+
+	contentType := "multipart/mixed; boundary=" + mixed.Boundary()
+	if format == "multipart" {
+		w.Header().Set("Content-Type", contentType)
+		w.Write(body.Bytes())
+		return
+	}
+	w.Header().Set("Content-Type", "message/rfc822")
+	fmt.Fprint(w, "From: go-httpbin <httpbin@example.com>\r\n"+
+		"To: client <client@example.com>\r\n"+
+		"Subject: go-httpbin MIME fixture\r\n"+
+		"Date: Mon, 02 Jan 2006 15:04:05 +0000\r\n"+
+		"Message-ID: <fixture@httpbin.example.com>\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: "+contentType+"\r\n\r\n")
+	w.Write(body.Bytes())
+}
+
+func writeQuotedPrintablePart(mw *multipart.Writer, contentType, s string) {
+	pw, _ := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	qw := quotedprintable.NewWriter(pw)
+	qw.Write([]byte(s))
+	qw.Close()
+}
@@ -0,0 +1,90 @@
+package httpbin_test
+
+import (
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/mail"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMIME_rfc822(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/mime?size=100&filename=data.bin")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "message/rfc822", resp.Header.Get("Content-Type"))
+
+	msg, err := mail.ReadMessage(resp.Body)
+	require.Nil(t, err)
+	require.Equal(t, "go-httpbin MIME fixture", msg.Header.Get("Subject"))
+	mt, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.Nil(t, err)
+	require.Equal(t, "multipart/mixed", mt)
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	p, err := mr.NextPart()
+	require.Nil(t, err)
+	mt, params, err = mime.ParseMediaType(p.Header.Get("Content-Type"))
+	require.Nil(t, err)
+	require.Equal(t, "multipart/alternative", mt)
+	ar := multipart.NewReader(p, params["boundary"])
+	var types []string
+	for {
+		ap, err := ar.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.Nil(t, err)
+		types = append(types, ap.Header.Get("Content-Type"))
+	}
+	require.Equal(t, []string{"text/plain; charset=utf-8", "text/html; charset=utf-8"}, types)
+
+	p, err = mr.NextPart()
+	require.Nil(t, err)
+	require.Equal(t, "data.bin", p.FileName())
+	b, err := ioutil.ReadAll(base64.NewDecoder(base64.StdEncoding, p))
+	require.Nil(t, err)
+	require.Len(t, b, 100)
+
+	_, err = mr.NextPart()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestMIME_multipart(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/mime?parts=attachment&format=multipart")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	mt, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	require.Nil(t, err)
+	require.Equal(t, "multipart/mixed", mt)
+
+	mr := multipart.NewReader(resp.Body, params["boundary"])
+	p, err := mr.NextPart()
+	require.Nil(t, err)
+	require.Equal(t, "attachment.bin", p.FileName())
+	_, err = mr.NextPart()
+	require.Equal(t, io.EOF, err)
+}
+
+func TestMIME_badParams(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for _, q := range []string{"parts=video", "size=-1", "format=mbox"} {
+		resp, err := http.Get(srv.URL + "/mime?" + q)
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, q)
+	}
+}
@@ -0,0 +1,146 @@
+package httpbin
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// MirrorTemplateHeader is the request header naming the template /mirror
+// renders, among those given to WithMirrorTemplates. Without it, /mirror
+// renders the template named "default".
+const MirrorTemplateHeader = "X-Httpbin-Mirror-Template"
+
+// MirrorFuncs returns the functions /mirror templates can call, when parsed
+// with them:
+//
+//   - json returns its argument as JSON, e.g. {{.name | json}};
+//   - uuid returns a random version 4 UUID;
+//   - now returns the current time in RFC 3339 format, in UTC;
+//   - header returns the first value of the named request header;
+//   - query returns the first value of the named query parameter.
+func MirrorFuncs() template.FuncMap {
+	return template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"uuid": func() (string, error) {
+			b := make([]byte, 16)
+			if _, err := rand.Read(b); err != nil {
+				return "", err
+			}
+			b[6] = b[6]&0x0f | 0x40
+			b[8] = b[8]&0x3f | 0x80
+			return fmt.Sprintf("%x-%x-%x-%x-%x", b[:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+		},
+		"now": func() string {
+			return time.Now().UTC().Format(time.RFC3339Nano)
+		},
+		// header and query are bound to the request when rendering
+		"header": func(string) string { return "" },
+		"query":  func(string) string { return "" },
+	}
+}
+
+// WithMirrorTemplates sets the templates /mirror renders, by name. Parse
+// them with Funcs(MirrorFuncs()) to call its functions, e.g.
+//
+//	template.Must(template.New("").Funcs(httpbin.MirrorFuncs()).ParseGlob("templates/*.tmpl"))
+func WithMirrorTemplates(t *template.Template) Option {
+	return func(h *HTTPBin) { h.mirrorTemplates = t }
+}
+
+// definedTemplate reports whether t has a non-empty definition.
+func definedTemplate(t *template.Template) bool {
+	return t != nil && t.Tree != nil && !parse.IsEmptyTree(t.Tree.Root)
+}
+
+// mirrorTemplateNames returns the names of the defined templates of t.
+func mirrorTemplateNames(t *template.Template) []string {
+	var names []string
+	if t == nil {
+		return names
+	}
+	for _, tt := range t.Templates() {
+		if definedTemplate(tt) {
+			names = append(names, tt.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MirrorHandler renders the template named by the MirrorTemplateHeader,
+// "default" if it is not set, with the JSON request body as dot, so that a
+// fake API can derive realistic responses from requests. It responds with
+// the status given by the 'status' query parameter (default 200), as
+// application/json if the result is JSON and text/plain otherwise. Unknown
+// templates and bodies that are not JSON get 400, and bodies the template
+// fails on get 422.
+func MirrorHandler(w http.ResponseWriter, r *http.Request) {
+	status := http.StatusOK
+	if !intParam(w, r, "status", 200, 599, &status) {
+		return
+	}
+	name := r.Header.Get(MirrorTemplateHeader)
+	if name == "" {
+		name = "default"
+	}
+	templates := instance(r).mirrorTemplates
+	var t *template.Template
+	if templates != nil {
+		t = templates.Lookup(name)
+	}
+	if !definedTemplate(t) {
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("unknown template %q, want one of [%s]", name, strings.Join(mirrorTemplateNames(templates), ", ")))
+		return
+	}
+
+	var body interface{}
+	if r.Body != nil {
+		dec := json.NewDecoder(r.Body)
+		dec.UseNumber()
+		if err := dec.Decode(&body); err != nil && err != io.EOF {
+			writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("failed to parse JSON body: %w", err))
+			return
+		}
+		if dec.More() {
+			writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("failed to parse JSON body: trailing data"))
+			return
+		}
+	}
+
+	t, err := t.Clone()
+	if err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to clone template: %w", err))
+		return
+	}
+	t.Funcs(template.FuncMap{
+		"header": r.Header.Get,
+		"query":  r.URL.Query().Get,
+	})
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, body); err != nil {
+		writeErrorJSONStatus(w, http.StatusUnprocessableEntity, fmt.Errorf("failed to render template: %w", err))
+		return
+	}
+
+	if json.Valid(buf.Bytes()) {
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	w.Header().Set(MirrorTemplateHeader, name)
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
@@ -0,0 +1,93 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+const mirrorTemplates = `
+{{define "default"}}{"id": {{uuid | json}}, "name": {{.name | json}}, "qty": {{.qty}}, "trace": {{header "X-Trace" | json}}}{{end}}
+{{define "greeting"}}Hello, {{.name}}! ({{query "lang"}}){{end}}
+{{define "broken"}}{{.name.first}}{{end}}
+`
+
+func mirror(t *testing.T, url, tmpl, body string) (*http.Response, string) {
+	req, err := http.NewRequest("POST", url, strings.NewReader(body))
+	require.Nil(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Trace", "t-1")
+	if tmpl != "" {
+		req.Header.Set(httpbin.MirrorTemplateHeader, tmpl)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	require.Nil(t, err)
+	return resp, string(b)
+}
+
+func TestMirror(t *testing.T) {
+	tmpl := template.Must(template.New("").Funcs(httpbin.MirrorFuncs()).Parse(mirrorTemplates))
+	srv := httptest.NewServer(httpbin.New(httpbin.WithMirrorTemplates(tmpl)).Handler())
+	defer srv.Close()
+
+	resp, b := mirror(t, srv.URL+"/mirror?status=201", "", `{"name": "widget", "qty": 12345678901234567890}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, b)
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	require.Equal(t, "default", resp.Header.Get(httpbin.MirrorTemplateHeader))
+	var v struct {
+		ID    string
+		Name  string
+		Qty   json.Number
+		Trace string
+	}
+	require.Nil(t, json.Unmarshal([]byte(b), &v))
+	require.Len(t, v.ID, 36)
+	require.Equal(t, "4", v.ID[14:15])
+	require.Equal(t, "widget", v.Name)
+	require.Equal(t, "12345678901234567890", v.Qty.String())
+	require.Equal(t, "t-1", v.Trace)
+
+	resp, b = mirror(t, srv.URL+"/mirror?lang=en", "greeting", `{"name": "Ada"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/plain; charset=utf-8", resp.Header.Get("Content-Type"))
+	require.Equal(t, "Hello, Ada! (en)", b)
+
+	for _, tc := range []struct {
+		tmpl, body string
+		status     int
+	}{
+		{"missing", `{}`, http.StatusBadRequest},
+		{"greeting", `not json`, http.StatusBadRequest},
+		{"greeting", `{} {}`, http.StatusBadRequest},
+		{"broken", `{"name": "Ada"}`, http.StatusUnprocessableEntity},
+	} {
+		resp, b := mirror(t, srv.URL+"/mirror", tc.tmpl, tc.body)
+		require.Equal(t, tc.status, resp.StatusCode, tc.tmpl+" "+tc.body)
+		require.Contains(t, b, `"error"`)
+	}
+
+	require.Contains(t, string(get(t, srv.URL+"/config")), `"mirror_templates": [
+      "broken",
+      "default",
+      "greeting"
+    ]`)
+}
+
+func TestMirror_noTemplates(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, b := mirror(t, srv.URL+"/mirror", "", `{}`)
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.Contains(t, b, `unknown template \"default\"`)
+}
@@ -0,0 +1,437 @@
+//go:build httpbin_mqtt && !httpbin_nowebsocket
+// +build httpbin_mqtt,!httpbin_nowebsocket
+
+package httpbin
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+func init() {
+	featureRoutes = append(featureRoutes,
+		route{name: "mqtt", path: `/mqtt`, methods: []string{http.MethodGet}, description: "An MQTT 3.1.1 over WebSocket broker that only delivers a client's publications back to itself.", handler: http.HandlerFunc(MQTTHandler)},
+	)
+}
+
+// MQTT control packet types.
+const (
+	mqttConnect     = 1
+	mqttConnack     = 2
+	mqttPublish     = 3
+	mqttPuback      = 4
+	mqttPubrec      = 5
+	mqttPubrel      = 6
+	mqttPubcomp     = 7
+	mqttSubscribe   = 8
+	mqttSuback      = 9
+	mqttUnsubscribe = 10
+	mqttUnsuback    = 11
+	mqttPingreq     = 12
+	mqttPingresp    = 13
+	mqttDisconnect  = 14
+)
+
+// errMQTTMalformed is a protocol violation by the client, which closes the
+// connection.
+var errMQTTMalformed = errors.New("malformed MQTT packet")
+
+// MQTTHandler is an MQTT 3.1.1 over WebSocket (subprotocol "mqtt") echo
+// broker: each connection is a broker of its own, to which the client can
+// subscribe and publish, and which delivers the client's publications back
+// to it if they match its subscriptions. QoS 0 to 2 and retained messages
+// are supported; sessions, wills and authentication are not.
+func MQTTHandler(w http.ResponseWriter, r *http.Request) {
+	c, _ := upgradeWebSocket(w, r, []string{"mqtt"})
+	if c == nil {
+		return
+	}
+	defer c.conn.Close()
+	s := &mqttSession{c: c, subs: make(map[string]byte), retained: make(map[string]mqttMessage)}
+	s.serve()
+}
+
+// mqttMessage is a retained message.
+type mqttMessage struct {
+	qos     byte
+	payload []byte
+}
+
+// mqttSession is the broker of a connection.
+type mqttSession struct {
+	c        *wsConn
+	subs     map[string]byte // granted QoS by topic filter
+	retained map[string]mqttMessage
+	lastID   uint16 // of the packets the server sent
+}
+
+// serve handles the client's packets until the connection is closed.
+func (s *mqttSession) serve() {
+	br := bufio.NewReader(&wsMessageReader{c: s.c})
+	connected := false
+	for {
+		typ, flags, body, err := readMQTTPacket(br, s.c.messageMax)
+		if err == nil && (typ == mqttConnect) == connected {
+			err = errMQTTMalformed // the first packet, and only it, is CONNECT
+		}
+		if err == nil {
+			switch typ {
+			case mqttConnect:
+				connected = true
+				err = s.connect(body)
+			case mqttPublish:
+				err = s.publish(flags, body)
+			case mqttPuback, mqttPubcomp:
+				// acknowledgements of deliveries, which are not retried
+			case mqttPubrec:
+				err = s.ack(mqttPubrel<<4|0x2, body)
+			case mqttPubrel:
+				err = s.ack(mqttPubcomp<<4, body)
+			case mqttSubscribe:
+				err = s.subscribe(flags, body)
+			case mqttUnsubscribe:
+				err = s.unsubscribe(flags, body)
+			case mqttPingreq:
+				err = s.write(mqttPingresp<<4, nil)
+			case mqttDisconnect:
+				s.c.close(1000, "")
+				return
+			default:
+				err = errMQTTMalformed
+			}
+		}
+		if err != nil {
+			if err == errMQTTMalformed {
+				s.c.close(1002, err.Error())
+			}
+			return
+		}
+	}
+}
+
+// connect accepts the CONNECT packet body if it asks for MQTT 3.1.1.
+func (s *mqttSession) connect(body []byte) error {
+	r := &mqttReader{b: body}
+	name, level, flags := r.string(), r.byte(), r.byte()
+	r.uint16() // keep alive, left to the WebSocket idle timeout
+	clientID := r.string()
+	if flags&0x04 != 0 { // will
+		r.string()
+		r.bytes()
+	}
+	if flags&0x80 != 0 {
+		r.string() // user name
+	}
+	if flags&0x40 != 0 {
+		r.bytes() // password
+	}
+	if r.err != nil || name != "MQTT" || flags&0x01 != 0 {
+		return errMQTTMalformed
+	}
+
+	var code byte
+	switch {
+	case level != 4:
+		code = 0x01 // unacceptable protocol level
+	case clientID == "" && flags&0x02 == 0:
+		code = 0x02 // identifier rejected, as there are no sessions to keep
+	}
+	if err := s.write(mqttConnack<<4, []byte{0, code}); err != nil {
+		return err
+	}
+	if code != 0 {
+		s.c.close(1000, "")
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+// publish acknowledges a PUBLISH packet and delivers it back if the client
+// subscribed to its topic.
+func (s *mqttSession) publish(flags byte, body []byte) error {
+	qos, retain := flags>>1&0x3, flags&0x1 != 0
+	r := &mqttReader{b: body}
+	topic := r.string()
+	var id uint16
+	if qos > 0 {
+		id = r.uint16()
+	}
+	if r.err != nil || qos > 2 || qos > 0 && id == 0 || topic == "" || strings.ContainsAny(topic, "+#") {
+		return errMQTTMalformed
+	}
+	payload := r.b
+
+	switch qos {
+	case 1:
+		if err := s.ack(mqttPuback<<4, body[len(topic)+2:]); err != nil {
+			return err
+		}
+	case 2:
+		if err := s.ack(mqttPubrec<<4, body[len(topic)+2:]); err != nil {
+			return err
+		}
+	}
+	if retain {
+		if len(payload) == 0 {
+			delete(s.retained, topic)
+		} else {
+			s.retained[topic] = mqttMessage{qos, append([]byte(nil), payload...)}
+		}
+	}
+
+	// overlapping subscriptions get a single delivery, at the highest QoS
+	matched, granted := false, byte(0)
+	for filter, q := range s.subs {
+		if mqttMatch(filter, topic) {
+			matched = true
+			if q > granted {
+				granted = q
+			}
+		}
+	}
+	if !matched {
+		return nil
+	}
+	if granted > qos {
+		granted = qos
+	}
+	return s.deliver(topic, granted, false, payload)
+}
+
+// subscribe adds the subscriptions of a SUBSCRIBE packet and delivers the
+// retained messages they match.
+func (s *mqttSession) subscribe(flags byte, body []byte) error {
+	r := &mqttReader{b: body}
+	id := r.uint16()
+	var filters []string
+	codes := make([]byte, 2, 2+len(body)/3)
+	binary.BigEndian.PutUint16(codes, id)
+	for r.err == nil && len(r.b) > 0 {
+		filter, qos := r.string(), r.byte()
+		if qos > 2 {
+			return errMQTTMalformed
+		}
+		if !validMQTTFilter(filter) {
+			codes = append(codes, 0x80)
+			continue
+		}
+		s.subs[filter] = qos
+		filters = append(filters, filter)
+		codes = append(codes, qos)
+	}
+	if r.err != nil || flags != 0x2 || len(codes) == 2 {
+		return errMQTTMalformed
+	}
+	if err := s.write(mqttSuback<<4, codes); err != nil {
+		return err
+	}
+
+	for _, filter := range filters {
+		for topic, m := range s.retained {
+			if !mqttMatch(filter, topic) {
+				continue
+			}
+			qos := m.qos
+			if qos > s.subs[filter] {
+				qos = s.subs[filter]
+			}
+			if err := s.deliver(topic, qos, true, m.payload); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// unsubscribe removes the subscriptions of an UNSUBSCRIBE packet.
+func (s *mqttSession) unsubscribe(flags byte, body []byte) error {
+	r := &mqttReader{b: body}
+	id := r.uint16()
+	n := 0
+	for ; r.err == nil && len(r.b) > 0; n++ {
+		delete(s.subs, r.string())
+	}
+	if r.err != nil || flags != 0x2 || n == 0 {
+		return errMQTTMalformed
+	}
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], id)
+	return s.write(mqttUnsuback<<4, b[:])
+}
+
+// deliver sends a PUBLISH packet to the client.
+func (s *mqttSession) deliver(topic string, qos byte, retain bool, payload []byte) error {
+	b := make([]byte, 2, 4+len(topic)+len(payload))
+	binary.BigEndian.PutUint16(b, uint16(len(topic)))
+	b = append(b, topic...)
+	if qos > 0 {
+		if s.lastID++; s.lastID == 0 {
+			s.lastID++
+		}
+		b = append(b, byte(s.lastID>>8), byte(s.lastID))
+	}
+	flags := qos << 1
+	if retain {
+		flags |= 0x1
+	}
+	return s.write(mqttPublish<<4|flags, append(b, payload...))
+}
+
+// ack sends the acknowledgement header of the packet whose body starts with
+// the packet identifier in body.
+func (s *mqttSession) ack(header byte, body []byte) error {
+	if len(body) < 2 {
+		return errMQTTMalformed
+	}
+	return s.write(header, body[:2])
+}
+
+// write sends a packet as a binary message.
+func (s *mqttSession) write(header byte, body []byte) error {
+	b := append(make([]byte, 0, 5+len(body)), header)
+	n := len(body)
+	for {
+		c := byte(n % 128)
+		if n /= 128; n > 0 {
+			c |= 0x80
+		}
+		b = append(b, c)
+		if n == 0 {
+			break
+		}
+	}
+	return s.c.writeFrame(true, wsBinary, append(b, body...))
+}
+
+// readMQTTPacket reads the next control packet, of at most max bytes.
+func readMQTTPacket(r *bufio.Reader, max int) (typ, flags byte, body []byte, err error) {
+	h, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	n := 0
+	for shift := uint(0); ; shift += 7 {
+		c, err := r.ReadByte()
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		if shift > 21 {
+			return 0, 0, nil, errMQTTMalformed
+		}
+		n |= int(c&0x7f) << shift
+		if c&0x80 == 0 {
+			break
+		}
+	}
+	if n > max {
+		return 0, 0, nil, errMQTTMalformed
+	}
+	body = make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, 0, nil, err
+	}
+	return h >> 4, h & 0x0f, body, nil
+}
+
+// mqttReader decodes the fields of a packet body, remembering whether it
+// ran out of bytes.
+type mqttReader struct {
+	b   []byte
+	err error
+}
+
+func (r *mqttReader) byte() byte {
+	if len(r.b) < 1 {
+		r.err = errMQTTMalformed
+		return 0
+	}
+	c := r.b[0]
+	r.b = r.b[1:]
+	return c
+}
+
+func (r *mqttReader) uint16() uint16 {
+	if len(r.b) < 2 {
+		r.err = errMQTTMalformed
+		return 0
+	}
+	v := binary.BigEndian.Uint16(r.b)
+	r.b = r.b[2:]
+	return v
+}
+
+func (r *mqttReader) bytes() []byte {
+	n := int(r.uint16())
+	if len(r.b) < n {
+		r.err = errMQTTMalformed
+		return nil
+	}
+	b := r.b[:n]
+	r.b = r.b[n:]
+	return b
+}
+
+func (r *mqttReader) string() string {
+	return string(r.bytes())
+}
+
+// validMQTTFilter reports whether the topic filter f uses its wildcards
+// correctly: a whole level for +, the last one for #.
+func validMQTTFilter(f string) bool {
+	levels := strings.Split(f, "/")
+	for i, l := range levels {
+		if strings.ContainsAny(l, "+#") && len(l) > 1 || l == "#" && i < len(levels)-1 {
+			return false
+		}
+	}
+	return f != ""
+}
+
+// mqttMatch reports whether the topic matches the topic filter. Wildcards
+// at the first level don't match topics starting with $.
+func mqttMatch(filter, topic string) bool {
+	if strings.HasPrefix(topic, "$") && (strings.HasPrefix(filter, "+") || strings.HasPrefix(filter, "#")) {
+		return false
+	}
+	f, t := strings.Split(filter, "/"), strings.Split(topic, "/")
+	for i, l := range f {
+		if l == "#" {
+			return true
+		}
+		if i >= len(t) || l != "+" && l != t[i] {
+			return false
+		}
+	}
+	return len(f) == len(t)
+}
+
+// wsMessageReader reads the binary messages of a WebSocket connection as a
+// stream.
+type wsMessageReader struct {
+	c   *wsConn
+	buf []byte
+}
+
+func (r *wsMessageReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		op, msg, err := r.c.readMessage()
+		if err == errWSClosed {
+			return 0, io.EOF
+		}
+		if err != nil {
+			return 0, err
+		}
+		if op != wsBinary {
+			r.c.close(1003, "MQTT packets must be sent in binary messages")
+			return 0, errors.New("text message")
+		}
+		r.buf = msg
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
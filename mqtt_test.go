@@ -0,0 +1,80 @@
+//go:build httpbin_mqtt && !httpbin_nowebsocket
+// +build httpbin_mqtt,!httpbin_nowebsocket
+
+package httpbin_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// mqttString encodes s as an MQTT string.
+func mqttString(s string) string {
+	return string([]byte{byte(len(s) >> 8), byte(len(s))}) + s
+}
+
+// mqttPacket encodes a packet with a short body.
+func mqttPacket(header byte, body string) []byte {
+	return append([]byte{header, byte(len(body))}, body...)
+}
+
+func TestMQTT(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+	c, protocol := dialWebSocketPath(t, srv.URL, "/mqtt", "mqtt")
+	defer c.conn.Close()
+	require.Equal(t, "mqtt", protocol)
+
+	read := func() []byte {
+		_, op, p := c.read(t)
+		require.Equal(t, byte(0x2), op)
+		return p
+	}
+
+	// CONNECT and SUBSCRIBE in a single message
+	connect := mqttPacket(0x10, mqttString("MQTT")+"\x04\x02\x00\x3c"+mqttString("test"))
+	subscribe := mqttPacket(0x82, "\x00\x01"+mqttString("a/+")+"\x01"+mqttString("a/#/b")+"\x00")
+	c.write(t, true, 0x2, append(connect, subscribe...))
+	require.Equal(t, []byte{0x20, 2, 0, 0}, read())
+	require.Equal(t, []byte{0x90, 4, 0, 1, 1, 0x80}, read())
+
+	// QoS 1 is acknowledged and delivered back
+	c.write(t, true, 0x2, mqttPacket(0x32, mqttString("a/b")+"\x00\x07hi"))
+	require.Equal(t, []byte{0x40, 2, 0, 7}, read())
+	require.Equal(t, mqttPacket(0x32, mqttString("a/b")+"\x00\x01hi"), read())
+
+	// a packet split across messages, for a topic not subscribed to
+	c.write(t, true, 0x2, mqttPacket(0x31, mqttString("c")+"kept")[:3])
+	c.write(t, true, 0x2, mqttPacket(0x31, mqttString("c")+"kept")[3:])
+	c.write(t, true, 0x2, mqttPacket(0xc0, ""))
+	require.Equal(t, []byte{0xd0, 0}, read())
+
+	// retained messages are delivered on subscription
+	c.write(t, true, 0x2, mqttPacket(0x82, "\x00\x02"+mqttString("#")+"\x00"))
+	require.Equal(t, []byte{0x90, 3, 0, 2, 0}, read())
+	require.Equal(t, mqttPacket(0x31, mqttString("c")+"kept"), read())
+
+	c.write(t, true, 0x2, mqttPacket(0xe0, ""))
+	_, op, _ := c.read(t)
+	require.Equal(t, byte(0x8), op)
+}
+
+func TestMQTT_protocol(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	// MQTT 3.1 is refused with a return code
+	c, _ := dialWebSocketPath(t, srv.URL, "/mqtt", "mqtt")
+	c.write(t, true, 0x2, mqttPacket(0x10, mqttString("MQTT")+"\x03\x02\x00\x3c"+mqttString("test")))
+	_, _, p := c.read(t)
+	require.Equal(t, []byte{0x20, 2, 0, 1}, p)
+	c.conn.Close()
+
+	// the first packet must be CONNECT
+	c, _ = dialWebSocketPath(t, srv.URL, "/mqtt", "mqtt")
+	c.write(t, true, 0x2, mqttPacket(0xc0, ""))
+	_, op, _ := c.read(t)
+	require.Equal(t, byte(0x8), op)
+	c.conn.Close()
+}
@@ -0,0 +1,277 @@
+package httpbin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+var (
+	// defaultNTLMChallenge is the canned NTLM CHALLENGE (type 2) message
+	// sent in reply to a client's NEGOTIATE (type 1) message.
+	defaultNTLMChallenge = newNTLMChallenge("HTTPBIN", [8]byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef})
+
+	// defaultNegotiateTokens are the canned server tokens sent on
+	// /negotiate-auth in reply to successive non-NTLM client tokens.
+	defaultNegotiateTokens = [][]byte{
+		{0xa1, 0x07, 0x30, 0x05, 0xa0, 0x03, 0x0a, 0x01, 0x00}, // SPNEGO NegTokenResp: accept-completed
+	}
+)
+
+// WithNTLMChallenge sets the NTLM CHALLENGE (type 2) message sent in reply
+// to a client's NEGOTIATE (type 1) message on /ntlm-auth and
+// /negotiate-auth. It defaults to a challenge from the HTTPBIN domain.
+func WithNTLMChallenge(msg []byte) Option {
+	return func(h *HTTPBin) { h.ntlmChallenge = msg }
+}
+
+// WithNegotiateTokens sets the server tokens sent on /negotiate-auth in
+// reply to successive non-NTLM (e.g. Kerberos/SPNEGO) client tokens. All but
+// the last are sent with a 401 to ask for another round trip; the last one
+// is sent with the final 200 response. It defaults to a single SPNEGO
+// accept-completed token.
+func WithNegotiateTokens(tokens [][]byte) Option {
+	return func(h *HTTPBin) { h.negotiateTokens = tokens }
+}
+
+const (
+	ntlmNegotiate    = 1
+	ntlmChallenge    = 2
+	ntlmAuthenticate = 3
+
+	ntlmFlagUnicode = 0x00000001
+)
+
+var ntlmSignature = []byte("NTLMSSP\x00")
+
+// authFlows holds the client tokens seen so far in each connection's ongoing
+// NTLM/Negotiate handshake, as these schemes authenticate connections.
+type authFlows struct {
+	mu sync.Mutex
+	m  map[string][]string
+}
+
+const maxAuthFlows = 1024
+
+func (f *authFlows) add(key, token string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.m[key]; !ok && len(f.m) >= maxAuthFlows {
+		f.m = make(map[string][]string) // abandoned handshakes, start over
+	}
+	f.m[key] = append(f.m[key], token)
+	return f.m[key]
+}
+
+func (f *authFlows) done(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.m, key)
+}
+
+// NTLMAuthHandler walks the client through an NTLM handshake: a bare
+// "WWW-Authenticate: NTLM" challenge, then the NTLM challenge in reply to the
+// client's NEGOTIATE message, then a report of the messages the client sent
+// once it sends its AUTHENTICATE message. Credentials are not verified.
+func NTLMAuthHandler(w http.ResponseWriter, r *http.Request) {
+	challengeAuthHandler(w, r, "NTLM")
+}
+
+// NegotiateAuthHandler is like NTLMAuthHandler for the Negotiate scheme.
+// NTLM tokens are handled as on /ntlm-auth, other tokens are answered with
+// the Negotiate tokens, one per round trip.
+func NegotiateAuthHandler(w http.ResponseWriter, r *http.Request) {
+	challengeAuthHandler(w, r, "Negotiate")
+}
+
+func challengeAuthHandler(w http.ResponseWriter, r *http.Request, scheme string) {
+	key := scheme + " " + r.RemoteAddr
+	hb := instance(r)
+	flows := hb.flows
+
+	token, ok := parseAuthToken(r.Header.Get("Authorization"), scheme)
+	if !ok {
+		flows.done(key)
+		w.Header().Set("WWW-Authenticate", scheme)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	tokens := flows.add(key, base64.StdEncoding.EncodeToString(token))
+
+	if bytes.HasPrefix(token, ntlmSignature) {
+		msg, err := parseNTLMMessage(token)
+		if err != nil {
+			flows.done(key)
+			writeErrorJSONStatus(w, http.StatusBadRequest, err)
+			return
+		}
+		switch msg.Type {
+		case ntlmNegotiate:
+			w.Header().Set("WWW-Authenticate", scheme+" "+base64.StdEncoding.EncodeToString(hb.ntlmChallenge))
+			w.WriteHeader(http.StatusUnauthorized)
+		case ntlmAuthenticate:
+			flows.done(key)
+			writeAuthFlowResponse(w, scheme, tokens)
+		default:
+			flows.done(key)
+			writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("unexpected NTLM message type %d", msg.Type))
+		}
+		return
+	}
+
+	replies := hb.negotiateTokens
+	if scheme != "Negotiate" || len(replies) == 0 {
+		flows.done(key)
+		writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("not an NTLM message"))
+		return
+	}
+	reply := replies[len(replies)-1]
+	if len(tokens) < len(replies) {
+		reply = replies[len(tokens)-1]
+	}
+	w.Header().Set("WWW-Authenticate", scheme+" "+base64.StdEncoding.EncodeToString(reply))
+	if len(tokens) < len(replies) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	flows.done(key)
+	writeAuthFlowResponse(w, scheme, tokens)
+}
+
+func writeAuthFlowResponse(w http.ResponseWriter, scheme string, tokens []string) {
+	v := authFlowResponse{
+		Authenticated: true,
+		Scheme:        scheme,
+	}
+	for _, t := range tokens {
+		tok := authToken{Token: t}
+		if b, err := base64.StdEncoding.DecodeString(t); err == nil && bytes.HasPrefix(b, ntlmSignature) {
+			tok.NTLM, _ = parseNTLMMessage(b)
+		}
+		v.Tokens = append(v.Tokens, tok)
+	}
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
+
+// parseAuthToken returns the decoded token from an Authorization header
+// value for the given scheme.
+func parseAuthToken(auth, scheme string) ([]byte, bool) {
+	if len(auth) <= len(scheme)+1 || !strings.EqualFold(auth[:len(scheme)], scheme) || auth[len(scheme)] != ' ' {
+		return nil, false
+	}
+	b, err := base64.StdEncoding.DecodeString(strings.TrimSpace(auth[len(scheme)+1:]))
+	if err != nil || len(b) == 0 {
+		return nil, false
+	}
+	return b, true
+}
+
+// parseNTLMMessage decodes the fields of interest of an NTLM message.
+func parseNTLMMessage(b []byte) (*ntlmMessage, error) {
+	if len(b) < 12 || !bytes.HasPrefix(b, ntlmSignature) {
+		return nil, errors.New("not an NTLM message")
+	}
+	msg := &ntlmMessage{Type: int(binary.LittleEndian.Uint32(b[8:]))}
+
+	switch msg.Type {
+	case ntlmNegotiate:
+		if len(b) < 16 {
+			return nil, errors.New("short NTLM NEGOTIATE message")
+		}
+		flags := binary.LittleEndian.Uint32(b[12:])
+		msg.Flags = fmt.Sprintf("0x%08x", flags)
+		if len(b) >= 32 {
+			// NEGOTIATE messages always use OEM strings
+			msg.Domain = string(ntlmField(b, 16))
+			msg.Workstation = string(ntlmField(b, 24))
+		}
+	case ntlmChallenge:
+		if len(b) < 24 {
+			return nil, errors.New("short NTLM CHALLENGE message")
+		}
+		msg.Flags = fmt.Sprintf("0x%08x", binary.LittleEndian.Uint32(b[20:]))
+	case ntlmAuthenticate:
+		if len(b) < 64 {
+			return nil, errors.New("short NTLM AUTHENTICATE message")
+		}
+		flags := binary.LittleEndian.Uint32(b[60:])
+		msg.Flags = fmt.Sprintf("0x%08x", flags)
+		msg.LMResponseLength = len(ntlmField(b, 12))
+		msg.NTResponseLength = len(ntlmField(b, 20))
+		msg.Domain = ntlmString(ntlmField(b, 28), flags)
+		msg.User = ntlmString(ntlmField(b, 36), flags)
+		msg.Workstation = ntlmString(ntlmField(b, 44), flags)
+	}
+	return msg, nil
+}
+
+// ntlmField returns the payload referred to by the length/offset field
+// structure at the given offset of an NTLM message.
+func ntlmField(b []byte, off int) []byte {
+	n := int(binary.LittleEndian.Uint16(b[off:]))
+	start := int(binary.LittleEndian.Uint32(b[off+4:]))
+	if start < 0 || start+n > len(b) {
+		return nil
+	}
+	return b[start : start+n]
+}
+
+func ntlmString(b []byte, flags uint32) string {
+	if flags&ntlmFlagUnicode == 0 {
+		return string(b)
+	}
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[2*i:])
+	}
+	return string(utf16.Decode(u))
+}
+
+func utf16le(s string) []byte {
+	u := utf16.Encode([]rune(s))
+	b := make([]byte, 2*len(u))
+	for i, c := range u {
+		binary.LittleEndian.PutUint16(b[2*i:], c)
+	}
+	return b
+}
+
+// newNTLMChallenge builds an NTLM CHALLENGE message for the given NetBIOS
+// domain/server name and server challenge.
+func newNTLMChallenge(name string, challenge [8]byte) []byte {
+	const (
+		headerLen = 48
+		flags     = 0xa2898205 // 56, 128, TARGET_INFO, EXTENDED_SESSIONSECURITY, TARGET_TYPE_DOMAIN, ALWAYS_SIGN, NTLM, REQUEST_TARGET, UNICODE
+	)
+	target := utf16le(name)
+
+	var info bytes.Buffer
+	for _, avID := range []uint16{2, 1} { // MsvAvNbDomainName, MsvAvNbComputerName
+		binary.Write(&info, binary.LittleEndian, avID)
+		binary.Write(&info, binary.LittleEndian, uint16(len(target)))
+		info.Write(target)
+	}
+	info.Write([]byte{0, 0, 0, 0}) // MsvAvEOL
+
+	b := make([]byte, headerLen, headerLen+len(target)+info.Len())
+	copy(b, ntlmSignature)
+	binary.LittleEndian.PutUint32(b[8:], ntlmChallenge)
+	binary.LittleEndian.PutUint16(b[12:], uint16(len(target)))
+	binary.LittleEndian.PutUint16(b[14:], uint16(len(target)))
+	binary.LittleEndian.PutUint32(b[16:], headerLen)
+	binary.LittleEndian.PutUint32(b[20:], flags)
+	copy(b[24:], challenge[:])
+	binary.LittleEndian.PutUint16(b[40:], uint16(info.Len()))
+	binary.LittleEndian.PutUint16(b[42:], uint16(info.Len()))
+	binary.LittleEndian.PutUint32(b[44:], uint32(headerLen+len(target)))
+	b = append(b, target...)
+	return append(b, info.Bytes()...)
+}
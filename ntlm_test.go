@@ -0,0 +1,141 @@
+package httpbin_test
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+func ntlmNegotiateMessage() []byte {
+	b := make([]byte, 32)
+	copy(b, "NTLMSSP\x00")
+	binary.LittleEndian.PutUint32(b[8:], 1)
+	binary.LittleEndian.PutUint32(b[12:], 0xa2088207)
+	return b
+}
+
+func ntlmAuthenticateMessage(domain, user, workstation string) []byte {
+	b := make([]byte, 64)
+	copy(b, "NTLMSSP\x00")
+	binary.LittleEndian.PutUint32(b[8:], 3)
+	binary.LittleEndian.PutUint32(b[60:], 0xa2888205)
+	for i, s := range []string{domain, user, workstation} {
+		var p []byte
+		for _, c := range utf16.Encode([]rune(s)) {
+			p = append(p, byte(c), byte(c>>8))
+		}
+		off := 28 + 8*i
+		binary.LittleEndian.PutUint16(b[off:], uint16(len(p)))
+		binary.LittleEndian.PutUint16(b[off+2:], uint16(len(p)))
+		binary.LittleEndian.PutUint32(b[off+4:], uint32(len(b)))
+		b = append(b, p...)
+	}
+	return b
+}
+
+func authRoundTrip(t *testing.T, cl *http.Client, u, auth string) *http.Response {
+	req, err := http.NewRequest("GET", u, nil)
+	require.Nil(t, err)
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	resp, err := cl.Do(req)
+	require.Nil(t, err)
+	return resp
+}
+
+func TestNTLMAuth(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+	cl := &http.Client{}
+	u := srv.URL + "/ntlm-auth"
+
+	resp := authRoundTrip(t, cl, u, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	require.Equal(t, "NTLM", resp.Header.Get("WWW-Authenticate"))
+
+	resp = authRoundTrip(t, cl, u, "NTLM "+base64.StdEncoding.EncodeToString(ntlmNegotiateMessage()))
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	challenge, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(resp.Header.Get("WWW-Authenticate"), "NTLM "))
+	require.Nil(t, err)
+	require.Equal(t, []byte("NTLMSSP\x00\x02\x00\x00\x00"), challenge[:12])
+
+	resp = authRoundTrip(t, cl, u, "NTLM "+base64.StdEncoding.EncodeToString(ntlmAuthenticateMessage("CORP", "alice", "WS1")))
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var v struct {
+		Authenticated bool   `json:"authenticated"`
+		Scheme        string `json:"scheme"`
+		Tokens        []struct {
+			NTLM struct {
+				Type        int    `json:"type"`
+				Domain      string `json:"domain"`
+				User        string `json:"user"`
+				Workstation string `json:"workstation"`
+			} `json:"ntlm"`
+		} `json:"tokens"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	require.True(t, v.Authenticated)
+	require.Equal(t, "NTLM", v.Scheme)
+	require.Len(t, v.Tokens, 2)
+	require.Equal(t, 1, v.Tokens[0].NTLM.Type)
+	require.Equal(t, 3, v.Tokens[1].NTLM.Type)
+	require.Equal(t, "CORP", v.Tokens[1].NTLM.Domain)
+	require.Equal(t, "alice", v.Tokens[1].NTLM.User)
+	require.Equal(t, "WS1", v.Tokens[1].NTLM.Workstation)
+}
+
+func TestNegotiateAuth_multipleRounds(t *testing.T) {
+	srv := httptest.NewServer(httpbin.New(httpbin.WithNegotiateTokens([][]byte{[]byte("round1"), []byte("round2"), []byte("final")})).Handler())
+	defer srv.Close()
+	cl := &http.Client{}
+	u := srv.URL + "/negotiate-auth"
+
+	resp := authRoundTrip(t, cl, u, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	require.Equal(t, "Negotiate", resp.Header.Get("WWW-Authenticate"))
+
+	for _, want := range []string{"round1", "round2"} {
+		resp = authRoundTrip(t, cl, u, "Negotiate "+base64.StdEncoding.EncodeToString([]byte("client-"+want)))
+		resp.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		require.Equal(t, "Negotiate "+base64.StdEncoding.EncodeToString([]byte(want)), resp.Header.Get("WWW-Authenticate"))
+	}
+
+	resp = authRoundTrip(t, cl, u, "Negotiate "+base64.StdEncoding.EncodeToString([]byte("client-final")))
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "Negotiate "+base64.StdEncoding.EncodeToString([]byte("final")), resp.Header.Get("WWW-Authenticate"))
+
+	var v struct {
+		Tokens []struct {
+			Token string `json:"token"`
+		} `json:"tokens"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	require.Len(t, v.Tokens, 3)
+	b, _ := base64.StdEncoding.DecodeString(v.Tokens[2].Token)
+	require.True(t, strings.HasSuffix(string(b), "final"))
+}
+
+func TestNTLMAuth_notNTLM(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp := authRoundTrip(t, &http.Client{}, srv.URL+"/ntlm-auth", "NTLM "+base64.StdEncoding.EncodeToString([]byte("garbage")))
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,434 @@
+package httpbin
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultOIDCClients maps the client IDs the /oidc endpoints accept to
+// their secrets.
+var defaultOIDCClients = map[string]string{"httpbin": "httpbin-secret"}
+
+// defaultOIDCTokenTTL is the lifetime of the ID and access tokens.
+const defaultOIDCTokenTTL = time.Hour
+
+// WithOIDCClients sets the client IDs the /oidc endpoints accept and their
+// secrets. It defaults to the client httpbin with the secret httpbin-secret.
+func WithOIDCClients(clients map[string]string) Option {
+	return func(h *HTTPBin) { h.oidcClients = clients }
+}
+
+// WithOIDCSigningKey sets the key that signs the ID and access tokens of
+// /oidc/token and is published at /.well-known/jwks.json. If nil, the
+// default, a 2048-bit key is generated on first use.
+func WithOIDCSigningKey(key *rsa.PrivateKey) Option {
+	return func(h *HTTPBin) { h.oidcSigningKey = key }
+}
+
+// WithOIDCTokenTTL sets the lifetime of the ID and access tokens. It
+// defaults to an hour.
+func WithOIDCTokenTTL(d time.Duration) Option {
+	return func(h *HTTPBin) { h.oidcTokenTTL = d }
+}
+
+// oidcCodeTTL is the lifetime of authorization codes.
+const oidcCodeTTL = time.Minute
+
+// oidcState holds the authorization codes issued by /oidc/authorize and the
+// key generated in place of a configured signing key.
+type oidcState struct {
+	mu    sync.Mutex
+	key   *rsa.PrivateKey // generated if no signing key is configured
+	codes map[string]*oidcCode
+}
+
+// oidcCode is an authorization code issued by /oidc/authorize.
+type oidcCode struct {
+	clientID      string
+	redirectURI   string
+	user          string
+	nonce         string
+	scope         string
+	challenge     string // PKCE code_challenge, if any
+	challengeType string
+	expires       time.Time
+}
+
+// signingKey returns the configured key, or the key generated in its place
+// if it is nil.
+func (s *oidcState) signingKey(configured *rsa.PrivateKey) (*rsa.PrivateKey, error) {
+	if configured != nil {
+		return configured, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.key == nil {
+		k, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate signing key: %w", err)
+		}
+		s.key = k
+	}
+	return s.key, nil
+}
+
+// jwkThumbprint returns the RFC 7638 thumbprint of the public key, used as
+// its key ID.
+func jwkThumbprint(k *rsa.PublicKey) string {
+	j := publicJWK(k, "")
+	b, _ := json.Marshal(struct {
+		E   string `json:"e"`
+		Kty string `json:"kty"`
+		N   string `json:"n"`
+	}{j.E, j.Kty, j.N}) // the required members, in lexicographic order
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func publicJWK(k *rsa.PublicKey, kid string) jwk {
+	return jwk{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: "RS256",
+		Kid: kid,
+		N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+	}
+}
+
+// signJWT returns the claims as a JWT signed with RS256.
+func signJWT(key *rsa.PrivateKey, typ string, claims interface{}) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": typ, "kid": jwkThumbprint(&key.PublicKey)})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode claims: %w", err)
+	}
+	s := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	sum := sha256.Sum256([]byte(s))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return s + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// verifyJWT checks the RS256 signature of token and decodes its claims.
+func verifyJWT(key *rsa.PublicKey, token string, claims interface{}) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errors.New("malformed token")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return errors.New("malformed token signature")
+	}
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], sig); err != nil {
+		return errors.New("invalid token signature")
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return errors.New("malformed token claims")
+	}
+	if err := json.Unmarshal(b, claims); err != nil {
+		return fmt.Errorf("malformed token claims: %w", err)
+	}
+	return nil
+}
+
+// oidcIssuer returns the issuer identifier, the URL of the server the
+// request was made to, under its prefix.
+func oidcIssuer(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + prefixed(r, "")
+}
+
+// writeOAuthError writes an RFC 6749 error response.
+func writeOAuthError(w http.ResponseWriter, status int, code, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = writeJSON(w, oauthErrorResponse{Error: code, Description: description}) // status already written
+}
+
+// OIDCAuthorizeHandler is a simplified OpenID Connect authorization
+// endpoint supporting the authorization code flow. It approves every valid
+// request without asking, for the user named by 'login_hint', or else the
+// user of the /login session cookie, or else "user", and redirects to
+// 'redirect_uri' with a code and 'state'. PKCE ('code_challenge' with
+// 'code_challenge_method' S256 or plain) is supported.
+func OIDCAuthorizeHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	clientID := q.Get("client_id")
+	if _, ok := instance(r).oidcClients[clientID]; !ok {
+		writeOAuthError(w, http.StatusBadRequest, "unauthorized_client", "unknown client_id")
+		return
+	}
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || !redirect.IsAbs() || redirect.Fragment != "" {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "redirect_uri must be an absolute URL without a fragment")
+		return
+	}
+
+	// from here on errors are reported to the client through the redirect
+	rq := redirect.Query()
+	if s := q.Get("state"); s != "" {
+		rq.Set("state", s)
+	}
+	fail := func(code, description string) {
+		rq.Set("error", code)
+		rq.Set("error_description", description)
+		redirect.RawQuery = rq.Encode()
+		http.Redirect(w, r, redirect.String(), http.StatusFound)
+	}
+	if q.Get("response_type") != "code" {
+		fail("unsupported_response_type", "only the authorization code flow is supported")
+		return
+	}
+	scope := q.Get("scope")
+	if !strings.Contains(" "+scope+" ", " openid ") {
+		fail("invalid_scope", "scope must include openid")
+		return
+	}
+	method := q.Get("code_challenge_method")
+	if q.Get("code_challenge") != "" && method == "" {
+		method = "plain"
+	}
+	if method != "" && method != "S256" && method != "plain" {
+		fail("invalid_request", "code_challenge_method must be S256 or plain")
+		return
+	}
+
+	user := q.Get("login_hint")
+	if user == "" {
+		user = "user"
+		if c, err := r.Cookie(sessionCookie); err == nil {
+			sessions := instance(r).sessions
+			sessions.mu.Lock()
+			if s := sessions.sessions[c.Value]; s != nil {
+				user = s.user
+			}
+			sessions.mu.Unlock()
+		}
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to generate code: %w", err))
+		return
+	}
+	code := hex.EncodeToString(b)
+	now := time.Now()
+	oidc := instance(r).oidc
+	oidc.mu.Lock()
+	for c, oc := range oidc.codes {
+		if now.After(oc.expires) {
+			delete(oidc.codes, c)
+		}
+	}
+	oidc.codes[code] = &oidcCode{
+		clientID:      clientID,
+		redirectURI:   q.Get("redirect_uri"),
+		user:          user,
+		nonce:         q.Get("nonce"),
+		scope:         scope,
+		challenge:     q.Get("code_challenge"),
+		challengeType: method,
+		expires:       now.Add(oidcCodeTTL),
+	}
+	oidc.mu.Unlock()
+
+	rq.Set("code", code)
+	redirect.RawQuery = rq.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// OIDCTokenHandler exchanges a code from /oidc/authorize for an ID token and
+// an access token, both JWTs signed with the key published at
+// /.well-known/jwks.json. Clients authenticate with their client secret,
+// using HTTP Basic auth or the 'client_secret' form field, or without a
+// secret if the code was requested with PKCE.
+func OIDCTokenHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "failed to parse form")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", "only authorization_code is supported")
+		return
+	}
+	clientID, secret, basic := r.BasicAuth()
+	if !basic {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	code := r.PostForm.Get("code")
+	hb := instance(r)
+	oidc := hb.oidc
+	oidc.mu.Lock()
+	oc := oidc.codes[code]
+	delete(oidc.codes, code) // codes are single use
+	oidc.mu.Unlock()
+
+	want, known := hb.oidcClients[clientID]
+	switch {
+	case !known, secret != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(want)) != 1:
+		w.Header().Set("WWW-Authenticate", `Basic realm="httpbin oidc"`)
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return
+	case secret == "" && (oc == nil || oc.challenge == ""):
+		w.Header().Set("WWW-Authenticate", `Basic realm="httpbin oidc"`)
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "client_secret is required without PKCE")
+		return
+	case oc == nil || time.Now().After(oc.expires):
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "unknown, used or expired code")
+		return
+	case oc.clientID != clientID || oc.redirectURI != r.PostForm.Get("redirect_uri"):
+		writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "code was issued to another client or redirect_uri")
+		return
+	}
+	if oc.challenge != "" {
+		verifier := r.PostForm.Get("code_verifier")
+		if oc.challengeType == "S256" {
+			sum := sha256.Sum256([]byte(verifier))
+			verifier = base64.RawURLEncoding.EncodeToString(sum[:])
+		}
+		if verifier == "" || subtle.ConstantTimeCompare([]byte(verifier), []byte(oc.challenge)) != 1 {
+			writeOAuthError(w, http.StatusBadRequest, "invalid_grant", "code_verifier does not match code_challenge")
+			return
+		}
+	}
+
+	key, err := oidc.signingKey(hb.oidcSigningKey)
+	if err != nil {
+		writeErrorJSON(w, err)
+		return
+	}
+	now := time.Now()
+	claims := oidcClaims{
+		Issuer:    oidcIssuer(r),
+		Subject:   oc.user,
+		Audience:  clientID,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(hb.oidcTokenTTL).Unix(),
+		Nonce:     oc.nonce,
+	}
+	idToken, err := signJWT(key, "JWT", claims)
+	if err != nil {
+		writeErrorJSON(w, err)
+		return
+	}
+	claims.Audience, claims.Nonce, claims.Scope = claims.Issuer+"/oidc/userinfo", "", oc.scope
+	accessToken, err := signJWT(key, "at+jwt", claims)
+	if err != nil {
+		writeErrorJSON(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	v := oidcTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(hb.oidcTokenTTL / time.Second),
+		IDToken:     idToken,
+		Scope:       oc.scope,
+	}
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
+
+// OIDCUserInfoHandler returns the claims of the user of the access token
+// from /oidc/token given as a bearer token.
+func OIDCUserInfoHandler(w http.ResponseWriter, r *http.Request) {
+	fail := func(description string) {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description="`+description+`"`)
+		writeOAuthError(w, http.StatusUnauthorized, "invalid_token", description)
+	}
+	auth := r.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+		fail("a bearer token is required")
+		return
+	}
+	hb := instance(r)
+	key, err := hb.oidc.signingKey(hb.oidcSigningKey)
+	if err != nil {
+		writeErrorJSON(w, err)
+		return
+	}
+	var claims oidcClaims
+	if err := verifyJWT(&key.PublicKey, auth[7:], &claims); err != nil {
+		fail(err.Error())
+		return
+	}
+	if claims.Audience != oidcIssuer(r)+"/oidc/userinfo" || time.Now().Unix() >= claims.ExpiresAt {
+		fail("token is expired or not an access token")
+		return
+	}
+	v := oidcUserInfo{
+		Subject:           claims.Subject,
+		Name:              claims.Subject,
+		PreferredUsername: claims.Subject,
+		Email:             claims.Subject + "@example.com",
+		EmailVerified:     true,
+	}
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
+
+// JWKSHandler publishes the public key of the OIDC signing key as a JSON
+// Web Key Set.
+func JWKSHandler(w http.ResponseWriter, r *http.Request) {
+	hb := instance(r)
+	key, err := hb.oidc.signingKey(hb.oidcSigningKey)
+	if err != nil {
+		writeErrorJSON(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/jwk-set+json")
+	v := jwksResponse{Keys: []jwk{publicJWK(&key.PublicKey, jwkThumbprint(&key.PublicKey))}}
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
+
+// OIDCDiscoveryHandler serves the OpenID Provider metadata of the /oidc
+// endpoints.
+func OIDCDiscoveryHandler(w http.ResponseWriter, r *http.Request) {
+	iss := oidcIssuer(r)
+	v := oidcDiscovery{
+		Issuer:                            iss,
+		AuthorizationEndpoint:             iss + "/oidc/authorize",
+		TokenEndpoint:                     iss + "/oidc/token",
+		UserInfoEndpoint:                  iss + "/oidc/userinfo",
+		JWKSURI:                           iss + "/.well-known/jwks.json",
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{"RS256"},
+		ScopesSupported:                   []string{"openid", "profile", "email"},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256", "plain"},
+	}
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
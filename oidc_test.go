@@ -0,0 +1,161 @@
+package httpbin_test
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// oidcAuthorize runs /oidc/authorize with the query and returns the code and
+// state it redirects with.
+func oidcAuthorize(t *testing.T, srvURL string, q url.Values) url.Values {
+	resp, err := noFollowGet(noRedirectClient(), srvURL+"/oidc/authorize?"+q.Encode())
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	loc, err := url.Parse(resp.Header.Get("Location"))
+	require.Nil(t, err)
+	require.Equal(t, "/callback", loc.Path)
+	return loc.Query()
+}
+
+func oidcToken(t *testing.T, srvURL string, form url.Values, user, pass string) (*http.Response, map[string]interface{}) {
+	req, _ := http.NewRequest("POST", srvURL+"/oidc/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if user != "" {
+		req.SetBasicAuth(user, pass)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	var v map[string]interface{}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	return resp, v
+}
+
+func TestOIDC(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+	redirect := "http://client.example/callback"
+
+	q := oidcAuthorize(t, srv.URL, url.Values{
+		"response_type": {"code"}, "client_id": {"httpbin"}, "redirect_uri": {redirect},
+		"scope": {"openid email"}, "state": {"xyz"}, "nonce": {"n-0S6"}, "login_hint": {"alice"},
+	})
+	require.Equal(t, "xyz", q.Get("state"))
+	code := q.Get("code")
+	require.NotEmpty(t, code)
+
+	form := url.Values{"grant_type": {"authorization_code"}, "code": {code}, "redirect_uri": {redirect}}
+	resp, v := oidcToken(t, srv.URL, form, "httpbin", "wrong")
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	require.Equal(t, "invalid_client", v["error"])
+
+	q = oidcAuthorize(t, srv.URL, url.Values{
+		"response_type": {"code"}, "client_id": {"httpbin"}, "redirect_uri": {redirect},
+		"scope": {"openid"}, "nonce": {"n-0S6"}, "login_hint": {"alice"},
+	})
+	form.Set("code", q.Get("code"))
+	resp, v = oidcToken(t, srv.URL, form, "httpbin", "httpbin-secret")
+	require.Equal(t, http.StatusOK, resp.StatusCode, v)
+	require.Equal(t, "Bearer", v["token_type"])
+
+	// the ID token verifies with the published key
+	resp, err := http.Get(srv.URL + "/.well-known/jwks.json")
+	require.Nil(t, err)
+	var jwks struct {
+		Keys []struct{ N, E string } `json:"keys"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&jwks))
+	resp.Body.Close()
+	require.Len(t, jwks.Keys, 1)
+	n, _ := base64.RawURLEncoding.DecodeString(jwks.Keys[0].N)
+	e, _ := base64.RawURLEncoding.DecodeString(jwks.Keys[0].E)
+	pub := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	idToken := v["id_token"].(string)
+	parts := strings.Split(idToken, ".")
+	require.Len(t, parts, 3)
+	sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	require.Nil(t, rsa.VerifyPKCS1v15(pub, crypto.SHA256, sum[:], sig))
+	b, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	var claims map[string]interface{}
+	require.Nil(t, json.Unmarshal(b, &claims))
+	require.Equal(t, "alice", claims["sub"])
+	require.Equal(t, "httpbin", claims["aud"])
+	require.Equal(t, "n-0S6", claims["nonce"])
+	require.Equal(t, srv.URL, claims["iss"])
+
+	req, _ := http.NewRequest("GET", srv.URL+"/oidc/userinfo", nil)
+	req.Header.Set("Authorization", "Bearer "+v["access_token"].(string))
+	resp, err = http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	var info map[string]interface{}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&info))
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "alice", info["sub"])
+
+	// codes are single use, and ID tokens are not access tokens
+	resp, v = oidcToken(t, srv.URL, form, "httpbin", "httpbin-secret")
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.Equal(t, "invalid_grant", v["error"])
+	req.Header.Set("Authorization", "Bearer "+idToken)
+	resp, err = http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestOIDC_pkce(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+	redirect := "http://client.example/callback"
+
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	sum := sha256.Sum256([]byte(verifier))
+	q := oidcAuthorize(t, srv.URL, url.Values{
+		"response_type": {"code"}, "client_id": {"httpbin"}, "redirect_uri": {redirect}, "scope": {"openid"},
+		"code_challenge": {base64.RawURLEncoding.EncodeToString(sum[:])}, "code_challenge_method": {"S256"},
+	})
+	form := url.Values{"grant_type": {"authorization_code"}, "code": {q.Get("code")}, "redirect_uri": {redirect},
+		"client_id": {"httpbin"}, "code_verifier": {verifier}}
+	resp, v := oidcToken(t, srv.URL, form, "", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode, v)
+
+	q = oidcAuthorize(t, srv.URL, url.Values{
+		"response_type": {"code"}, "client_id": {"httpbin"}, "redirect_uri": {redirect}, "scope": {"openid"},
+		"code_challenge": {base64.RawURLEncoding.EncodeToString(sum[:])}, "code_challenge_method": {"S256"},
+	})
+	form.Set("code", q.Get("code"))
+	form.Set("code_verifier", "wrong")
+	resp, v = oidcToken(t, srv.URL, form, "", "")
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.Equal(t, "invalid_grant", v["error"])
+}
+
+func TestOIDC_authorizeErrors(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/oidc/authorize?client_id=nope&redirect_uri=http://client.example/callback")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	q := oidcAuthorize(t, srv.URL, url.Values{
+		"response_type": {"token"}, "client_id": {"httpbin"}, "redirect_uri": {"http://client.example/callback"},
+		"scope": {"openid"}, "state": {"s"},
+	})
+	require.Equal(t, "unsupported_response_type", q.Get("error"))
+	require.Equal(t, "s", q.Get("state"))
+}
@@ -0,0 +1,109 @@
+package httpbin
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultOnceTokensMax is the maximum number of /once tokens remembered.
+const defaultOnceTokensMax = 10000
+
+// WithOnceTokensMax sets the maximum number of /once tokens remembered;
+// minting more forgets the oldest ones, which then redeem with 404. It
+// defaults to 10000.
+func WithOnceTokensMax(n int) Option {
+	return func(h *HTTPBin) { h.onceTokensMax = n }
+}
+
+// onceStore tracks the tokens minted by /once/new.
+type onceStore struct {
+	mu     sync.Mutex
+	tokens map[string]*onceToken
+	order  []string // minting order, oldest first
+}
+
+type onceToken struct {
+	expires  time.Time // zero for no TTL
+	redeemed bool
+}
+
+// NewOnceHandler mints a token that /once/:token redeems exactly once, within
+// the number of seconds given by the optional 'ttl' query parameter.
+func NewOnceHandler(w http.ResponseWriter, r *http.Request) {
+	var ttl time.Duration
+	if s := r.URL.Query().Get("ttl"); s != "" {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || f <= 0 {
+			writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("failed to parse 'ttl'"))
+			return
+		}
+		ttl = time.Duration(f * float64(time.Second))
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to generate token: %w", err))
+		return
+	}
+	token := hex.EncodeToString(b)
+	v := onceResponse{Token: token, URL: prefixed(r, "/once/"+token)}
+	t := &onceToken{}
+	if ttl > 0 {
+		t.expires = time.Now().Add(ttl)
+		v.ExpiresAt = t.expires.UTC().Format(time.RFC3339Nano)
+	}
+
+	hb := instance(r)
+	onceTokens := hb.onceTokens
+	onceTokens.mu.Lock()
+	onceTokens.tokens[token] = t
+	onceTokens.order = append(onceTokens.order, token)
+	for len(onceTokens.order) > hb.onceTokensMax {
+		delete(onceTokens.tokens, onceTokens.order[0])
+		onceTokens.order = onceTokens.order[1:]
+	}
+	onceTokens.mu.Unlock()
+
+	w.WriteHeader(http.StatusCreated)
+	_ = writeJSON(w, v) // status already written, nothing else to do
+}
+
+// OnceHandler redeems a token minted by /once/new: the first request within
+// its TTL gets 200, later ones and ones after the TTL get 410 Gone, and
+// unknown tokens get 404.
+func OnceHandler(w http.ResponseWriter, r *http.Request) {
+	token := routeVars(r)["token"]
+
+	onceTokens := instance(r).onceTokens
+	onceTokens.mu.Lock()
+	t, ok := onceTokens.tokens[token]
+	var gone string
+	switch {
+	case !ok:
+	case t.redeemed:
+		gone = "token already redeemed"
+	case !t.expires.IsZero() && time.Now().After(t.expires):
+		gone = "token expired"
+	default:
+		t.redeemed = true
+	}
+	onceTokens.mu.Unlock()
+
+	switch {
+	case !ok:
+		writeErrorJSONStatus(w, http.StatusNotFound, errors.New("unknown token"))
+	case gone != "":
+		writeErrorJSONStatus(w, http.StatusGone, errors.New(gone))
+	default:
+		v := onceResponse{Token: token, URL: r.URL.Path, Redeemed: true}
+		if err := writeJSON(w, v); err != nil {
+			writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+func mintOnce(t *testing.T, url string) (token, path string) {
+	resp, err := http.Get(url)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var v struct {
+		Token string `json:"token"`
+		URL   string `json:"url"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	require.NotEmpty(t, v.Token)
+	return v.Token, v.URL
+}
+
+func statusOf(t *testing.T, url string) int {
+	resp, err := http.Get(url)
+	require.Nil(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestOnce(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	_, path := mintOnce(t, srv.URL+"/once/new")
+	require.Equal(t, http.StatusOK, statusOf(t, srv.URL+path))
+	require.Equal(t, http.StatusGone, statusOf(t, srv.URL+path))
+	require.Equal(t, http.StatusNotFound, statusOf(t, srv.URL+"/once/0123abcd"))
+}
+
+func TestOnce_prefix(t *testing.T) {
+	srv := httptest.NewServer(httpbin.New(httpbin.WithPrefix("/hb")).Handler())
+	defer srv.Close()
+
+	_, path := mintOnce(t, srv.URL+"/hb/once/new")
+	require.True(t, strings.HasPrefix(path, "/hb/once/"), path)
+	require.Equal(t, http.StatusOK, statusOf(t, srv.URL+path))
+}
+
+func TestOnce_ttl(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	_, path := mintOnce(t, srv.URL+"/once/new?ttl=0.05")
+	time.Sleep(100 * time.Millisecond)
+	require.Equal(t, http.StatusGone, statusOf(t, srv.URL+path))
+
+	require.Equal(t, http.StatusBadRequest, statusOf(t, srv.URL+"/once/new?ttl=-1"))
+}
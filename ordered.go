@@ -0,0 +1,236 @@
+package httpbin
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultOrderedGroupsMax is the maximum number of /ordered groups kept.
+const defaultOrderedGroupsMax = 1000
+
+// WithOrderedGroupsMax sets the maximum number of /ordered groups kept;
+// starting more forgets the oldest ones. It defaults to 1000.
+func WithOrderedGroupsMax(n int) Option {
+	return func(h *HTTPBin) { h.orderedGroupsMax = n }
+}
+
+// orderedGroupSet tracks the requests of each /ordered group.
+type orderedGroupSet struct {
+	mu     sync.Mutex
+	groups map[string]*orderedGroup
+	order  []string // first started first
+}
+
+type orderedGroup struct {
+	arrived   int
+	completed int
+	done      map[int]bool
+	changed   chan struct{} // closed and replaced as requests complete
+}
+
+// get returns the group named name, starting it if needed and forgetting
+// the oldest groups beyond max.
+func (s *orderedGroupSet) get(name string, max int) *orderedGroup {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.groups[name]
+	if !ok {
+		g = &orderedGroup{done: make(map[int]bool), changed: make(chan struct{})}
+		s.groups[name] = g
+		s.order = append(s.order, name)
+		for len(s.order) > max {
+			delete(s.groups, s.order[0])
+			s.order = s.order[1:]
+		}
+	}
+	return g
+}
+
+// reset forgets the group named name, reporting whether there was one.
+func (s *orderedGroupSet) reset(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.groups[name]; !ok {
+		return false
+	}
+	delete(s.groups, name)
+	for i, n := range s.order {
+		if n == name {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// arrive counts a request of the group and returns its arrival index.
+func (s *orderedGroupSet) arrive(g *orderedGroup) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g.arrived++
+	return g.arrived - 1
+}
+
+// complete marks seq completed and returns its completion index.
+func (s *orderedGroupSet) complete(g *orderedGroup, seq int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g.done[seq] = true
+	g.completed++
+	close(g.changed)
+	g.changed = make(chan struct{})
+	return g.completed - 1
+}
+
+// pending returns the seqs of after not completed yet, and a channel closed
+// when that may change.
+func (s *orderedGroupSet) pending(g *orderedGroup, after []int) ([]int, <-chan struct{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var seqs []int
+	for _, seq := range after {
+		if !g.done[seq] {
+			seqs = append(seqs, seq)
+		}
+	}
+	return seqs, g.changed
+}
+
+// waitFor waits until the seqs of after completed, for at most d, reporting
+// false if it gave up or the client went away first.
+func (s *orderedGroupSet) waitFor(r *http.Request, g *orderedGroup, after []int, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	for {
+		seqs, changed := s.pending(g, after)
+		if len(seqs) == 0 {
+			return true
+		}
+		select {
+		case <-changed:
+		case <-t.C:
+			return false
+		case <-r.Context().Done():
+			return false
+		}
+	}
+}
+
+// secondsListParam parses the query parameter name, a comma-separated list of
+// numbers of seconds, capping each at the delay maximum of the HTTPBin
+// serving r. It writes a 400 and reports false if any is not a non-negative
+// number.
+func secondsListParam(w http.ResponseWriter, r *http.Request, name string, ds *[]time.Duration) bool {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return true
+	}
+	max := instance(r).delayMax
+	for _, v := range strings.Split(s, ",") {
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil || !(f >= 0) {
+			writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("'%s' must be a list of numbers of seconds", name))
+			return false
+		}
+		d := max
+		if f < max.Seconds() {
+			d = time.Duration(f * float64(time.Second))
+		}
+		*ds = append(*ds, d)
+	}
+	return true
+}
+
+// parseOrder parses a comma-separated list of distinct seqs.
+func parseOrder(s string) ([]int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var order []int
+	seen := make(map[int]bool)
+	for _, v := range strings.Split(s, ",") {
+		seq, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || seq < 0 {
+			return nil, fmt.Errorf("'order' must be a list of sequence numbers")
+		}
+		if seen[seq] {
+			return nil, fmt.Errorf("'order' lists %d twice", seq)
+		}
+		seen[seq] = true
+		order = append(order, seq)
+	}
+	return order, nil
+}
+
+// OrderedHandler completes the requests of a group out of the order they were
+// sent in, so that clients multiplexing or pipelining them can check they
+// match responses to requests. Request seq of the group first waits for the
+// seq-th of the comma-separated 'delays' seconds, if any, then, if it is in
+// the comma-separated 'order' the group's requests should complete in,
+// until those before it in there completed, for at most the delay maximum.
+// DELETE forgets the group, so its name can be reused.
+func OrderedHandler(w http.ResponseWriter, r *http.Request) {
+	vars := routeVars(r)
+	group := vars["group"]
+	hb := instance(r)
+	orderedGroups := hb.orderedGroups
+	if r.Method == http.MethodDelete {
+		if !orderedGroups.reset(group) {
+			writeErrorJSONStatus(w, http.StatusNotFound, fmt.Errorf("no group %q", group))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	seq, err := strconv.Atoi(vars["seq"])
+	if err != nil {
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("bad sequence number: %w", err))
+		return
+	}
+	var delays []time.Duration
+	if !secondsListParam(w, r, "delays", &delays) {
+		return
+	}
+	order, err := parseOrder(r.URL.Query().Get("order"))
+	if err != nil {
+		writeErrorJSONStatus(w, http.StatusBadRequest, err)
+		return
+	}
+
+	g := orderedGroups.get(group, hb.orderedGroupsMax)
+	v := orderedResponse{
+		Group:   group,
+		Seq:     seq,
+		Arrival: orderedGroups.arrive(g),
+	}
+	if seq < len(delays) {
+		v.Delay = delays[seq].Seconds()
+		if !sleepRequest(r, delays[seq]) {
+			return
+		}
+	}
+	for i, s := range order {
+		if s == seq {
+			v.After = order[:i]
+			break
+		}
+	}
+	if len(v.After) > 0 && !orderedGroups.waitFor(r, g, v.After, instance(r).delayMax) {
+		if r.Context().Err() != nil {
+			return
+		}
+		v.TimedOut = true
+	}
+	v.Completion = orderedGroups.complete(g, seq)
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Httpbin-Seq", strconv.Itoa(seq))
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
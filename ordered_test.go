@@ -0,0 +1,115 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type orderedStatus struct {
+	Seq        int
+	Arrival    int
+	Completion int
+	Delay      float64
+	After      []int
+	TimedOut   bool `json:"timed_out"`
+}
+
+func getOrdered(t *testing.T, url string) orderedStatus {
+	resp, err := http.Get(url)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var v orderedStatus
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	require.Equal(t, strconv.Itoa(v.Seq), resp.Header.Get("X-Httpbin-Seq"))
+	return v
+}
+
+func TestOrdered_order(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	group := unique("order")
+	var (
+		wg sync.WaitGroup
+		vs [3]orderedStatus
+	)
+	for seq := range vs {
+		wg.Add(1)
+		go func(seq int) {
+			defer wg.Done()
+			vs[seq] = getOrdered(t, fmt.Sprintf("%s/ordered/%s/%d?order=2,0,1", srv.URL, group, seq))
+		}(seq)
+	}
+	wg.Wait()
+
+	require.Equal(t, 0, vs[2].Completion)
+	require.Equal(t, 1, vs[0].Completion)
+	require.Equal(t, 2, vs[1].Completion)
+	require.Equal(t, []int{2, 0}, vs[1].After)
+	for _, v := range vs {
+		require.False(t, v.TimedOut)
+	}
+}
+
+func TestOrdered_delays(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	group := unique("delays")
+	var (
+		wg sync.WaitGroup
+		vs [2]orderedStatus
+	)
+	for seq := range vs {
+		wg.Add(1)
+		go func(seq int) {
+			defer wg.Done()
+			vs[seq] = getOrdered(t, fmt.Sprintf("%s/ordered/%s/%d?delays=0.3,0", srv.URL, group, seq))
+		}(seq)
+	}
+	wg.Wait()
+
+	require.Equal(t, 0.3, vs[0].Delay)
+	require.Equal(t, 1, vs[0].Completion)
+	require.Equal(t, 0, vs[1].Completion)
+}
+
+func TestOrdered_reset(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	url := srv.URL + "/ordered/" + unique("reset")
+	require.Equal(t, 0, getOrdered(t, url+"/0").Arrival)
+	require.Equal(t, 1, getOrdered(t, url+"/1").Arrival)
+
+	req, _ := http.NewRequest("DELETE", url, nil)
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	require.Equal(t, 0, getOrdered(t, url+"/0").Arrival)
+}
+
+func TestOrdered_badParams(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for _, q := range []string{"delays=x", "delays=-1", "order=1,1", "order=a"} {
+		resp, err := http.Get(srv.URL + "/ordered/bad/0?" + q)
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, q)
+	}
+}
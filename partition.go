@@ -0,0 +1,216 @@
+package httpbin
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// partitionMax caps how long a partition lasts.
+const partitionMax = 10 * time.Minute
+
+// WithPartitionToken sets the bearer token POST and DELETE requests to
+// /partition require to start and end network partitions. If empty, the
+// default, partitions can only be started with StartPartition.
+func WithPartitionToken(token string) Option {
+	return func(h *HTTPBin) { h.partitionToken = token }
+}
+
+// PartitionMode is how the connections accepted by a Listener misbehave
+// during a simulated network partition.
+type PartitionMode string
+
+const (
+	// PartitionRefuse resets new connections as soon as they are accepted,
+	// which is as close to refusing them as a server can get.
+	PartitionRefuse PartitionMode = "refuse"
+	// PartitionBlackhole accepts new connections but never reads from them,
+	// closing them when the partition ends, and stops reading from open
+	// connections until then. Responses being written are finished.
+	PartitionBlackhole PartitionMode = "blackhole"
+	// PartitionReset resets open connections and, like PartitionRefuse, new
+	// ones.
+	PartitionReset PartitionMode = "reset"
+)
+
+// partition is the current partition of the connections accepted by
+// Listener.
+var partition = &partitionState{}
+
+type partitionState struct {
+	mu     sync.Mutex
+	mode   PartitionMode // empty if there is no partition
+	ends   time.Time
+	timer  *time.Timer
+	healed chan struct{} // closed when the partition ends
+	spare  *arrivalConn  // connection left alone, if any
+	held   []net.Conn    // blackholed new connections
+}
+
+// StartPartition partitions the connections accepted by Listener as mode
+// asks for d, capped at 10 minutes, replacing the partition in progress.
+func StartPartition(mode PartitionMode, d time.Duration) error {
+	return startPartition(mode, d, nil)
+}
+
+// EndPartition ends the partition in progress, if any.
+func EndPartition() {
+	partition.mu.Lock()
+	defer partition.mu.Unlock()
+	partition.end()
+}
+
+// startPartition starts a partition sparing the connection spare, if set,
+// so that the client starting it can end it.
+func startPartition(mode PartitionMode, d time.Duration, spare *arrivalConn) error {
+	switch mode {
+	case PartitionRefuse, PartitionBlackhole, PartitionReset:
+	default:
+		return fmt.Errorf("unknown partition mode %q", mode)
+	}
+	if d > partitionMax {
+		d = partitionMax
+	}
+
+	p := partition
+	p.mu.Lock()
+	p.end()
+	healed := make(chan struct{})
+	p.mode, p.ends, p.healed, p.spare = mode, time.Now().Add(d), healed, spare
+	p.timer = time.AfterFunc(d, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.healed == healed {
+			p.end()
+		}
+	})
+	p.mu.Unlock()
+
+	if mode == PartitionReset {
+		var open []*arrivalConn
+		arrivals.mu.Lock()
+		for _, c := range arrivals.conns {
+			if c != spare {
+				open = append(open, c)
+			}
+		}
+		arrivals.mu.Unlock()
+		for _, c := range open {
+			resetConn(c.Conn)
+		}
+	}
+	return nil
+}
+
+// end ends the partition in progress, with p.mu held.
+func (p *partitionState) end() {
+	if p.mode == "" {
+		return
+	}
+	p.timer.Stop()
+	close(p.healed)
+	for _, c := range p.held {
+		c.Close()
+	}
+	p.mode, p.ends, p.timer, p.healed, p.spare, p.held = "", time.Time{}, nil, nil, nil, nil
+}
+
+// intercept reports whether the partition takes the newly accepted
+// connection c, resetting or holding it.
+func (p *partitionState) intercept(c net.Conn) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch p.mode {
+	case PartitionRefuse, PartitionReset:
+		resetConn(c)
+		return true
+	case PartitionBlackhole:
+		p.held = append(p.held, c)
+		return true
+	}
+	return false
+}
+
+// wait blocks a read from c until the blackhole partition in progress, if
+// any, ends.
+func (p *partitionState) wait(c *arrivalConn) {
+	p.mu.Lock()
+	healed := p.healed
+	blocked := p.mode == PartitionBlackhole && p.spare != c
+	p.mu.Unlock()
+	if blocked {
+		<-healed
+	}
+}
+
+// resetConn closes c with a RST rather than a FIN, if it is a TCP
+// connection.
+func resetConn(c net.Conn) {
+	if tc, ok := c.(*net.TCPConn); ok {
+		tc.SetLinger(0)
+	}
+	c.Close()
+}
+
+// PartitionHandler reports the network partition in progress. With the
+// partition token as a bearer token, POST starts one in the mode given by the
+// 'mode' query parameter (refuse, blackhole or reset) for the number of
+// seconds in 'duration' (default 10), and DELETE ends it. The connection
+// that started a partition is spared, so it can end it early. Partitions
+// only affect connections accepted by a Listener.
+func PartitionHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost, http.MethodDelete:
+		token := instance(r).partitionToken
+		if token == "" {
+			writeErrorJSONStatus(w, http.StatusForbidden, errors.New("partitions can't be started over HTTP"))
+			return
+		}
+		if !checkBearerToken(w, r, token, "httpbin partition") {
+			return
+		}
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		d := 10 * time.Second
+		if s := r.URL.Query().Get("duration"); s != "" {
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil || !(f > 0) {
+				writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("'duration' must be a positive number of seconds"))
+				return
+			}
+			if d = partitionMax; f < partitionMax.Seconds() {
+				d = time.Duration(f * float64(time.Second))
+			}
+		}
+		arrivals.mu.Lock()
+		spare, ok := arrivals.conns[r.RemoteAddr]
+		arrivals.mu.Unlock()
+		if !ok {
+			writeErrorJSONStatus(w, http.StatusNotImplemented, errors.New("partitions need the server to accept connections with httpbin.Listener"))
+			return
+		}
+		if err := startPartition(PartitionMode(r.URL.Query().Get("mode")), d, spare); err != nil {
+			writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("want mode refuse, blackhole or reset: %w", err))
+			return
+		}
+	case http.MethodDelete:
+		EndPartition()
+	}
+
+	partition.mu.Lock()
+	v := partitionResponse{Active: partition.mode != "", Mode: string(partition.mode)}
+	if v.Active {
+		v.Ends = partition.ends.UTC().Format(time.RFC3339Nano)
+		v.RemainingMS = milliseconds(time.Until(partition.ends))
+	}
+	partition.mu.Unlock()
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
@@ -0,0 +1,101 @@
+package httpbin_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+// rawConn sends HTTP/1.1 requests over a single connection.
+type rawConn struct {
+	net.Conn
+	br *bufio.Reader
+}
+
+func dialRaw(t *testing.T, srv *httptest.Server) *rawConn {
+	c, err := net.Dial("tcp", srv.Listener.Addr().String())
+	require.Nil(t, err)
+	return &rawConn{c, bufio.NewReader(c)}
+}
+
+func (c *rawConn) do(method, target string, timeout time.Duration) (*http.Response, error) {
+	c.SetDeadline(time.Now().Add(timeout))
+	_, err := io.WriteString(c, method+" "+target+" HTTP/1.1\r\nHost: x\r\nAuthorization: Bearer secret\r\nContent-Length: 0\r\n\r\n")
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.ReadResponse(c.br, nil)
+	if err == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	return resp, err
+}
+
+func TestPartition(t *testing.T) {
+	srv := httptest.NewUnstartedServer(httpbin.New(httpbin.WithPartitionToken("secret")).Handler())
+	srv.Listener = httpbin.Listener(srv.Listener)
+	srv.Config.ConnState = httpbin.ConnState
+	srv.Start()
+	defer srv.Close()
+	defer httpbin.EndPartition()
+
+	// without a token, partitions are off
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/partition?mode=reset", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	httpbin.GetMux().ServeHTTP(rec, req)
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	admin, other := dialRaw(t, srv), dialRaw(t, srv)
+	defer admin.Close()
+	defer other.Close()
+	_, err := other.do("GET", "/get", time.Second)
+	require.Nil(t, err)
+
+	// reset: open and new connections are reset, but not the admin's
+	resp, err := admin.do("POST", "/partition?mode=reset&duration=5", time.Second)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	_, err = other.do("GET", "/get", time.Second)
+	require.NotNil(t, err)
+	_, err = dialRaw(t, srv).do("GET", "/get", time.Second)
+	require.NotNil(t, err)
+
+	resp, err = admin.do("DELETE", "/partition", time.Second)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	_, err = dialRaw(t, srv).do("GET", "/get", time.Second)
+	require.Nil(t, err)
+
+	// blackhole: nothing is read until the partition ends
+	other = dialRaw(t, srv)
+	defer other.Close()
+	resp, err = admin.do("POST", "/partition?mode=blackhole&duration=0.3", time.Second)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	_, err = dialRaw(t, srv).do("GET", "/get", 100*time.Millisecond)
+	require.NotNil(t, err)
+	start := time.Now()
+	resp, err = other.do("GET", "/get", 2*time.Second)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.True(t, time.Since(start) >= 150*time.Millisecond, "answered after %v", time.Since(start))
+
+	res, err := http.Get(srv.URL + "/partition")
+	require.Nil(t, err)
+	var v struct {
+		Active bool `json:"active"`
+	}
+	require.Nil(t, json.NewDecoder(res.Body).Decode(&v))
+	res.Body.Close()
+	require.False(t, v.Active)
+}
@@ -0,0 +1,231 @@
+package httpbin
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// payloadRecord is a record of the canonical document /payloads/:format
+// serves in every format.
+type payloadRecord struct {
+	ID     int     `json:"id" xml:"id"`
+	Name   string  `json:"name" xml:"name"`
+	Email  string  `json:"email" xml:"email"`
+	Active bool    `json:"active" xml:"active"`
+	Score  float64 `json:"score" xml:"score"`
+}
+
+type payloadDocument struct {
+	XMLName xml.Name        `json:"-" xml:"document"`
+	Records []payloadRecord `json:"records" xml:"record"`
+}
+
+// payloadFields are the fields of payloadRecord, in order.
+var payloadFields = []string{"id", "name", "email", "active", "score"}
+
+var payload = payloadDocument{Records: []payloadRecord{
+	{ID: 1, Name: "Alice", Email: "alice@example.com", Active: true, Score: 9.5},
+	{ID: 2, Name: "Smith, Bob", Email: "bob@example.com", Active: false, Score: 7.25},
+	{ID: 3, Name: "Zoë \"Z\" Müller", Email: "zoe@example.com", Active: true, Score: -0.125},
+}}
+
+func (p payloadRecord) values() []interface{} {
+	return []interface{}{p.ID, p.Name, p.Email, p.Active, p.Score}
+}
+
+// payloadFormats maps the formats of /payloads/:format to their content type
+// and encoder.
+var payloadFormats = map[string]struct {
+	contentType string
+	encode      func(payloadDocument) []byte
+}{
+	"json":    {"application/json", encodePayloadJSON},
+	"xml":     {"application/xml", encodePayloadXML},
+	"yaml":    {"application/yaml", encodePayloadYAML},
+	"toml":    {"application/toml", encodePayloadTOML},
+	"csv":     {"text/csv; charset=utf-8", encodePayloadCSV},
+	"msgpack": {"application/msgpack", func(d payloadDocument) []byte { return appendMsgpack(nil, d.generic()) }},
+	"cbor":    {"application/cbor", func(d payloadDocument) []byte { return appendCBOR(nil, d.generic()) }},
+}
+
+// PayloadsHandler serves the same canonical document, a list of records, in
+// the format given by the 'format' route variable: json, xml, yaml, toml,
+// csv, msgpack or cbor.
+func PayloadsHandler(w http.ResponseWriter, r *http.Request) {
+	f, ok := payloadFormats[routeVars(r)["format"]]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", f.contentType)
+	w.Write(f.encode(payload))
+}
+
+// payloadsCacheKey caches /payloads/:format responses by format.
+func payloadsCacheKey(r *http.Request) (string, bool) {
+	return routeVars(r)["format"], true
+}
+
+// generic returns the document as decoded from JSON into an interface{}.
+func (d payloadDocument) generic() interface{} {
+	records := make([]interface{}, len(d.Records))
+	for i, rec := range d.Records {
+		m := make(map[string]interface{}, len(payloadFields))
+		for j, v := range rec.values() {
+			m[payloadFields[j]] = v
+		}
+		records[i] = m
+	}
+	return map[string]interface{}{"records": records}
+}
+
+func encodePayloadJSON(d payloadDocument) []byte {
+	var buf bytes.Buffer
+	writeJSON(&buf, d)
+	return buf.Bytes()
+}
+
+func encodePayloadXML(d payloadDocument) []byte {
+	b, _ := xml.MarshalIndent(d, "", "  ")
+	return append([]byte(xml.Header), append(b, '\n')...)
+}
+
+// payloadScalar formats v as a YAML or TOML scalar; both accept JSON strings
+// for the characters used in the document.
+func payloadScalar(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		b, _ := json.Marshal(v)
+		return string(b)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+func encodePayloadYAML(d payloadDocument) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("records:\n")
+	for _, rec := range d.Records {
+		for i, v := range rec.values() {
+			prefix := "    "
+			if i == 0 {
+				prefix = "  - "
+			}
+			fmt.Fprintf(&buf, "%s%s: %s\n", prefix, payloadFields[i], payloadScalar(v))
+		}
+	}
+	return buf.Bytes()
+}
+
+func encodePayloadTOML(d payloadDocument) []byte {
+	var buf bytes.Buffer
+	for i, rec := range d.Records {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString("[[records]]\n")
+		for j, v := range rec.values() {
+			fmt.Fprintf(&buf, "%s = %s\n", payloadFields[j], payloadScalar(v))
+		}
+	}
+	return buf.Bytes()
+}
+
+func encodePayloadCSV(d payloadDocument) []byte {
+	var buf bytes.Buffer
+	cw := csv.NewWriter(&buf)
+	cw.Write(payloadFields)
+	for _, rec := range d.Records {
+		row := make([]string, 0, len(payloadFields))
+		for _, v := range rec.values() {
+			if f, ok := v.(float64); ok {
+				row = append(row, strconv.FormatFloat(f, 'f', -1, 64))
+			} else {
+				row = append(row, fmt.Sprint(v))
+			}
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return buf.Bytes()
+}
+
+// appendMsgpack appends the MessagePack encoding of v, which may be any of
+// the types appendCBOR accepts. Map keys are sorted so the encoding is
+// deterministic.
+func appendMsgpack(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xc0)
+	case bool:
+		if v {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	case int:
+		return appendMsgpackInt(b, int64(v))
+	case int64:
+		return appendMsgpackInt(b, v)
+	case float64:
+		return appendUint64(append(b, 0xcb), math.Float64bits(v))
+	case string:
+		b = appendMsgpackHead(b, 0xa0, 31, [3]byte{0xd9, 0xda, 0xdb}, len(v))
+		return append(b, v...)
+	case []byte:
+		b = appendMsgpackHead(b, 0, -1, [3]byte{0xc4, 0xc5, 0xc6}, len(v))
+		return append(b, v...)
+	case []interface{}:
+		b = appendMsgpackHead(b, 0x90, 15, [3]byte{0, 0xdc, 0xdd}, len(v))
+		for _, e := range v {
+			b = appendMsgpack(b, e)
+		}
+		return b
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b = appendMsgpackHead(b, 0x80, 15, [3]byte{0, 0xde, 0xdf}, len(v))
+		for _, k := range keys {
+			b = appendMsgpack(b, k)
+			b = appendMsgpack(b, v[k])
+		}
+		return b
+	}
+	panic("msgpack: unsupported type")
+}
+
+func appendMsgpackInt(b []byte, i int64) []byte {
+	switch {
+	case i >= 0 && i <= 127, i < 0 && i >= -32:
+		return append(b, byte(i))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		n := uint32(i)
+		return append(b, 0xd2, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	return appendUint64(append(b, 0xd3), uint64(i))
+}
+
+// appendMsgpackHead appends the header of a string, binary, array or map of
+// length n: fix ORed with n if n <= fixMax, or else the first of the 8, 16
+// and 32-bit length types (0 if the kind has none) that can hold n.
+func appendMsgpackHead(b []byte, fix byte, fixMax int, types [3]byte, n int) []byte {
+	switch {
+	case n <= fixMax:
+		return append(b, fix|byte(n))
+	case n <= math.MaxUint8 && types[0] != 0:
+		return append(b, types[0], byte(n))
+	case n <= math.MaxUint16:
+		return append(b, types[1], byte(n>>8), byte(n))
+	}
+	return append(b, types[2], byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
@@ -0,0 +1,81 @@
+package httpbin_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type payloadRecord struct {
+	ID     int     `json:"id" xml:"id"`
+	Name   string  `json:"name" xml:"name"`
+	Email  string  `json:"email" xml:"email"`
+	Active bool    `json:"active" xml:"active"`
+	Score  float64 `json:"score" xml:"score"`
+}
+
+func getPayload(t *testing.T, url, contentType string) []byte {
+	resp, err := http.Get(url)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, contentType, resp.Header.Get("Content-Type"))
+	b, err := ioutil.ReadAll(resp.Body)
+	require.Nil(t, err)
+	return b
+}
+
+func TestPayloads_sameDocument(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	var j struct {
+		Records []payloadRecord `json:"records"`
+	}
+	require.Nil(t, json.Unmarshal(getPayload(t, srv.URL+"/payloads/json", "application/json"), &j))
+	require.Len(t, j.Records, 3)
+
+	var x struct {
+		Records []payloadRecord `xml:"record"`
+	}
+	require.Nil(t, xml.Unmarshal(getPayload(t, srv.URL+"/payloads/xml", "application/xml"), &x))
+	require.Equal(t, j.Records, x.Records)
+
+	rows, err := csv.NewReader(strings.NewReader(string(getPayload(t, srv.URL+"/payloads/csv", "text/csv; charset=utf-8")))).ReadAll()
+	require.Nil(t, err)
+	require.Equal(t, []string{"id", "name", "email", "active", "score"}, rows[0])
+	require.Len(t, rows, len(j.Records)+1)
+	require.Equal(t, j.Records[1].Name, rows[2][1])
+
+	yaml := string(getPayload(t, srv.URL+"/payloads/yaml", "application/yaml"))
+	require.True(t, strings.HasPrefix(yaml, "records:\n  - id: 1\n    name: \"Alice\"\n"))
+	toml := string(getPayload(t, srv.URL+"/payloads/toml", "application/toml"))
+	require.True(t, strings.HasPrefix(toml, "[[records]]\nid = 1\nname = \"Alice\"\n"))
+}
+
+func TestPayloads_binary(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	// a map of one entry, "records", to an array of three maps of five entries
+	msgpack := getPayload(t, srv.URL+"/payloads/msgpack", "application/msgpack")
+	require.Equal(t, append(append([]byte{0x81, 0xa7}, "records"...), 0x93, 0x85), msgpack[:11])
+	cbor := getPayload(t, srv.URL+"/payloads/cbor", "application/cbor")
+	require.Equal(t, append(append([]byte{0xa1, 0x67}, "records"...), 0x83, 0xa5), cbor[:11])
+}
+
+func TestPayloads_unknownFormat(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/payloads/protobuf")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
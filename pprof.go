@@ -0,0 +1,147 @@
+package httpbin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// WithProfiling sets whether h serves runtime profiles under /debug/pprof/
+// and accounts the memory each route's handler allocates, reported by
+// /debug/handler-allocs. The accounting reads the runtime's allocation
+// counters around every request, so it is exact only when requests are not
+// served concurrently, and costs a brief stop of the world per request. It
+// defaults to false.
+func WithProfiling(enabled bool) Option {
+	return func(h *HTTPBin) { h.profiling = enabled }
+}
+
+// pprofRoutes are the routes added with WithProfiling.
+var pprofRoutes = []route{
+	{name: "pprof", path: `/debug/pprof/{profile:.*}`, methods: getHead, params: []string{"debug", "seconds"}, description: "Serves the runtime profiles in the format of net/http/pprof: heap, allocs, goroutine, profile (CPU), trace and so on.", example: "debug/pprof/", handler: http.HandlerFunc(PprofHandler)},
+	{name: "handler-allocs", path: `/debug/handler-allocs`, methods: getHead, description: "Returns the requests, bytes and objects allocated by each route's handler.", example: "debug/handler-allocs", handler: http.HandlerFunc(HandlerAllocsHandler)},
+}
+
+// allocTracker accumulates the allocations of handlers by route name.
+type allocTracker struct {
+	mu     sync.Mutex
+	routes map[string]*handlerAllocsStats
+}
+
+// PprofHandler serves the runtime profile named by the 'profile' route
+// variable like net/http/pprof does, without importing it as it registers
+// its handlers on http.DefaultServeMux. "profile" is a CPU profile and
+// "trace" an execution trace, of 'seconds' seconds (default 30 and 1); other
+// names are runtime/pprof profiles, written in text with a non-zero 'debug'
+// parameter. An empty name lists the profiles.
+func PprofHandler(w http.ResponseWriter, r *http.Request) {
+	name := routeVars(r)["profile"]
+	q := r.URL.Query()
+	seconds := func(def int) (time.Duration, bool) {
+		if s := q.Get("seconds"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n <= 0 {
+				writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("'seconds' must be a positive integer"))
+				return 0, false
+			}
+			def = n
+		}
+		return time.Duration(def) * time.Second, true
+	}
+	sleep := func(d time.Duration) {
+		select {
+		case <-time.After(d):
+		case <-r.Context().Done():
+		}
+	}
+
+	switch name {
+	case "":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, p := range pprof.Profiles() {
+			fmt.Fprintf(w, "%d\t%s\n", p.Count(), p.Name())
+		}
+		fmt.Fprint(w, "-\tprofile\n-\ttrace\n")
+	case "profile":
+		d, ok := seconds(30)
+		if !ok {
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		if err := pprof.StartCPUProfile(w); err != nil {
+			writeErrorJSONStatus(w, http.StatusConflict, fmt.Errorf("failed to start CPU profile: %w", err))
+			return
+		}
+		sleep(d)
+		pprof.StopCPUProfile()
+	case "trace":
+		d, ok := seconds(1)
+		if !ok {
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		if err := trace.Start(w); err != nil {
+			writeErrorJSONStatus(w, http.StatusConflict, fmt.Errorf("failed to start trace: %w", err))
+			return
+		}
+		sleep(d)
+		trace.Stop()
+	default:
+		p := pprof.Lookup(name)
+		if p == nil {
+			writeErrorJSONStatus(w, http.StatusNotFound, fmt.Errorf("unknown profile %q", name))
+			return
+		}
+		debug, _ := strconv.Atoi(q.Get("debug"))
+		if debug != 0 {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", "application/octet-stream")
+		}
+		p.WriteTo(w, debug)
+	}
+}
+
+// HandlerAllocsHandler reports the allocations of each route's handler.
+func HandlerAllocsHandler(w http.ResponseWriter, r *http.Request) {
+	handlerAllocs := instance(r).handlerAllocs
+	v := handlerAllocsResponse{Routes: make(map[string]handlerAllocsStats)}
+	handlerAllocs.mu.Lock()
+	for name, s := range handlerAllocs.routes {
+		s := *s
+		s.BytesPerRequest = s.Bytes / s.Requests
+		v.Routes[name] = s
+	}
+	handlerAllocs.mu.Unlock()
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
+
+// allocHandler accounts the allocations h makes under the route name.
+func allocHandler(name string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		h.ServeHTTP(w, r)
+		runtime.ReadMemStats(&after)
+
+		handlerAllocs := instance(r).handlerAllocs
+		handlerAllocs.mu.Lock()
+		defer handlerAllocs.mu.Unlock()
+		s := handlerAllocs.routes[name]
+		if s == nil {
+			s = &handlerAllocsStats{}
+			handlerAllocs.routes[name] = s
+		}
+		s.Requests++
+		s.Bytes += after.TotalAlloc - before.TotalAlloc
+		s.Objects += after.Mallocs - before.Mallocs
+	})
+}
@@ -0,0 +1,60 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProfiling(t *testing.T) {
+	srv := testServer()
+	resp, err := http.Get(srv.URL + "/debug/pprof/")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode, "profiling is off by default")
+	srv.Close()
+
+	srv = httptest.NewServer(httpbin.New(httpbin.WithProfiling(true)).Handler())
+	defer srv.Close()
+
+	for _, p := range []string{"", "heap", "goroutine?debug=1"} {
+		resp, err := http.Get(srv.URL + "/debug/pprof/" + p)
+		require.Nil(t, err, p)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode, p)
+	}
+	resp, err = http.Get(srv.URL + "/debug/pprof/nope")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	before := handlerAllocs(t, srv.URL)["bytes"]
+	for i := 0; i < 3; i++ {
+		resp, err := http.Get(srv.URL + "/bytes/1024")
+		require.Nil(t, err)
+		resp.Body.Close()
+	}
+	after := handlerAllocs(t, srv.URL)["bytes"]
+	require.Equal(t, uint64(3), after.Requests-before.Requests)
+	require.True(t, after.Bytes-before.Bytes >= 1024)
+}
+
+type routeAllocs struct {
+	Requests uint64 `json:"requests"`
+	Bytes    uint64 `json:"bytes"`
+}
+
+func handlerAllocs(t *testing.T, url string) map[string]routeAllocs {
+	resp, err := http.Get(url + "/debug/handler-allocs")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	var v struct {
+		Routes map[string]routeAllocs `json:"routes"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	return v.Routes
+}
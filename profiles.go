@@ -0,0 +1,146 @@
+package httpbin
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WithAltServices sets the addresses (":port" or "host:port") of the
+// listeners serving behavior profiles, by profile name, that /alt-svc
+// advertises and redirects to. It defaults to none; the go-httpbin command
+// sets it from its -profile flags.
+func WithAltServices(services map[string]string) Option {
+	return func(h *HTTPBin) { h.altServices = services }
+}
+
+// profileLatency is the latency of the "slow" profile.
+var profileLatency LatencyDistribution = LogNormal{Mean: time.Second, StdDev: 300 * time.Millisecond}
+
+// ProfileHandler wraps h to behave according to the named profile: "fast"
+// serves requests as h does, "slow" adds lognormal(1s, 300ms) latency and
+// "flaky" fails 20% of requests with 503 Service Unavailable and resets the
+// connection of another 10%.
+func ProfileHandler(h http.Handler, profile string) (http.Handler, error) {
+	switch profile {
+	case "fast":
+		return h, nil
+	case "slow":
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t := time.NewTimer(profileLatency.Sample())
+			select {
+			case <-t.C:
+			case <-r.Context().Done():
+				t.Stop()
+				return
+			}
+			h.ServeHTTP(w, r)
+		}), nil
+	case "flaky":
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch x := rand.Float64(); {
+			case x < 0.2:
+				writeStatus(w, r, http.StatusServiceUnavailable)
+			case x < 0.3:
+				if err := closeConn(w, true); err != nil {
+					writeErrorJSON(w, err)
+				}
+			default:
+				h.ServeHTTP(w, r)
+			}
+		}), nil
+	}
+	return nil, fmt.Errorf("unknown profile %q, want fast, slow or flaky", profile)
+}
+
+// closeConn closes the request's connection without a response, with a TCP
+// reset if reset is set.
+func closeConn(w http.ResponseWriter, reset bool) error {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return errors.New("connection does not support hijacking")
+	}
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		return fmt.Errorf("failed to hijack connection: %w", err)
+	}
+	if tc, ok := conn.(*net.TCPConn); ok && reset {
+		tc.SetLinger(0) // close with RST
+	}
+	return conn.Close()
+}
+
+// AltSvcHandler points clients at the listeners of the alternative
+// services. By default it advertises them, or the one named by the
+// 'profile' query parameter, in an Alt-Svc header and returns them; with
+// 'mode=redirect' it answers with a 307 to 'path' (default /get) on the
+// listener of 'profile' instead.
+func AltSvcHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	services := instance(r).altServices
+	if p := q.Get("profile"); p != "" {
+		addr, ok := services[p]
+		if !ok {
+			writeErrorJSONStatus(w, http.StatusNotFound, fmt.Errorf("no listener for profile %q", p))
+			return
+		}
+		services = map[string]string{p: addr}
+	}
+
+	switch q.Get("mode") {
+	case "", "header":
+	case "redirect":
+		if q.Get("profile") == "" {
+			writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("redirect mode needs a 'profile'"))
+			return
+		}
+		path := q.Get("path")
+		if path == "" {
+			path = prefixed(r, "/get")
+		}
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		w.Header().Set("Location", scheme+"://"+altSvcHost(r.Host, services[q.Get("profile")])+"/"+strings.TrimPrefix(path, "/"))
+		w.WriteHeader(http.StatusTemporaryRedirect)
+		return
+	default:
+		writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("'mode' must be header or redirect"))
+		return
+	}
+
+	names := make([]string, 0, len(services))
+	for name := range services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	alts := make([]string, 0, len(names))
+	for _, name := range names {
+		alts = append(alts, fmt.Sprintf("http%%2F1.1=%q; ma=86400", services[name]))
+	}
+	if len(alts) > 0 {
+		w.Header().Set("Alt-Svc", strings.Join(alts, ", "))
+	}
+	if err := writeJSON(w, altSvcResponse{Services: services}); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
+
+// altSvcHost returns the host:port of a listener at addr, taking the host of
+// the request if addr has none.
+func altSvcHost(reqHost, addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || host != "" {
+		return addr
+	}
+	if h, _, err := net.SplitHostPort(reqHost); err == nil {
+		reqHost = h
+	}
+	return net.JoinHostPort(strings.Trim(reqHost, "[]"), port)
+}
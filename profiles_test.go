@@ -0,0 +1,82 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAltSvc(t *testing.T) {
+	services := map[string]string{"slow": ":8081", "flaky": "127.0.0.1:8082"}
+	srv := httptest.NewServer(httpbin.New(httpbin.WithAltServices(services)).Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/alt-svc")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, `http%2F1.1="127.0.0.1:8082"; ma=86400, http%2F1.1=":8081"; ma=86400`, resp.Header.Get("Alt-Svc"))
+	var v struct {
+		Services map[string]string `json:"services"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	require.Equal(t, services, v.Services)
+
+	resp, err = noFollowGet(noRedirectClient(), srv.URL+"/alt-svc?profile=slow&mode=redirect&path=/ip")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+	require.Equal(t, "http://127.0.0.1:8081/ip", resp.Header.Get("Location"))
+
+	for _, q := range []string{"profile=fast", "mode=redirect", "mode=dns"} {
+		resp, err := http.Get(srv.URL + "/alt-svc?" + q)
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.NotEqual(t, http.StatusOK, resp.StatusCode, q)
+	}
+}
+
+func TestAltSvc_prefix(t *testing.T) {
+	srv := httptest.NewServer(httpbin.New(
+		httpbin.WithAltServices(map[string]string{"slow": "127.0.0.1:8081"}),
+		httpbin.WithPrefix("/hb"),
+	).Handler())
+	defer srv.Close()
+
+	resp, err := noFollowGet(noRedirectClient(), srv.URL+"/hb/alt-svc?profile=slow&mode=redirect")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+	require.Equal(t, "http://127.0.0.1:8081/hb/get", resp.Header.Get("Location"))
+}
+
+func TestProfileHandler_flaky(t *testing.T) {
+	h, err := httpbin.ProfileHandler(httpbin.GetMux(), "flaky")
+	require.Nil(t, err)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	// the transport retries requests reset on reused connections
+	cl := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	counts := make(map[int]int)
+	for i := 0; i < 100; i++ {
+		resp, err := cl.Get(srv.URL + "/get")
+		if err != nil {
+			counts[0]++
+			continue
+		}
+		resp.Body.Close()
+		counts[resp.StatusCode]++
+	}
+	require.True(t, counts[http.StatusOK] > 50)
+	require.True(t, counts[http.StatusServiceUnavailable] > 0)
+	require.True(t, counts[0] > 0)
+}
+
+func TestProfileHandler_unknown(t *testing.T) {
+	_, err := httpbin.ProfileHandler(httpbin.GetMux(), "turbo")
+	require.NotNil(t, err)
+}
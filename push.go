@@ -0,0 +1,71 @@
+package httpbin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// defaultPushMax is the maximum number of resources /push pushes.
+const defaultPushMax = 20
+
+// WithPushMax sets the maximum number of resources /push pushes. It defaults
+// to 20.
+func WithPushMax(n int) Option {
+	return func(h *HTTPBin) { h.pushMax = n }
+}
+
+// PushHandler pushes the number of resources given by the 'n' query parameter
+// (default 1, at most the push maximum), each 'size' bytes (default 1024) of
+// /bytes/:n data, and reports for each whether the push was made. Pushes are
+// refused with an error when the request is not over HTTP/2 or the client has
+// disabled push, as Go's client always does.
+func PushHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	n, size := 1, 1024
+	if s := q.Get("n"); s != "" {
+		v, err := strconv.Atoi(s)
+		if max := instance(r).pushMax; err != nil || v < 0 || v > max {
+			writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("'n' must be between 0 and %d", max))
+			return
+		}
+		n = v
+	}
+	if s := q.Get("size"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 {
+			writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("failed to parse 'size'"))
+			return
+		}
+		size = v
+	}
+
+	pusher, ok := w.(http.Pusher)
+	v := pushResponse{
+		Supported: ok && r.ProtoMajor == 2,
+		Resources: make([]pushedResource, 0, n),
+	}
+	for i := 0; i < n; i++ {
+		pr := pushedResource{Path: prefixed(r, "/bytes/"+strconv.Itoa(size)+"?seed="+strconv.Itoa(i))}
+		var err error
+		if ok {
+			err = pusher.Push(pr.Path, nil)
+		} else {
+			err = http.ErrNotSupported
+		}
+		v.Attempted++
+		if err != nil {
+			pr.Error = err.Error()
+			v.Refused++
+		} else {
+			pr.Pushed = true
+			v.Pushed++
+		}
+		v.Resources = append(v.Resources, pr)
+	}
+
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
@@ -0,0 +1,80 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+type pushResult struct {
+	Supported bool `json:"supported"`
+	Attempted int  `json:"attempted"`
+	Pushed    int  `json:"pushed"`
+	Refused   int  `json:"refused"`
+	Resources []struct {
+		Path   string `json:"path"`
+		Pushed bool   `json:"pushed"`
+		Error  string `json:"error"`
+	} `json:"resources"`
+}
+
+func TestPush_refusedByClient(t *testing.T) {
+	srv := httptest.NewUnstartedServer(httpbin.GetMux())
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL + "/push?n=2&size=10")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Go's client disables push, so the server must not push
+	var v pushResult
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	require.True(t, v.Supported)
+	require.Equal(t, 2, v.Attempted)
+	require.Equal(t, 2, v.Refused)
+	require.Len(t, v.Resources, 2)
+	require.Equal(t, "/bytes/10?seed=1", v.Resources[1].Path)
+	require.NotEmpty(t, v.Resources[1].Error)
+}
+
+func TestPush_prefix(t *testing.T) {
+	srv := httptest.NewServer(httpbin.New(httpbin.WithPrefix("/hb")).Handler())
+	defer srv.Close()
+
+	var v pushResult
+	require.Nil(t, json.Unmarshal(get(t, srv.URL+"/hb/push?size=16"), &v))
+	require.Len(t, v.Resources, 1)
+	require.Equal(t, "/hb/bytes/16?seed=0", v.Resources[0].Path)
+	require.Len(t, get(t, srv.URL+v.Resources[0].Path), 16)
+}
+
+func TestPush_http1(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	var v pushResult
+	require.Nil(t, json.Unmarshal(get(t, srv.URL+"/push"), &v))
+	require.False(t, v.Supported)
+	require.Equal(t, 1, v.Attempted)
+	require.Equal(t, 0, v.Pushed)
+	require.Equal(t, 1, v.Refused)
+}
+
+func TestPush_badParams(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for _, q := range []string{"n=-1", "n=1000", "size=x"} {
+		resp, err := http.Get(srv.URL + "/push?" + q)
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, q)
+	}
+}
@@ -0,0 +1,177 @@
+package httpbin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Quota is a daily allowance of requests and of request and response body
+// bytes. Zero fields are unlimited.
+type Quota struct {
+	Requests int64
+	Bytes    int64
+}
+
+// WithQuotas maps API keys, sent in the X-Api-Key header, to their daily
+// Quota, which resets at midnight UTC, counted by h on its own. Requests
+// without a known key count against the "*" entry, or are refused with 401
+// if there is none. Requests over quota are refused with 429. Nil, the
+// default, disables quotas.
+func WithQuotas(quotas map[string]Quota) Option {
+	return func(h *HTTPBin) { h.quotas = quotas }
+}
+
+const (
+	apiKeyHeader   = "X-Api-Key"
+	quotaAnonymous = "*"
+)
+
+// quotaUsage is the usage of an API key on the current day.
+type quotaUsage struct {
+	requests int64
+	bytes    int64
+}
+
+// quotaTracker tracks the usage of each API key on the current day.
+type quotaTracker struct {
+	mu   sync.Mutex
+	day  time.Time
+	used map[string]*quotaUsage
+}
+
+// usage returns the usage of key today, starting a new day if it is over.
+func (q *quotaTracker) usage(key string, now time.Time) *quotaUsage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if day := now.UTC().Truncate(24 * time.Hour); !day.Equal(q.day) {
+		q.day = day
+		q.used = make(map[string]*quotaUsage)
+	}
+	u := q.used[key]
+	if u == nil {
+		u = &quotaUsage{}
+		q.used[key] = u
+	}
+	return u
+}
+
+// quotaKey returns the entry of quotas the request counts against, and false
+// if there is none.
+func quotaKey(r *http.Request, quotas map[string]Quota) (string, bool) {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		if _, ok := quotas[key]; ok {
+			return key, true
+		}
+	}
+	_, ok := quotas[quotaAnonymous]
+	return quotaAnonymous, ok
+}
+
+// quotaReset returns the time to the next midnight UTC, when quotas reset.
+func quotaReset(now time.Time) time.Duration {
+	now = now.UTC()
+	return now.Truncate(24 * time.Hour).Add(24 * time.Hour).Sub(now)
+}
+
+// quotaHandler counts the requests to h and the bytes of their request and
+// response bodies against the quota of their API key, refusing requests
+// over quota with 429, and reports the request quota in RateLimit headers.
+// Requests to /quota are not counted. It must be wrapped by bufferedHandler,
+// which counts the response bytes.
+func quotaHandler(name string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hb := instance(r)
+		key, ok := quotaKey(r, hb.quotas)
+		if !ok {
+			writeErrorJSONStatus(w, http.StatusUnauthorized, fmt.Errorf("a known API key is required in %s", apiKeyHeader))
+			return
+		}
+		if name == "quota" {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		now := time.Now()
+		q, u := hb.quotas[key], hb.quotaUsed.usage(key, now)
+		reset := quotaReset(now)
+		requests := atomic.AddInt64(&u.requests, 1)
+		over := q.Requests > 0 && requests > q.Requests || q.Bytes > 0 && atomic.LoadInt64(&u.bytes) >= q.Bytes
+		if q.Requests > 0 {
+			remaining := q.Requests - requests
+			if remaining < 0 {
+				remaining = 0
+			}
+			w.Header().Set("RateLimit-Policy", strconv.FormatInt(q.Requests, 10)+";w=86400")
+			w.Header().Set("RateLimit-Limit", strconv.FormatInt(q.Requests, 10))
+			w.Header().Set("RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+			w.Header().Set("RateLimit-Reset", strconv.FormatInt(int64(reset/time.Second), 10))
+		}
+		if over {
+			atomic.AddInt64(&u.requests, -1) // refused requests don't count
+			w.Header().Set("Retry-After", strconv.FormatInt(int64(reset/time.Second), 10))
+			writeErrorJSONStatus(w, http.StatusTooManyRequests, fmt.Errorf("daily quota of API key %q exhausted", key))
+			return
+		}
+
+		var in int64
+		if r.Body != nil {
+			r.Body = &countingBody{ReadCloser: r.Body, n: &in}
+		}
+		defer func() { // also when the response is aborted
+			n := atomic.LoadInt64(&in)
+			if br, ok := w.(*bufferedResponse); ok {
+				n += br.written
+			}
+			atomic.AddInt64(&u.bytes, n)
+		}()
+		h.ServeHTTP(w, r)
+	})
+}
+
+// QuotaHandler returns the daily quota of the request's API key, how much
+// of it is used and left, and when it resets.
+func QuotaHandler(w http.ResponseWriter, r *http.Request) {
+	h := instance(r)
+	if h.quotas == nil {
+		writeErrorJSONStatus(w, http.StatusNotFound, errors.New("quotas are not enabled"))
+		return
+	}
+	key, ok := quotaKey(r, h.quotas)
+	if !ok {
+		writeErrorJSONStatus(w, http.StatusUnauthorized, fmt.Errorf("a known API key is required in %s", apiKeyHeader))
+		return
+	}
+
+	now := time.Now()
+	q, u := h.quotas[key], h.quotaUsed.usage(key, now)
+	reset := quotaReset(now)
+	v := quotaResponse{
+		Key:          key,
+		Requests:     newQuotaAllowance(q.Requests, atomic.LoadInt64(&u.requests)),
+		Bytes:        newQuotaAllowance(q.Bytes, atomic.LoadInt64(&u.bytes)),
+		Reset:        now.Add(reset).UTC().Format(time.RFC3339),
+		ResetSeconds: int64(reset / time.Second),
+	}
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
+
+// newQuotaAllowance describes a daily limit, 0 for unlimited, of which used
+// is used.
+func newQuotaAllowance(limit, used int64) quotaAllowance {
+	a := quotaAllowance{Used: used}
+	if limit > 0 {
+		remaining := limit - used
+		if remaining < 0 {
+			remaining = 0
+		}
+		a.Limit, a.Remaining = &limit, &remaining
+	}
+	return a
+}
@@ -0,0 +1,109 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+func quotaServer(quotas map[string]httpbin.Quota) *httptest.Server {
+	return httptest.NewServer(httpbin.New(httpbin.WithQuotas(quotas)).Handler())
+}
+
+func getWithKey(t *testing.T, url, key string) *http.Response {
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if key != "" {
+		req.Header.Set("X-Api-Key", key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	return resp
+}
+
+func TestQuota_requests(t *testing.T) {
+	srv := quotaServer(map[string]httpbin.Quota{"team-a": {Requests: 2}, "*": {Requests: 1}})
+	defer srv.Close()
+
+	for i, remaining := range []string{"1", "0"} {
+		resp := getWithKey(t, srv.URL+"/get", "team-a")
+		resp.Body.Close()
+		require.EqualValuesf(t, http.StatusOK, resp.StatusCode, "request %d", i)
+		require.EqualValues(t, "2", resp.Header.Get("RateLimit-Limit"))
+		require.EqualValues(t, remaining, resp.Header.Get("RateLimit-Remaining"))
+		require.NotEmpty(t, resp.Header.Get("RateLimit-Reset"))
+	}
+	resp := getWithKey(t, srv.URL+"/get", "team-a")
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusTooManyRequests, resp.StatusCode)
+	require.NotEmpty(t, resp.Header.Get("Retry-After"))
+
+	// unknown keys share the anonymous quota
+	resp = getWithKey(t, srv.URL+"/get", "unknown")
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	resp = getWithKey(t, srv.URL+"/get", "")
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	resp = getWithKey(t, srv.URL+"/quota", "team-a")
+	defer resp.Body.Close()
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	var v struct {
+		Key      string `json:"key"`
+		Requests struct {
+			Limit     *int64 `json:"limit"`
+			Used      int64  `json:"used"`
+			Remaining *int64 `json:"remaining"`
+		} `json:"requests"`
+		Bytes struct {
+			Limit *int64 `json:"limit"`
+			Used  int64  `json:"used"`
+		} `json:"bytes"`
+		ResetSeconds int64 `json:"reset_seconds"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	require.EqualValues(t, "team-a", v.Key)
+	require.EqualValues(t, 2, *v.Requests.Limit)
+	require.EqualValues(t, 2, v.Requests.Used)
+	require.EqualValues(t, 0, *v.Requests.Remaining)
+	require.Nil(t, v.Bytes.Limit)
+	require.NotZero(t, v.Bytes.Used)
+	require.True(t, v.ResetSeconds > 0 && v.ResetSeconds <= 86400)
+}
+
+func TestQuota_bytes(t *testing.T) {
+	srv := quotaServer(map[string]httpbin.Quota{"team-b": {Bytes: 1000}})
+	defer srv.Close()
+
+	resp := getWithKey(t, srv.URL+"/bytes/1500", "team-b")
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	require.Empty(t, resp.Header.Get("RateLimit-Limit"))
+
+	resp = getWithKey(t, srv.URL+"/bytes/10", "team-b")
+	defer resp.Body.Close()
+	require.EqualValues(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	// no anonymous quota
+	resp = getWithKey(t, srv.URL+"/get", "")
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestQuota_disabled(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp := getWithKey(t, srv.URL+"/quota", "")
+	defer resp.Body.Close()
+	require.EqualValues(t, http.StatusNotFound, resp.StatusCode)
+	require.Empty(t, resp.Header.Get("RateLimit-Limit"))
+	b, err := ioutil.ReadAll(resp.Body)
+	require.Nil(t, err)
+	require.Contains(t, string(b), "not enabled")
+}
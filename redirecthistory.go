@@ -0,0 +1,150 @@
+package httpbin
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redirectHopsMax is the maximum number of hops a history token carries;
+// longer chains keep counting but only list their latest hops, so that the
+// Location of each hop stays bounded however long the chain.
+const redirectHopsMax = 20
+
+var errBadRedirectHistory = errors.New("malformed or forged redirect history")
+
+// newRedirectHistoryKey returns a random key to sign history tokens with.
+func newRedirectHistoryKey() []byte {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// redirectHistoryToken is the payload of a history token: the number of
+// hops taken and the latest redirectHopsMax of them.
+type redirectHistoryToken struct {
+	Count int           `json:"n"`
+	Hops  []redirectHop `json:"h"`
+}
+
+// redirectHop is the compact form of a hop in a history token.
+type redirectHop struct {
+	Path   string `json:"p"`
+	Status int    `json:"s"`
+	Time   int64  `json:"t"` // unix nanoseconds
+}
+
+// signRedirectHistory encodes t as base64url JSON followed by a dot and its
+// base64url HMAC-SHA256 under key.
+func signRedirectHistory(key []byte, t redirectHistoryToken) string {
+	b, _ := json.Marshal(t)
+	payload := base64.RawURLEncoding.EncodeToString(b)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(redirectHistoryMAC(key, payload))
+}
+
+// parseRedirectHistory verifies and decodes a token of signRedirectHistory.
+// The empty token is an empty history.
+func parseRedirectHistory(key []byte, token string) (redirectHistoryToken, error) {
+	var t redirectHistoryToken
+	if token == "" {
+		return t, nil
+	}
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 {
+		return t, errBadRedirectHistory
+	}
+	payload := token[:i]
+	mac, err := base64.RawURLEncoding.DecodeString(token[i+1:])
+	if err != nil || !hmac.Equal(mac, redirectHistoryMAC(key, payload)) {
+		return t, errBadRedirectHistory
+	}
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return t, errBadRedirectHistory
+	}
+	if err := json.Unmarshal(b, &t); err != nil {
+		return t, errBadRedirectHistory
+	}
+	return t, nil
+}
+
+func redirectHistoryMAC(key []byte, payload string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
+
+// redirectWithHistory responds with a 302 to loc. If r has a 'history'
+// query parameter, empty to start a chain, the request is appended as a
+// hop to the history it carries, which is signed into the 'history' of loc,
+// and X-Redirect-Count is set to the number of hops taken so far.
+func redirectWithHistory(w http.ResponseWriter, r *http.Request, loc string) {
+	if _, ok := r.URL.Query()["history"]; !ok {
+		w.Header().Set("Location", loc)
+		w.WriteHeader(http.StatusFound)
+		return
+	}
+	key := instance(r).redirectKey
+	t, err := parseRedirectHistory(key, r.URL.Query().Get("history"))
+	if err != nil {
+		writeErrorJSONStatus(w, http.StatusBadRequest, err)
+		return
+	}
+	t.Count++
+	t.Hops = append(t.Hops, redirectHop{
+		Path:   r.URL.Path,
+		Status: http.StatusFound,
+		Time:   time.Now().UnixNano(),
+	})
+	if len(t.Hops) > redirectHopsMax {
+		t.Hops = t.Hops[len(t.Hops)-redirectHopsMax:]
+	}
+	w.Header().Set("X-Redirect-Count", strconv.Itoa(t.Count))
+	w.Header().Set("Location", loc+"?history="+signRedirectHistory(key, t))
+	w.WriteHeader(http.StatusFound)
+}
+
+// redirectHistory returns the hops of the /redirect/:n chain carried in the
+// 'history' query parameter of r, if it has a valid one, setting
+// X-Redirect-Count.
+func redirectHistory(w http.ResponseWriter, r *http.Request) (redirectHistoryResponse, error) {
+	t, err := parseRedirectHistory(instance(r).redirectKey, r.URL.Query().Get("history"))
+	if err != nil {
+		return redirectHistoryResponse{}, err
+	}
+	v := redirectHistoryResponse{RedirectCount: t.Count, Hops: []redirectHistoryHop{}}
+	for _, h := range t.Hops {
+		v.Hops = append(v.Hops, redirectHistoryHop{
+			Path:      h.Path,
+			Status:    h.Status,
+			Timestamp: time.Unix(0, h.Time).UTC().Format(time.RFC3339Nano),
+		})
+	}
+	w.Header().Set("X-Redirect-Count", strconv.Itoa(t.Count))
+	return v, nil
+}
+
+// RedirectHistoryHandler lists the hops of the /redirect/:n chain carried
+// in the signed 'history' query parameter, as /get does at the end of the
+// chain, or none if it is not set. Histories not signed by this server get
+// 400.
+func RedirectHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	v, err := redirectHistory(w, r)
+	if err != nil {
+		writeErrorJSONStatus(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
@@ -0,0 +1,51 @@
+package httpbin
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// WithRegion sets the region and zone labels of h, as in us-east-1 and
+// us-east-1a, so that clients of several instances can tell which one served
+// them. They are sent in the X-Httpbin-Region and X-Httpbin-Zone headers of
+// every response and returned by /region. They default to none.
+func WithRegion(region, zone string) Option {
+	return func(h *HTTPBin) { h.region, h.zone = region, zone }
+}
+
+// WithRegionLatency sets the distribution of the base latency added to
+// every request, on top of the route latencies, to emulate the distance of
+// the instance's region from its clients. It defaults to none.
+func WithRegionLatency(d LatencyDistribution) Option {
+	return func(h *HTTPBin) { h.regionLatency = d }
+}
+
+// regionHandler labels the responses of h with the region and zone of the
+// HTTPBin serving them.
+func regionHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hb := instance(r)
+		if hb.region != "" {
+			w.Header().Set("X-Httpbin-Region", hb.region)
+		}
+		if hb.zone != "" {
+			w.Header().Set("X-Httpbin-Zone", hb.zone)
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// RegionHandler returns the region and zone of the instance, its hostname
+// and its base latency.
+func RegionHandler(w http.ResponseWriter, r *http.Request) {
+	h := instance(r)
+	v := regionResponse{Region: h.region, Zone: h.zone}
+	v.Hostname, _ = os.Hostname()
+	if h.regionLatency != nil {
+		v.BaseLatency = describeLatency(h.regionLatency)
+	}
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
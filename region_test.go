@@ -0,0 +1,53 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegion(t *testing.T) {
+	srv := httptest.NewServer(httpbin.New(
+		httpbin.WithRegion("eu-west-1", "eu-west-1b"),
+		httpbin.WithRegionLatency(httpbin.Fixed(50*time.Millisecond)),
+		httpbin.WithRouteLatencies(map[string]httpbin.LatencyDistribution{"/get": httpbin.Fixed(20 * time.Millisecond)}),
+	).Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/get")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, "eu-west-1", resp.Header.Get("X-Httpbin-Region"))
+	require.Equal(t, "eu-west-1b", resp.Header.Get("X-Httpbin-Zone"))
+	require.Equal(t, "70ms", resp.Header.Get("X-Httpbin-Latency"))
+
+	resp, err = http.Get(srv.URL + "/region")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, "50ms", resp.Header.Get("X-Httpbin-Latency"))
+	var v struct {
+		Region      string
+		Zone        string
+		BaseLatency string `json:"base_latency"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	require.Equal(t, "eu-west-1", v.Region)
+	require.Equal(t, "eu-west-1b", v.Zone)
+	require.Equal(t, "fixed(50ms)", v.BaseLatency)
+}
+
+func TestRegion_unlabelled(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/get")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Empty(t, resp.Header.Get("X-Httpbin-Region"))
+	require.Empty(t, resp.Header.Get("X-Httpbin-Latency"))
+}
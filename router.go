@@ -0,0 +1,191 @@
+package httpbin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// routeMux routes requests to the first of its routes matching their path,
+// method and query parameters. Route paths and query values are templates
+// where {name} matches a path segment, or a query value, and {name:pattern}
+// the regexp pattern, as in gorilla/mux; the values they match are returned
+// by routeVars. Requests matching no route go to notFound.
+type routeMux struct {
+	routes   []*muxRoute
+	notFound http.Handler
+}
+
+type muxRoute struct {
+	name    string
+	path    string // template
+	re      *regexp.Regexp
+	vars    []string
+	methods []string // any if empty
+	queries []muxQuery
+	handler http.Handler
+}
+
+// muxQuery is a query parameter a route requires, with its first value
+// matching re.
+type muxQuery struct {
+	key  string
+	re   *regexp.Regexp
+	vars []string
+}
+
+// routeMatch is the route a request was routed to and the values of its
+// variables.
+type routeMatch struct {
+	name string
+	vars map[string]string
+}
+
+type routeContextKey struct{}
+
+// compileTemplate compiles a path or query value template into an anchored
+// regexp, with variables matching defaultPattern unless they have one.
+func compileTemplate(tpl, defaultPattern string) (*regexp.Regexp, []string) {
+	var (
+		b    strings.Builder
+		vars []string
+		end  int
+	)
+	b.WriteByte('^')
+	for _, m := range routeVar.FindAllStringSubmatchIndex(tpl, -1) {
+		pattern := defaultPattern
+		if m[4] >= 0 {
+			pattern = tpl[m[4]:m[5]]
+		}
+		b.WriteString(regexp.QuoteMeta(tpl[end:m[0]]))
+		b.WriteString("(" + pattern + ")")
+		vars = append(vars, tpl[m[2]:m[3]])
+		end = m[1]
+	}
+	b.WriteString(regexp.QuoteMeta(tpl[end:]))
+	b.WriteByte('$')
+	re := regexp.MustCompile(b.String())
+	if re.NumSubexp() != len(vars) {
+		panic(fmt.Sprintf("route template %s has capturing groups, use (?:pattern) instead", tpl))
+	}
+	return re, vars
+}
+
+// handle adds a route serving the path template, for the given methods (any
+// if none) and requiring the query parameters given as key and value
+// template pairs.
+func (m *routeMux) handle(name, tpl string, methods, queries []string, h http.Handler) {
+	re, vars := compileTemplate(tpl, "[^/]+")
+	route := &muxRoute{name: name, path: tpl, re: re, vars: vars, methods: methods, handler: h}
+	for i := 0; i+1 < len(queries); i += 2 {
+		re, vars := compileTemplate(queries[i+1], "[^?&]*")
+		route.queries = append(route.queries, muxQuery{queries[i], re, vars})
+	}
+	m.routes = append(m.routes, route)
+}
+
+// match returns the route r goes to and the values of its variables, or nil
+// if there is none.
+func (m *routeMux) match(r *http.Request) (*muxRoute, map[string]string) {
+	var query map[string][]string
+	for _, route := range m.routes {
+		sub := route.re.FindStringSubmatch(r.URL.Path)
+		if sub == nil || !route.matchMethod(r.Method) {
+			continue
+		}
+		vars := make(map[string]string, len(route.vars))
+		for i, name := range route.vars {
+			vars[name] = sub[i+1]
+		}
+		if len(route.queries) > 0 && query == nil {
+			query = r.URL.Query()
+		}
+		matched := true
+		for _, q := range route.queries {
+			var sub []string
+			if values := query[q.key]; len(values) > 0 {
+				sub = q.re.FindStringSubmatch(values[0])
+			}
+			if sub == nil {
+				matched = false
+				break
+			}
+			for i, name := range q.vars {
+				vars[name] = sub[i+1]
+			}
+		}
+		if matched {
+			return route, vars
+		}
+	}
+	return nil, nil
+}
+
+func (route *muxRoute) matchMethod(method string) bool {
+	if len(route.methods) == 0 {
+		return true
+	}
+	for _, m := range route.methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// ServeHTTP redirects requests for paths with . or .. elements or duplicate
+// slashes, or the bare prefix of the HTTPBin serving them, to the clean path
+// and serves the others with their route.
+func (m *routeMux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if p := cleanPath(r.URL.Path); p != r.URL.Path {
+		u := *r.URL
+		u.Path, u.RawPath = prefixed(r, p), ""
+		w.Header().Set("Location", u.String())
+		w.WriteHeader(http.StatusMovedPermanently)
+		return
+	}
+	route, vars := m.match(r)
+	if route == nil {
+		m.notFound.ServeHTTP(w, r)
+		return
+	}
+	ctx := context.WithValue(r.Context(), routeContextKey{}, &routeMatch{name: route.name, vars: vars})
+	route.handler.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// cleanPath returns the canonical form of p, keeping its trailing slash.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	if p[0] != '/' {
+		p = "/" + p
+	}
+	np := path.Clean(p)
+	if p[len(p)-1] == '/' && np != "/" {
+		np += "/"
+	}
+	return np
+}
+
+// routeVars returns the values of the variables of the route r was routed
+// to, from its path and query parameters.
+func routeVars(r *http.Request) map[string]string {
+	if m, ok := r.Context().Value(routeContextKey{}).(*routeMatch); ok {
+		return m.vars
+	}
+	return map[string]string{}
+}
+
+// RouteName returns the name of the httpbin route r was routed to, such as
+// "get" or "status", or "" if it was not routed to one. The hooks of
+// WithHooks are called with routed requests.
+func RouteName(r *http.Request) string {
+	if m, ok := r.Context().Value(routeContextKey{}).(*routeMatch); ok {
+		return m.name
+	}
+	return ""
+}
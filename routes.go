@@ -0,0 +1,282 @@
+package httpbin
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// route describes an httpbin endpoint. The route table is the single source
+// of truth for the mux, the home page and the OpenAPI spec.
+type route struct {
+	name        string   // also the mux route name, e.g. for metrics labels
+	path        string   // template, see routeMux
+	methods     []string // empty to accept any method
+	queries     []string // required query parameters, as key and value template pairs
+	params      []string // optional query parameters
+	description string   // plain text
+	example     string   // home page link, relative to /; empty for none
+	handler     http.Handler
+
+	// cacheKey, if set, memoizes the route's responses in the response cache
+	// under the returned key, for requests for which it returns true.
+	cacheKey func(*http.Request) (string, bool)
+}
+
+var getHead = []string{http.MethodGet, http.MethodHead}
+
+// WithDisabledRoutes leaves out the named routes, as listed in the
+// endpoints of /config. They are not listed on the home page either. It
+// defaults to none.
+func WithDisabledRoutes(names map[string]bool) Option {
+	return func(h *HTTPBin) { h.disabledRoutes = names }
+}
+
+// featureRoutes are the routes of optional features, which live in files
+// with a build tag to leave them out (e.g. httpbin_noimage for image.go) and
+// register their routes from init. They are listed after the core routes.
+var featureRoutes []route
+
+// routeTable returns the routes of h in the order they are listed on the
+// home page. Handlers that need the router are built for the given one, which
+// may be nil when only the descriptions are needed.
+func (h *HTTPBin) routeTable(router *routeMux) []route {
+	routes := []route{
+		{name: "home", path: `/`, methods: getHead, description: "This page.", example: "/", handler: homeHandler(router)},
+		{name: "openapi", path: `/openapi.json`, methods: getHead, description: "Returns the OpenAPI spec of these endpoints.", example: "openapi.json", handler: http.HandlerFunc(OpenAPIHandler)},
+		{name: "ip", path: `/ip`, methods: getHead, description: "Returns Origin IP.", example: "ip", handler: http.HandlerFunc(IPHandler)},
+		{name: "user-agent", path: `/user-agent`, methods: getHead, description: "Returns user-agent.", example: "user-agent", handler: http.HandlerFunc(UserAgentHandler)},
+		{name: "headers", path: `/headers`, methods: getHead, description: "Returns header dict.", example: "headers", handler: http.HandlerFunc(HeadersHandler)},
+		{name: "headers-reflect", path: `/headers/reflect`, methods: getHead, description: "Sets the query parameters as response headers, reporting how CR, LF and control characters were sanitized.", example: "headers/reflect?X-Test=a%0D%0ASet-Cookie:%20injected=1", handler: http.HandlerFunc(HeadersReflectHandler)},
+		{name: "get", path: `/get`, methods: getHead, description: "Returns GET data.", example: "get", handler: http.HandlerFunc(GetHandler)},
+		{name: "post", path: `/post`, methods: []string{http.MethodPost}, description: "Returns POST data.", handler: http.HandlerFunc(PostHandler)},
+		{name: "put", path: `/put`, methods: []string{http.MethodPut}, description: "Returns PUT data.", handler: http.HandlerFunc(PostHandler)},
+		{name: "patch", path: `/patch`, methods: []string{http.MethodPatch}, description: "Returns PATCH data.", handler: http.HandlerFunc(PostHandler)},
+		{name: "delete", path: `/delete`, methods: []string{http.MethodDelete}, description: "Returns DELETE data.", handler: http.HandlerFunc(PostHandler)},
+		{name: "anything", path: `/anything`, description: "Returns the request data, including the method, for any method.", example: "anything", handler: http.HandlerFunc(AnythingHandler)},
+		{name: "anything-path", path: `/anything/{path:.*}`, description: "Like /anything for any subpath.", example: "anything/foo/bar", handler: http.HandlerFunc(AnythingHandler)},
+		{name: "status", path: `/status/{code:[\d]+}`, description: "Returns given HTTP Status code.", example: "status/418", handler: http.HandlerFunc(StatusHandler)},
+		{name: "matrix", path: `/matrix`, params: []string{"spec"}, description: "Samples a status code, latency or failure (timeout, reset, close) per request from a weighted spec.", example: "matrix?spec=200@10ms:0.8,500@5ms:0.1,timeout:0.1", handler: http.HandlerFunc(MatrixHandler)},
+		{name: "redirect", path: `/redirect/{n:[\d]+}`, methods: getHead, description: "302 Redirects n times to /get, with ?history carrying the signed hops taken.", example: "redirect/6", handler: http.HandlerFunc(RedirectHandler)},
+		{name: "redirect-history", path: `/redirect/history`, methods: getHead, params: []string{"history"}, description: "Lists the hops of the /redirect/n chain in the signed history token, with X-Redirect-Count.", example: "redirect/history", handler: http.HandlerFunc(RedirectHistoryHandler)},
+		{name: "redirect-infinite", path: `/redirect/infinite`, methods: getHead, description: "302 Redirects to /redirect/infinite/1, the start of a chain that never ends.", example: "redirect/infinite", handler: http.HandlerFunc(InfiniteRedirectHandler)},
+		{name: "redirect-infinite-n", path: `/redirect/infinite/{n:[\d]+}`, methods: getHead, description: "302 Redirects to /redirect/infinite/(n+1).", example: "redirect/infinite/5", handler: http.HandlerFunc(InfiniteRedirectHandler)},
+		{name: "redirect-edge", path: `/redirect/edge`, methods: getHead, params: []string{"case"}, description: "302 Redirects with a legal or borderline Location header, reporting the RFC 3986 resolution in X-Httpbin-Expected-Location.", example: "redirect/edge?case=relative_no_slash", handler: http.HandlerFunc(EdgeRedirectHandler)},
+		{name: "absolute-redirect", path: `/absolute-redirect/{n:[\d]+}`, methods: getHead, description: "302 Absolute redirects n times.", example: "absolute-redirect/6", handler: http.HandlerFunc(AbsoluteRedirectHandler)},
+		{name: "redirect-to", path: `/redirect-to`, methods: getHead, queries: []string{"url", "{url:.+}"}, description: "302 Redirects to the given URL.", example: "redirect-to?url=http%3A%2F%2Fexample.com%2F", handler: http.HandlerFunc(RedirectToHandler)},
+		{name: "stream-bytes", path: `/stream-bytes/{n:[\d]+}`, methods: getHead, params: []string{"seed", "chunk_size"}, description: "Streams n random bytes in flushed chunks of chunk_size bytes, accepts optional seed integer parameter.", example: "stream-bytes/1024?chunk_size=256", handler: http.HandlerFunc(StreamBytesHandler)},
+		{name: "stream", path: `/stream/{n:[\d]+}`, methods: getHead, description: "Streams n lines of JSON objects.", example: "stream/20", handler: http.HandlerFunc(StreamHandler)},
+		{name: "sse", path: `/sse`, methods: getHead, params: []string{"n", "interval", "retry", "mode", "at", "size"}, description: "Streams n server-sent events, optionally disconnecting midway, sending only keepalives, unusually framed or huge events.", example: "sse?n=5&interval=1&retry=2000", handler: http.HandlerFunc(SSEHandler)},
+		{name: "delay", path: `/delay/{n:\d+(?:\.\d+)?}`, methods: getHead, description: "Delays responding for min(n, 10) seconds.", example: "delay/3", handler: http.HandlerFunc(DelayHandler)},
+		{name: "timeout", path: `/timeout/{kind}`, methods: getHead, params: []string{"stall", "size", "percent"}, description: "Stalls at a given point of the response: connect-accepted-but-silent, headers-then-stall, body-stall-at-percent or slow-trailers.", example: "timeout/headers-then-stall?stall=5", handler: http.HandlerFunc(TimeoutHandler)},
+		{name: "ordered", path: `/ordered/{group}/{seq:\d+}`, methods: getHead, params: []string{"delays", "order"}, description: "Completes the requests of a group out of submission order, after per-seq delays or once those before seq in order completed.", example: "ordered/g1/0?order=2,1,0", handler: http.HandlerFunc(OrderedHandler)},
+		{name: "ordered-reset", path: `/ordered/{group}`, methods: []string{http.MethodDelete}, description: "Forgets an /ordered group so its name can be reused.", example: "ordered/g1", handler: http.HandlerFunc(OrderedHandler)},
+		{name: "bytes", path: `/bytes/{n:[\d]+}`, methods: getHead, params: []string{"seed"}, description: "Generates n random bytes of binary data, accepts optional seed integer parameter.", example: "bytes/1024", handler: http.HandlerFunc(BytesHandler), cacheKey: bytesCacheKey},
+		{name: "base64-encode", path: `/base64/encode/{value:.+}`, methods: getHead, params: []string{"alphabet"}, description: "Base64-encodes value, in the standard or URL-safe alphabet.", example: "base64/encode/hello%20world", handler: http.HandlerFunc(Base64EncodeHandler)},
+		{name: "base64", path: `/base64/{value:.+}`, methods: getHead, description: "Decodes the standard or URL-safe base64 value and returns the plaintext.", example: "base64/aGVsbG8gd29ybGQ=", handler: http.HandlerFunc(Base64Handler)},
+		{name: "cookies", path: `/cookies`, methods: getHead, description: "Returns cookie data.", example: "cookies", handler: http.HandlerFunc(CookiesHandler)},
+		{name: "cookies-set", path: `/cookies/set`, methods: getHead, description: "Sets one or more simple cookies from the query parameters.", example: "cookies/set?k1=v1&k2=v2", handler: http.HandlerFunc(SetCookiesHandler)},
+		{name: "cookies-delete", path: `/cookies/delete`, methods: getHead, description: "Deletes the cookies named by the query parameters.", example: "cookies/delete?k1=&k2=", handler: http.HandlerFunc(DeleteCookiesHandler)},
+		{name: "drip", path: `/drip`, methods: getHead, queries: []string{"numbytes", `{numbytes:\d+}`, "duration", `{duration:\d+(?:\.\d+)?}`}, params: []string{"delay", "code"}, description: "Drips data over a duration after an optional initial delay, then optionally returns with the given status code.", example: "drip?code=200&numbytes=5&duration=5", handler: http.HandlerFunc(DripHandler)},
+		{name: "cache", path: `/cache`, methods: getHead, description: "Returns 200 unless an If-Modified-Since or If-None-Match header is provided, when it returns a 304.", example: "cache", handler: http.HandlerFunc(CacheHandler)},
+		{name: "cache-n", path: `/cache/{n:[\d]+}`, methods: getHead, description: "Sets a Cache-Control header for n seconds.", example: "cache/60", handler: http.HandlerFunc(SetCacheHandler)},
+		{name: "cache-leak", path: `/cache/leak`, methods: getHead, params: []string{"body", "forbidden_headers"}, description: "Like /cache, but the 304 optionally carries a body and representation headers forbidden by RFC 7232.", example: "cache/leak?body=foo&forbidden_headers=true", handler: http.HandlerFunc(LeakyCacheHandler)},
+		{name: "once-new", path: `/once/new`, methods: []string{http.MethodGet, http.MethodPost}, params: []string{"ttl"}, description: "Mints a token that /once/:token redeems exactly once, optionally within ttl seconds.", example: "once/new?ttl=60", handler: http.HandlerFunc(NewOnceHandler)},
+		{name: "once", path: `/once/{token:[0-9a-f]+}`, methods: getHead, description: "Redeems a token minted by /once/new, returning 410 Gone once redeemed or expired.", handler: http.HandlerFunc(OnceHandler)},
+		{name: "dedupe", path: `/dedupe`, params: []string{"window", "reject"}, description: "Fingerprints the request by method, path and body hash and reports whether it duplicates one seen within a window, for any method.", example: "dedupe?window=30", handler: http.HandlerFunc(DedupeHandler)},
+		{name: "dedupe-path", path: `/dedupe/{path:.*}`, params: []string{"window", "reject"}, description: "Like /dedupe for any subpath.", example: "dedupe/orders/1", handler: http.HandlerFunc(DedupeHandler)},
+		{name: "jobs-new", path: `/jobs`, methods: []string{http.MethodPost}, params: []string{"phases", "outcome", "callback"}, description: "Starts a simulated long-running job, returning 202 with its Location.", handler: http.HandlerFunc(NewJobHandler)},
+		{name: "jobs", path: `/jobs/{id:[0-9a-f]+}`, methods: []string{http.MethodGet, http.MethodHead, http.MethodDelete}, description: "Reports the status of a /jobs job, with Retry-After until it is done; DELETE cancels it.", handler: http.HandlerFunc(JobHandler)},
+		{name: "cdn", path: `/cdn`, methods: getHead, params: []string{"age", "via", "cache", "hits", "warning", "max_age"}, description: "Returns GET data with the Age, Via, X-Cache and Warning headers a CDN would add.", example: "cdn?age=120&cache=HIT&warning=110", handler: http.HandlerFunc(CDNHandler)},
+		{name: "conditional", path: `/conditional`, methods: getHead, params: []string{"size", "weak"}, description: "Serves a body with fixed validators, honoring If-Match, If-Unmodified-Since, If-None-Match, If-Modified-Since, Range and If-Range.", example: "conditional?size=64", handler: http.HandlerFunc(ConditionalHandler)},
+		{name: "range", path: `/range/{n:[0-9]+}`, methods: getHead, params: []string{"duration", "chunk_size"}, description: "Streams n bytes with Range and If-Range support, optionally over duration seconds in chunk_size chunks.", example: "range/1024", handler: http.HandlerFunc(RangeHandler)},
+		{name: "serve", path: `/serve/{name}`, methods: []string{http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete}, params: []string{"status", "header"}, description: "Serves the fixture registered under name with conditional and Range support; PUT and DELETE, with the fixture token, register and remove fixtures.", handler: http.HandlerFunc(ServeFixtureHandler)},
+		{name: "hash", path: `/hash/{alg:md5|sha1|sha256|sha512|crc32}`, methods: []string{http.MethodPost, http.MethodPut}, description: "Streams the request body through the md5, sha1, sha256, sha512 or crc32 hash and returns its digest, length and throughput.", handler: http.HandlerFunc(HashHandler)},
+		{name: "diff", path: `/diff`, methods: []string{http.MethodPost}, description: "Compares the multipart uploads a, or the fixture named by fixture, and b: line by line for text, byte ranges for binary.", handler: http.HandlerFunc(DiffHandler)},
+		{name: "validate-json-schema", path: `/validate/json-schema`, methods: []string{http.MethodPost}, params: []string{"fixture"}, description: "Validates the body, or multipart document part, against the JSON Schema in the fixture named by fixture or the schema part.", handler: http.HandlerFunc(JSONSchemaHandler)},
+		{name: "byteranges", path: `/byteranges`, methods: getHead, params: []string{"size", "boundary", "order"}, description: "Serves requests for several ranges as multipart/byteranges with a chosen boundary, in request, reverse or shuffled order.", example: "byteranges?boundary=sep&order=reverse", handler: http.HandlerFunc(ByteRangesHandler)},
+		{name: "gzip", path: `/gzip`, methods: getHead, description: "Returns gzip-encoded data.", example: "gzip", handler: http.HandlerFunc(GZIPHandler)},
+		{name: "gzip-stream", path: `/gzip/stream`, methods: getHead, params: []string{"n", "every", "interval"}, description: "Streams n lines of gzip-encoded NDJSON, flushing every few lines at an interval.", example: "gzip/stream?n=10&every=2&interval=1", handler: http.HandlerFunc(GZIPStreamHandler)},
+		{name: "gzip-corrupt", path: `/gzip/corrupt`, methods: getHead, params: []string{"at", "mode"}, description: "Returns gzip-encoded data with the byte at an offset flipped, or truncated there.", example: "gzip/corrupt?at=40&mode=flip", handler: http.HandlerFunc(GZIPCorruptHandler)},
+		{name: "deflate", path: `/deflate`, methods: getHead, description: "Returns deflate-encoded data.", example: "deflate", handler: http.HandlerFunc(DeflateHandler)},
+		{name: "deflate-corrupt", path: `/deflate/corrupt`, methods: getHead, params: []string{"at", "mode"}, description: "Returns deflate-encoded data with the byte at an offset flipped, or truncated there.", example: "deflate/corrupt?mode=truncate", handler: http.HandlerFunc(DeflateCorruptHandler)},
+		{name: "sniff", path: `/sniff`, methods: getHead, params: []string{"body", "type", "nosniff"}, description: "Serves a body whose content contradicts the declared Content-Type, optionally with X-Content-Type-Options: nosniff.", example: "sniff?body=html&type=text/plain", handler: http.HandlerFunc(SniffHandler)},
+		{name: "mime", path: `/mime`, methods: getHead, params: []string{"parts", "size", "filename", "format"}, description: "Returns an email-style multipart MIME message with text, HTML and attachment parts.", example: "mime", handler: http.HandlerFunc(MIMEHandler)},
+		{name: "payloads", path: `/payloads/{format:json|xml|yaml|toml|csv|msgpack|cbor}`, methods: getHead, description: "Returns the same canonical document as json, xml, yaml, toml, csv, msgpack or cbor.", example: "payloads/yaml", handler: http.HandlerFunc(PayloadsHandler), cacheKey: payloadsCacheKey},
+		{name: "json-fail", path: `/json/fail`, methods: getHead, params: []string{"items", "stream"}, description: "Fails to encode JSON after some items, returning a clean 500, or truncated JSON when streamed.", example: "json/fail?items=3&stream=true", handler: http.HandlerFunc(JSONFailHandler)},
+		{name: "split", path: `/split`, methods: getHead, params: []string{"a", "b", "ratio"}, description: "Returns body a with probability ratio, else b, keeping each client on its variant with a cookie.", example: "split?a=control&b=treatment&ratio=0.5", handler: http.HandlerFunc(SplitHandler)},
+		{name: "truncate", path: `/truncate`, methods: getHead, params: []string{"bytes", "of"}, description: "Serves the first bytes of a json, image or gzip payload with the Content-Length of all of it.", example: "truncate?bytes=100&of=json", handler: http.HandlerFunc(TruncateHandler)},
+		{name: "html", path: `/html`, methods: getHead, description: "Renders an HTML Page.", example: "html", handler: http.HandlerFunc(HTMLHandler)},
+		{name: "forms-post", path: `/forms/post`, methods: getHead, description: "Renders an HTML form that submits to /post.", example: "forms/post", handler: http.HandlerFunc(FormsPostHandler)},
+		{name: "encoding-utf8", path: `/encoding/utf8`, methods: getHead, description: "Renders an HTML page of UTF-8 encoded text.", example: "encoding/utf8", handler: http.HandlerFunc(UTF8Handler)},
+		{name: "links", path: `/links/{n:[0-9]+}`, methods: getHead, description: "Redirects to the first page of n links, /links/:n/0.", example: "links/10", handler: http.HandlerFunc(LinksHandler)},
+		{name: "links-offset", path: `/links/{n:[0-9]+}/{offset:[0-9]+}`, methods: getHead, description: "Renders an HTML page of n links to each other, leaving out the link to the offset page.", example: "links/10/3", handler: http.HandlerFunc(LinksHandler)},
+		{name: "xml", path: `/xml`, methods: getHead, description: "Returns some XML.", example: "xml", handler: http.HandlerFunc(XMLHandler)},
+		{name: "video-mp4", path: `/video/mp4`, methods: getHead, params: []string{"duration"}, description: "Returns a small H.264 MP4 video lasting duration seconds, with Range support.", example: "video/mp4?duration=2", handler: http.HandlerFunc(MP4Handler), cacheKey: mediaCacheKey},
+		{name: "audio-wav", path: `/audio/wav`, methods: getHead, params: []string{"duration", "freq"}, description: "Returns a WAV file of a sine tone lasting duration seconds, with Range support.", example: "audio/wav?duration=2&freq=440", handler: http.HandlerFunc(WAVHandler), cacheKey: mediaCacheKey},
+		{name: "pdf", path: `/pdf`, methods: getHead, params: []string{"pages"}, description: "Returns a PDF document of the given number of pages.", example: "pdf?pages=3", handler: http.HandlerFunc(PDFHandler), cacheKey: mediaCacheKey},
+		{name: "zip", path: `/zip`, methods: getHead, params: []string{"entries", "size", "method"}, description: "Returns a ZIP archive of entries files of size bytes, deflated or stored.", example: "zip?entries=3&size=1024", handler: http.HandlerFunc(ZIPHandler), cacheKey: mediaCacheKey},
+		{name: "archive", path: `/archive/{format:tar|tar.gz}`, methods: getHead, params: []string{"entries", "entry_size"}, description: "Streams a tar or tar.gz archive of entries files of entry_size bytes, generated on the fly.", example: "archive/tar.gz?entries=10&entry_size=1024", handler: http.HandlerFunc(ArchiveHandler)},
+		{name: "robots", path: `/robots.txt`, methods: getHead, description: "Returns some robots.txt rules.", example: "robots.txt", handler: http.HandlerFunc(RobotsTXTHandler)},
+		{name: "deny", path: `/deny`, methods: getHead, description: "Denied by robots.txt file.", example: "deny", handler: http.HandlerFunc(DenyHandler)},
+		{name: "basic-auth", path: `/basic-auth/{u}/{p}`, methods: getHead, description: "Challenges HTTP Basic Auth.", example: "basic-auth/user/passwd", handler: http.HandlerFunc(BasicAuthHandler)},
+		{name: "digest-auth", path: `/digest-auth/{qop:auth|auth-int}/{u}/{p}`, methods: []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut}, description: "Challenges HTTP Digest Auth with MD5.", example: "digest-auth/auth/user/passwd", handler: http.HandlerFunc(DigestAuthHandler)},
+		{name: "digest-auth-algorithm", path: `/digest-auth/{qop:auth|auth-int}/{u}/{p}/{algorithm:MD5|MD5-sess|SHA-256|SHA-256-sess}`, methods: []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut}, description: "Challenges HTTP Digest Auth with the given algorithm.", example: "digest-auth/auth/user/passwd/SHA-256", handler: http.HandlerFunc(DigestAuthHandler)},
+		{name: "hidden-basic-auth", path: `/hidden-basic-auth/{u}/{p}`, methods: getHead, description: "Challenges HTTP Basic Auth and returns 404 on failure.", example: "hidden-basic-auth/user/passwd", handler: http.HandlerFunc(HiddenBasicAuthHandler)},
+		{name: "bearer", path: `/bearer`, methods: getHead, description: "Challenges Bearer token auth and echoes the token.", example: "bearer", handler: http.HandlerFunc(BearerHandler)},
+		{name: "login", path: `/login`, methods: []string{http.MethodGet, http.MethodHead, http.MethodPost}, params: []string{"next"}, description: "Serves a login form; posting valid credentials sets a session cookie and redirects to next.", example: "login", handler: http.HandlerFunc(LoginHandler)},
+		{name: "me", path: `/me`, methods: getHead, description: "Returns the user of the /login session cookie, or 401.", example: "me", handler: http.HandlerFunc(MeHandler)},
+		{name: "logout", path: `/logout`, methods: []string{http.MethodGet, http.MethodHead, http.MethodPost}, description: "Ends the /login session and redirects to /login.", example: "logout", handler: http.HandlerFunc(LogoutHandler)},
+		{name: "oidc-authorize", path: `/oidc/authorize`, methods: getHead, params: []string{"response_type", "client_id", "redirect_uri", "scope", "state", "nonce", "login_hint", "code_challenge", "code_challenge_method"}, description: "OpenID Connect authorization endpoint: approves the request and redirects with a code.", example: "oidc/authorize?response_type=code&client_id=httpbin&redirect_uri=http%3A%2F%2Flocalhost%2Fcallback&scope=openid&state=xyz", handler: http.HandlerFunc(OIDCAuthorizeHandler)},
+		{name: "oidc-token", path: `/oidc/token`, methods: []string{http.MethodPost}, description: "OpenID Connect token endpoint: exchanges a code for signed ID and access tokens.", handler: http.HandlerFunc(OIDCTokenHandler)},
+		{name: "oidc-userinfo", path: `/oidc/userinfo`, methods: []string{http.MethodGet, http.MethodHead, http.MethodPost}, description: "OpenID Connect userinfo endpoint: returns the claims of the bearer access token's user.", handler: http.HandlerFunc(OIDCUserInfoHandler)},
+		{name: "jwks", path: `/.well-known/jwks.json`, methods: getHead, description: "Returns the JSON Web Key Set the OpenID Connect tokens are signed with.", example: ".well-known/jwks.json", handler: http.HandlerFunc(JWKSHandler)},
+		{name: "well-known", path: `/.well-known/{name}`, methods: getHead, description: "Serves a well-known resource: security.txt, change-password, openid-configuration, apple-app-site-association or one set with httpbin.WithWellKnown.", example: ".well-known/security.txt", handler: http.HandlerFunc(WellKnownHandler)},
+		{name: "proxy-auth", path: `/proxy-auth/{u}/{p}`, methods: getHead, params: []string{"echo"}, description: "Challenges proxy Basic Auth with a 407, optionally returning the /get response once authenticated.", example: "proxy-auth/user/passwd", handler: http.HandlerFunc(ProxyAuthHandler)},
+		{name: "ntlm-auth", path: `/ntlm-auth`, methods: getHead, description: "Walks the client through an NTLM handshake with a canned challenge and reports the messages it sent.", example: "ntlm-auth", handler: http.HandlerFunc(NTLMAuthHandler)},
+		{name: "negotiate-auth", path: `/negotiate-auth`, methods: getHead, description: "Like /ntlm-auth for the Negotiate scheme, answering non-NTLM tokens with canned server tokens.", example: "negotiate-auth", handler: http.HandlerFunc(NegotiateAuthHandler)},
+		{name: "sigv4", path: `/sigv4`, description: "Verifies the request's AWS Signature Version 4, returning the canonical request and, on failure, a diff against the client's.", handler: http.HandlerFunc(SigV4Handler)},
+		{name: "sigv4-path", path: `/sigv4/{path:.*}`, description: "Like /sigv4 for any path.", handler: http.HandlerFunc(SigV4Handler)},
+		{name: "extract", path: `/extract`, methods: []string{http.MethodPost, http.MethodPut}, params: []string{"path", "syntax"}, description: "Applies a JSONPath or JMESPath expression to the JSON body and returns what it selects, with per-step diagnostics.", handler: http.HandlerFunc(ExtractHandler)},
+		{name: "webhook-verify", path: `/webhook/verify`, methods: []string{http.MethodPost, http.MethodPut}, params: []string{"scheme", "header", "alg"}, description: "Checks the body's webhook signature (GitHub, Stripe or a generic HMAC) and returns the verdict.", handler: http.HandlerFunc(WebhookVerifyHandler)},
+		{name: "transform", path: `/transform`, methods: []string{http.MethodPost}, params: []string{"op", "alg"}, description: "Applies an operation (base64, hash, reverse, uppercase, jsonpretty) to the body and returns the result.", handler: http.HandlerFunc(TransformHandler)},
+		{name: "mirror", path: `/mirror`, methods: []string{http.MethodPost}, params: []string{"status"}, description: "Renders the template named by the X-Httpbin-Mirror-Template header against the JSON request body.", handler: http.HandlerFunc(MirrorHandler)},
+		{name: "clock-sync", path: `/clock-sync`, methods: getHead, params: []string{"t0", "t3", "state"}, description: "Estimates the client's clock offset and round trip time from timestamps exchanged over a chain of requests, like NTP.", example: "clock-sync", handler: http.HandlerFunc(ClockSyncHandler)},
+		{name: "tls-info", path: `/tls-info`, methods: getHead, description: "Returns the TLS version, cipher suite, SNI name and ALPN protocol negotiated for the connection.", example: "tls-info", handler: http.HandlerFunc(TLSInfoHandler)},
+		{name: "http2", path: `/http2`, description: "Returns the HTTP/2 details of the request: pseudo-headers, priority, trailers, header list size and push support.", example: "http2", handler: http.HandlerFunc(HTTP2Handler)},
+		{name: "push", path: `/push`, methods: getHead, params: []string{"n", "size"}, description: "Pushes n resources of size bytes over HTTP/2 and reports which pushes were made or refused.", example: "push?n=3&size=1024", handler: http.HandlerFunc(PushHandler)},
+		{name: "dns-query", path: `/dns-query`, methods: []string{http.MethodGet, http.MethodHead, http.MethodPost}, params: []string{"dns"}, description: "Answers RFC 8484 DNS-over-HTTPS queries from canned records.", handler: http.HandlerFunc(DNSQueryHandler)},
+		{name: "alt-svc", path: `/alt-svc`, methods: getHead, params: []string{"profile", "mode", "path"}, description: "Advertises the listeners of the fast, slow and flaky profiles in Alt-Svc, or redirects to one of them.", example: "alt-svc", handler: http.HandlerFunc(AltSvcHandler)},
+		{name: "connections", path: `/connections`, methods: getHead, description: "Returns the server's open connections by state, remote host and protocol.", example: "connections", handler: http.HandlerFunc(ConnectionsHandler)},
+		{name: "idle-close", path: `/idle-close`, methods: getHead, params: []string{"after"}, description: "Returns GET data, then closes the connection once it has been idle for the given number of seconds.", example: "idle-close?after=5", handler: http.HandlerFunc(IdleCloseHandler)},
+		{name: "partition", path: `/partition`, methods: []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodDelete}, params: []string{"mode", "duration"}, description: "Reports, and with a bearer token starts (refuse, blackhole or reset) or ends, a simulated network partition of the server's connections.", example: "partition", handler: http.HandlerFunc(PartitionHandler)},
+		{name: "maintenance", path: `/maintenance`, methods: []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodDelete}, params: []string{"retry_after", "duration", "bypass"}, description: "Reports, and with a bearer token starts or ends, maintenance mode, when every other endpoint responds 503 with a Retry-After.", example: "maintenance", handler: http.HandlerFunc(MaintenanceHandler)},
+		{name: "region", path: `/region`, methods: getHead, description: "Returns the region and zone labels of the instance and its artificial base latency.", example: "region", handler: http.HandlerFunc(RegionHandler)},
+		{name: "sticky", path: `/sticky`, methods: getHead, params: []string{"cookie", "header", "buckets"}, description: "Pins the client to the instance with an affinity cookie and reports whether it landed on the one it was pinned to, and the consistent hash bucket of a routing header.", example: "sticky?header=X-Route-Key&buckets=8", handler: http.HandlerFunc(StickyHandler)},
+		{name: "config", path: `/config`, methods: getHead, description: "Returns the effective limits, feature flags and endpoints, optionally requiring a bearer token.", example: "config", handler: http.HandlerFunc(ConfigHandler)},
+		{name: "stats", path: `/stats`, methods: []string{http.MethodGet, http.MethodHead, http.MethodDelete}, description: "Returns the requests and request and response body bytes of each endpoint; DELETE resets them.", example: "stats", handler: http.HandlerFunc(StatsHandler)},
+		{name: "quota", path: `/quota`, methods: getHead, description: "Returns the daily request and byte quota of the X-Api-Key, how much of it is left and when it resets.", example: "quota", handler: http.HandlerFunc(QuotaHandler)},
+		{name: "response-cache", path: `/response-cache`, methods: getHead, description: "Returns the size and hit statistics of the cache of generated responses.", example: "response-cache", handler: http.HandlerFunc(ResponseCacheHandler)},
+		{name: "methods", path: `/methods/{path:.*}`, methods: getHead, description: "Returns the methods supported on the given path.", example: "methods/get", handler: methodsHandler(router)},
+	}
+	routes = append(routes, featureRoutes...)
+	if h.profiling {
+		routes = append(routes, pprofRoutes...)
+	}
+	if len(h.disabledRoutes) > 0 {
+		enabled := routes[:0]
+		for _, rt := range routes {
+			if !h.disabledRoutes[rt.name] {
+				enabled = append(enabled, rt)
+			}
+		}
+		routes = enabled
+	}
+	return routes
+}
+
+// routeVar matches the variables of a route template, with the optional
+// regexp in the second group.
+var routeVar = regexp.MustCompile(`\{([^{}:]+)(?::((?:[^{}]|\{[^{}]*\})*))?\}`)
+
+// pathParams returns the names of the variables in the route's path.
+func (rt route) pathParams() []string {
+	var names []string
+	for _, m := range routeVar.FindAllStringSubmatch(rt.path, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// displayPath returns the route's path with variables written as :name.
+func (rt route) displayPath() string {
+	return routeVar.ReplaceAllString(rt.path, ":$1")
+}
+
+// register adds the route to the router.
+func (rt route) register(r *routeMux, hb *HTTPBin) {
+	h := rt.handler
+	if rt.cacheKey != nil {
+		h = cachedHandler(rt.name, rt.cacheKey, h)
+	}
+	if hb.profiling {
+		h = allocHandler(rt.name, h)
+	}
+	if hb.decompressRequests {
+		h = decompressHandler(h)
+	}
+	h = statsHandler(rt.name, h)
+	if hb.quotas != nil {
+		h = quotaHandler(rt.name, h)
+	}
+	h = maintenanceHandler(rt.name, h)
+	h = regionHandler(h)
+	r.handle(rt.name, rt.path, rt.methods, rt.queries, bufferedHandler(h))
+}
+
+// OpenAPIHandler serves an OpenAPI 3 description of the httpbin endpoints.
+func OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	if err := writeJSON(w, openAPISpec(instance(r).routeTable(nil))); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
+
+func openAPISpec(routes []route) openAPIDocument {
+	doc := openAPIDocument{
+		OpenAPI: "3.0.3",
+		Info:    openAPIInfo{Title: "go-httpbin", Version: "1.0.0"},
+		Paths:   make(map[string]map[string]openAPIOperation),
+	}
+	for _, rt := range routes {
+		p := routeVar.ReplaceAllString(rt.path, "{$1}")
+		var params []openAPIParameter
+		for _, m := range routeVar.FindAllStringSubmatch(rt.path, -1) {
+			params = append(params, openAPIParameter{Name: m[1], In: "path", Required: true, Schema: paramSchema(m[2])})
+		}
+		for i := 0; i+1 < len(rt.queries); i += 2 {
+			var pattern string
+			if m := routeVar.FindStringSubmatch(rt.queries[i+1]); m != nil {
+				pattern = m[2]
+			}
+			params = append(params, openAPIParameter{Name: rt.queries[i], In: "query", Required: true, Schema: paramSchema(pattern)})
+		}
+		for _, q := range rt.params {
+			params = append(params, openAPIParameter{Name: q, In: "query", Schema: paramSchema("")})
+		}
+
+		methods := rt.methods
+		if len(methods) == 0 {
+			methods = probeMethods
+		}
+		ops := doc.Paths[p]
+		if ops == nil {
+			ops = make(map[string]openAPIOperation)
+			doc.Paths[p] = ops
+		}
+		for _, m := range methods {
+			op := openAPIOperation{
+				OperationID: rt.name,
+				Summary:     rt.description,
+				Parameters:  params,
+				Responses:   map[string]openAPIResponse{"default": {Description: "See summary."}},
+			}
+			if len(methods) > 1 {
+				op.OperationID += "-" + strings.ToLower(m)
+			}
+			ops[strings.ToLower(m)] = op
+		}
+	}
+	return doc
+}
+
+// paramSchema guesses the schema of a parameter from its route regexp.
+func paramSchema(pattern string) openAPISchema {
+	switch pattern {
+	case `[\d]+`, `\d+`:
+		return openAPISchema{Type: "integer"}
+	case `\d+(?:\.\d+)?`:
+		return openAPISchema{Type: "number"}
+	}
+	return openAPISchema{Type: "string"}
+}
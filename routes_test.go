@@ -0,0 +1,111 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHome_listsRoutes(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	b := string(get(t, srv.URL))
+	require.Contains(t, b, `<a href="status/418"><code>/status/:code</code></a>`)
+	require.Contains(t, b, `<code>/post</code> Returns POST data.`)
+	require.Contains(t, b, `<a href="gzip"><code>/gzip</code></a>`)
+}
+
+func TestOpenAPI(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	type param struct {
+		Name     string `json:"name"`
+		In       string `json:"in"`
+		Required bool   `json:"required"`
+		Schema   struct {
+			Type string `json:"type"`
+		} `json:"schema"`
+	}
+	var v struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			OperationID string  `json:"operationId"`
+			Parameters  []param `json:"parameters"`
+		} `json:"paths"`
+	}
+	require.Nil(t, json.Unmarshal(get(t, srv.URL+"/openapi.json"), &v))
+	require.Equal(t, "3.0.3", v.OpenAPI)
+
+	status := v.Paths["/status/{code}"]["get"]
+	require.Equal(t, "status-get", status.OperationID)
+	require.Len(t, status.Parameters, 1)
+	require.Equal(t, "code", status.Parameters[0].Name)
+	require.Equal(t, "integer", status.Parameters[0].Schema.Type)
+
+	require.Contains(t, v.Paths["/post"], "post")
+	require.NotContains(t, v.Paths["/post"], "get")
+
+	drip := v.Paths["/drip"]["get"]
+	var required, optional []string
+	for _, p := range drip.Parameters {
+		if p.Required {
+			required = append(required, p.Name)
+		} else {
+			optional = append(optional, p.Name)
+		}
+	}
+	require.Equal(t, []string{"numbytes", "duration"}, required)
+	require.Equal(t, []string{"delay", "code"}, optional)
+}
+
+// routeNames records the route names of the requests it is notified of.
+type routeNames struct {
+	httpbin.NopEvents
+	names []string
+}
+
+func (e *routeNames) OnRequestStart(r *http.Request) {
+	e.names = append(e.names, httpbin.RouteName(r))
+}
+
+func TestGetMux_routeNames(t *testing.T) {
+	e := &routeNames{}
+	r := httpbin.New(httpbin.WithHooks(e)).Handler()
+
+	for _, target := range []string{"/ip", "/status/418", "/redirect-to?url=/get", "/nope"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	require.Equal(t, []string{"ip", "status", "redirect-to"}, e.names)
+	require.Empty(t, httpbin.RouteName(httptest.NewRequest(http.MethodGet, "/ip", nil)))
+}
+
+func TestGetMux_routing(t *testing.T) {
+	r := httpbin.GetMux()
+
+	for _, tc := range []struct {
+		method, target string
+		status         int
+		location       string
+	}{
+		{http.MethodGet, "/status/418", http.StatusTeapot, ""},
+		{http.MethodGet, "/status/abc", http.StatusNotFound, ""},
+		{http.MethodPost, "/ip", http.StatusNotFound, ""},
+		{http.MethodGet, "/ip/", http.StatusNotFound, ""},
+		{http.MethodGet, "/redirect-to", http.StatusNotFound, ""},
+		{http.MethodGet, "/drip?numbytes=5&duration=0", http.StatusOK, ""},
+		{http.MethodGet, "/drip?numbytes=x&duration=0", http.StatusNotFound, ""},
+		{http.MethodGet, "/a/../get?x=1", http.StatusMovedPermanently, "/get?x=1"},
+		{http.MethodGet, "//ip", http.StatusMovedPermanently, "/ip"},
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tc.method, tc.target, nil))
+		require.Equal(t, tc.status, w.Code, tc.method+" "+tc.target)
+		require.Equal(t, tc.location, w.Header().Get("Location"), tc.target)
+	}
+}
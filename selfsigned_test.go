@@ -0,0 +1,49 @@
+package httpbin_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelfSignedCertificate(t *testing.T) {
+	cert, err := httpbin.SelfSignedCertificate("example.test", "127.0.0.1")
+	require.Nil(t, err)
+	require.Equal(t, []string{"example.test"}, cert.Leaf.DNSNames)
+	require.Len(t, cert.Leaf.IPAddresses, 1)
+
+	srv := httptest.NewUnstartedServer(httpbin.GetMux())
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	srv.StartTLS()
+	defer srv.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert.Leaf)
+	_, port, _ := net.SplitHostPort(srv.Listener.Addr().String())
+	for _, tc := range []struct {
+		host string
+		ok   bool
+	}{{"example.test", true}, {"other.test", false}} {
+		c := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, ServerName: tc.host},
+		}}
+		resp, err := c.Get("https://127.0.0.1:" + port + "/get")
+		if !tc.ok {
+			require.NotNil(t, err, tc.host)
+			continue
+		}
+		require.Nil(t, err, tc.host)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	cert, err = httpbin.SelfSignedCertificate()
+	require.Nil(t, err)
+	require.Equal(t, []string{"localhost"}, cert.Leaf.DNSNames)
+}
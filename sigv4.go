@@ -0,0 +1,273 @@
+package httpbin
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// defaultSigV4Credentials are the example credentials used in the AWS
+// Signature Version 4 test suite.
+var defaultSigV4Credentials = map[string]string{
+	"AKIDEXAMPLE": "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+}
+
+// WithSigV4Credentials sets the AWS access key IDs and secret keys /sigv4
+// verifies signatures with. It defaults to the example credentials used in
+// the AWS Signature Version 4 test suite.
+func WithSigV4Credentials(credentials map[string]string) Option {
+	return func(h *HTTPBin) { h.sigV4Credentials = credentials }
+}
+
+const (
+	sigV4Algorithm = "AWS4-HMAC-SHA256"
+
+	// sigV4CanonicalRequestHeader optionally carries the base64-encoded
+	// canonical request the client signed, to be diffed against the one the
+	// server computed.
+	sigV4CanonicalRequestHeader = "X-Httpbin-Canonical-Request"
+)
+
+type sigV4Request struct {
+	accessKey     string
+	date          string // yyyymmdd, from the credential scope
+	region        string
+	service       string
+	amzDate       string // x-amz-date or X-Amz-Date
+	signedHeaders []string
+	signature     string
+	presigned     bool
+}
+
+// SigV4Handler verifies the AWS Signature Version 4 of the request, sent
+// either in the Authorization header or as presigned URL query parameters,
+// using the SigV4 credentials. It responds with the canonical request and string
+// to sign the server computed, and, when the signature does not match and
+// the client sent its canonical request in the X-Httpbin-Canonical-Request
+// header, a line by line diff of the two.
+func SigV4Handler(w http.ResponseWriter, r *http.Request) {
+	body, err := parseData(r)
+	if err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to read body: %w", err))
+		return
+	}
+
+	sr, err := parseSigV4Request(r)
+	if err != nil {
+		writeErrorJSONStatus(w, http.StatusBadRequest, err)
+		return
+	}
+	secret, ok := instance(r).sigV4Credentials[sr.accessKey]
+	if !ok {
+		writeErrorJSONStatus(w, http.StatusForbidden, fmt.Errorf("unknown access key %q", sr.accessKey))
+		return
+	}
+
+	lines, labels := sigV4CanonicalRequest(r, sr, body)
+	canonical := strings.Join(lines, "\n")
+	scope := strings.Join([]string{sr.date, sr.region, sr.service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{sigV4Algorithm, sr.amzDate, scope, hexSHA256([]byte(canonical))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secret), sr.date)
+	for _, s := range []string{sr.region, sr.service, "aws4_request"} {
+		key = hmacSHA256(key, s)
+	}
+	expected := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	v := sigV4Response{
+		Verified:          hmac.Equal([]byte(expected), []byte(sr.signature)),
+		AccessKey:         sr.accessKey,
+		CredentialScope:   scope,
+		SignedHeaders:     sr.signedHeaders,
+		Signature:         sr.signature,
+		ExpectedSignature: expected,
+		CanonicalRequest:  canonical,
+		StringToSign:      stringToSign,
+	}
+	if !v.Verified {
+		if enc := r.Header.Get(sigV4CanonicalRequestHeader); enc != "" {
+			theirs, err := base64.StdEncoding.DecodeString(enc)
+			if err != nil {
+				writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("failed to decode %s: %w", sigV4CanonicalRequestHeader, err))
+				return
+			}
+			v.Diff = diffCanonicalRequests(lines, labels, strings.Split(string(theirs), "\n"))
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
+
+// parseSigV4Request extracts the signature parameters from the Authorization
+// header or, failing that, from presigned URL query parameters.
+func parseSigV4Request(r *http.Request) (*sigV4Request, error) {
+	var sr sigV4Request
+	var credential, signedHeaders string
+
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if !strings.HasPrefix(auth, sigV4Algorithm+" ") {
+			return nil, fmt.Errorf("unsupported authorization scheme, want %s", sigV4Algorithm)
+		}
+		for _, kv := range strings.Split(strings.TrimPrefix(auth, sigV4Algorithm+" "), ",") {
+			kv = strings.TrimSpace(kv)
+			i := strings.IndexByte(kv, '=')
+			if i < 0 {
+				return nil, fmt.Errorf("malformed authorization component %q", kv)
+			}
+			switch kv[:i] {
+			case "Credential":
+				credential = kv[i+1:]
+			case "SignedHeaders":
+				signedHeaders = kv[i+1:]
+			case "Signature":
+				sr.signature = kv[i+1:]
+			}
+		}
+		sr.amzDate = r.Header.Get("X-Amz-Date")
+	} else {
+		q := r.URL.Query()
+		if q.Get("X-Amz-Algorithm") != sigV4Algorithm {
+			return nil, errors.New("missing Authorization header or presigned URL parameters")
+		}
+		sr.presigned = true
+		credential = q.Get("X-Amz-Credential")
+		signedHeaders = q.Get("X-Amz-SignedHeaders")
+		sr.signature = q.Get("X-Amz-Signature")
+		sr.amzDate = q.Get("X-Amz-Date")
+	}
+
+	scope := strings.Split(credential, "/")
+	if len(scope) != 5 || scope[4] != "aws4_request" {
+		return nil, fmt.Errorf("malformed credential %q, want <key>/<date>/<region>/<service>/aws4_request", credential)
+	}
+	sr.accessKey, sr.date, sr.region, sr.service = scope[0], scope[1], scope[2], scope[3]
+	if signedHeaders == "" || sr.signature == "" || sr.amzDate == "" {
+		return nil, errors.New("missing SignedHeaders, Signature or X-Amz-Date")
+	}
+	sr.signedHeaders = strings.Split(signedHeaders, ";")
+	return &sr, nil
+}
+
+// sigV4CanonicalRequest returns the lines of the canonical request and a
+// description of what each line holds.
+func sigV4CanonicalRequest(r *http.Request, sr *sigV4Request, body []byte) (lines, labels []string) {
+	add := func(label, line string) {
+		labels = append(labels, label)
+		lines = append(lines, line)
+	}
+
+	add("method", r.Method)
+
+	segments := strings.Split(r.URL.Path, "/")
+	for i, s := range segments {
+		s = sigV4Escape(s)
+		if sr.service != "s3" {
+			s = sigV4Escape(s) // all services but S3 encode path segments twice
+		}
+		segments[i] = s
+	}
+	path := strings.Join(segments, "/")
+	if path == "" {
+		path = "/"
+	}
+	add("canonical URI", path)
+
+	q := r.URL.Query()
+	var params []string
+	for k, vs := range q {
+		if sr.presigned && k == "X-Amz-Signature" {
+			continue
+		}
+		for _, v := range vs {
+			params = append(params, sigV4Escape(k)+"="+sigV4Escape(v))
+		}
+	}
+	sort.Strings(params)
+	add("canonical query string", strings.Join(params, "&"))
+
+	for _, h := range sr.signedHeaders {
+		vs := r.Header[http.CanonicalHeaderKey(h)]
+		if h == "host" {
+			vs = []string{r.Host}
+		}
+		trimmed := make([]string, len(vs))
+		for i, v := range vs {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		add("header "+h, h+":"+strings.Join(trimmed, ","))
+	}
+	add("end of headers", "")
+	add("signed headers", strings.Join(sr.signedHeaders, ";"))
+
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash == "" {
+		if sr.presigned {
+			payloadHash = "UNSIGNED-PAYLOAD"
+		} else {
+			payloadHash = hexSHA256(body)
+		}
+	}
+	add("payload hash", payloadHash)
+	return lines, labels
+}
+
+// diffCanonicalRequests compares the server's canonical request lines to the
+// client's and returns the lines that differ.
+func diffCanonicalRequests(ours, labels, theirs []string) []canonicalRequestDiff {
+	var diff []canonicalRequestDiff
+	n := len(ours)
+	if len(theirs) > n {
+		n = len(theirs)
+	}
+	for i := 0; i < n; i++ {
+		var d canonicalRequestDiff
+		d.Line = i + 1
+		if i < len(ours) {
+			d.Field = labels[i]
+			d.Expected = ours[i]
+		}
+		if i < len(theirs) {
+			d.Received = theirs[i]
+		}
+		if i >= len(ours) || i >= len(theirs) || d.Expected != d.Received {
+			diff = append(diff, d)
+		}
+	}
+	return diff
+}
+
+// sigV4Escape percent-encodes everything but the RFC 3986 unreserved
+// characters, as SigV4 requires.
+func sigV4Escape(s string) string {
+	var b bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hexSHA256(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
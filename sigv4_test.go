@@ -0,0 +1,83 @@
+package httpbin_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+// Requests and signatures from the AWS Signature Version 4 test suite.
+func TestSigV4_testSuite(t *testing.T) {
+	cases := []struct {
+		url, signature string
+	}{
+		{"http://example.amazonaws.com/", "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"},
+		{"http://example.amazonaws.com/?Param2=value2&Param1=value1", "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", c.url, nil)
+		r.Header.Set("X-Amz-Date", "20150830T123600Z")
+		r.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+			"SignedHeaders=host;x-amz-date, Signature="+c.signature)
+		w := httptest.NewRecorder()
+		httpbin.SigV4Handler(w, r)
+		require.Equal(t, http.StatusOK, w.Code, "%s: %s", c.url, w.Body.String())
+
+		var v struct {
+			Verified bool `json:"verified"`
+		}
+		require.Nil(t, json.Unmarshal(w.Body.Bytes(), &v))
+		require.True(t, v.Verified, c.url)
+	}
+}
+
+func TestSigV4_mismatchDiff(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	req, err := http.NewRequest("GET", srv.URL+"/sigv4?b=2&a=1", nil)
+	require.Nil(t, err)
+	req.Header.Set("X-Amz-Date", "20150830T123600Z")
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, Signature=0000")
+	theirs := "GET\n/sigv4\nb=2&a=1\nhost:" + req.URL.Host + "\nx-amz-date:20150830T123600Z\n\nhost;x-amz-date\n" +
+		"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	req.Header.Set("X-Httpbin-Canonical-Request", base64.StdEncoding.EncodeToString([]byte(theirs)))
+
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	var v struct {
+		Verified bool `json:"verified"`
+		Diff     []struct {
+			Line     int    `json:"line"`
+			Field    string `json:"field"`
+			Expected string `json:"expected"`
+			Received string `json:"received"`
+		} `json:"diff"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	require.False(t, v.Verified)
+	require.Len(t, v.Diff, 1)
+	require.Equal(t, 3, v.Diff[0].Line)
+	require.Equal(t, "canonical query string", v.Diff[0].Field)
+	require.Equal(t, "a=1&b=2", v.Diff[0].Expected)
+	require.Equal(t, "b=2&a=1", v.Diff[0].Received)
+}
+
+func TestSigV4_unsigned(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/sigv4")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,66 @@
+package httpbin
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sniffBodies are the bodies /sniff serves, each recognizable by content
+// sniffing regardless of the declared Content-Type.
+var sniffBodies = map[string]string{
+	"html":   "<!DOCTYPE html>\n<html><body><h1>sniffed as HTML</h1><script>document.title = 'executed';</script></body></html>\n",
+	"script": "document.title = 'executed';\n",
+	"json":   "{\"sniffed\": \"json\"}\n",
+	"xml":    "<?xml version=\"1.0\"?>\n<sniffed>xml</sniffed>\n",
+	"png":    "\x89\x50\x4e\x47\x0d\x0a\x1a\x0a\x00\x00\x00\x0d\x49\x48\x44\x52\x00\x00\x00\x01\x00\x00\x00\x01\x08\x00\x00\x00\x00\x3a\x7e\x9b\x55\x00\x00\x00\x0a\x49\x44\x41\x54\x78\x9c\x63\x60\x00\x00\x00\x02\x00\x01\x48\xaf\xa4\x71\x00\x00\x00\x00\x49\x45\x4e\x44\xae\x42\x60\x82",
+	"gif":    "GIF89a\x01\x00\x01\x00\x80\x00\x00\x00\x00\x00\xff\xff\xff!\xf9\x04\x01\x00\x00\x00\x00,\x00\x00\x00\x00\x01\x00\x01\x00\x00\x02\x02D\x01\x00;",
+	"pdf":    "%PDF-1.0\n%%EOF\n",
+}
+
+// SniffHandler serves the body named by the 'body' query parameter (html,
+// script, json, xml, png, gif or pdf; default html) with the Content-Type
+// given by 'type' (default text/plain), which is meant to contradict it, to
+// study client and browser MIME sniffing. A 'type' of "none" sends no
+// Content-Type at all, and 'nosniff=true' adds X-Content-Type-Options:
+// nosniff. The type Go's implementation of the WHATWG sniffing algorithm
+// detects is reported in X-Httpbin-Sniffed.
+func SniffHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	name := q.Get("body")
+	if name == "" {
+		name = "html"
+	}
+	body, ok := sniffBodies[name]
+	if !ok {
+		names := make([]string, 0, len(sniffBodies))
+		for n := range sniffBodies {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("unknown body %q, want one of %s", name, strings.Join(names, ", ")))
+		return
+	}
+
+	contentType := q.Get("type")
+	switch contentType {
+	case "":
+		contentType = "text/plain"
+	case "none":
+		contentType = ""
+	}
+	nosniff, _ := strconv.ParseBool(q.Get("nosniff"))
+
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	} else {
+		w.Header()["Content-Type"] = nil // keep net/http from sniffing it
+	}
+	if nosniff {
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+	}
+	w.Header().Set("X-Httpbin-Sniffed", http.DetectContentType([]byte(body)))
+	w.Write([]byte(body))
+}
@@ -0,0 +1,43 @@
+package httpbin_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSniff(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		query, contentType, sniffed, nosniff string
+	}{
+		{"", "text/plain", "text/html; charset=utf-8", ""},
+		{"body=png&type=application/json", "application/json", "image/png", ""},
+		{"body=gif&type=text/html&nosniff=true", "text/html", "image/gif", "nosniff"},
+		{"body=html&type=none", "", "text/html; charset=utf-8", ""},
+	} {
+		resp, err := http.Get(srv.URL + "/sniff?" + tc.query)
+		require.Nil(t, err)
+		_, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.Nil(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode, tc.query)
+		require.Equal(t, tc.contentType, resp.Header.Get("Content-Type"), tc.query)
+		require.Equal(t, tc.sniffed, resp.Header.Get("X-Httpbin-Sniffed"), tc.query)
+		require.Equal(t, tc.nosniff, resp.Header.Get("X-Content-Type-Options"), tc.query)
+	}
+}
+
+func TestSniff_unknownBody(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/sniff?body=exe")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
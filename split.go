@@ -0,0 +1,61 @@
+package httpbin
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+)
+
+// splitCookie is the cookie holding the variant a client was assigned by
+// /split.
+const splitCookie = "httpbin_split"
+
+// SplitHandler serves one of two response bodies, the 'a' and 'b' query
+// parameters (default "a" and "b"), picking a with probability 'ratio'
+// (default 0.5). The assignment is made sticky with a cookie: a client
+// presenting it keeps getting the same variant, which is also reported in
+// the X-Split-Variant header.
+func SplitHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	templates := map[string]string{"a": "a", "b": "b"}
+	for k := range templates {
+		if v, ok := q[k]; ok {
+			templates[k] = v[0]
+		}
+	}
+	ratio := 0.5
+	if s := q.Get("ratio"); s != "" {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || f < 0 || f > 1 {
+			writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("'ratio' must be a number between 0 and 1"))
+			return
+		}
+		ratio = f
+	}
+
+	variant := ""
+	if c, err := r.Cookie(splitCookie); err == nil {
+		if _, ok := templates[c.Value]; ok {
+			variant = c.Value
+		}
+	}
+	if variant == "" {
+		variant = "b"
+		if rand.Float64() < ratio {
+			variant = "a"
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:  splitCookie,
+			Value: variant,
+			Path:  prefixed(r, "/split"),
+		})
+	}
+
+	h := w.Header()
+	h.Set("Content-Type", "text/plain; charset=utf-8")
+	h.Set("Cache-Control", "private, no-store")
+	h.Set("Vary", "Cookie")
+	h.Set("X-Split-Variant", variant)
+	w.Write([]byte(templates[variant]))
+}
@@ -0,0 +1,47 @@
+package httpbin_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplit_ratio(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for ratio, want := range map[string]string{"1": "control", "0": "treatment"} {
+		resp, err := http.Get(srv.URL + "/split?a=control&b=treatment&ratio=" + ratio)
+		require.Nil(t, err)
+		b, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.Equal(t, want, string(b))
+		require.NotEmpty(t, resp.Cookies())
+	}
+
+	resp, err := http.Get(srv.URL + "/split?ratio=2")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestSplit_sticky(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	jar, _ := cookiejar.New(nil)
+	c := &http.Client{Jar: jar}
+	get := func(ratio string) string {
+		resp, err := c.Get(srv.URL + "/split?ratio=" + ratio)
+		require.Nil(t, err)
+		b, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.Equal(t, string(b), resp.Header.Get("X-Split-Variant"))
+		return string(b)
+	}
+	require.Equal(t, "a", get("1"))
+	require.Equal(t, "a", get("0"), "assignment should stick")
+}
@@ -0,0 +1,117 @@
+package httpbin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sseMalformed is the stream of /sse?mode=malformed: events that are framed
+// unusually but legally, each of which an EventSource parser must get
+// right, ending with an unterminated event that must be discarded.
+var sseMalformed = []string{
+	"\xef\xbb\xbfdata: bom\n\n",                     // a leading BOM is skipped
+	"data: cr\r\r",                                  // CR line endings
+	"data: crlf\r\n\r\n",                            // CRLF line endings
+	"data:no-space\n\n",                             // no space after the colon
+	"data\n\n",                                      // a field without a colon, empty data
+	"data: multi\ndata: line\n\n",                   // data lines are joined by LF
+	"foo: bar\ndata: unknown-field\n\n",             // unknown fields are ignored
+	"retry: soon\ndata: bad-retry\n\n",              // non-numeric retry is ignored
+	"id: a\x00b\ndata: nul-id\n\n",                  // ids with NUL are ignored
+	"event: split\n", "data: across-writes\n", "\n", // an event split across writes
+	"data: unterminated", // dispatched only once a blank line follows
+}
+
+// sseModes are the modes of /sse.
+var sseModes = map[string]bool{"normal": true, "disconnect": true, "keepalive": true, "malformed": true, "huge": true}
+
+// SSEHandler streams server-sent events, 'n' (default 10) of them
+// 'interval' seconds apart (default: the stream interval), numbered from the
+// Last-Event-ID header so clients can resume. If 'retry' is set, a retry
+// field with that many milliseconds is sent first. 'mode' breaks the stream
+// to test EventSource clients:
+//
+//   - disconnect drops the connection after 'at' events (default n/2).
+//   - keepalive sends only comments.
+//   - malformed sends events with unusual but legal framing.
+//   - huge sends a single event of 'size' bytes of data (default 1MiB).
+func SSEHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	mode := q.Get("mode")
+	if mode == "" {
+		mode = "normal"
+	}
+	if !sseModes[mode] {
+		var modes []string
+		for m := range sseModes {
+			modes = append(modes, m)
+		}
+		sort.Strings(modes)
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("unknown mode %q, want one of %s", mode, strings.Join(modes, ", ")))
+		return
+	}
+	n, retry, size, interval := 10, -1, 1<<20, instance(r).streamInterval
+	if !intParam(w, r, "n", 0, 10000, &n) ||
+		!intParam(w, r, "retry", 0, 1<<30, &retry) ||
+		!intParam(w, r, "size", 0, 100<<20, &size) ||
+		!secondsParam(w, r, "interval", &interval) {
+		return
+	}
+	at := n / 2
+	if !intParam(w, r, "at", 0, n, &at) {
+		return
+	}
+	id := 0
+	if s := r.Header.Get("Last-Event-ID"); s != "" {
+		if v, err := strconv.Atoi(s); err == nil && v >= 0 {
+			id = v + 1
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	send := func(s string) {
+		io.WriteString(w, s)
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+	}
+	if retry >= 0 {
+		send(fmt.Sprintf("retry: %d\n\n", retry))
+	}
+
+	switch mode {
+	case "malformed":
+		for _, s := range sseMalformed {
+			send(s)
+		}
+		return
+	case "huge":
+		send(fmt.Sprintf("id: %d\ndata: %s\n\n", id, bytes.Repeat([]byte{'x'}, size)))
+		return
+	}
+	for i := 0; i < n; i++ {
+		if i > 0 && !sleepRequest(r, interval) {
+			return
+		}
+		if mode == "disconnect" && i == at {
+			panic(http.ErrAbortHandler)
+		}
+		if mode == "keepalive" {
+			send(": keepalive\n\n")
+			continue
+		}
+		b, _ := json.Marshal(struct {
+			N    int       `json:"n"`
+			Time time.Time `json:"time"`
+		}{id + i, time.Now().UTC()})
+		send(fmt.Sprintf("id: %d\nevent: tick\ndata: %s\n\n", id+i, b))
+	}
+}
@@ -0,0 +1,68 @@
+package httpbin_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSSE(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	req, _ := http.NewRequest("GET", srv.URL+"/sse?n=3&interval=0&retry=1500", nil)
+	req.Header.Set("Last-Event-ID", "41")
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Nil(t, err)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	body := string(b)
+	require.True(t, strings.HasPrefix(body, "retry: 1500\n\n"), body)
+	require.Equal(t, 3, strings.Count(body, "event: tick\n"))
+	require.Contains(t, body, "id: 42\n")
+	require.Contains(t, body, "id: 44\n")
+
+	resp, err = http.Get(srv.URL + "/sse?mode=bogus")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestSSE_modes(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/sse?mode=disconnect&n=4&at=2&interval=0")
+	require.Nil(t, err)
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NotNil(t, err, "the stream should end abruptly")
+	require.Equal(t, 2, strings.Count(string(b), "event: tick\n"))
+
+	resp, err = http.Get(srv.URL + "/sse?mode=keepalive&n=3&interval=0")
+	require.Nil(t, err)
+	b, err = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Nil(t, err)
+	require.Equal(t, strings.Repeat(": keepalive\n\n", 3), string(b))
+
+	resp, err = http.Get(srv.URL + "/sse?mode=huge&size=100000")
+	require.Nil(t, err)
+	b, err = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Nil(t, err)
+	require.Equal(t, "id: 0\ndata: "+strings.Repeat("x", 100000)+"\n\n", string(b))
+
+	resp, err = http.Get(srv.URL + "/sse?mode=malformed")
+	require.Nil(t, err)
+	b, err = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Nil(t, err)
+	require.True(t, strings.HasPrefix(string(b), "\xef\xbb\xbfdata: bom\n\n"))
+	require.True(t, strings.HasSuffix(string(b), "data: unterminated"))
+}
@@ -0,0 +1,86 @@
+package httpbin
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// statsTracker accumulates the requests and body bytes of each route.
+type statsTracker struct {
+	mu     sync.Mutex
+	routes map[string]*routeStatsEntry
+}
+
+// statsHandler counts the requests to h and the bytes of their request and
+// response bodies under the route name. It must be wrapped by
+// bufferedHandler, which counts the response bytes.
+func statsHandler(name string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in int64
+		if r.Body != nil {
+			r.Body = &countingBody{ReadCloser: r.Body, n: &in}
+		}
+		defer func() { // also when the response is aborted
+			var out int64
+			if br, ok := w.(*bufferedResponse); ok {
+				out = br.written
+			}
+			routeStats := instance(r).routeStats
+			routeStats.mu.Lock()
+			defer routeStats.mu.Unlock()
+			s := routeStats.routes[name]
+			if s == nil {
+				s = &routeStatsEntry{}
+				routeStats.routes[name] = s
+			}
+			s.Requests++
+			s.BytesIn += atomic.LoadInt64(&in)
+			s.BytesOut += out
+		}()
+		h.ServeHTTP(w, r)
+	})
+}
+
+// countingBody counts the bytes read from a request body.
+type countingBody struct {
+	io.ReadCloser
+	n *int64
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	atomic.AddInt64(b.n, int64(n))
+	return n, err
+}
+
+// StatsHandler returns the number of requests and the request and response
+// body bytes of each route, and the request bodies decompressed and rejected
+// for WithDecompressRequests, since the server started or the stats were last
+// reset. DELETE resets them, returning the stats up to the reset.
+func StatsHandler(w http.ResponseWriter, r *http.Request) {
+	routeStats, decompression := instance(r).routeStats, instance(r).decompression
+	v := statsResponse{Routes: make(map[string]routeStatsEntry)}
+	routeStats.mu.Lock()
+	for name, s := range routeStats.routes {
+		v.Routes[name] = *s
+		v.Total.Requests += s.Requests
+		v.Total.BytesIn += s.BytesIn
+		v.Total.BytesOut += s.BytesOut
+	}
+	decompression.mu.Lock()
+	v.Decompression = decompression.v
+	if r.Method == http.MethodDelete {
+		routeStats.routes = make(map[string]*routeStatsEntry)
+		decompression.v = decompressionStats{}
+		v.Reset = true
+	}
+	decompression.mu.Unlock()
+	routeStats.mu.Unlock()
+
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
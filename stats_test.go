@@ -0,0 +1,53 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type statsEntry struct {
+	Requests int64 `json:"requests"`
+	BytesIn  int64 `json:"bytes_in"`
+	BytesOut int64 `json:"bytes_out"`
+}
+
+func getStats(t *testing.T, method, url string) map[string]statsEntry {
+	req, _ := http.NewRequest(method, url, nil)
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	var v struct {
+		Routes map[string]statsEntry `json:"routes"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	return v.Routes
+}
+
+func TestStats(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	getStats(t, "DELETE", srv.URL+"/stats")
+	for i := 0; i < 2; i++ {
+		resp, err := http.Post(srv.URL+"/post", "text/plain", strings.NewReader("hello"))
+		require.Nil(t, err)
+		resp.Body.Close()
+	}
+	resp, err := http.Get(srv.URL + "/bytes/100")
+	require.Nil(t, err)
+	resp.Body.Close()
+
+	routes := getStats(t, "GET", srv.URL+"/stats")
+	require.Equal(t, int64(2), routes["post"].Requests)
+	require.Equal(t, int64(10), routes["post"].BytesIn)
+	require.True(t, routes["post"].BytesOut > 10)
+	require.Equal(t, statsEntry{Requests: 1, BytesOut: 100}, routes["bytes"])
+
+	getStats(t, "DELETE", srv.URL+"/stats")
+	routes = getStats(t, "GET", srv.URL+"/stats")
+	require.NotContains(t, routes, "post")
+}
@@ -0,0 +1,140 @@
+package httpbin
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sync"
+)
+
+// defaultInstanceID is the instance ID picked at start.
+var defaultInstanceID = randomInstanceID()
+
+// WithInstanceID sets the ID that identifies the instance to /sticky
+// clients, and those of a load balancer in front of several instances, to
+// check which one served them. It defaults to a random ID picked at start.
+func WithInstanceID(id string) Option {
+	return func(h *HTTPBin) { h.instanceID = id }
+}
+
+// defaultStickyKeysMax is the maximum number of /sticky routing keys
+// counted.
+const defaultStickyKeysMax = 10000
+
+// WithStickyKeysMax sets the maximum number of /sticky routing keys
+// counted; counting more forgets the oldest ones. It defaults to 10000.
+func WithStickyKeysMax(n int) Option {
+	return func(h *HTTPBin) { h.stickyKeysMax = n }
+}
+
+// stickyCookie is the default affinity cookie of /sticky.
+const stickyCookie = "httpbin_affinity"
+
+// stickyBucketsMax caps the 'buckets' of /sticky.
+const stickyBucketsMax = 1 << 16
+
+func randomInstanceID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// stickyKeyCounter counts the requests with each routing key this instance
+// served.
+type stickyKeyCounter struct {
+	mu       sync.Mutex
+	requests map[string]int
+	order    []string // first seen first
+}
+
+// count counts a request with key, forgetting the oldest keys beyond max,
+// and returns how many this instance has served.
+func (c *stickyKeyCounter) count(key string, max int) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.requests[key]; !ok {
+		c.order = append(c.order, key)
+		for len(c.order) > max {
+			delete(c.requests, c.order[0])
+			c.order = c.order[1:]
+		}
+	}
+	c.requests[key]++
+	return c.requests[key]
+}
+
+// jumpHash maps key to one of n buckets with Lamping and Veach's jump
+// consistent hash, so that changing n moves as few keys as possible.
+func jumpHash(key uint64, n int) int {
+	var b, j int64 = -1, 0
+	for j < int64(n) {
+		b = j
+		key = key*2862933555777941757 + 1
+		j = int64(float64(b+1) * (float64(int64(1)<<31) / float64((key>>33)+1)))
+	}
+	return int(b)
+}
+
+// StickyHandler reports the instance ID, also in the X-Httpbin-Instance
+// header, and whether the request landed on the instance it is pinned to by
+// the affinity cookie named by the 'cookie' query parameter (default
+// httpbin_affinity), pinning it to this instance. With the 'header' query
+// parameter, it also reports the routing key in that request header, its
+// 64-bit FNV-1a hash, its jump consistent hash bucket out of 'buckets', if
+// set, and how many requests with the key this instance served.
+func StickyHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	name := q.Get("cookie")
+	if name == "" {
+		name = stickyCookie
+	}
+	buckets := 0
+	if !intParam(w, r, "buckets", 1, stickyBucketsMax, &buckets) {
+		return
+	}
+	if buckets > 0 && q.Get("header") == "" {
+		writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("'buckets' needs a routing key 'header'"))
+		return
+	}
+
+	hb := instance(r)
+	v := stickyResponse{
+		Instance:   hb.instanceID,
+		Affinity:   stickyAffinity{Cookie: name},
+		Consistent: true,
+	}
+	if c, err := r.Cookie(name); err == nil && c.Value != "" {
+		consistent := c.Value == hb.instanceID
+		v.Affinity.PinnedTo, v.Affinity.Consistent = c.Value, &consistent
+		v.Consistent = consistent
+	}
+	if header := q.Get("header"); header != "" {
+		k := &stickyRoutingKey{Header: header, Key: r.Header.Get(header)}
+		if k.Key != "" {
+			h := fnv.New64a()
+			h.Write([]byte(k.Key))
+			k.Hash = h.Sum64()
+			if buckets > 0 {
+				bucket := jumpHash(k.Hash, buckets)
+				k.Buckets, k.Bucket = buckets, &bucket
+			}
+			k.Requests = hb.stickyKeys.count(k.Key, hb.stickyKeysMax)
+		}
+		v.RoutingKey = k
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    hb.instanceID,
+		Path:     prefixed(r, "/"),
+		HttpOnly: true,
+	})
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Header().Set("X-Httpbin-Instance", hb.instanceID)
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
@@ -0,0 +1,102 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+type stickyStatus struct {
+	Instance string
+	Affinity struct {
+		PinnedTo   string `json:"pinned_to"`
+		Consistent *bool
+	}
+	RoutingKey *struct {
+		Key      string
+		Hash     uint64
+		Bucket   *int
+		Requests int
+	} `json:"routing_key"`
+	Consistent bool
+}
+
+func getSticky(t *testing.T, c *http.Client, url, key string) stickyStatus {
+	req, err := http.NewRequest("GET", url, nil)
+	require.Nil(t, err)
+	if key != "" {
+		req.Header.Set("X-Route-Key", key)
+	}
+	resp, err := c.Do(req)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var v stickyStatus
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	require.Equal(t, v.Instance, resp.Header.Get("X-Httpbin-Instance"))
+	return v
+}
+
+func TestSticky(t *testing.T) {
+	srv := httptest.NewServer(httpbin.New(httpbin.WithInstanceID("this")).Handler())
+	defer srv.Close()
+	jar, err := cookiejar.New(nil)
+	require.Nil(t, err)
+	c := &http.Client{Jar: jar}
+
+	v := getSticky(t, c, srv.URL+"/sticky", "")
+	require.Equal(t, "this", v.Instance)
+	require.Nil(t, v.Affinity.Consistent)
+	require.True(t, v.Consistent)
+
+	v = getSticky(t, c, srv.URL+"/sticky", "")
+	require.Equal(t, "this", v.Affinity.PinnedTo)
+	require.True(t, *v.Affinity.Consistent)
+
+	// as if a load balancer sent the client to another instance
+	other := httptest.NewServer(httpbin.New(httpbin.WithInstanceID("other")).Handler())
+	defer other.Close()
+	v = getSticky(t, c, other.URL+"/sticky", "")
+	require.Equal(t, "other", v.Instance)
+	require.Equal(t, "this", v.Affinity.PinnedTo)
+	require.False(t, *v.Affinity.Consistent)
+	require.False(t, v.Consistent)
+}
+
+func TestSticky_routingKey(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	url := srv.URL + "/sticky?header=X-Route-Key&buckets=8"
+	key := unique("sticky-user")
+	v := getSticky(t, http.DefaultClient, url, key)
+	require.Equal(t, key, v.RoutingKey.Key)
+	require.NotNil(t, v.RoutingKey.Bucket)
+	require.Equal(t, 1, v.RoutingKey.Requests)
+	w := getSticky(t, http.DefaultClient, url, key)
+	require.Equal(t, v.RoutingKey.Hash, w.RoutingKey.Hash)
+	require.Equal(t, *v.RoutingKey.Bucket, *w.RoutingKey.Bucket)
+	require.Equal(t, 2, w.RoutingKey.Requests)
+
+	// adding a bucket only moves keys to it
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("sticky-key-%d", i)
+		a := getSticky(t, http.DefaultClient, srv.URL+"/sticky?header=X-Route-Key&buckets=5", key)
+		b := getSticky(t, http.DefaultClient, srv.URL+"/sticky?header=X-Route-Key&buckets=6", key)
+		require.True(t, *a.RoutingKey.Bucket < 5)
+		if *b.RoutingKey.Bucket != *a.RoutingKey.Bucket {
+			require.Equal(t, 5, *b.RoutingKey.Bucket, key)
+		}
+	}
+
+	resp, err := http.Get(srv.URL + "/sticky?buckets=8")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
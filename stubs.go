@@ -0,0 +1,394 @@
+package httpbin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StubRule is a canned response served for the requests it matches.
+type StubRule struct {
+	// Method matches the request method; empty matches any.
+	Method string `json:"method"`
+	// Path matches the request path, in the syntax of path.Match.
+	Path string `json:"path"`
+	// Query and Headers map query parameters and request headers to the
+	// values they must have.
+	Query   map[string]string `json:"query"`
+	Headers map[string]string `json:"headers"`
+	// RequestBody, if set, must hold for the request body.
+	RequestBody *StubBodyMatch `json:"request_body"`
+
+	// Status is the response status, 200 if zero.
+	Status int `json:"status"`
+	// ResponseHeaders are set on the response.
+	ResponseHeaders map[string]string `json:"response_headers"`
+	// Body is the response body, unless JSON is set, when the response is
+	// JSON encoded.
+	Body string      `json:"body"`
+	JSON interface{} `json:"json"`
+	// Delay, a time.Duration string, is waited before responding.
+	Delay string `json:"delay"`
+
+	delay time.Duration
+	file  string
+}
+
+// StubBodyMatch is a predicate on a request body, which holds if all of its
+// conditions do. Bodies are looked at up to their first 10 MiB; the JSON
+// conditions don't hold for bodies that aren't JSON.
+type StubBodyMatch struct {
+	// Regexp must match the body.
+	Regexp string `json:"regexp"`
+	// MinSize and MaxSize bound the size of the body in bytes.
+	MinSize *int64 `json:"min_size"`
+	MaxSize *int64 `json:"max_size"`
+	// JSON maps JSONPath expressions, of the subset without filters, to a
+	// value one of the nodes they select must equal.
+	JSON map[string]interface{} `json:"json"`
+	// Present and Missing are JSONPath expressions that must select some
+	// node, or none.
+	Present []string `json:"present"`
+	Missing []string `json:"missing"`
+
+	regexp *regexp.Regexp
+	paths  map[string]jsonPath
+}
+
+// stubBodyMax is how much of a request body stub rules look at.
+const stubBodyMax = 10 << 20
+
+// compile checks the conditions and prepares them for matching.
+func (m *StubBodyMatch) compile() error {
+	if m.Regexp != "" {
+		re, err := regexp.Compile(m.Regexp)
+		if err != nil {
+			return fmt.Errorf("'regexp': %w", err)
+		}
+		m.regexp = re
+	}
+	m.paths = make(map[string]jsonPath)
+	exprs := append(append([]string(nil), m.Present...), m.Missing...)
+	for expr := range m.JSON {
+		exprs = append(exprs, expr)
+	}
+	for _, expr := range exprs {
+		p, err := parseJSONPath(expr)
+		if err != nil {
+			return err
+		}
+		m.paths[expr] = p
+	}
+	return nil
+}
+
+// matches reports whether the body of r satisfies the conditions.
+func (m *StubBodyMatch) matches(r *stubRequest) bool {
+	r.readBody()
+	if m.MinSize != nil && r.size < *m.MinSize || m.MaxSize != nil && r.size > *m.MaxSize {
+		return false
+	}
+	if m.regexp != nil && !m.regexp.Match(r.body) {
+		return false
+	}
+	if len(m.JSON) == 0 && len(m.Present) == 0 && len(m.Missing) == 0 {
+		return true
+	}
+	doc, ok := r.document()
+	if !ok {
+		return false
+	}
+	for expr, want := range m.JSON {
+		found := false
+		for _, n := range m.paths[expr].eval(doc) {
+			found = found || jsonEqual(n, want)
+		}
+		if !found {
+			return false
+		}
+	}
+	for _, expr := range m.Present {
+		if len(m.paths[expr].eval(doc)) == 0 {
+			return false
+		}
+	}
+	for _, expr := range m.Missing {
+		if len(m.paths[expr].eval(doc)) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// stubRequest is a request being matched against stub rules, whose body is
+// read once a rule needs it and put back for the handler.
+type stubRequest struct {
+	*http.Request
+
+	read bool
+	body []byte // up to stubBodyMax bytes
+	size int64
+
+	decoded bool
+	doc     interface{}
+	docOK   bool
+}
+
+// readBody reads the body, if it wasn't already.
+func (r *stubRequest) readBody() {
+	if r.read {
+		return
+	}
+	r.read = true
+	r.body, _ = ioutil.ReadAll(io.LimitReader(r.Body, stubBodyMax))
+	if r.size = int64(len(r.body)); r.ContentLength > r.size {
+		r.size = r.ContentLength
+	}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(r.body), r.Body), r.Body}
+}
+
+// document returns the body decoded as JSON, if it is JSON.
+func (r *stubRequest) document() (interface{}, bool) {
+	if !r.decoded {
+		r.decoded = true
+		r.docOK = json.Unmarshal(r.body, &r.doc) == nil
+	}
+	return r.doc, r.docOK
+}
+
+// Stubs serves the StubRules defined in the .json, .yaml and .yml files of a
+// directory, in the order of their file names and then of their position in
+// the file. A file holds a rule, a list of rules, or an object with a list
+// of rules under "rules".
+type Stubs struct {
+	dir string
+
+	mu     sync.RWMutex
+	rules  []StubRule
+	state  string // names, sizes and modification times of the files
+	failed string // state of the files that last failed to load
+}
+
+// LoadStubs loads the stub rules of the files in dir.
+func LoadStubs(dir string) (*Stubs, error) {
+	s := &Stubs{dir: dir}
+	if _, err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Dir returns the directory the rules are loaded from.
+func (s *Stubs) Dir() string { return s.dir }
+
+// Rules returns the rules in the order they are matched.
+func (s *Stubs) Rules() []StubRule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.rules
+}
+
+// Reload loads the rules again if the files changed, reporting whether they
+// did. If a file fails to load, the rules are left as they were, and the
+// failure is only reported again once the files change.
+func (s *Stubs) Reload() (bool, error) {
+	files, state, err := stubFiles(s.dir)
+	if err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if state == s.state || state == s.failed {
+		return false, nil
+	}
+
+	var rules []StubRule
+	for _, f := range files {
+		rs, err := loadStubFile(f)
+		if err != nil {
+			s.failed = state
+			return false, err
+		}
+		rules = append(rules, rs...)
+	}
+	s.rules, s.state, s.failed = rules, state, ""
+	return true, nil
+}
+
+// Watch reloads the rules every interval until done is closed, calling
+// report, if not nil, with the outcome of every reload that found the files
+// changed or failed.
+func (s *Stubs) Watch(interval time.Duration, done <-chan struct{}, report func(error)) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-t.C:
+		}
+		if changed, err := s.Reload(); (changed || err != nil) && report != nil {
+			report(err)
+		}
+	}
+}
+
+// stubFiles lists the rule files of dir and describes their state, so that
+// any change to them changes it.
+func stubFiles(dir string) ([]string, string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read stubs directory: %w", err)
+	}
+	var (
+		files []string
+		state strings.Builder
+	)
+	for _, fi := range infos {
+		switch strings.ToLower(filepath.Ext(fi.Name())) {
+		case ".json", ".yaml", ".yml":
+		default:
+			continue
+		}
+		if !fi.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, filepath.Join(dir, fi.Name()))
+		fmt.Fprintf(&state, "%s\x00%d\x00%d\n", fi.Name(), fi.Size(), fi.ModTime().UnixNano())
+	}
+	return files, state.String(), nil
+}
+
+// loadStubFile decodes and checks the rules of a file.
+func loadStubFile(file string) ([]StubRule, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var v interface{}
+	if ext := strings.ToLower(filepath.Ext(file)); ext == ".json" {
+		err = json.Unmarshal(b, &v)
+	} else {
+		err = yaml.Unmarshal(b, &v)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	// go through JSON so YAML rules decode like JSON ones
+	if m, ok := v.(map[string]interface{}); ok {
+		if list, ok := m["rules"]; ok {
+			v = list
+		} else {
+			v = []interface{}{m}
+		}
+	}
+	b, err = json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	var rules []StubRule
+	if err := json.Unmarshal(b, &rules); err != nil {
+		return nil, fmt.Errorf("%s: malformed rules: %w", file, err)
+	}
+
+	for i := range rules {
+		rl := &rules[i]
+		rl.file = file
+		if _, err := path.Match(rl.Path, "/"); rl.Path == "" || err != nil {
+			return nil, fmt.Errorf("%s: rule %d: 'path' must be a path pattern", file, i+1)
+		}
+		if rl.Status != 0 && (rl.Status < 100 || rl.Status > 999) {
+			return nil, fmt.Errorf("%s: rule %d: 'status' must be between 100 and 999", file, i+1)
+		}
+		if rl.RequestBody != nil {
+			if err := rl.RequestBody.compile(); err != nil {
+				return nil, fmt.Errorf("%s: rule %d: 'request_body': %v", file, i+1, err)
+			}
+		}
+		if rl.Delay != "" {
+			if rl.delay, err = time.ParseDuration(rl.Delay); err != nil || rl.delay < 0 {
+				return nil, fmt.Errorf("%s: rule %d: 'delay' must be a duration", file, i+1)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// matches reports whether the rule applies to r, looking at the body last.
+func (rl *StubRule) matches(r *stubRequest) bool {
+	if rl.Method != "" && !strings.EqualFold(rl.Method, r.Method) {
+		return false
+	}
+	if ok, _ := path.Match(rl.Path, r.URL.Path); !ok {
+		return false
+	}
+	q := r.URL.Query()
+	for k, v := range rl.Query {
+		if q.Get(k) != v {
+			return false
+		}
+	}
+	for k, v := range rl.Headers {
+		if r.Header.Get(k) != v {
+			return false
+		}
+	}
+	return rl.RequestBody == nil || rl.RequestBody.matches(r)
+}
+
+// serve writes the rule's response.
+func (rl *StubRule) serve(w http.ResponseWriter, r *http.Request) {
+	if rl.delay > 0 && !sleepRequest(r, rl.delay) {
+		return
+	}
+	w.Header().Set("X-Httpbin-Stub", filepath.Base(rl.file))
+	for k, v := range rl.ResponseHeaders {
+		w.Header().Set(k, v)
+	}
+	status := rl.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if rl.JSON != nil {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		w.WriteHeader(status)
+		writeJSON(w, rl.JSON)
+		return
+	}
+	w.WriteHeader(status)
+	fmt.Fprint(w, rl.Body)
+}
+
+// StubHandler wraps h to answer the requests matching a rule of stubs with
+// the first matching rule's response, and to pass the others to h. Rules
+// take effect as soon as they are reloaded.
+func StubHandler(h http.Handler, stubs *Stubs) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rules := stubs.Rules()
+		sr := &stubRequest{Request: r}
+		for i := range rules {
+			if rules[i].matches(sr) {
+				rules[i].serve(w, r)
+				return
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
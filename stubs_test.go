@@ -0,0 +1,192 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+func writeStub(t *testing.T, dir, name, content string) {
+	require.Nil(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+}
+
+func TestStubs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stubs")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	writeStub(t, dir, "a.json", `{"method": "POST", "path": "/api/users", "status": 201, "json": {"id": 7}}`)
+	writeStub(t, dir, "b.yaml", `# users API
+rules:
+  - path: /api/users/*
+    headers:
+      Authorization: Bearer t0ken
+    response_headers:
+      X-Custom: "yes"   # quoted
+    body: |
+      line 1
+      line 2
+  - path: /api/users/*
+    status: 401
+    delay: 10ms
+    body: >-
+      please
+      log in
+`)
+	writeStub(t, dir, "notes.txt", `not a rule`)
+
+	stubs, err := httpbin.LoadStubs(dir)
+	require.Nil(t, err)
+	require.Len(t, stubs.Rules(), 3)
+	srv := httptest.NewServer(httpbin.StubHandler(httpbin.GetMux(), stubs))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/api/users", "application/json", nil)
+	require.Nil(t, err)
+	var v map[string]int
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusCreated, resp.StatusCode)
+	require.EqualValues(t, "a.json", resp.Header.Get("X-Httpbin-Stub"))
+	require.EqualValues(t, map[string]int{"id": 7}, v)
+
+	req, _ := http.NewRequest("GET", srv.URL+"/api/users/7", nil)
+	req.Header.Set("Authorization", "Bearer t0ken")
+	resp, err = http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	require.EqualValues(t, "yes", resp.Header.Get("X-Custom"))
+	require.EqualValues(t, "line 1\nline 2\n", string(b))
+
+	resp, err = http.Get(srv.URL + "/api/users/7")
+	require.Nil(t, err)
+	b, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusUnauthorized, resp.StatusCode)
+	require.EqualValues(t, "please log in", string(b))
+
+	// other requests reach the mux
+	resp, err = http.Get(srv.URL + "/get")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.EqualValues(t, http.StatusOK, resp.StatusCode)
+	require.Empty(t, resp.Header.Get("X-Httpbin-Stub"))
+}
+
+func TestStubs_reload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stubs")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	writeStub(t, dir, "a.yml", "path: /a\nbody: one\n")
+	stubs, err := httpbin.LoadStubs(dir)
+	require.Nil(t, err)
+	changed, err := stubs.Reload()
+	require.Nil(t, err)
+	require.False(t, changed)
+
+	// a broken file leaves the rules as they were
+	writeStub(t, dir, "b.json", `{"path": "/b",`)
+	changed, err = stubs.Reload()
+	require.NotNil(t, err)
+	require.False(t, changed)
+	require.EqualValues(t, "one", stubs.Rules()[0].Body)
+	changed, err = stubs.Reload()
+	require.Nil(t, err, "reported the same failure twice")
+	require.False(t, changed)
+
+	writeStub(t, dir, "b.json", `[{"path": "/b", "body": "two"}]`)
+	done := make(chan struct{})
+	defer close(done)
+	reloaded := make(chan error, 1)
+	go stubs.Watch(10*time.Millisecond, done, func(err error) { reloaded <- err })
+	select {
+	case err := <-reloaded:
+		require.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("rules not reloaded")
+	}
+	var bodies []string
+	for _, rl := range stubs.Rules() {
+		bodies = append(bodies, rl.Body)
+	}
+	require.EqualValues(t, "one,two", strings.Join(bodies, ","))
+}
+
+func TestStubs_invalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stubs")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	writeStub(t, dir, "a.yaml", "- path: /a\n  delay: soon\n")
+	_, err = httpbin.LoadStubs(dir)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "'delay' must be a duration")
+}
+
+func TestStubs_requestBody(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stubs")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	writeStub(t, dir, "a.yaml", `rules:
+  - path: /post
+    request_body:
+      missing: ["$.customer.id"]
+    status: 500
+  - path: /post
+    request_body:
+      json:
+        "$.items[*].sku": "X-1"
+        "$..currency": "XTS"
+    status: 402
+  - path: /post
+    request_body:
+      regexp: "(?i)boom"
+      max_size: 10
+    status: 418
+`)
+	stubs, err := httpbin.LoadStubs(dir)
+	require.Nil(t, err)
+	srv := httptest.NewServer(httpbin.StubHandler(httpbin.GetMux(), stubs))
+	defer srv.Close()
+
+	for _, tt := range []struct {
+		body   string
+		status int
+	}{
+		{`{"customer": {}}`, http.StatusInternalServerError},
+		{`{"customer": {"id": 1}, "items": [{"sku": "A"}, {"sku": "X-1"}], "total": {"currency": "XTS"}}`, http.StatusPaymentRequired},
+		{`{"customer": {"id": 1}, "items": [{"sku": "A"}], "total": {"currency": "XTS"}}`, http.StatusOK},
+		{`BOOM`, http.StatusTeapot},
+		{`BOOM BOOM BOOM`, http.StatusOK},
+	} {
+		resp, err := http.Post(srv.URL+"/post", "text/plain", strings.NewReader(tt.body))
+		require.Nil(t, err)
+		var v struct {
+			Data string `json:"data"`
+		}
+		if resp.StatusCode == http.StatusOK {
+			require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+			require.EqualValues(t, tt.body, v.Data, "the body reaches the endpoint")
+		}
+		resp.Body.Close()
+		require.EqualValues(t, tt.status, resp.StatusCode, tt.body)
+	}
+
+	writeStub(t, dir, "b.yaml", "path: /a\nrequest_body:\n  present: [\"$.a[?(@.b)]\"]\n")
+	_, err = httpbin.LoadStubs(dir)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "unsupported selector")
+}
@@ -0,0 +1,145 @@
+package httpbin
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeoutKinds are the scenarios of /timeout/:kind. Each stalls for the
+// given duration at a different point of the response, then completes it.
+var timeoutKinds = map[string]func(w http.ResponseWriter, r *http.Request, stall time.Duration){
+	// nothing is sent until the stall is over, as with a server that accepts
+	// connections but does not answer
+	"connect-accepted-but-silent": func(w http.ResponseWriter, r *http.Request, stall time.Duration) {
+		if sleepRequest(r, stall) {
+			GetHandler(w, r)
+		}
+	},
+	// the status and headers are sent right away, the body after the stall
+	"headers-then-stall": func(w http.ResponseWriter, r *http.Request, stall time.Duration) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		if sleepRequest(r, stall) {
+			GetHandler(w, r)
+		}
+	},
+	// 'percent' percent (default 50) of a body of 'size' bytes (default
+	// 1024) is sent, the rest after the stall
+	"body-stall-at-percent": func(w http.ResponseWriter, r *http.Request, stall time.Duration) {
+		size, percent := 1024, 50
+		if !intParam(w, r, "size", 0, 100<<20, &size) || !intParam(w, r, "percent", 0, 100, &percent) {
+			return
+		}
+		body := bytes.Repeat([]byte{'*'}, size)
+		at := size * percent / 100
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.Itoa(size))
+		w.Write(body[:at])
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		if sleepRequest(r, stall) {
+			w.Write(body[at:])
+		}
+	},
+	// the body is sent right away, its trailers after the stall
+	"slow-trailers": func(w http.ResponseWriter, r *http.Request, stall time.Duration) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Trailer", "X-Stalled-For")
+		w.Write([]byte("trailers follow\n"))
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		if sleepRequest(r, stall) {
+			w.Header().Set("X-Stalled-For", stall.String())
+		}
+	},
+}
+
+// TimeoutHandler serves the timeout scenario named by the 'kind' route
+// variable, stalling for the number of seconds given by the 'stall' query
+// parameter, at most and by default the delay limit. Stalls end early if the client
+// goes away.
+func TimeoutHandler(w http.ResponseWriter, r *http.Request) {
+	kind := routeVars(r)["kind"]
+	f, ok := timeoutKinds[kind]
+	if !ok {
+		var kinds []string
+		for k := range timeoutKinds {
+			kinds = append(kinds, k)
+		}
+		sort.Strings(kinds)
+		writeErrorJSONStatus(w, http.StatusNotFound, fmt.Errorf("unknown kind %q, want one of %s", kind, strings.Join(kinds, ", ")))
+		return
+	}
+	stall := instance(r).delayMax
+	if s := r.URL.Query().Get("stall"); s != "" {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || f < 0 {
+			writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("failed to parse 'stall'"))
+			return
+		}
+		if d := time.Duration(f * float64(time.Second)); d < stall {
+			stall = d
+		}
+	}
+	f(w, r, stall)
+}
+
+// intParam parses the query parameter name into v, if it is set, writing a
+// 400 and reporting false if it is not an integer between min and max.
+func intParam(w http.ResponseWriter, r *http.Request, name string, min, max int, v *int) bool {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return true
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < min || n > max {
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("'%s' must be an integer between %d and %d", name, min, max))
+		return false
+	}
+	*v = n
+	return true
+}
+
+// secondsParam parses the query parameter name, a number of seconds, into d,
+// if it is set, capping it at the delay maximum of the HTTPBin serving r. It
+// writes a 400 and reports false if the parameter is not a non-negative
+// number.
+func secondsParam(w http.ResponseWriter, r *http.Request, name string, d *time.Duration) bool {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return true
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || !(f >= 0) {
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("'%s' must be a number of seconds", name))
+		return false
+	}
+	max := instance(r).delayMax
+	if *d = max; f < max.Seconds() {
+		*d = time.Duration(f * float64(time.Second))
+	}
+	return true
+}
+
+// sleepRequest waits for d, reporting false if the client went away first.
+func sleepRequest(r *http.Request, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
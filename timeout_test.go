@@ -0,0 +1,60 @@
+package httpbin_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeout(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	// the client timeout fires before the response is complete
+	c := &http.Client{Timeout: 100 * time.Millisecond}
+	for _, kind := range []string{"connect-accepted-but-silent", "headers-then-stall", "body-stall-at-percent"} {
+		resp, err := c.Get(srv.URL + "/timeout/" + kind + "?stall=2")
+		if err == nil {
+			_, err = ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+		}
+		require.NotNil(t, err, kind)
+	}
+
+	// and given time it completes
+	resp, err := http.Get(srv.URL + "/timeout/body-stall-at-percent?stall=0.05&size=100&percent=30")
+	require.Nil(t, err)
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Nil(t, err)
+	require.Len(t, b, 100)
+
+	resp, err = http.Get(srv.URL + "/timeout/slow-trailers?stall=0.05")
+	require.Nil(t, err)
+	_, err = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Nil(t, err)
+	require.Equal(t, "50ms", resp.Trailer.Get("X-Stalled-For"))
+
+	resp, err = http.Get(srv.URL + "/timeout/forever")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestTimeout_headersFirst(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	start := time.Now()
+	resp, err := http.Get(srv.URL + "/timeout/headers-then-stall?stall=0.3")
+	require.Nil(t, err)
+	require.True(t, time.Since(start) < 250*time.Millisecond, "headers should arrive before the stall")
+	_, err = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Nil(t, err)
+	require.True(t, time.Since(start) >= 300*time.Millisecond)
+}
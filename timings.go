@@ -0,0 +1,130 @@
+package httpbin
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// arrivals tracks when the first byte of the current request was read on
+// each connection accepted by a Listener, by remote address.
+var arrivals = &arrivalTracker{conns: make(map[string]*arrivalConn)}
+
+type arrivalTracker struct {
+	mu    sync.Mutex
+	conns map[string]*arrivalConn
+}
+
+// arm has the next byte read from the connection of remote mark the arrival
+// of a request.
+func (t *arrivalTracker) arm(remote string) {
+	t.mu.Lock()
+	c, ok := t.conns[remote]
+	t.mu.Unlock()
+	if ok {
+		c.mu.Lock()
+		c.armed = true
+		c.mu.Unlock()
+	}
+}
+
+// Listener wraps l so that the server can tell when the first byte of each
+// request arrives, and the timings of /post include how long reading the
+// request headers took. The server must also use the ConnState hook. Only
+// HTTP/1 requests are timed. Network partitions, see StartPartition, affect
+// the connections it accepts.
+func Listener(l net.Listener) net.Listener {
+	return arrivalListener{l}
+}
+
+type arrivalListener struct {
+	net.Listener
+}
+
+func (l arrivalListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	for err == nil && partition.intercept(c) {
+		c, err = l.Listener.Accept()
+	}
+	if err != nil {
+		return nil, err
+	}
+	ac := &arrivalConn{Conn: c, armed: true}
+	arrivals.mu.Lock()
+	arrivals.conns[c.RemoteAddr().String()] = ac
+	arrivals.mu.Unlock()
+	return ac, nil
+}
+
+// arrivalConn records when a byte is first read after being armed.
+type arrivalConn struct {
+	net.Conn
+
+	mu    sync.Mutex
+	armed bool
+	at    time.Time
+}
+
+func (c *arrivalConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	partition.wait(c) // what arrives during a blackhole is only read after it
+	if n > 0 {
+		c.mu.Lock()
+		if c.armed {
+			c.at, c.armed = time.Now(), false
+		}
+		c.mu.Unlock()
+	}
+	return n, err
+}
+
+func (c *arrivalConn) Close() error {
+	arrivals.mu.Lock()
+	if arrivals.conns[c.RemoteAddr().String()] == c {
+		delete(arrivals.conns, c.RemoteAddr().String())
+	}
+	arrivals.mu.Unlock()
+	return c.Conn.Close()
+}
+
+// requestArrival returns when the first byte of r was read, if it was
+// accepted by a Listener and is an HTTP/1 request.
+func requestArrival(r *http.Request) (time.Time, bool) {
+	if r.ProtoMajor != 1 {
+		return time.Time{}, false
+	}
+	arrivals.mu.Lock()
+	c, ok := arrivals.conns[r.RemoteAddr]
+	arrivals.mu.Unlock()
+	if !ok {
+		return time.Time{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.at, !c.armed && !c.at.IsZero()
+}
+
+// newRequestTimings reports how the server saw r arrive: its handler
+// started at start and spent bodyRead reading the size bytes of its body.
+// How long reading the headers took is only known for requests accepted by
+// a Listener.
+func newRequestTimings(r *http.Request, start time.Time, bodyRead time.Duration, size int64) *requestTimings {
+	t := &requestTimings{
+		ReadBodyMs: milliseconds(bodyRead),
+		HandlerMs:  milliseconds(time.Since(start)),
+	}
+	if size > 0 && bodyRead > 0 {
+		t.UploadBytesPerSec = float64(size) / bodyRead.Seconds()
+	}
+	if at, ok := requestArrival(r); ok {
+		d := milliseconds(start.Sub(at))
+		t.ReadHeadersMs = &d
+	}
+	return t
+}
+
+// milliseconds returns d in milliseconds, with microsecond precision.
+func milliseconds(d time.Duration) float64 {
+	return float64(d/time.Microsecond) / 1e3
+}
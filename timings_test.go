@@ -0,0 +1,121 @@
+package httpbin_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+type requestTimings struct {
+	ReadHeadersMs     *float64 `json:"read_headers_ms"`
+	ReadBodyMs        float64  `json:"read_body_ms"`
+	HandlerMs         float64  `json:"handler_ms"`
+	UploadBytesPerSec float64  `json:"upload_bytes_per_sec"`
+}
+
+func postTimings(t *testing.T, resp *http.Response) requestTimings {
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var v struct {
+		Timings requestTimings `json:"timings"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	return v.Timings
+}
+
+// readWaitListener tells on reading when the server of the connection it
+// accepted is waiting for more of a request.
+type readWaitListener struct {
+	net.Listener
+	reading chan struct{}
+}
+
+func (l readWaitListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &readWaitConn{Conn: c, reading: l.reading}, nil
+}
+
+type readWaitConn struct {
+	net.Conn
+	reading chan struct{}
+	reads   int
+}
+
+// Read signals once the first read returned data and the server reads again,
+// so the arrival of the request has been recorded by then.
+func (c *readWaitConn) Read(b []byte) (int, error) {
+	if c.reads == 1 {
+		c.reading <- struct{}{}
+	}
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.reads++
+	}
+	return n, err
+}
+
+func TestPost_timings(t *testing.T) {
+	reading := make(chan struct{}, 1)
+	srv := httptest.NewUnstartedServer(httpbin.GetMux())
+	srv.Listener = httpbin.Listener(readWaitListener{srv.Listener, reading})
+	srv.Config.ConnState = httpbin.ConnState
+	srv.Start()
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	require.Nil(t, err)
+	defer conn.Close()
+	br := bufio.NewReader(conn)
+
+	// the headers and the body each arrive in two parts 100ms apart, the
+	// headers counting from when the server read their first part
+	for i, s := range []string{"POST /post HTTP/1.1\r\nHost: x\r\n", "Content-Length: 10\r\n\r\n12345", "67890"} {
+		_, err = io.WriteString(conn, s)
+		require.Nil(t, err)
+		if i == 0 {
+			<-reading
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	resp, err := http.ReadResponse(br, nil)
+	require.Nil(t, err)
+	v := postTimings(t, resp)
+	require.NotNil(t, v.ReadHeadersMs)
+	require.True(t, *v.ReadHeadersMs >= 100, "read_headers_ms %v", *v.ReadHeadersMs)
+	require.True(t, v.ReadBodyMs >= 50, "read_body_ms %v", v.ReadBodyMs)
+	require.True(t, v.HandlerMs >= v.ReadBodyMs)
+	require.True(t, v.UploadBytesPerSec > 0 && v.UploadBytesPerSec < 1000, "upload_bytes_per_sec %v", v.UploadBytesPerSec)
+
+	// time spent idle between requests does not count
+	time.Sleep(200 * time.Millisecond)
+	_, err = io.WriteString(conn, "POST /post HTTP/1.1\r\nHost: x\r\nContent-Length: 2\r\n\r\nok")
+	require.Nil(t, err)
+	resp, err = http.ReadResponse(br, nil)
+	require.Nil(t, err)
+	v = postTimings(t, resp)
+	require.NotNil(t, v.ReadHeadersMs)
+	require.True(t, *v.ReadHeadersMs < 100, "read_headers_ms %v", *v.ReadHeadersMs)
+}
+
+func TestPost_timingsWithoutListener(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/post", "text/plain", strings.NewReader("hello"))
+	require.Nil(t, err)
+	v := postTimings(t, resp)
+	require.Nil(t, v.ReadHeadersMs)
+	require.True(t, v.UploadBytesPerSec > 0)
+}
@@ -0,0 +1,43 @@
+package httpbin
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+)
+
+// tlsVersions names the TLS versions.
+var tlsVersions = map[uint16]string{
+	tls.VersionTLS10: "TLS 1.0",
+	tls.VersionTLS11: "TLS 1.1",
+	tls.VersionTLS12: "TLS 1.2",
+	tls.VersionTLS13: "TLS 1.3",
+}
+
+// TLSInfoHandler returns the parameters negotiated for the request's TLS
+// connection: the version, cipher suite, SNI server name, ALPN protocol,
+// whether the session was resumed and the client certificates, if any.
+// Requests over plain HTTP get tls: false.
+func TLSInfoHandler(w http.ResponseWriter, r *http.Request) {
+	var v tlsInfoResponse
+	if cs := r.TLS; cs != nil {
+		version, ok := tlsVersions[cs.Version]
+		if !ok {
+			version = fmt.Sprintf("0x%04x", cs.Version)
+		}
+		v = tlsInfoResponse{
+			TLS:                true,
+			Version:            version,
+			CipherSuite:        tls.CipherSuiteName(cs.CipherSuite),
+			ServerName:         cs.ServerName,
+			NegotiatedProtocol: cs.NegotiatedProtocol,
+			Resumed:            cs.DidResume,
+		}
+		for _, c := range cs.PeerCertificates {
+			v.ClientCertificates = append(v.ClientCertificates, c.Subject.String())
+		}
+	}
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
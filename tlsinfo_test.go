@@ -0,0 +1,35 @@
+package httpbin_test
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSInfo(t *testing.T) {
+	srv := httptest.NewUnstartedServer(httpbin.GetMux())
+	srv.TLS = &tls.Config{MaxVersion: tls.VersionTLS12, CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}}
+	srv.StartTLS()
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL + "/tls-info")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	var v struct {
+		TLS         bool   `json:"tls"`
+		Version     string `json:"version"`
+		CipherSuite string `json:"cipher_suite"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	require.True(t, v.TLS)
+	require.Equal(t, "TLS 1.2", v.Version)
+	require.Equal(t, "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256", v.CipherSuite)
+
+	plain := testServer()
+	defer plain.Close()
+	require.JSONEq(t, `{"tls": false}`, string(get(t, plain.URL+"/tls-info")))
+}
@@ -0,0 +1,131 @@
+package httpbin
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithTraceRequests lets clients ask for a trace of how the server processed
+// their request with the X-Httpbin-Trace request header: the matched route,
+// the decisions of the middlewares and when they were made. The trace is
+// sent in the X-Httpbin-Trace response header or, for the value "json" and
+// buffered JSON object responses, in their trace field. It defaults to
+// false.
+func WithTraceRequests(enabled bool) Option {
+	return func(h *HTTPBin) { h.traceRequests = enabled }
+}
+
+const traceHeader = "X-Httpbin-Trace"
+
+// requestTrace collects the events of a traced request.
+type requestTrace struct {
+	start time.Time
+	json  bool
+
+	mu sync.Mutex
+	v  traceReport
+}
+
+type traceContextKey struct{}
+
+// traceHandler traces the requests to the route that ask for it.
+func traceHandler(name, path string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mode := strings.ToLower(strings.TrimSpace(r.Header.Get(traceHeader)))
+		if on, err := strconv.ParseBool(mode); mode == "" || err == nil && !on {
+			h.ServeHTTP(w, r)
+			return
+		}
+		t := &requestTrace{
+			start: time.Now(),
+			json:  mode == "json",
+			v:     traceReport{Route: name, Path: path, Events: []traceEvent{}},
+		}
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), traceContextKey{}, t)))
+	})
+}
+
+// withTrace wraps the handler of every route of the router with
+// traceHandler, outside of latencyHandler so injected latency is traced.
+func withTrace(r *routeMux) {
+	for _, route := range r.routes {
+		route.handler = traceHandler(route.name, route.path, route.handler)
+	}
+}
+
+// traceEventf records an event in the trace of r, if it is traced.
+func traceEventf(r *http.Request, format string, args ...interface{}) {
+	t, _ := r.Context().Value(traceContextKey{}).(*requestTrace)
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.v.Events = append(t.v.Events, traceEvent{
+		AtMS:  milliseconds(time.Since(t.start)),
+		Event: fmt.Sprintf(format, args...),
+	})
+}
+
+// writeTrace adds the trace of the request of br, if it is traced, to the
+// response about to be committed: into the body if the client asked for
+// json and it is a buffered JSON object, into the X-Httpbin-Trace header
+// otherwise.
+func writeTrace(br *bufferedResponse) {
+	t, _ := br.r.Context().Value(traceContextKey{}).(*requestTrace)
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	v := t.v
+	v.ElapsedMS = milliseconds(time.Since(t.start))
+	b, err := json.Marshal(v)
+	t.mu.Unlock()
+	if err != nil {
+		return
+	}
+
+	if t.json && spliceTrace(br, b) {
+		return
+	}
+	br.w.Header().Set(traceHeader, string(b))
+}
+
+// spliceTrace adds the trace b as the last field of the buffered JSON object
+// body of br, reporting whether it could. Bodies without a Content-Type, as
+// most JSON endpoints write, are taken for JSON if they parse.
+func spliceTrace(br *bufferedResponse, b []byte) bool {
+	h := br.w.Header()
+	mt, _, _ := mime.ParseMediaType(h.Get("Content-Type"))
+	if mt != "application/json" && mt != "" || h.Get("Content-Encoding") != "" || br.r.Method == http.MethodHead {
+		return false
+	}
+	body := bytes.TrimSpace(br.body.Bytes())
+	if len(body) < 2 || body[0] != '{' || body[len(body)-1] != '}' || !json.Valid(body) {
+		return false
+	}
+
+	var buf bytes.Buffer
+	buf.Write(body[:len(body)-1])
+	if len(bytes.TrimSpace(body[1:len(body)-1])) > 0 {
+		buf.WriteByte(',')
+	}
+	buf.WriteString(`"trace":`)
+	buf.Write(b)
+	buf.WriteString("}\n")
+
+	br.written += int64(buf.Len() - br.body.Len())
+	br.body = buf
+	if h.Get("Content-Length") != "" {
+		h.Set("Content-Length", strconv.Itoa(buf.Len()))
+	}
+	return true
+}
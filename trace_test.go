@@ -0,0 +1,89 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+type traceReport struct {
+	Route  string `json:"route"`
+	Path   string `json:"path"`
+	Events []struct {
+		AtMS  float64 `json:"at_ms"`
+		Event string  `json:"event"`
+	} `json:"events"`
+	ElapsedMS float64 `json:"elapsed_ms"`
+}
+
+func traceServer() *httptest.Server {
+	return httptest.NewServer(httpbin.New(httpbin.WithTraceRequests(true)).Handler())
+}
+
+func getTraced(t *testing.T, url, mode string) *http.Response {
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if mode != "" {
+		req.Header.Set("X-Httpbin-Trace", mode)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	return resp
+}
+
+func TestTrace_header(t *testing.T) {
+	srv := traceServer()
+	defer srv.Close()
+
+	resp := getTraced(t, srv.URL+"/bytes/16?seed=1", "1")
+	resp.Body.Close()
+	var v traceReport
+	require.Nil(t, json.Unmarshal([]byte(resp.Header.Get("X-Httpbin-Trace")), &v))
+	require.EqualValues(t, "bytes", v.Route)
+	require.EqualValues(t, `/bytes/{n:[\d]+}`, v.Path)
+	var events []string
+	for _, e := range v.Events {
+		events = append(events, e.Event)
+	}
+	require.Contains(t, events, "cache: miss bytes?n=16&seed=1")
+	require.Contains(t, events, "handler returned")
+	require.Contains(t, events, "response committed: status 200, 16 body bytes buffered")
+
+	resp = getTraced(t, srv.URL+"/bytes/16?seed=1", "")
+	resp.Body.Close()
+	require.Empty(t, resp.Header.Get("X-Httpbin-Trace"))
+}
+
+func TestTrace_json(t *testing.T) {
+	srv := traceServer()
+	defer srv.Close()
+
+	resp := getTraced(t, srv.URL+"/get?a=1", "json")
+	defer resp.Body.Close()
+	require.Empty(t, resp.Header.Get("X-Httpbin-Trace"))
+	var v struct {
+		Args  map[string]interface{} `json:"args"`
+		Trace traceReport            `json:"trace"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	require.EqualValues(t, map[string]interface{}{"a": "1"}, v.Args)
+	require.EqualValues(t, "get", v.Trace.Route)
+	require.NotEmpty(t, v.Trace.Events)
+
+	// not JSON, so in the header
+	resp = getTraced(t, srv.URL+"/html", "json")
+	resp.Body.Close()
+	require.Contains(t, resp.Header.Get("X-Httpbin-Trace"), `"route":"html"`)
+}
+
+func TestTrace_disabled(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp := getTraced(t, srv.URL+"/get", "1")
+	resp.Body.Close()
+	require.Empty(t, resp.Header.Get("X-Httpbin-Trace"))
+}
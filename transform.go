@@ -0,0 +1,91 @@
+package httpbin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"unicode/utf8"
+)
+
+// TransformHandler applies the operation named by the 'op' query parameter to
+// the request body and returns the result:
+//
+//   - "base64" base64-encodes the body, as text/plain;
+//   - "hash" returns the hex digest of the body, as text/plain, using the
+//     hash named by 'alg' (sha1, sha256 or sha512; default sha256);
+//   - "reverse" reverses the body, by runes if it is valid UTF-8 and by bytes
+//     otherwise, keeping the request's Content-Type;
+//   - "uppercase" upper-cases the body, keeping the request's Content-Type;
+//   - "jsonpretty" indents the JSON body, as application/json.
+func TransformHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := parseData(r)
+	if err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to read body: %w", err))
+		return
+	}
+
+	q := r.URL.Query()
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	var out []byte
+	switch op := q.Get("op"); op {
+	case "base64":
+		contentType = "text/plain; charset=utf-8"
+		out = []byte(base64.StdEncoding.EncodeToString(body))
+	case "hash":
+		alg := q.Get("alg")
+		if alg == "" {
+			alg = "sha256"
+		}
+		newHash, ok := webhookHashes[alg]
+		if !ok {
+			writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("unsupported alg %q", alg))
+			return
+		}
+		h := newHash()
+		h.Write(body)
+		contentType = "text/plain; charset=utf-8"
+		out = []byte(hex.EncodeToString(h.Sum(nil)))
+	case "reverse":
+		out = reverseBody(body)
+	case "uppercase":
+		out = bytes.ToUpper(body)
+	case "jsonpretty":
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, body, "", "  "); err != nil {
+			writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("invalid JSON body: %w", err))
+			return
+		}
+		buf.WriteByte('\n')
+		contentType = "application/json"
+		out = buf.Bytes()
+	default:
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("unsupported op %q, want base64, hash, reverse, uppercase or jsonpretty", op))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Write(out)
+}
+
+func reverseBody(b []byte) []byte {
+	out := make([]byte, len(b))
+	if !utf8.Valid(b) {
+		for i, c := range b {
+			out[len(b)-1-i] = c
+		}
+		return out
+	}
+	n := len(b)
+	for len(b) > 0 {
+		_, size := utf8.DecodeRune(b)
+		copy(out[n-size:], b[:size])
+		b, n = b[size:], n-size
+	}
+	return out
+}
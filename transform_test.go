@@ -0,0 +1,48 @@
+package httpbin_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransform(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		op, contentType, body string
+		wantType, want        string
+	}{
+		{"base64", "text/plain", "hello", "text/plain; charset=utf-8", "aGVsbG8="},
+		{"hash", "text/plain", "hello", "text/plain; charset=utf-8", "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{"hash&alg=sha1", "text/plain", "hello", "text/plain; charset=utf-8", "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"},
+		{"reverse", "text/plain", "héllo", "text/plain", "olléh"},
+		{"uppercase", "text/csv", "a,b", "text/csv", "A,B"},
+		{"jsonpretty", "application/json", `{"a":[1,2]}`, "application/json", "{\n  \"a\": [\n    1,\n    2\n  ]\n}\n"},
+	} {
+		resp, err := http.Post(srv.URL+"/transform?op="+tc.op, tc.contentType, strings.NewReader(tc.body))
+		require.Nil(t, err)
+		b, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.Nil(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode, tc.op)
+		require.Equal(t, tc.wantType, resp.Header.Get("Content-Type"), tc.op)
+		require.Equal(t, tc.want, string(b), tc.op)
+	}
+}
+
+func TestTransform_badRequest(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for _, op := range []string{"", "rot13", "hash&alg=md5", "jsonpretty"} {
+		resp, err := http.Post(srv.URL+"/transform?op="+op, "application/json", strings.NewReader("{"))
+		require.Nil(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode, op)
+	}
+}
@@ -0,0 +1,67 @@
+package httpbin
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// truncatePayload is a payload /truncate serves.
+type truncatePayload struct {
+	contentType string
+	encode      func() []byte
+}
+
+// truncatePayloads maps the names of the payloads /truncate serves to them.
+// image.go adds "image".
+var truncatePayloads = map[string]truncatePayload{
+	"json": {"application/json", func() []byte { return encodePayloadJSON(payload) }},
+	"gzip": {"application/gzip", func() []byte {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(encodePayloadJSON(payload))
+		zw.Close()
+		return buf.Bytes()
+	}},
+}
+
+// TruncateHandler serves a well-known payload, named by the 'of' query
+// parameter: the /payloads/json document ("json", the default), the /image/png
+// image ("image", unless built with httpbin_noimage) or the gzip-compressed /payloads/json document ("gzip").
+// It sends the Content-Length and Repr-Digest of the whole payload but only
+// its first 'bytes' bytes (default half of them), then closes the
+// connection, so clients see the download cut short.
+func TruncateHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	of := q.Get("of")
+	if of == "" {
+		of = "json"
+	}
+	p, ok := truncatePayloads[of]
+	if !ok {
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("unknown payload %q", of))
+		return
+	}
+	b := p.encode()
+	n := len(b) / 2
+	if s := q.Get("bytes"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 0 || v > len(b) {
+			writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("'bytes' must be between 0 and the payload length %d", len(b)))
+			return
+		}
+		n = v
+	}
+
+	sum := sha256.Sum256(b)
+	h := w.Header()
+	h.Set("Content-Type", p.contentType)
+	h.Set("Content-Length", strconv.Itoa(len(b)))
+	h.Set("Repr-Digest", "sha-256=:"+base64.StdEncoding.EncodeToString(sum[:])+":")
+	// the server closes the connection as the body is shorter than declared
+	w.Write(b[:n])
+}
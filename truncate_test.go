@@ -0,0 +1,44 @@
+package httpbin_test
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTruncate(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	for _, of := range []string{"json", "gzip"} {
+		resp, err := http.Get(srv.URL + "/truncate?bytes=10&of=" + of)
+		require.Nil(t, err, of)
+		b, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		require.Equal(t, io.ErrUnexpectedEOF, err, of)
+		require.Len(t, b, 10, of)
+		require.True(t, resp.ContentLength > 10, of)
+		require.NotEmpty(t, resp.Header.Get("Repr-Digest"), of)
+	}
+
+	resp, err := http.Get(srv.URL + "/truncate?of=json&bytes=0")
+	require.Nil(t, err)
+	n := resp.ContentLength
+	resp.Body.Close()
+
+	resp, err = http.Get(srv.URL + "/truncate?of=json&bytes=" + strconv.FormatInt(n, 10))
+	require.Nil(t, err)
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Nil(t, err, "the whole payload is not truncated")
+	require.Equal(t, "{\n", string(b[:2]))
+
+	resp, err = http.Get(srv.URL + "/truncate?of=video")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -24,22 +24,56 @@ type cookiesResponse struct {
 	Cookies map[string]string `json:"cookies"`
 }
 
+type queryResponse struct {
+	RawQuery      string   `json:"raw_query"`
+	ArgsOrder     []string `json:"args_order"`
+	SemicolonArgs []string `json:"semicolon_args,omitempty"`
+	Fragment      *string  `json:"fragment,omitempty"`
+}
+
 type getResponse struct {
 	headersResponse
 	ipResponse
+	queryResponse
 	URL  string                 `json:"url"`
 	Args map[string]interface{} `json:"args"`
+
+	RedirectHistory *redirectHistoryResponse `json:"redirect_history,omitempty"`
 }
 
 type postResponse struct {
 	headersResponse
 	ipResponse
+	queryResponse
 	URL   string                 `json:"url"`
 	Args  map[string]interface{} `json:"args"`
 	Data  string                 `json:"data"`
 	Files map[string]string      `json:"files"`
 	Form  map[string]interface{} `json:"form"`
 	JSON  interface{}            `json:"json"`
+
+	BodyDigest *bodyDigest `json:"body_digest,omitempty"` // instead of data, for large bodies
+
+	Timings *requestTimings `json:"timings"`
+}
+
+type anythingResponse struct {
+	Method string `json:"method"`
+	postResponse
+}
+
+type requestTimings struct {
+	ReadHeadersMs     *float64 `json:"read_headers_ms,omitempty"`
+	ReadBodyMs        float64  `json:"read_body_ms"`
+	HandlerMs         float64  `json:"handler_ms"`
+	UploadBytesPerSec float64  `json:"upload_bytes_per_sec"`
+}
+
+type bodyDigest struct {
+	Size       int64  `json:"size"`
+	SHA256     string `json:"sha256"`
+	HeadBase64 string `json:"head_base64"`
+	TailBase64 string `json:"tail_base64"`
 }
 
 type gzipResponse struct {
@@ -54,7 +88,591 @@ type deflateResponse struct {
 	Deflated bool `json:"deflated"`
 }
 
+type brotliResponse struct {
+	headersResponse
+	ipResponse
+	Brotli bool `json:"brotli"`
+}
+
+type zstdResponse struct {
+	headersResponse
+	ipResponse
+	Zstd bool `json:"zstd"`
+}
+
 type basicAuthResponse struct {
 	Authenticated bool   `json:"authenticated"`
 	User          string `json:"user"`
 }
+
+type bearerResponse struct {
+	Authenticated bool   `json:"authenticated"`
+	Token         string `json:"token"`
+}
+
+type methodsResponse struct {
+	Path    string   `json:"path"`
+	Methods []string `json:"methods"`
+}
+
+type methodNotAllowedResponse struct {
+	Error   errObj   `json:"error"`
+	Methods []string `json:"methods"`
+}
+
+type authFlowResponse struct {
+	Authenticated bool        `json:"authenticated"`
+	Scheme        string      `json:"scheme"`
+	Tokens        []authToken `json:"tokens"`
+}
+
+type authToken struct {
+	Token string       `json:"token"`
+	NTLM  *ntlmMessage `json:"ntlm,omitempty"`
+}
+
+type ntlmMessage struct {
+	Type             int    `json:"type"`
+	Flags            string `json:"flags"`
+	Domain           string `json:"domain,omitempty"`
+	User             string `json:"user,omitempty"`
+	Workstation      string `json:"workstation,omitempty"`
+	LMResponseLength int    `json:"lm_response_length,omitempty"`
+	NTResponseLength int    `json:"nt_response_length,omitempty"`
+}
+
+type sigV4Response struct {
+	Verified          bool                   `json:"verified"`
+	AccessKey         string                 `json:"access_key"`
+	CredentialScope   string                 `json:"credential_scope"`
+	SignedHeaders     []string               `json:"signed_headers"`
+	Signature         string                 `json:"signature"`
+	ExpectedSignature string                 `json:"expected_signature"`
+	CanonicalRequest  string                 `json:"canonical_request"`
+	StringToSign      string                 `json:"string_to_sign"`
+	Diff              []canonicalRequestDiff `json:"diff,omitempty"`
+}
+
+type canonicalRequestDiff struct {
+	Line     int    `json:"line"`
+	Field    string `json:"field,omitempty"`
+	Expected string `json:"expected"`
+	Received string `json:"received"`
+}
+
+type webhookResponse struct {
+	Scheme    string `json:"scheme"`
+	Verified  bool   `json:"verified"`
+	Reason    string `json:"reason,omitempty"`
+	Timestamp int64  `json:"timestamp,omitempty"`
+}
+
+type connectionsResponse struct {
+	Tracking  bool           `json:"tracking"`
+	Open      int            `json:"open"`
+	States    map[string]int `json:"states"`
+	Remotes   map[string]int `json:"remotes"`
+	Protocols map[string]int `json:"protocols"`
+}
+
+type http2Response struct {
+	Protocol       string            `json:"protocol"`
+	HTTP2          bool              `json:"http2"`
+	PseudoHeaders  map[string]string `json:"pseudo_headers,omitempty"`
+	HeaderListSize int               `json:"header_list_size"`
+	Priority       *http2Priority    `json:"priority,omitempty"`
+	UsedTrailers   bool              `json:"used_trailers"`
+	Trailers       map[string]string `json:"trailers"`
+	PushSupported  bool              `json:"push_supported"`
+}
+
+type http2Priority struct {
+	Raw         string `json:"raw"`
+	Urgency     int    `json:"urgency"`
+	Incremental bool   `json:"incremental"`
+}
+
+type pushResponse struct {
+	Supported bool             `json:"supported"`
+	Attempted int              `json:"attempted"`
+	Pushed    int              `json:"pushed"`
+	Refused   int              `json:"refused"`
+	Resources []pushedResource `json:"resources"`
+}
+
+type pushedResource struct {
+	Path   string `json:"path"`
+	Pushed bool   `json:"pushed"`
+	Error  string `json:"error,omitempty"`
+}
+
+type onceResponse struct {
+	Token     string `json:"token"`
+	URL       string `json:"url"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+	Redeemed  bool   `json:"redeemed"`
+}
+
+type fixtureResponse struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Status int    `json:"status"`
+	Size   int    `json:"size"`
+	ETag   string `json:"etag"`
+}
+
+type diffResponse struct {
+	Equal     bool        `json:"equal"`
+	Binary    bool        `json:"binary"`
+	ASize     int         `json:"a_size"`
+	BSize     int         `json:"b_size"`
+	Hunks     []diffHunk  `json:"hunks,omitempty"`  // for text
+	Ranges    []diffRange `json:"ranges,omitempty"` // for binary
+	Truncated bool        `json:"truncated,omitempty"`
+}
+
+type diffHunk struct {
+	AStart int      `json:"a_start"`
+	ALines int      `json:"a_lines"`
+	BStart int      `json:"b_start"`
+	BLines int      `json:"b_lines"`
+	Lines  []string `json:"lines"` // prefixed with ' ', '-' or '+'
+
+	start, end int // ops of the hunk
+}
+
+type diffRange struct {
+	Offset int `json:"offset"`
+	Length int `json:"length"`
+}
+
+type schemaResponse struct {
+	Valid     bool          `json:"valid"`
+	Errors    []schemaError `json:"errors"`
+	Truncated bool          `json:"truncated,omitempty"`
+}
+
+type schemaError struct {
+	InstancePath string `json:"instance_path"` // JSON pointers
+	SchemaPath   string `json:"schema_path"`
+	Keyword      string `json:"keyword"`
+	Message      string `json:"message"`
+}
+
+type hashResponse struct {
+	Algorithm      string  `json:"algorithm"`
+	Digest         string  `json:"digest"` // hex
+	DigestBase64   string  `json:"digest_base64"`
+	Length         int64   `json:"length"`
+	ElapsedMS      float64 `json:"elapsed_ms"`
+	BytesPerSecond float64 `json:"bytes_per_second"`
+}
+
+type altSvcResponse struct {
+	Services map[string]string `json:"services"`
+}
+
+type meResponse struct {
+	Authenticated bool   `json:"authenticated"`
+	User          string `json:"user"`
+	LoggedInAt    string `json:"logged_in_at"`
+}
+
+type oauthErrorResponse struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+type oidcClaims struct {
+	Issuer    string `json:"iss"`
+	Subject   string `json:"sub"`
+	Audience  string `json:"aud"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+	Nonce     string `json:"nonce,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+}
+
+type oidcTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+	IDToken     string `json:"id_token"`
+	Scope       string `json:"scope"`
+}
+
+type oidcUserInfo struct {
+	Subject           string `json:"sub"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Kid string `json:"kid,omitempty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jwksResponse struct {
+	Keys []jwk `json:"keys"`
+}
+
+type oidcDiscovery struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+}
+
+type redirectHistoryResponse struct {
+	RedirectCount int                  `json:"redirect_count"`
+	Hops          []redirectHistoryHop `json:"hops"`
+}
+
+type redirectHistoryHop struct {
+	Path      string `json:"path"`
+	Status    int    `json:"status"`
+	Timestamp string `json:"timestamp"`
+}
+
+type configResponse struct {
+	Limits    configLimits   `json:"limits"`
+	Features  configFeatures `json:"features"`
+	Endpoints []string       `json:"endpoints"`
+}
+
+type configLimits struct {
+	BinaryChunkSize    int    `json:"binary_chunk_size"`
+	DelayMax           string `json:"delay_max"`
+	StreamInterval     string `json:"stream_interval"`
+	ResponseCacheSize  int    `json:"response_cache_size"`
+	ResponseBufferMax  int    `json:"response_buffer_max"`
+	PushMax            int    `json:"push_max"`
+	EchoBodyMax        int    `json:"echo_body_max"`
+	OnceTokensMax      int    `json:"once_tokens_max"`
+	WebhookTolerance   string `json:"webhook_tolerance"`
+	ConnectDialTimeout string `json:"connect_dial_timeout"`
+	MaxBodySize        int64  `json:"max_body_size"`
+}
+
+type configFeatures struct {
+	StrictMethods       bool              `json:"strict_methods"`
+	ConnectionTracking  bool              `json:"connection_tracking"`
+	Hooks               bool              `json:"hooks"`
+	RouteLatencies      map[string]string `json:"route_latencies"`
+	AltServices         map[string]string `json:"alt_services"`
+	SigV4AccessKeys     []string          `json:"sigv4_access_keys"`
+	WebhookSecretSet    bool              `json:"webhook_secret_set"`
+	ConfigTokenSet      bool              `json:"config_token_set"`
+	DNSRecords          int               `json:"dns_records"`
+	DecompressRequests  bool              `json:"decompress_requests"`
+	TraceRequests       bool              `json:"trace_requests"`
+	QuotaKeys           int               `json:"quota_keys"`
+	Fixtures            []string          `json:"fixtures"`
+	FixtureTokenSet     bool              `json:"fixture_token_set"`
+	PartitionTokenSet   bool              `json:"partition_token_set"`
+	JobCallbacks        bool              `json:"job_callbacks"`
+	MaintenanceTokenSet bool              `json:"maintenance_token_set"`
+	Region              string            `json:"region"`
+	Zone                string            `json:"zone"`
+	RegionLatency       string            `json:"region_latency,omitempty"`
+	LatencyProfiles     map[string]string `json:"latency_profiles"`
+	MirrorTemplates     []string          `json:"mirror_templates"`
+	Prefix              string            `json:"prefix"`
+}
+
+type partitionResponse struct {
+	Active      bool    `json:"active"`
+	Mode        string  `json:"mode,omitempty"`
+	Ends        string  `json:"ends,omitempty"`
+	RemainingMS float64 `json:"remaining_ms,omitempty"`
+}
+
+// stickyResponse is Consistent unless the affinity cookie pins the client
+// to another instance.
+type stickyResponse struct {
+	Instance   string            `json:"instance"`
+	Affinity   stickyAffinity    `json:"affinity"`
+	RoutingKey *stickyRoutingKey `json:"routing_key,omitempty"`
+	Consistent bool              `json:"consistent"`
+}
+
+// stickyAffinity has a nil Consistent for requests without the cookie.
+type stickyAffinity struct {
+	Cookie     string `json:"cookie"`
+	PinnedTo   string `json:"pinned_to,omitempty"`
+	Consistent *bool  `json:"consistent"`
+}
+
+type stickyRoutingKey struct {
+	Header   string `json:"header"`
+	Key      string `json:"key"`
+	Hash     uint64 `json:"hash,omitempty"`
+	Buckets  int    `json:"buckets,omitempty"`
+	Bucket   *int   `json:"bucket,omitempty"`
+	Requests int    `json:"requests"`
+}
+
+// orderedResponse has the Arrival and Completion index of the request among
+// those of its group, and the seqs it waited for, After.
+type orderedResponse struct {
+	Group      string  `json:"group"`
+	Seq        int     `json:"seq"`
+	Arrival    int     `json:"arrival"`
+	Completion int     `json:"completion"`
+	Delay      float64 `json:"delay,omitempty"`
+	After      []int   `json:"after,omitempty"`
+	TimedOut   bool    `json:"timed_out,omitempty"`
+}
+
+// dedupeResponse has the PreviousSeen time of Duplicate requests, seen
+// within Window seconds of the previous one.
+type dedupeResponse struct {
+	Fingerprint     string  `json:"fingerprint"`
+	Method          string  `json:"method"`
+	Path            string  `json:"path"`
+	BodySHA256      string  `json:"body_sha256"`
+	Duplicate       bool    `json:"duplicate"`
+	Count           int     `json:"count"`
+	FirstSeen       string  `json:"first_seen"`
+	PreviousSeen    string  `json:"previous_seen,omitempty"`
+	SincePreviousMS float64 `json:"since_previous_ms,omitempty"`
+	Window          float64 `json:"window"`
+}
+
+type regionResponse struct {
+	Region      string `json:"region"`
+	Zone        string `json:"zone"`
+	Hostname    string `json:"hostname"`
+	BaseLatency string `json:"base_latency,omitempty"`
+}
+
+type maintenanceResponse struct {
+	Active       bool    `json:"active"`
+	RetryAfter   int64   `json:"retry_after,omitempty"`
+	Ends         string  `json:"ends,omitempty"`
+	RemainingMS  float64 `json:"remaining_ms,omitempty"`
+	BypassHeader string  `json:"bypass_header"`
+	BypassSet    bool    `json:"bypass_set"`
+}
+
+type openAPIDocument struct {
+	OpenAPI string                                 `json:"openapi"`
+	Info    openAPIInfo                            `json:"info"`
+	Paths   map[string]map[string]openAPIOperation `json:"paths"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIParameter struct {
+	Name     string        `json:"name"`
+	In       string        `json:"in"`
+	Required bool          `json:"required,omitempty"`
+	Schema   openAPISchema `json:"schema"`
+}
+
+type openAPISchema struct {
+	Type string `json:"type"`
+}
+
+type openAPIResponse struct {
+	Description string `json:"description"`
+}
+
+type handlerAllocsResponse struct {
+	Routes map[string]handlerAllocsStats `json:"routes"`
+}
+
+type handlerAllocsStats struct {
+	Requests        uint64 `json:"requests"`
+	Bytes           uint64 `json:"bytes"`
+	Objects         uint64 `json:"objects"`
+	BytesPerRequest uint64 `json:"bytes_per_request"`
+}
+
+type clockSyncResponse struct {
+	T0      float64           `json:"t0,omitempty"`
+	T1      float64           `json:"t1"`
+	T2      float64           `json:"t2"`
+	Offset  float64           `json:"offset"`
+	RTT     float64           `json:"rtt"`
+	Samples []clockSyncSample `json:"samples"`
+	State   string            `json:"state"`
+}
+
+type clockSyncSample struct {
+	Offset float64 `json:"offset"`
+	RTT    float64 `json:"rtt"`
+}
+
+type headersReflectResponse struct {
+	Headers []reflectedHeader `json:"headers"`
+}
+
+type reflectedHeader struct {
+	Name      string         `json:"name"`
+	Value     string         `json:"value"`
+	Sanitized string         `json:"sanitized"`
+	NameValid bool           `json:"name_valid"`
+	Sent      bool           `json:"sent"`
+	Dropped   string         `json:"dropped,omitempty"` // why it was not sent
+	Changes   []headerChange `json:"changes"`
+}
+
+type headerChange struct {
+	Offset int    `json:"offset"`
+	Char   string `json:"char"`
+	Action string `json:"action"`
+}
+
+type tlsInfoResponse struct {
+	TLS                bool     `json:"tls"`
+	Version            string   `json:"version,omitempty"`
+	CipherSuite        string   `json:"cipher_suite,omitempty"`
+	ServerName         string   `json:"server_name,omitempty"`
+	NegotiatedProtocol string   `json:"negotiated_protocol,omitempty"`
+	Resumed            bool     `json:"resumed,omitempty"`
+	ClientCertificates []string `json:"client_certificates,omitempty"`
+}
+
+type statsResponse struct {
+	Routes        map[string]routeStatsEntry `json:"routes"`
+	Total         routeStatsEntry            `json:"total"`
+	Decompression decompressionStats         `json:"decompression"`
+	Reset         bool                       `json:"reset"`
+}
+
+type decompressionStats struct {
+	Requests          int64 `json:"requests"`
+	CompressedBytes   int64 `json:"compressed_bytes"`
+	DecompressedBytes int64 `json:"decompressed_bytes"`
+	RejectedSize      int64 `json:"rejected_size"`
+	RejectedRatio     int64 `json:"rejected_ratio"`
+}
+
+type decompressionErrorResponse struct {
+	Error             errObj  `json:"error"`
+	Limit             string  `json:"limit"`
+	MaxBytes          int64   `json:"max_bytes"`
+	MaxRatio          float64 `json:"max_ratio"`
+	CompressedBytes   int64   `json:"compressed_bytes"`
+	DecompressedBytes int64   `json:"decompressed_bytes"`
+	Ratio             float64 `json:"ratio"`
+}
+
+type routeStatsEntry struct {
+	Requests int64 `json:"requests"`
+	BytesIn  int64 `json:"bytes_in"`
+	BytesOut int64 `json:"bytes_out"`
+}
+
+type responseCacheResponse struct {
+	Entries   int `json:"entries"`
+	Bytes     int `json:"bytes"`
+	MaxBytes  int `json:"max_bytes"`
+	Hits      int `json:"hits"`
+	Misses    int `json:"misses"`
+	Bypasses  int `json:"bypasses"`
+	Evictions int `json:"evictions"`
+}
+
+type traceReport struct {
+	Route     string       `json:"route"`
+	Path      string       `json:"path"`
+	Events    []traceEvent `json:"events"`
+	ElapsedMS float64      `json:"elapsed_ms"`
+}
+
+type traceEvent struct {
+	AtMS  float64 `json:"at_ms"`
+	Event string  `json:"event"`
+}
+
+type quotaResponse struct {
+	Key          string         `json:"key"`
+	Requests     quotaAllowance `json:"requests"`
+	Bytes        quotaAllowance `json:"bytes"`
+	Reset        string         `json:"reset"`
+	ResetSeconds int64          `json:"reset_seconds"`
+}
+
+// quotaAllowance has a nil Limit and Remaining when unlimited.
+type quotaAllowance struct {
+	Limit     *int64 `json:"limit"`
+	Used      int64  `json:"used"`
+	Remaining *int64 `json:"remaining"`
+}
+
+// extractResponse has the value of a definite expression, or null if it
+// matched nothing, and the list of matches of any other.
+type extractResponse struct {
+	Expression string        `json:"expression"`
+	Syntax     string        `json:"syntax"`
+	JSONPath   string        `json:"jsonpath"`
+	Definite   bool          `json:"definite"`
+	Value      interface{}   `json:"value"`
+	Matches    []interface{} `json:"matches"`
+	Count      int           `json:"count"`
+	Steps      []extractStep `json:"steps"`
+}
+
+// extractStep is the number of nodes selected once a step of the
+// expression is applied.
+type extractStep struct {
+	Step    string `json:"step"`
+	Matched int    `json:"matched"`
+}
+
+// jobResponse has the Result of a succeeded job, the Error of a failed one
+// and, while the job runs, when its status changes Next.
+type jobResponse struct {
+	ID       string             `json:"id"`
+	URL      string             `json:"url"`
+	Status   string             `json:"status"`
+	Done     bool               `json:"done"`
+	Progress float64            `json:"progress"`
+	Since    string             `json:"since"`
+	Next     string             `json:"next,omitempty"`
+	Created  string             `json:"created"`
+	Phases   []jobPhaseResponse `json:"phases"`
+	Result   interface{}        `json:"result,omitempty"`
+	Error    string             `json:"error,omitempty"`
+	Callback *jobCallback       `json:"callback,omitempty"`
+}
+
+type jobPhaseResponse struct {
+	Name    string  `json:"name"`
+	Seconds float64 `json:"seconds"`
+}
+
+// jobCallback is the delivery of a job callback, once attempted.
+type jobCallback struct {
+	URL         string `json:"url"`
+	AttemptedAt string `json:"attempted_at,omitempty"`
+	Delivered   bool   `json:"delivered"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
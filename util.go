@@ -1,22 +1,130 @@
 package httpbin
 
 import (
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
-
-	"github.com/pkg/errors"
+	"strconv"
+	"strings"
 )
 
+// probeMethods are the methods tried when finding out which methods a path
+// supports.
+var probeMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+	http.MethodTrace,
+}
+
+// allowedMethods returns the methods for which the router has a route
+// matching u.
+func allowedMethods(router *routeMux, u *url.URL) []string {
+	methods := make([]string, 0, len(probeMethods))
+	for _, m := range probeMethods {
+		req := &http.Request{Method: m, URL: u, Header: make(http.Header)}
+		if route, _ := router.match(req); route != nil {
+			methods = append(methods, m)
+		}
+	}
+	return methods
+}
+
 func writeJSON(w io.Writer, v interface{}) error {
 	e := json.NewEncoder(w)
 	e.SetIndent("", "  ")
-	return errors.Wrap(e.Encode(v), "failed to encode JSON")
+	if err := e.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return nil
+}
+
+// writeNegotiated writes v as CBOR if the request's Accept header prefers
+// application/cbor to application/json, and as JSON otherwise.
+func writeNegotiated(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	w.Header().Add("Vary", "Accept")
+	if !acceptsCBOR(r.Header.Get("Accept")) {
+		return writeJSON(w, v)
+	}
+	// go through JSON so the CBOR has the same fields as the JSON would
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	var generic interface{}
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return fmt.Errorf("failed to decode JSON: %w", err)
+	}
+	w.Header().Set("Content-Type", cborContentType)
+	if _, err := w.Write(appendCBOR(nil, generic)); err != nil {
+		return fmt.Errorf("failed to write CBOR: %w", err)
+	}
+	return nil
+}
+
+// acceptsCBOR reports whether the Accept header lists application/cbor with
+// a quality at least that of an explicitly listed application/json.
+func acceptsCBOR(accept string) bool {
+	qCBOR, qJSON := 0.0, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if s, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				q = f
+			}
+		}
+		switch mt {
+		case cborContentType:
+			qCBOR = q
+		case "application/json":
+			qJSON = q
+		}
+	}
+	return qCBOR > 0 && qCBOR >= qJSON
 }
 
 func writeErrorJSON(w http.ResponseWriter, err error) {
-	w.WriteHeader(http.StatusInternalServerError)
+	writeErrorJSONStatus(w, http.StatusInternalServerError, err)
+}
+
+// discarder is a ResponseWriter buffering the response, which can drop what
+// was written so far for an error response to take its place.
+type discarder interface {
+	// discard drops the buffered response, reporting false if it is too
+	// late as the response is committed.
+	discard() bool
+}
+
+func writeErrorJSONStatus(w http.ResponseWriter, status int, err error) {
+	if d, ok := w.(discarder); ok && !d.discard() {
+		// an error response would be appended to what was already sent,
+		// abort instead so the client sees the response truncated
+		panic(http.ErrAbortHandler)
+	}
+	var de *decompressionError
+	if errors.As(err, &de) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		_ = writeJSON(w, de.response())
+		return
+	}
+	var mbe *http.MaxBytesError
+	if errors.As(err, &mbe) {
+		status = http.StatusRequestEntityTooLarge
+	}
+	w.WriteHeader(status)
 	_ = writeJSON(w, errorResponse{errObj{err.Error()}}) // ignore error, can't do anything
 }
 
@@ -48,3 +156,54 @@ func flattenValues(uv url.Values) map[string]interface{} {
 	}
 	return m
 }
+
+// getQuery reports the request's query string as sent, the order of its
+// parameters and the parts that parse differently between implementations:
+// semicolon-separated parameters, which net/url ignores, and a fragment,
+// which clients should not send at all.
+func getQuery(r *http.Request) queryResponse {
+	v := queryResponse{RawQuery: r.URL.RawQuery, ArgsOrder: []string{}}
+	if i := strings.IndexByte(r.RequestURI, '#'); i >= 0 {
+		frag := r.RequestURI[i+1:]
+		v.Fragment = &frag
+		if j := strings.IndexByte(v.RawQuery, '#'); j >= 0 {
+			v.RawQuery = v.RawQuery[:j]
+		}
+	}
+	for _, kv := range strings.Split(v.RawQuery, "&") {
+		if kv == "" {
+			continue
+		}
+		if strings.Contains(kv, ";") {
+			v.SemicolonArgs = append(v.SemicolonArgs, kv)
+			continue
+		}
+		k := kv
+		if i := strings.IndexByte(kv, '='); i >= 0 {
+			k = kv[:i]
+		}
+		if uk, err := url.QueryUnescape(k); err == nil {
+			k = uk
+		}
+		v.ArgsOrder = append(v.ArgsOrder, k)
+	}
+	return v
+}
+
+// parseBasicAuth parses a "Basic" credentials header value, such as the one
+// in Authorization or Proxy-Authorization.
+func parseBasicAuth(auth string) (user, pass string, ok bool) {
+	const prefix = "Basic "
+	if len(auth) < len(prefix) || !strings.EqualFold(auth[:len(prefix)], prefix) {
+		return "", "", false
+	}
+	b, err := base64.StdEncoding.DecodeString(auth[len(prefix):])
+	if err != nil {
+		return "", "", false
+	}
+	i := strings.IndexByte(string(b), ':')
+	if i < 0 {
+		return "", "", false
+	}
+	return string(b[:i]), string(b[i+1:]), true
+}
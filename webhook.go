@@ -0,0 +1,188 @@
+package httpbin
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultWebhookSecret is the shared secret /webhook/verify checks
+	// signatures with.
+	defaultWebhookSecret = "httpbin"
+
+	// defaultWebhookTolerance is the maximum age of a Stripe-Signature
+	// timestamp accepted by /webhook/verify.
+	defaultWebhookTolerance = 5 * time.Minute
+)
+
+// WithWebhookSecret sets the shared secret /webhook/verify checks
+// signatures with and job callbacks are signed with. It defaults to
+// "httpbin".
+func WithWebhookSecret(secret string) Option {
+	return func(h *HTTPBin) { h.webhookSecret = secret }
+}
+
+// WithWebhookTolerance sets the maximum age of a Stripe-Signature timestamp
+// accepted by /webhook/verify. It defaults to 5 minutes.
+func WithWebhookTolerance(d time.Duration) Option {
+	return func(h *HTTPBin) { h.webhookTolerance = d }
+}
+
+var webhookHashes = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// WebhookVerifyHandler checks the request body's webhook signature against
+// the webhook secret and reports the verdict, with 200 if the signature is valid
+// and 401 otherwise.
+//
+// The scheme is taken from the 'scheme' query parameter or detected from the
+// headers: "github" (X-Hub-Signature-256, or X-Hub-Signature with SHA-1),
+// "stripe" (Stripe-Signature, with its timestamp checked against the
+// webhook tolerance) or "hmac", a hex or base64 HMAC of the body in the header
+// named by the 'header' query parameter (default X-Signature) using the hash
+// named by 'alg' (sha1, sha256 or sha512; default sha256).
+func WebhookVerifyHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := parseData(r)
+	if err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to read body: %w", err))
+		return
+	}
+
+	q := r.URL.Query()
+	scheme := q.Get("scheme")
+	if scheme == "" {
+		switch {
+		case r.Header.Get("X-Hub-Signature-256") != "" || r.Header.Get("X-Hub-Signature") != "":
+			scheme = "github"
+		case r.Header.Get("Stripe-Signature") != "":
+			scheme = "stripe"
+		default:
+			scheme = "hmac"
+		}
+	}
+
+	hb := instance(r)
+	v := webhookResponse{Scheme: scheme}
+	switch scheme {
+	case "github":
+		err = verifyGitHubSignature(r, hb.webhookSecret, body)
+	case "stripe":
+		v.Timestamp, err = verifyStripeSignature(r.Header.Get("Stripe-Signature"), hb.webhookSecret, hb.webhookTolerance, body, time.Now())
+	case "hmac":
+		header := q.Get("header")
+		if header == "" {
+			header = "X-Signature"
+		}
+		alg := q.Get("alg")
+		if alg == "" {
+			alg = "sha256"
+		}
+		err = verifyHMACSignature(r.Header.Get(header), hb.webhookSecret, alg, body)
+	default:
+		writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("unknown scheme %q", scheme))
+		return
+	}
+
+	v.Verified = err == nil
+	if err != nil {
+		v.Reason = err.Error()
+		w.WriteHeader(http.StatusUnauthorized)
+	}
+	if err := writeJSON(w, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
+
+func verifyGitHubSignature(r *http.Request, secret string, body []byte) error {
+	sig, alg := r.Header.Get("X-Hub-Signature-256"), "sha256"
+	if sig == "" {
+		sig, alg = r.Header.Get("X-Hub-Signature"), "sha1"
+	}
+	if !strings.HasPrefix(sig, alg+"=") {
+		return fmt.Errorf("signature must have the form %s=<hex digest>", alg)
+	}
+	got, err := hex.DecodeString(sig[len(alg)+1:])
+	if err != nil {
+		return fmt.Errorf("signature is not hex encoded: %w", err)
+	}
+	if !hmac.Equal(got, webhookMAC(secret, alg, body)) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
+
+// verifyStripeSignature checks a Stripe-Signature header value of the form
+// t=<unix time>,v1=<hex digest>[,v1=...], signed with secret and at most
+// tolerance old, and returns its timestamp.
+func verifyStripeSignature(header, secret string, tolerance time.Duration, body []byte, now time.Time) (int64, error) {
+	var ts int64
+	var sigs [][]byte
+	for _, kv := range strings.Split(header, ",") {
+		i := strings.IndexByte(kv, '=')
+		if i < 0 {
+			continue
+		}
+		switch k, v := strings.TrimSpace(kv[:i]), kv[i+1:]; k {
+		case "t":
+			ts, _ = strconv.ParseInt(v, 10, 64)
+		case "v1":
+			if b, err := hex.DecodeString(v); err == nil {
+				sigs = append(sigs, b)
+			}
+		}
+	}
+	if ts == 0 || len(sigs) == 0 {
+		return ts, errors.New("Stripe-Signature must have the form t=<timestamp>,v1=<hex digest>")
+	}
+	if age := now.Sub(time.Unix(ts, 0)); math.Abs(float64(age)) > float64(tolerance) {
+		return ts, fmt.Errorf("timestamp is %v away from server time, tolerance is %v", age.Round(time.Second), tolerance)
+	}
+
+	want := webhookMAC(secret, "sha256", []byte(fmt.Sprintf("%d.%s", ts, body)))
+	for _, sig := range sigs {
+		if hmac.Equal(sig, want) {
+			return ts, nil
+		}
+	}
+	return ts, errors.New("no v1 signature matches")
+}
+
+func verifyHMACSignature(sig, secret, alg string, body []byte) error {
+	if _, ok := webhookHashes[alg]; !ok {
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+	if sig == "" {
+		return errors.New("missing signature header")
+	}
+	sig = strings.TrimPrefix(sig, alg+"=")
+	want := webhookMAC(secret, alg, body)
+
+	if got, err := hex.DecodeString(sig); err == nil && hmac.Equal(got, want) {
+		return nil
+	}
+	if got, err := base64.StdEncoding.DecodeString(sig); err == nil && hmac.Equal(got, want) {
+		return nil
+	}
+	return errors.New("signature mismatch")
+}
+
+func webhookMAC(secret, alg string, data []byte) []byte {
+	h := hmac.New(webhookHashes[alg], []byte(secret))
+	h.Write(data)
+	return h.Sum(nil)
+}
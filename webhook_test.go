@@ -0,0 +1,99 @@
+package httpbin_test
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func webhookSign(data string) []byte {
+	h := hmac.New(sha256.New, []byte("httpbin"))
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func webhookVerify(t *testing.T, u, body string, hdr http.Header) (int, string) {
+	req, err := http.NewRequest("POST", u, bytes.NewBufferString(body))
+	require.Nil(t, err)
+	for k, v := range hdr {
+		req.Header[k] = v
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+
+	var v struct {
+		Scheme   string `json:"scheme"`
+		Verified bool   `json:"verified"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	require.Equal(t, resp.StatusCode == http.StatusOK, v.Verified)
+	return resp.StatusCode, v.Scheme
+}
+
+func TestWebhookVerify_github(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	body := `{"action":"opened"}`
+	code, scheme := webhookVerify(t, srv.URL+"/webhook/verify", body, http.Header{
+		"X-Hub-Signature-256": {"sha256=" + hex.EncodeToString(webhookSign(body))},
+	})
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "github", scheme)
+
+	code, _ = webhookVerify(t, srv.URL+"/webhook/verify", body+" ", http.Header{
+		"X-Hub-Signature-256": {"sha256=" + hex.EncodeToString(webhookSign(body))},
+	})
+	require.Equal(t, http.StatusUnauthorized, code)
+}
+
+func TestWebhookVerify_stripe(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	body := `{"type":"charge.succeeded"}`
+	now := time.Now().Unix()
+	sig := hex.EncodeToString(webhookSign(fmt.Sprintf("%d.%s", now, body)))
+	code, scheme := webhookVerify(t, srv.URL+"/webhook/verify", body, http.Header{
+		"Stripe-Signature": {fmt.Sprintf("t=%d,v1=%s", now, sig)},
+	})
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "stripe", scheme)
+
+	old := now - int64(5*time.Minute/time.Second) - 60
+	sig = hex.EncodeToString(webhookSign(fmt.Sprintf("%d.%s", old, body)))
+	code, _ = webhookVerify(t, srv.URL+"/webhook/verify", body, http.Header{
+		"Stripe-Signature": {fmt.Sprintf("t=%d,v1=%s", old, sig)},
+	})
+	require.Equal(t, http.StatusUnauthorized, code)
+}
+
+func TestWebhookVerify_hmac(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	body := "payload"
+	code, scheme := webhookVerify(t, srv.URL+"/webhook/verify", body, http.Header{
+		"X-Signature": {base64.StdEncoding.EncodeToString(webhookSign(body))},
+	})
+	require.Equal(t, http.StatusOK, code)
+	require.Equal(t, "hmac", scheme)
+
+	code, _ = webhookVerify(t, srv.URL+"/webhook/verify?header=X-My-Sig", body, http.Header{
+		"X-My-Sig": {hex.EncodeToString(webhookSign(body))},
+	})
+	require.Equal(t, http.StatusOK, code)
+
+	code, _ = webhookVerify(t, srv.URL+"/webhook/verify", body, nil)
+	require.Equal(t, http.StatusUnauthorized, code)
+}
@@ -0,0 +1,166 @@
+//go:build !httpbin_noimage
+// +build !httpbin_noimage
+
+package httpbin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// encodeWebP writes m as a lossless WebP (VP8L) image. It makes no attempt
+// at compression: every channel of every pixel is a literal with an 8-bit
+// prefix code, so the file is about as large as the raw pixels.
+func encodeWebP(w io.Writer, m image.Image) error {
+	b := m.Bounds()
+	if b.Dx() < 1 || b.Dy() < 1 || b.Dx() > 1<<14 || b.Dy() > 1<<14 {
+		return fmt.Errorf("webp: invalid image size %dx%d", b.Dx(), b.Dy())
+	}
+	pixels := make([]color.NRGBA, 0, b.Dx()*b.Dy())
+	opaque := true
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(m.At(x, y)).(color.NRGBA)
+			opaque = opaque && c.A == 0xff
+			pixels = append(pixels, c)
+		}
+	}
+
+	var bw webpBitWriter
+	bw.buf.WriteByte(0x2f) // VP8L signature
+	bw.write(uint64(b.Dx()-1), 14)
+	bw.write(uint64(b.Dy()-1), 14)
+	if opaque {
+		bw.write(0, 1)
+	} else {
+		bw.write(1, 1) // alpha is used
+	}
+	bw.write(0, 3) // version
+	bw.write(0, 1) // no transforms
+	bw.write(0, 1) // no color cache
+	bw.write(0, 1) // no meta prefix codes
+
+	// prefix codes for green (with the unused backward reference lengths),
+	// red, blue, alpha and distance
+	bw.literalCode(256 + 24)
+	bw.literalCode(256)
+	bw.literalCode(256)
+	if opaque {
+		bw.singleCode(0xff)
+	} else {
+		bw.literalCode(256)
+	}
+	bw.singleCode(0)
+
+	for _, c := range pixels {
+		bw.literal(c.G)
+		bw.literal(c.R)
+		bw.literal(c.B)
+		if !opaque {
+			bw.literal(c.A)
+		}
+	}
+	data := bw.bytes()
+
+	var hdr [20]byte
+	copy(hdr[0:], "RIFF")
+	binary.LittleEndian.PutUint32(hdr[4:], uint32(12+len(data)+len(data)%2))
+	copy(hdr[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(hdr[16:], uint32(len(data)))
+	if _, err := w.Write(hdr[:]); err != nil {
+		return err
+	}
+	if len(data)%2 == 1 {
+		data = append(data, 0) // chunks are padded to an even size
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// webpBitWriter writes a VP8L bitstream, least significant bit first.
+type webpBitWriter struct {
+	buf   bytes.Buffer
+	bits  uint64
+	nbits uint
+}
+
+func (bw *webpBitWriter) write(v uint64, n uint) {
+	bw.bits |= v << bw.nbits
+	bw.nbits += n
+	for bw.nbits >= 8 {
+		bw.buf.WriteByte(byte(bw.bits))
+		bw.bits >>= 8
+		bw.nbits -= 8
+	}
+}
+
+func (bw *webpBitWriter) bytes() []byte {
+	if bw.nbits > 0 {
+		bw.write(0, 8-bw.nbits)
+	}
+	return bw.buf.Bytes()
+}
+
+// singleCode writes a simple prefix code with the single 8-bit symbol s,
+// which then takes no bits to write.
+func (bw *webpBitWriter) singleCode(s byte) {
+	bw.write(1, 1) // simple code
+	bw.write(0, 1) // one symbol
+	bw.write(1, 1) // of 8 bits
+	bw.write(uint64(s), 8)
+}
+
+// literalCode writes a normal prefix code of an alphabet of size symbols
+// whose first 256 have 8-bit codes, their own values, and the rest none.
+func (bw *webpBitWriter) literalCode(size int) {
+	bw.write(0, 1) // normal code
+	// the code length code, in which lengths 0 and 8 both have 1-bit codes:
+	// 12 code length code lengths, in the order 17, 18, 0, 1, 2, 3, 4, 5,
+	// 16, 6, 7, 8
+	bw.write(12-4, 4)
+	for i := 0; i < 12; i++ {
+		switch i {
+		case 2, 11: // lengths 0 and 8
+			bw.write(1, 3)
+		default:
+			bw.write(0, 3)
+		}
+	}
+	bw.write(0, 1) // code lengths for the whole alphabet
+	for i := 0; i < size; i++ {
+		if i < 256 {
+			bw.write(1, 1) // length 8
+		} else {
+			bw.write(0, 1) // length 0
+		}
+	}
+}
+
+// literal writes the symbol v of a literalCode: its 8-bit code, most
+// significant bit first.
+func (bw *webpBitWriter) literal(v byte) {
+	var r byte
+	for i := 0; i < 8; i++ {
+		r |= (v >> i & 1) << (7 - i)
+	}
+	bw.write(uint64(r), 8)
+}
+
+// encodeSVG writes m as an SVG image embedding it as a PNG.
+func encodeSVG(w io.Writer, m image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, m); err != nil {
+		return err
+	}
+	b := m.Bounds()
+	_, err := fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %[1]d %[2]d">`+
+		`<image width="%[1]d" height="%[2]d" href="data:image/png;base64,%s"/></svg>`+"\n",
+		b.Dx(), b.Dy(), base64.StdEncoding.EncodeToString(buf.Bytes()))
+	return err
+}
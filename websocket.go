@@ -0,0 +1,393 @@
+//go:build !httpbin_nowebsocket
+// +build !httpbin_nowebsocket
+
+package httpbin
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+func init() {
+	featureRoutes = append(featureRoutes,
+		route{name: "websocket", path: `/websocket`, methods: []string{http.MethodGet}, params: []string{"close", "close_after", "reason", "pong_delay", "ping_interval", "fragment"}, description: "A WebSocket echo server that can send chosen close codes, delay pongs, send unsolicited pings and fragment messages.", handler: http.HandlerFunc(WebSocketHandler)},
+	)
+}
+
+// wsGUID is the RFC 6455 key suffix hashed into Sec-WebSocket-Accept.
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// wsPingIntervalMin is the shortest 'ping_interval' /websocket honours, so
+// that a tiny one cannot flood the connection with pings.
+const wsPingIntervalMin = 100 * time.Millisecond
+
+// WebSocket opcodes.
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xa
+)
+
+// wsFaults are the misbehaviours requested of a /websocket connection.
+type wsFaults struct {
+	closeCode    int           // close with this code, if set...
+	closeAfter   int           // ...after echoing this many messages
+	reason       string        // close reason
+	pongDelay    time.Duration // delay before answering a ping
+	pingInterval time.Duration // interval of unsolicited pings
+	fragment     int           // size of the fragments echoed messages are split in
+}
+
+// wsError is a protocol violation, closing the connection with code.
+type wsError struct {
+	code   int
+	reason string
+}
+
+func (e wsError) Error() string { return e.reason }
+
+var errWSClosed = errors.New("websocket closed")
+
+// WebSocketHandler is a WebSocket echo server whose misbehaviour can be
+// chosen with query parameters, to test the robustness of clients:
+//
+//   - 'close' is a close code (1000-4999, reserved ones included) the server
+//     closes with after echoing 'close_after' messages (default 0), with the
+//     reason given by 'reason'.
+//   - 'pong_delay' delays the answer to pings by that many seconds.
+//   - 'ping_interval' sends unsolicited pings every that many seconds, at
+//     most every wsPingIntervalMin.
+//   - 'fragment' splits echoed messages into fragments of that many bytes,
+//     cutting through UTF-8 sequences of text messages.
+//
+// Delays are capped at the delay limit.
+func WebSocketHandler(w http.ResponseWriter, r *http.Request) {
+	var f wsFaults
+	if !intParam(w, r, "close", 1000, 4999, &f.closeCode) ||
+		!intParam(w, r, "close_after", 0, 1<<20, &f.closeAfter) ||
+		!intParam(w, r, "fragment", 1, instance(r).webSocketMessageMax, &f.fragment) ||
+		!secondsParam(w, r, "pong_delay", &f.pongDelay) ||
+		!secondsParam(w, r, "ping_interval", &f.pingInterval) {
+		return
+	}
+	if f.pingInterval > 0 && f.pingInterval < wsPingIntervalMin {
+		f.pingInterval = wsPingIntervalMin
+	}
+	if f.reason = r.URL.Query().Get("reason"); len(f.reason) > 123 {
+		writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("'reason' must be at most 123 bytes"))
+		return
+	}
+
+	c, _ := upgradeWebSocket(w, r, nil)
+	if c == nil {
+		return
+	}
+	defer c.conn.Close()
+	c.serve(f)
+}
+
+// upgradeWebSocket completes the WebSocket handshake of r, agreeing on the
+// first subprotocol the client offers that is one of protocols, if any. It
+// writes an error and returns a nil connection, which the caller must
+// otherwise close, if the handshake fails.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request, protocols []string) (*wsConn, string) {
+	if !headerHasToken(r.Header, "Connection", "upgrade") || !headerHasToken(r.Header, "Upgrade", "websocket") {
+		w.Header().Set("Upgrade", "websocket")
+		writeErrorJSONStatus(w, http.StatusUpgradeRequired, errors.New("not a websocket handshake"))
+		return nil, ""
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		writeErrorJSONStatus(w, http.StatusUpgradeRequired, errors.New("unsupported websocket version"))
+		return nil, ""
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if b, err := base64.StdEncoding.DecodeString(key); err != nil || len(b) != 16 {
+		writeErrorJSONStatus(w, http.StatusBadRequest, errors.New("malformed Sec-WebSocket-Key"))
+		return nil, ""
+	}
+	var protocol, offered string
+	if len(protocols) > 0 {
+	offers:
+		for _, v := range r.Header["Sec-Websocket-Protocol"] {
+			for _, p := range strings.Split(v, ",") {
+				p = strings.TrimSpace(p)
+				offered = p
+				for _, q := range protocols {
+					if p == q {
+						protocol = p
+						break offers
+					}
+				}
+			}
+		}
+		if protocol == "" && offered != "" {
+			writeErrorJSONStatus(w, http.StatusBadRequest, fmt.Errorf("unsupported subprotocol, expected one of %s", strings.Join(protocols, ", ")))
+			return nil, ""
+		}
+	}
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		writeErrorJSON(w, errors.New("connection does not support hijacking"))
+		return nil, ""
+	}
+	conn, bw, err := hj.Hijack()
+	if err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to hijack connection: %w", err))
+		return nil, ""
+	}
+
+	accept := sha1.Sum([]byte(key + wsGUID))
+	var header string
+	if protocol != "" {
+		header = "Sec-WebSocket-Protocol: " + protocol + "\r\n"
+	}
+	if _, err := fmt.Fprintf(conn, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n%s\r\n",
+		base64.StdEncoding.EncodeToString(accept[:]), header); err != nil {
+		conn.Close()
+		return nil, ""
+	}
+	hb := instance(r)
+	return &wsConn{conn: conn, br: bw.Reader, idleTimeout: hb.webSocketIdleTimeout, messageMax: hb.webSocketMessageMax}, protocol
+}
+
+// headerHasToken reports whether the comma-separated header name of h lists
+// token, compared case-insensitively.
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h[http.CanonicalHeaderKey(name)] {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// wsConn is the server side of a WebSocket connection.
+type wsConn struct {
+	conn        net.Conn
+	br          *bufio.Reader
+	idleTimeout time.Duration // without a frame from the client
+	messageMax  int
+
+	mu     sync.Mutex // serializes frame writes
+	closed bool       // a close frame was sent
+
+	pongDelay time.Duration // delay before readMessage answers a ping
+}
+
+// serve echoes the client's messages, misbehaving as f asks, until the
+// connection is closed.
+func (c *wsConn) serve(f wsFaults) {
+	done := make(chan struct{})
+	defer close(done)
+	if f.pingInterval > 0 {
+		go c.ping(f.pingInterval, done)
+	}
+	if f.closeCode != 0 && f.closeAfter == 0 {
+		c.close(f.closeCode, f.reason)
+	}
+
+	c.pongDelay = f.pongDelay
+	for echoed := 1; ; echoed++ {
+		op, msg, err := c.readMessage()
+		if err != nil {
+			return
+		}
+		c.writeMessage(op, msg, f.fragment)
+		if f.closeCode != 0 && echoed == f.closeAfter {
+			c.close(f.closeCode, f.reason)
+		}
+	}
+}
+
+// readMessage reads a whole data message, answering pings in the meantime.
+// It returns errWSClosed once the close handshake is done, closing the
+// connection itself on protocol violations.
+func (c *wsConn) readMessage() (op byte, msg []byte, err error) {
+	for {
+		fin, fop, p, err := c.readFrame()
+		if err == nil && fop == wsContinuation && op == 0 {
+			err = wsError{1002, "unexpected continuation frame"}
+		} else if err == nil && (fop == wsText || fop == wsBinary) && op != 0 {
+			err = wsError{1002, "expected continuation frame"}
+		} else if err == nil && len(msg)+len(p) > c.messageMax {
+			err = wsError{1009, "message too big"}
+		}
+		if err != nil {
+			if we, ok := err.(wsError); ok {
+				c.close(we.code, we.reason)
+			}
+			return 0, nil, err
+		}
+
+		switch fop {
+		case wsPing:
+			if c.pongDelay > 0 {
+				time.AfterFunc(c.pongDelay, func() { c.writeFrame(true, wsPong, p) })
+			} else {
+				c.writeFrame(true, wsPong, p)
+			}
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			if len(p) > 2 {
+				p = p[:2]
+			}
+			c.writeFrame(true, wsClose, p)
+			return 0, nil, errWSClosed
+		case wsText, wsBinary:
+			op = fop
+		}
+		msg = append(msg, p...)
+		if !fin {
+			continue
+		}
+		if op == wsText && !utf8.Valid(msg) {
+			c.close(1007, "invalid UTF-8")
+			return 0, nil, wsError{1007, "invalid UTF-8"}
+		}
+		return op, msg, nil
+	}
+}
+
+// ping sends a ping every interval until done is closed.
+func (c *wsConn) ping(interval time.Duration, done <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for n := 1; ; n++ {
+		select {
+		case <-t.C:
+			if c.writeFrame(true, wsPing, []byte(strconv.Itoa(n))) != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// writeMessage sends a message of type op, split into frames of at most
+// fragment bytes if fragment is set.
+func (c *wsConn) writeMessage(op byte, msg []byte, fragment int) error {
+	for {
+		n := len(msg)
+		if fragment > 0 && n > fragment {
+			n = fragment
+		}
+		if err := c.writeFrame(n == len(msg), op, msg[:n]); err != nil {
+			return err
+		}
+		if msg, op = msg[n:], wsContinuation; len(msg) == 0 {
+			return nil
+		}
+	}
+}
+
+// close sends a close frame with code and reason.
+func (c *wsConn) close(code int, reason string) error {
+	p := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(p, uint16(code))
+	return c.writeFrame(true, wsClose, append(p, reason...))
+}
+
+// writeFrame sends a single unmasked frame. Nothing can be sent after a
+// close frame.
+func (c *wsConn) writeFrame(fin bool, op byte, p []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return errWSClosed
+	}
+	c.closed = op == wsClose
+
+	b := make([]byte, 2, 10+len(p))
+	if b[0] = op; fin {
+		b[0] |= 0x80
+	}
+	switch {
+	case len(p) < 126:
+		b[1] = byte(len(p))
+	case len(p) <= 0xffff:
+		b[1] = 126
+		b = b[:4]
+		binary.BigEndian.PutUint16(b[2:], uint16(len(p)))
+	default:
+		b[1] = 127
+		b = b[:10]
+		binary.BigEndian.PutUint64(b[2:], uint64(len(p)))
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(c.idleTimeout))
+	_, err := c.conn.Write(append(b, p...))
+	return err
+}
+
+// readFrame reads and unmasks a frame from the client.
+func (c *wsConn) readFrame() (fin bool, op byte, p []byte, err error) {
+	c.conn.SetReadDeadline(time.Now().Add(c.idleTimeout))
+	var h [14]byte
+	if _, err = io.ReadFull(c.br, h[:2]); err != nil {
+		return
+	}
+	fin, op = h[0]&0x80 != 0, h[0]&0x0f
+	masked, n := h[1]&0x80 != 0, uint64(h[1]&0x7f)
+	switch n {
+	case 126:
+		if _, err = io.ReadFull(c.br, h[2:4]); err != nil {
+			return
+		}
+		n = uint64(binary.BigEndian.Uint16(h[2:4]))
+	case 127:
+		if _, err = io.ReadFull(c.br, h[2:10]); err != nil {
+			return
+		}
+		n = binary.BigEndian.Uint64(h[2:10])
+	}
+
+	switch {
+	case h[0]&0x70 != 0:
+		err = wsError{1002, "reserved bits set"}
+	case !masked:
+		err = wsError{1002, "client frames must be masked"}
+	case op >= wsClose && (n > 125 || !fin):
+		err = wsError{1002, "control frames must be unfragmented and at most 125 bytes"}
+	case op > wsBinary && op < wsClose || op > wsPong:
+		err = wsError{1002, "unknown opcode"}
+	case n > uint64(c.messageMax):
+		err = wsError{1009, "message too big"}
+	}
+	if err != nil {
+		return
+	}
+
+	var mask [4]byte
+	if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+		return
+	}
+	p = make([]byte, n)
+	if _, err = io.ReadFull(c.br, p); err != nil {
+		return
+	}
+	for i := range p {
+		p[i] ^= mask[i%4]
+	}
+	return
+}
@@ -0,0 +1,183 @@
+//go:build !httpbin_nowebsocket
+// +build !httpbin_nowebsocket
+
+package httpbin_test
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type wsClient struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+func dialWebSocket(t *testing.T, srvURL, query string) *wsClient {
+	c, _ := dialWebSocketPath(t, srvURL, "/websocket?"+query, "")
+	return c
+}
+
+// dialWebSocketPath connects to the WebSocket endpoint at target offering
+// the subprotocols in protocols, if any, and returns the one agreed on.
+func dialWebSocketPath(t *testing.T, srvURL, target, protocols string) (*wsClient, string) {
+	u, err := url.Parse(srvURL)
+	require.Nil(t, err)
+	conn, err := net.Dial("tcp", u.Host)
+	require.Nil(t, err)
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	header := ""
+	if protocols != "" {
+		header = "Sec-WebSocket-Protocol: " + protocols + "\r\n"
+	}
+	_, err = io.WriteString(conn, "GET "+target+" HTTP/1.1\r\nHost: "+u.Host+"\r\n"+
+		"Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Version: 13\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n"+header+"\r\n")
+	require.Nil(t, err)
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	require.Nil(t, err)
+	require.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+	require.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", resp.Header.Get("Sec-WebSocket-Accept"))
+	return &wsClient{conn: conn, br: br}, resp.Header.Get("Sec-WebSocket-Protocol")
+}
+
+func (c *wsClient) write(t *testing.T, fin bool, op byte, p []byte) {
+	h := []byte{op, 0x80 | byte(len(p)), 1, 2, 3, 4}
+	if fin {
+		h[0] |= 0x80
+	}
+	for i, b := range p {
+		h = append(h, b^h[2+i%4])
+	}
+	_, err := c.conn.Write(h)
+	require.Nil(t, err)
+}
+
+func (c *wsClient) read(t *testing.T) (fin bool, op byte, p []byte) {
+	var h [2]byte
+	_, err := io.ReadFull(c.br, h[:])
+	require.Nil(t, err)
+	require.Zero(t, h[1]&0x80, "server frames are not masked")
+	n := int(h[1])
+	if n == 126 {
+		var l [2]byte
+		_, err = io.ReadFull(c.br, l[:])
+		require.Nil(t, err)
+		n = int(binary.BigEndian.Uint16(l[:]))
+	}
+	p = make([]byte, n)
+	_, err = io.ReadFull(c.br, p)
+	require.Nil(t, err)
+	return h[0]&0x80 != 0, h[0] & 0x0f, p
+}
+
+func TestWebSocket_echo(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+	c := dialWebSocket(t, srv.URL, "")
+	defer c.conn.Close()
+
+	c.write(t, false, 0x1, []byte("hel"))
+	c.write(t, true, 0x9, []byte("p"))
+	c.write(t, true, 0x0, []byte("lo"))
+	fin, op, p := c.read(t)
+	require.Equal(t, byte(0xa), op)
+	require.Equal(t, "p", string(p))
+	fin, op, p = c.read(t)
+	require.True(t, fin)
+	require.Equal(t, byte(0x1), op)
+	require.Equal(t, "hello", string(p))
+
+	c.write(t, true, 0x8, []byte{0x03, 0xe8})
+	_, op, p = c.read(t)
+	require.Equal(t, byte(0x8), op)
+	require.Equal(t, []byte{0x03, 0xe8}, p)
+}
+
+func TestWebSocket_faults(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	// fragments cut through the UTF-8 sequence
+	c := dialWebSocket(t, srv.URL, "fragment=2&close=4000&close_after=1&reason=bye")
+	c.write(t, true, 0x1, []byte("aé!"))
+	var got []string
+	for {
+		fin, op, p := c.read(t)
+		got = append(got, string(p))
+		if len(got) == 1 {
+			require.Equal(t, byte(0x1), op)
+		} else {
+			require.Equal(t, byte(0x0), op)
+		}
+		if fin {
+			break
+		}
+	}
+	require.Equal(t, []string{"a\xc3", "\xa9!"}, got)
+	_, op, p := c.read(t)
+	require.Equal(t, byte(0x8), op)
+	require.Equal(t, uint16(4000), binary.BigEndian.Uint16(p))
+	require.Equal(t, "bye", string(p[2:]))
+	c.conn.Close()
+
+	// tiny ping intervals are raised to 0.1s
+	c = dialWebSocket(t, srv.URL, "pong_delay=0.2&ping_interval=0.001")
+	start := time.Now()
+	c.write(t, true, 0x9, nil)
+	pings := 0
+	for {
+		_, op, _ := c.read(t)
+		if op == 0xa {
+			break
+		}
+		require.Equal(t, byte(0x9), op)
+		pings++
+	}
+	require.True(t, time.Since(start) >= 200*time.Millisecond)
+	require.True(t, pings >= 1 && pings <= 3, "pings %d", pings)
+	c.conn.Close()
+
+	// unmasked frames are a protocol error
+	c = dialWebSocket(t, srv.URL, "")
+	c.conn.Write([]byte{0x81, 0x01, 'x'})
+	_, op, p = c.read(t)
+	require.Equal(t, byte(0x8), op)
+	require.Equal(t, uint16(1002), binary.BigEndian.Uint16(p))
+	c.conn.Close()
+}
+
+func TestWebSocket_handshake(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/websocket")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUpgradeRequired, resp.StatusCode)
+
+	resp, err = http.Get(srv.URL + "/websocket?close=999")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	req, _ := http.NewRequest("GET", srv.URL+"/websocket", nil)
+	req.Header.Set("Connection", "keep-alive, Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "8")
+	resp, err = http.DefaultClient.Do(req)
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUpgradeRequired, resp.StatusCode)
+	require.True(t, strings.Contains(resp.Header.Get("Sec-WebSocket-Version"), "13"))
+}
@@ -0,0 +1,27 @@
+package httpbin
+
+import "time"
+
+const (
+	// defaultWebSocketIdleTimeout is how long a /websocket connection may go
+	// without a frame from the client before it is dropped.
+	defaultWebSocketIdleTimeout = time.Minute
+
+	// defaultWebSocketMessageMax is the size limit of messages sent to
+	// /websocket.
+	defaultWebSocketMessageMax = 1 << 20
+)
+
+// WithWebSocketIdleTimeout sets how long a WebSocket connection may go
+// without a frame from the client before it is dropped. It defaults to a
+// minute.
+func WithWebSocketIdleTimeout(d time.Duration) Option {
+	return func(h *HTTPBin) { h.webSocketIdleTimeout = d }
+}
+
+// WithWebSocketMessageMax sets the size limit of messages sent over
+// WebSocket connections. Larger ones are refused with close code 1009. It
+// defaults to 1 MiB.
+func WithWebSocketMessageMax(n int) Option {
+	return func(h *HTTPBin) { h.webSocketMessageMax = n }
+}
@@ -0,0 +1,80 @@
+package httpbin
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// WithWellKnown sets the resources served under /.well-known/, by name,
+// overriding the defaults for security.txt, change-password,
+// openid-configuration and apple-app-site-association, or adding others.
+// It defaults to none.
+func WithWellKnown(resources map[string]WellKnownResource) Option {
+	return func(h *HTTPBin) { h.wellKnown = resources }
+}
+
+// WellKnownResource is a resource served under /.well-known/.
+type WellKnownResource struct {
+	ContentType string // default text/plain
+	Body        string
+
+	// Redirect, if set, is where requests are redirected with a 302 instead
+	// of being served Body.
+	Redirect string
+}
+
+// wellKnownDefaults serve the well-known resources not set with
+// WithWellKnown.
+var wellKnownDefaults = map[string]http.HandlerFunc{
+	"security.txt": func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintf(w, "Contact: mailto:security@example.com\nExpires: %s\nPreferred-Languages: en\nCanonical: %s/.well-known/security.txt\n",
+			time.Now().AddDate(1, 0, 0).UTC().Format(time.RFC3339), oidcIssuer(r))
+	},
+	"change-password": func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, prefixed(r, "/login"), http.StatusFound)
+	},
+	"openid-configuration": OIDCDiscoveryHandler,
+	"apple-app-site-association": func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{
+  "applinks": {
+    "details": [
+      {
+        "appIDs": ["ABCDE12345.com.example.httpbin"],
+        "components": [{"/": "/*"}]
+      }
+    ]
+  },
+  "webcredentials": {
+    "apps": ["ABCDE12345.com.example.httpbin"]
+  }
+}
+`)
+	},
+}
+
+// WellKnownHandler serves the resource named by the 'name' route variable
+// from the well-known resources, or else its default, or 404.
+func WellKnownHandler(w http.ResponseWriter, r *http.Request) {
+	name := routeVars(r)["name"]
+	if res, ok := instance(r).wellKnown[name]; ok {
+		if res.Redirect != "" {
+			http.Redirect(w, r, res.Redirect, http.StatusFound)
+			return
+		}
+		ct := res.ContentType
+		if ct == "" {
+			ct = "text/plain; charset=utf-8"
+		}
+		w.Header().Set("Content-Type", ct)
+		fmt.Fprint(w, res.Body)
+		return
+	}
+	if h, ok := wellKnownDefaults[name]; ok {
+		h(w, r)
+		return
+	}
+	writeErrorJSONStatus(w, http.StatusNotFound, fmt.Errorf("no well-known resource %q, see httpbin.WithWellKnown", name))
+}
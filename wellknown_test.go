@@ -0,0 +1,69 @@
+package httpbin_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ahmetb/go-httpbin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWellKnown_defaults(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/.well-known/security.txt")
+	require.Nil(t, err)
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.True(t, strings.HasPrefix(string(b), "Contact: "))
+	require.Contains(t, string(b), "\nExpires: ")
+
+	assertLocationHeader(t, srv.URL+"/.well-known/change-password", "/login")
+
+	resp, err = http.Get(srv.URL + "/.well-known/openid-configuration")
+	require.Nil(t, err)
+	var v struct {
+		Issuer  string `json:"issuer"`
+		JWKSURI string `json:"jwks_uri"`
+	}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&v))
+	resp.Body.Close()
+	require.Equal(t, srv.URL, v.Issuer)
+	require.Equal(t, srv.URL+"/.well-known/jwks.json", v.JWKSURI)
+
+	resp, err = http.Get(srv.URL + "/.well-known/apple-app-site-association")
+	require.Nil(t, err)
+	var aasa map[string]interface{}
+	require.Nil(t, json.NewDecoder(resp.Body).Decode(&aasa))
+	resp.Body.Close()
+	require.Contains(t, aasa, "applinks")
+
+	resp, err = http.Get(srv.URL + "/.well-known/nope")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestWellKnown_overrides(t *testing.T) {
+	srv := httptest.NewServer(httpbin.New(httpbin.WithWellKnown(map[string]httpbin.WellKnownResource{
+		"security.txt":    {Body: "Contact: https://example.com/security\n"},
+		"change-password": {Redirect: "https://example.com/account"},
+		"assetlinks.json": {ContentType: "application/json", Body: "[]"},
+	})).Handler())
+	defer srv.Close()
+
+	require.Equal(t, "Contact: https://example.com/security\n", string(get(t, srv.URL+"/.well-known/security.txt")))
+	assertLocationHeader(t, srv.URL+"/.well-known/change-password", "https://example.com/account")
+
+	resp, err := http.Get(srv.URL + "/.well-known/assetlinks.json")
+	require.Nil(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+}
@@ -0,0 +1,49 @@
+//go:build !httpbin_nozstd
+// +build !httpbin_nozstd
+
+package httpbin
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+func init() {
+	featureRoutes = append(featureRoutes,
+		route{name: "zstd", path: `/zstd`, methods: getHead, description: "Returns zstd-encoded data.", example: "zstd", handler: http.HandlerFunc(ZstdHandler)},
+	)
+}
+
+// zstdEncoders pools encoders, which are costly to create, each encoding on
+// the calling goroutine only rather than starting one per CPU.
+var zstdEncoders = sync.Pool{New: func() interface{} {
+	enc, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1)) // fails only on bad options
+	return enc
+}}
+
+// ZstdHandler returns a Zstandard-encoded response.
+func ZstdHandler(w http.ResponseWriter, r *http.Request) {
+	h, _, _ := net.SplitHostPort(r.RemoteAddr)
+
+	v := zstdResponse{
+		headersResponse: headersResponse{getHeaders(r)},
+		ipResponse:      ipResponse{h},
+		Zstd:            true,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Encoding", "zstd")
+	ww := zstdEncoders.Get().(*zstd.Encoder)
+	ww.Reset(w)
+	defer func() {
+		ww.Close() // flush
+		zstdEncoders.Put(ww)
+	}()
+	if err := writeJSON(ww, v); err != nil {
+		writeErrorJSON(w, fmt.Errorf("failed to write json: %w", err))
+	}
+}
@@ -0,0 +1,35 @@
+//go:build !httpbin_nozstd
+// +build !httpbin_nozstd
+
+package httpbin_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestZstd(t *testing.T) {
+	srv := testServer()
+	defer srv.Close()
+
+	// twice, so that the second response reuses a pooled encoder
+	for i := 0; i < 2; i++ {
+		resp, err := http.Get(srv.URL + "/zstd")
+		require.Nil(t, err)
+		defer resp.Body.Close()
+
+		require.EqualValues(t, "zstd", resp.Header.Get("Content-Encoding"))
+		zr, err := zstd.NewReader(resp.Body)
+		require.Nil(t, err)
+		defer zr.Close()
+		var v struct {
+			Zstd bool `json:"zstd"`
+		}
+		require.Nil(t, json.NewDecoder(zr).Decode(&v))
+		require.True(t, v.Zstd)
+	}
+}